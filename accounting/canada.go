@@ -0,0 +1,271 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// Half of a Canadian capital gain is taxable. A loss is superficial, and
+// added to the cost of the replacement property instead of being deducted,
+// when identical property is bought within 30 days of the sale and still held
+// 30 days after it.
+const (
+	CANADA_INCLUSION_RATE        = "0.5"
+	CANADA_SUPERFICIAL_LOSS_DAYS = 30
+)
+
+type CanadaJurisdiction struct {
+	Jurisdiction
+}
+
+// Schedule3 lists the dispositions of a Canadian tax year valued at the
+// adjusted cost base (ACB) of each currency. Dates are written in Location, the
+// time zone the tax year was requested in.
+type Schedule3 struct {
+	Currency           string                 `json:"currency"`
+	Dispositions       []Schedule3Disposition `json:"dispositions"`
+	Holdings           []AdjustedCostBase     `json:"holdings"`
+	Proceeds           string                 `json:"proceeds"`
+	AdjustedCostBase   string                 `json:"adjusted_cost_base"`
+	Outlays            string                 `json:"outlays"`
+	SuperficialLosses  string                 `json:"superficial_losses"`
+	GainOrLoss         string                 `json:"gain_or_loss"`
+	TaxableCapitalGain string                 `json:"taxable_capital_gain"`
+	Location           *time.Location         `json:"-"`
+}
+
+type Schedule3Disposition struct {
+	TransactionId    string    `json:"transaction_id"`
+	Currency         string    `json:"currency"`
+	Description      string    `json:"description"`
+	DateDisposed     time.Time `json:"date_disposed"`
+	Quantity         string    `json:"quantity"`
+	Proceeds         string    `json:"proceeds"`
+	AdjustedCostBase string    `json:"adjusted_cost_base"`
+	Outlays          string    `json:"outlays"`
+	SuperficialLoss  string    `json:"superficial_loss"`
+	GainOrLoss       string    `json:"gain_or_loss"`
+	MissingBasis     string    `json:"missing_basis,omitempty"`
+}
+
+type AdjustedCostBase struct {
+	Currency string `json:"currency"`
+	Quantity string `json:"quantity"`
+	Total    string `json:"total"`
+	PerUnit  string `json:"per_unit"`
+}
+
+type acbEvent struct {
+	lot  Coinlot
+	sale bool
+}
+
+func (jurisdiction *CanadaJurisdiction) GetCode() string {
+	return JURISDICTION_CA
+}
+
+func (jurisdiction *CanadaJurisdiction) GetTaxYearBounds(year int, location *time.Location) (time.Time, time.Time) {
+	return TaxYearBounds(year, location)
+}
+
+// Calculate values each disposition at the average cost of the currency held
+// when it was made. Transactions in the 30 days after end are read so losses
+// late in the year can be tested against the superficial loss rule.
+func (jurisdiction *CanadaJurisdiction) Calculate(report *Report, start, end time.Time) TaxStatement {
+	buyLots, saleLots := report.collectLots(start, end.AddDate(0, 0, CANADA_SUPERFICIAL_LOSS_DAYS))
+	schedule := &Schedule3{
+		Currency: report.ctx.GetUser().GetLocalCurrency(),
+		Location: start.Location()}
+	var proceeds, adjustedCostBase, outlays, superficial, gainOrLoss decimal.Decimal
+	for _, currency := range lotCurrencies(buyLots, saleLots) {
+		events := newAcbEvents(buyLots[currency], saleLots[currency])
+		var quantity, acb decimal.Decimal
+		for i, event := range events {
+			if event.lot.Date.After(end) {
+				break
+			}
+			if !event.sale {
+				quantity = quantity.Add(event.lot.Quantity)
+				acb = acb.Add(event.lot.CostBasis)
+				continue
+			}
+			// The quantity disposed of beyond what is held has no cost and is
+			// flagged on the disposition so the missing acquisitions can be added.
+			sold := decimal.Min(event.lot.Quantity, quantity)
+			missing := event.lot.Quantity.Sub(sold)
+			if missing.IsPositive() {
+				report.ctx.GetLogger().Warningf("[CanadaJurisdiction.Calculate] Insufficient %s to cover %s disposed on %s (%s available)",
+					currency, event.lot.Quantity, event.lot.Date, quantity)
+			}
+			cost := decimal.Zero
+			if quantity.IsPositive() {
+				cost = acb.Mul(sold).Div(quantity)
+			}
+			quantity = quantity.Sub(sold)
+			acb = acb.Sub(cost)
+			if !isReportable(&event.lot) {
+				continue
+			}
+			gain := event.lot.SalePrice.Sub(cost)
+			denied := decimal.Zero
+			if gain.IsNegative() {
+				denied = superficialLoss(events, i, gain.Neg())
+				acb = acb.Add(denied)
+			}
+			if event.lot.Date.Before(start) {
+				continue
+			}
+			disposition := Schedule3Disposition{
+				TransactionId:    event.lot.TransactionId,
+				Currency:         currency,
+				Description:      fmt.Sprintf("%s %s", event.lot.Quantity, currency),
+				DateDisposed:     event.lot.Date,
+				Quantity:         event.lot.Quantity.String(),
+				Proceeds:         event.lot.SalePrice.Add(event.lot.Fee).StringFixed(2),
+				AdjustedCostBase: cost.StringFixed(2),
+				Outlays:          event.lot.Fee.StringFixed(2),
+				SuperficialLoss:  denied.StringFixed(2),
+				GainOrLoss:       gain.Add(denied).StringFixed(2)}
+			if missing.IsPositive() {
+				disposition.MissingBasis = missing.String()
+			}
+			schedule.Dispositions = append(schedule.Dispositions, disposition)
+			proceeds = proceeds.Add(event.lot.SalePrice.Add(event.lot.Fee))
+			adjustedCostBase = adjustedCostBase.Add(cost)
+			outlays = outlays.Add(event.lot.Fee)
+			superficial = superficial.Add(denied)
+			gainOrLoss = gainOrLoss.Add(gain.Add(denied))
+		}
+		if quantity.IsPositive() {
+			schedule.Holdings = append(schedule.Holdings, AdjustedCostBase{
+				Currency: currency,
+				Quantity: quantity.String(),
+				Total:    acb.StringFixed(2),
+				PerUnit:  acb.Div(quantity).StringFixed(2)})
+		}
+	}
+	sort.SliceStable(schedule.Dispositions, func(i, j int) bool {
+		return schedule.Dispositions[i].DateDisposed.Before(schedule.Dispositions[j].DateDisposed)
+	})
+	inclusionRate, _ := decimal.NewFromString(CANADA_INCLUSION_RATE)
+	taxable := decimal.Zero
+	if gainOrLoss.IsPositive() {
+		taxable = gainOrLoss.Mul(inclusionRate)
+	}
+	schedule.Proceeds = proceeds.StringFixed(2)
+	schedule.AdjustedCostBase = adjustedCostBase.StringFixed(2)
+	schedule.Outlays = outlays.StringFixed(2)
+	schedule.SuperficialLosses = superficial.StringFixed(2)
+	schedule.GainOrLoss = gainOrLoss.StringFixed(2)
+	schedule.TaxableCapitalGain = taxable.StringFixed(2)
+	return schedule
+}
+
+// newAcbEvents merges the acquisitions and disposals of a currency in date
+// order. Acquisitions come first when both happen at the same time.
+func newAcbEvents(buyLots, saleLots []Coinlot) []acbEvent {
+	var events []acbEvent
+	for _, lot := range buyLots {
+		events = append(events, acbEvent{lot: lot})
+	}
+	for _, lot := range saleLots {
+		events = append(events, acbEvent{lot: lot, sale: true})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].lot.Date.Before(events[j].lot.Date)
+	})
+	return events
+}
+
+// superficialLoss returns the portion of the loss on the sale at index that
+// is denied. It is prorated by the least of the quantity sold, the quantity
+// bought in the 61 day window around the sale and the quantity still held at
+// the end of the window. Units bought in the window that were themselves sold
+// are not replacements.
+func superficialLoss(events []acbEvent, index int, loss decimal.Decimal) decimal.Decimal {
+	sale := events[index].lot
+	windowStart := sale.Date.AddDate(0, 0, -CANADA_SUPERFICIAL_LOSS_DAYS)
+	windowEnd := sale.Date.AddDate(0, 0, CANADA_SUPERFICIAL_LOSS_DAYS)
+	var acquired, heldBefore, heldAfter decimal.Decimal
+	for _, event := range events {
+		quantity := event.lot.Quantity
+		if event.sale {
+			quantity = quantity.Neg()
+		} else if !event.lot.Date.Before(windowStart) && !event.lot.Date.After(windowEnd) {
+			acquired = acquired.Add(quantity)
+		}
+		if event.lot.Date.Before(windowStart) {
+			heldBefore = heldBefore.Add(quantity)
+		}
+		if !event.lot.Date.After(windowEnd) {
+			heldAfter = heldAfter.Add(quantity)
+		}
+	}
+	replacements := acquired.Sub(decimal.Max(decimal.Zero, sale.Quantity.Sub(heldBefore)))
+	least := decimal.Min(sale.Quantity, replacements, heldAfter)
+	if !least.IsPositive() {
+		return decimal.Zero
+	}
+	return loss.Mul(least).Div(sale.Quantity)
+}
+
+func (schedule *Schedule3) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{fmt.Sprintf("Capital Gains or Losses (Schedule 3, %s)", schedule.Currency)})
+	writer.Write([]string{
+		"Description",
+		"Date Disposed",
+		"Proceeds of Disposition",
+		"Adjusted Cost Base",
+		"Outlays and Expenses",
+		"Superficial Loss",
+		"Gain or loss"})
+	for _, disposition := range schedule.Dispositions {
+		if err := writer.Write(disposition.Record(reportLocation(schedule.Location))); err != nil {
+			return err
+		}
+	}
+
+	writer.Write([]string{})
+	writer.Write([]string{"Adjusted Cost Base"})
+	writer.Write([]string{"Currency", "Quantity", "Total", "Per Unit"})
+	for _, holding := range schedule.Holdings {
+		writer.Write([]string{holding.Currency, holding.Quantity, holding.Total, holding.PerUnit})
+	}
+
+	writer.Write([]string{})
+	writer.Write([]string{"Summary"})
+	writer.Write([]string{"Proceeds of disposition", schedule.Proceeds})
+	writer.Write([]string{"Adjusted cost base", schedule.AdjustedCostBase})
+	writer.Write([]string{"Outlays and expenses", schedule.Outlays})
+	writer.Write([]string{"Superficial losses denied", schedule.SuperficialLosses})
+	writer.Write([]string{"Capital gain or loss", schedule.GainOrLoss})
+	writer.Write([]string{"Taxable capital gain", schedule.TaxableCapitalGain})
+
+	return nil
+}
+
+// Record returns the disposition as a CSV record with its date in location.
+func (disposition *Schedule3Disposition) Record(location *time.Location) []string {
+	return []string{
+		disposition.Description,
+		disposition.DateDisposed.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		disposition.Proceeds,
+		disposition.AdjustedCostBase,
+		disposition.Outlays,
+		disposition.SuperficialLoss,
+		disposition.GainOrLoss}
+}
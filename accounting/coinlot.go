@@ -8,15 +8,48 @@ import (
 )
 
 type Coinlot struct {
-	Date      time.Time
-	Currency  string
-	Quantity  decimal.Decimal
-	UnitPrice decimal.Decimal
-	SalePrice decimal.Decimal
-	CostBasis decimal.Decimal
+	TransactionId string          `json:"transaction_id"`
+	Date          time.Time       `json:"date"`
+	Currency      string          `json:"currency"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	UnitPrice     decimal.Decimal `json:"unit_price"`
+	SalePrice     decimal.Decimal `json:"sale_price"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	Fee           decimal.Decimal `json:"fee"`
+	Disposal      string          `json:"disposal,omitempty"`
 }
 
 func (coinlot *Coinlot) String() string {
-	return fmt.Sprintf("[Coinlot] Date: %s, Currency: %s, Quantity: %s, UnitPrice: %s, SalePrice: %s, CostBasis: %s",
-		coinlot.Date, coinlot.Currency, coinlot.Quantity, coinlot.UnitPrice, coinlot.SalePrice, coinlot.CostBasis)
+	return fmt.Sprintf("[Coinlot] TransactionId: %s, Date: %s, Currency: %s, Quantity: %s, UnitPrice: %s, SalePrice: %s, CostBasis: %s, Fee: %s",
+		coinlot.TransactionId, coinlot.Date, coinlot.Currency, coinlot.Quantity, coinlot.UnitPrice, coinlot.SalePrice, coinlot.CostBasis, coinlot.Fee)
+}
+
+// UnitCost returns the cost basis per unit held in the lot.
+func (coinlot *Coinlot) UnitCost() decimal.Decimal {
+	if coinlot.Quantity.IsZero() {
+		return coinlot.UnitPrice
+	}
+	return coinlot.CostBasis.Div(coinlot.Quantity)
+}
+
+// UnitFee returns the portion of the lot's fees attributable to each unit.
+func (coinlot *Coinlot) UnitFee() decimal.Decimal {
+	if coinlot.Quantity.IsZero() {
+		return decimal.Zero
+	}
+	return coinlot.Fee.Div(coinlot.Quantity)
+}
+
+// Split divides the lot into the requested quantity and the remainder,
+// prorating the cost basis between the two.
+func (coinlot *Coinlot) Split(quantity decimal.Decimal) (Coinlot, Coinlot) {
+	portion := *coinlot
+	remainder := *coinlot
+	portion.Quantity = quantity
+	portion.CostBasis = coinlot.UnitCost().Mul(quantity)
+	portion.Fee = coinlot.UnitFee().Mul(quantity)
+	remainder.Quantity = coinlot.Quantity.Sub(quantity)
+	remainder.CostBasis = coinlot.CostBasis.Sub(portion.CostBasis)
+	remainder.Fee = coinlot.Fee.Sub(portion.Fee)
+	return portion, remainder
 }
@@ -0,0 +1,180 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"os"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// Disposals other than a sale or trade. Spends realize a gain like a sale,
+// donations and casualties consume lots into their own schedules while gifts
+// and the network fees lost in a transfer consume lots without realizing
+// anything.
+const (
+	DISPOSAL_SALE        = ""
+	DISPOSAL_SPEND       = "spend"
+	DISPOSAL_DONATION    = "donation"
+	DISPOSAL_CASUALTY    = "casualty"
+	DISPOSAL_GIFT        = "gift"
+	DISPOSAL_NETWORK_FEE = "network-fee"
+)
+
+var disposalCategories = map[string]string{
+	common.TX_CATEGORY_SPEND:    DISPOSAL_SPEND,
+	common.TX_CATEGORY_DONATION: DISPOSAL_DONATION,
+	common.TX_CATEGORY_LOST:     DISPOSAL_CASUALTY,
+	common.TX_CATEGORY_GIFT:     DISPOSAL_GIFT}
+
+// DisposalSchedule lists the donations (Form 8283) and lost or stolen coins
+// (Form 4684) disposed of during the reporting period. Dates are written in
+// Location, the time zone the reporting period was requested in.
+type DisposalSchedule struct {
+	CharitableContributions []CharitableContribution `json:"charitable_contributions"`
+	CasualtyLosses          []CasualtyLoss           `json:"casualty_losses"`
+	Location                *time.Location           `json:"-"`
+}
+
+type CharitableContribution struct {
+	Currency        string    `json:"currency"`
+	Description     string    `json:"description"`
+	DateAcquired    time.Time `json:"date_acquired"`
+	DateContributed time.Time `json:"date_contributed"`
+	Holding         string    `json:"holding"`
+	CostBasis       string    `json:"cost_basis"`
+	FairMarketValue string    `json:"fair_market_value"`
+	Deduction       string    `json:"deduction"`
+}
+
+type CasualtyLoss struct {
+	Currency     string    `json:"currency"`
+	Description  string    `json:"description"`
+	DateAcquired time.Time `json:"date_acquired"`
+	DateLost     time.Time `json:"date_lost"`
+	CostBasis    string    `json:"cost_basis"`
+	Loss         string    `json:"loss"`
+}
+
+// GetDisposalType returns the disposal type for an outgoing transaction, or
+// false when the transaction does not dispose of any lots.
+func GetDisposalType(tx common.Transaction) (string, bool) {
+	if tx.GetType() != common.WITHDRAWAL_ORDER_TYPE {
+		return "", false
+	}
+	disposal, ok := disposalCategories[tx.GetCategory()]
+	return disposal, ok
+}
+
+// NewCharitableContribution builds the Form 8283 entry for a donated lot. Long
+// term holdings deduct their fair market value, short term holdings the lesser
+// of cost basis and fair market value.
+func NewCharitableContribution(lineItem *Form8949LineItem, holding string) CharitableContribution {
+	costBasis, _ := decimal.NewFromString(lineItem.CostBasis)
+	fairMarketValue, _ := decimal.NewFromString(lineItem.Proceeds)
+	deduction := fairMarketValue
+	if holding == HOLDING_SHORT && costBasis.LessThan(fairMarketValue) {
+		deduction = costBasis
+	}
+	return CharitableContribution{
+		Currency:        lineItem.Currency,
+		Description:     lineItem.Description,
+		DateAcquired:    lineItem.DateAcquired,
+		DateContributed: lineItem.DateSold,
+		Holding:         holding,
+		CostBasis:       lineItem.CostBasis,
+		FairMarketValue: lineItem.Proceeds,
+		Deduction:       deduction.StringFixed(2)}
+}
+
+func NewCasualtyLoss(lineItem *Form8949LineItem) CasualtyLoss {
+	return CasualtyLoss{
+		Currency:     lineItem.Currency,
+		Description:  lineItem.Description,
+		DateAcquired: lineItem.DateAcquired,
+		DateLost:     lineItem.DateSold,
+		CostBasis:    lineItem.CostBasis,
+		Loss:         lineItem.CostBasis}
+}
+
+// filter drops disposals from before the start of the reporting period.
+func (schedule *DisposalSchedule) filter(start time.Time) {
+	var contributions []CharitableContribution
+	for _, contribution := range schedule.CharitableContributions {
+		if !contribution.DateContributed.Before(start) {
+			contributions = append(contributions, contribution)
+		}
+	}
+	var losses []CasualtyLoss
+	for _, loss := range schedule.CasualtyLosses {
+		if !loss.DateLost.Before(start) {
+			losses = append(losses, loss)
+		}
+	}
+	schedule.CharitableContributions = contributions
+	schedule.CasualtyLosses = losses
+}
+
+func (schedule *DisposalSchedule) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{"Charitable Contributions (Form 8283)"})
+	writer.Write([]string{
+		"Description",
+		"Date Acquired",
+		"Date Contributed",
+		"Holding",
+		"Cost Basis",
+		"Fair Market Value",
+		"Deduction"})
+	for _, contribution := range schedule.CharitableContributions {
+		if err := writer.Write(contribution.Record(reportLocation(schedule.Location))); err != nil {
+			return err
+		}
+	}
+
+	writer.Write([]string{})
+	writer.Write([]string{"Casualty and Theft Losses (Form 4684)"})
+	writer.Write([]string{
+		"Description",
+		"Date Acquired",
+		"Date Lost",
+		"Cost Basis",
+		"Loss"})
+	for _, loss := range schedule.CasualtyLosses {
+		if err := writer.Write(loss.Record(reportLocation(schedule.Location))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record returns the donation as a CSV record with its dates in location.
+func (contribution *CharitableContribution) Record(location *time.Location) []string {
+	return []string{
+		contribution.Description,
+		contribution.DateAcquired.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		contribution.DateContributed.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		contribution.Holding,
+		contribution.CostBasis,
+		contribution.FairMarketValue,
+		contribution.Deduction}
+}
+
+// Record returns the loss as a CSV record with its dates in location.
+func (loss *CasualtyLoss) Record(location *time.Location) []string {
+	return []string{
+		loss.Description,
+		loss.DateAcquired.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		loss.DateLost.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		loss.CostBasis,
+		loss.Loss}
+}
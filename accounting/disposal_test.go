@@ -0,0 +1,109 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDisposalTestTransactions() []common.Transaction {
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "5",
+			Date:         time.Date(2018, 04, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_LOST,
+			Quantity:     "1",
+			FiatPrice:    "7000",
+			FiatTotal:    "7000"},
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2018, 03, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_DONATION,
+			Quantity:     "1",
+			FiatPrice:    "9000",
+			FiatTotal:    "9000"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 06, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_SPEND,
+			Quantity:     "0.5",
+			FiatPrice:    "3000",
+			FiatTotal:    "1500"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Quantity:     "1",
+			FiatPrice:    "1000",
+			FiatTotal:    "1000"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRADE,
+			Quantity:     "3",
+			Total:        "3000",
+			FiatTotal:    "3000"}}
+}
+
+func TestReport_Disposals(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	report := NewFifoReport(ctx, createDisposalTestTransactions())
+
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "1500.00", form8949.ShortHolds[0].Proceeds)
+	assert.Equal(t, "500.00", form8949.ShortHolds[0].CostBasis)
+	assert.Equal(t, "1000.00", form8949.ShortHolds[0].GainOrLoss)
+	assert.Equal(t, 1, len(report.Spends))
+	assert.Equal(t, "2.5", report.GetOpenLots("BTC")[0].Quantity.String())
+	assert.Equal(t, "1", report.GetOpenLots("BTC")[0].TransactionId)
+
+	form8949 = report.Run(time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0, len(form8949.ShortHolds))
+	assert.Equal(t, 0, len(form8949.LongHolds))
+
+	schedule := report.GetDisposalSchedule()
+	assert.Equal(t, 1, len(schedule.CharitableContributions))
+	assert.Equal(t, HOLDING_LONG, schedule.CharitableContributions[0].Holding)
+	assert.Equal(t, "1000.00", schedule.CharitableContributions[0].CostBasis)
+	assert.Equal(t, "9000.00", schedule.CharitableContributions[0].FairMarketValue)
+	assert.Equal(t, "9000.00", schedule.CharitableContributions[0].Deduction)
+
+	assert.Equal(t, 1, len(schedule.CasualtyLosses))
+	assert.Equal(t, "1000.00", schedule.CasualtyLosses[0].Loss)
+	assert.Equal(t, "0.5", report.GetOpenLots("BTC")[0].Quantity.String())
+
+	// Dates are written in the time zone the period was requested in
+	assert.Equal(t, time.UTC, schedule.Location)
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	assert.Equal(t, "02-28-2018 19:00:00 EST", schedule.CharitableContributions[0].Record(newYork)[2])
+	assert.Equal(t, "03-31-2018 20:00:00 EDT", schedule.CasualtyLosses[0].Record(newYork)[2])
+}
+
+func TestNewCharitableContribution_ShortTerm(t *testing.T) {
+	contribution := NewCharitableContribution(&Form8949LineItem{
+		CostBasis: "1000.00",
+		Proceeds:  "1500.00"}, HOLDING_SHORT)
+	assert.Equal(t, "1000.00", contribution.Deduction)
+
+	contribution = NewCharitableContribution(&Form8949LineItem{
+		CostBasis: "1000.00",
+		Proceeds:  "800.00"}, HOLDING_SHORT)
+	assert.Equal(t, "800.00", contribution.Deduction)
+}
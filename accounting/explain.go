@@ -0,0 +1,90 @@
+package accounting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const EXPLAIN_DATE_FORMAT = "2006-01-02"
+
+// LineItemExplanation shows how a Form 8949 line was derived from the sale
+// and the acquisitions it consumed.
+type LineItemExplanation struct {
+	TransactionId string         `json:"transaction_id"`
+	Method        string         `json:"method"`
+	Holding       string         `json:"holding"`
+	Currency      string         `json:"currency"`
+	Description   string         `json:"description"`
+	DateAcquired  time.Time      `json:"date_acquired"`
+	DateSold      time.Time      `json:"date_sold"`
+	Proceeds      string         `json:"proceeds"`
+	CostBasis     string         `json:"cost_basis"`
+	Fees          string         `json:"fees"`
+	GainOrLoss    string         `json:"gain_or_loss"`
+	Lots          []LotReference `json:"lots"`
+	Derivation    []string       `json:"derivation"`
+}
+
+// ExplainLineItems returns the derivation of every line on the form reported
+// for the transaction. A single trade can produce more than one line, for
+// example when its fee was paid in a third currency.
+func ExplainLineItems(form *Form8949, transactionId, method string) []LineItemExplanation {
+	var explanations []LineItemExplanation
+	for _, holding := range []struct {
+		name      string
+		lineItems []Form8949LineItem
+	}{
+		{HOLDING_SHORT, form.ShortHolds},
+		{HOLDING_LONG, form.LongHolds}} {
+		for _, lineItem := range holding.lineItems {
+			if lineItem.TransactionId != transactionId {
+				continue
+			}
+			explanations = append(explanations, explainLineItem(&lineItem, holding.name, method))
+		}
+	}
+	return explanations
+}
+
+func explainLineItem(lineItem *Form8949LineItem, holding, method string) LineItemExplanation {
+	var derivation, bases []string
+	derivation = append(derivation, fmt.Sprintf("Sold %s on %s for proceeds of %s net of sale fees",
+		lineItem.Description, lineItem.DateSold.Format(EXPLAIN_DATE_FORMAT), lineItem.Proceeds))
+	for _, lot := range lineItem.Lots {
+		quantity, _ := decimal.NewFromString(lot.Quantity)
+		costBasis, _ := decimal.NewFromString(lot.CostBasis)
+		unitCost := decimal.Zero
+		if !quantity.IsZero() {
+			unitCost = costBasis.Div(quantity)
+		}
+		derivation = append(derivation, fmt.Sprintf("Lot %s acquired %s: %s %s at %s per unit = %s cost basis including %s fees",
+			lot.TransactionId, lot.Date.Format(EXPLAIN_DATE_FORMAT), lot.Quantity, lineItem.Currency,
+			unitCost.StringFixed(2), lot.CostBasis, lot.Fee))
+		bases = append(bases, lot.CostBasis)
+	}
+	if len(bases) > 1 {
+		derivation = append(derivation, fmt.Sprintf("Cost basis = %s = %s", strings.Join(bases, " + "), lineItem.CostBasis))
+	}
+	derivation = append(derivation, fmt.Sprintf("Gain or loss = %s proceeds - %s cost basis = %s",
+		lineItem.Proceeds, lineItem.CostBasis, lineItem.GainOrLoss))
+	days := int(lineItem.DateSold.Sub(lineItem.DateAcquired).Hours() / 24)
+	derivation = append(derivation, fmt.Sprintf("Held %d days from %s: %s term, lots selected by %s",
+		days, lineItem.DateAcquired.Format(EXPLAIN_DATE_FORMAT), holding, method))
+	return LineItemExplanation{
+		TransactionId: lineItem.TransactionId,
+		Method:        method,
+		Holding:       holding,
+		Currency:      lineItem.Currency,
+		Description:   lineItem.Description,
+		DateAcquired:  lineItem.DateAcquired,
+		DateSold:      lineItem.DateSold,
+		Proceeds:      lineItem.Proceeds,
+		CostBasis:     lineItem.CostBasis,
+		Fees:          lineItem.Fees,
+		GainOrLoss:    lineItem.GainOrLoss,
+		Lots:          lineItem.Lots,
+		Derivation:    derivation}
+}
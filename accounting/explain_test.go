@@ -0,0 +1,44 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainLineItems(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := createReportTestTransactions()
+	transactions[0].(*dto.TransactionDTO).Quantity = "1.5"
+	transactions[0].(*dto.TransactionDTO).FiatTotal = "7500"
+
+	report := NewFifoReport(ctx, transactions)
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, 0, len(ExplainLineItems(form8949, "1", COST_BASIS_FIFO)))
+
+	explanations := ExplainLineItems(form8949, "4", COST_BASIS_FIFO)
+	assert.Equal(t, 1, len(explanations))
+
+	explanation := explanations[0]
+	assert.Equal(t, HOLDING_SHORT, explanation.Holding)
+	assert.Equal(t, "2500.00", explanation.CostBasis)
+	assert.Equal(t, 2, len(explanation.Lots))
+	assert.Equal(t, "1", explanation.Lots[0].TransactionId)
+	assert.Equal(t, "1", explanation.Lots[0].Quantity)
+	assert.Equal(t, "1000.00", explanation.Lots[0].CostBasis)
+	assert.Equal(t, "2", explanation.Lots[1].TransactionId)
+	assert.Equal(t, "0.5", explanation.Lots[1].Quantity)
+	assert.Equal(t, "1500.00", explanation.Lots[1].CostBasis)
+	assert.Equal(t, []string{
+		"Sold 1.5 BTC on 2017-06-01 for proceeds of 7500.00 net of sale fees",
+		"Lot 1 acquired 2017-01-01: 1 BTC at 1000.00 per unit = 1000.00 cost basis including 0.00 fees",
+		"Lot 2 acquired 2017-03-01: 0.5 BTC at 3000.00 per unit = 1500.00 cost basis including 0.00 fees",
+		"Cost basis = 1000.00 + 1500.00 = 2500.00",
+		"Gain or loss = 7500.00 proceeds - 2500.00 cost basis = 5000.00",
+		"Held 151 days from 2017-01-01: short term, lots selected by fifo"}, explanation.Derivation)
+}
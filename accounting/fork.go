@@ -0,0 +1,48 @@
+package accounting
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// IsFork returns true when the transaction received coins from a chain split
+// or an airdrop. Neither is a purchase: the coins are acquired at the cost
+// basis of the fork event, zero unless the user configured one.
+func IsFork(tx common.Transaction) bool {
+	if tx.GetType() != common.DEPOSIT_ORDER_TYPE {
+		return false
+	}
+	return tx.GetCategory() == common.TX_CATEGORY_FORK ||
+		tx.GetCategory() == common.TX_CATEGORY_AIRDROP
+}
+
+// SetForkEvents provides the cost basis of the coins received from forks and
+// airdrops. Without them those coins have a zero basis.
+func (report *Report) SetForkEvents(events []common.ForkEvent) {
+	report.forkEvents = events
+}
+
+// forkLot creates the lot acquired by a fork or airdrop deposit.
+func (report *Report) forkLot(tx common.Transaction) Coinlot {
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	unitPrice := report.forkUnitPrice(tx)
+	return Coinlot{
+		TransactionId: tx.GetId(),
+		Date:          tx.GetDate(),
+		Currency:      tx.GetCurrencyPair().Base,
+		Quantity:      quantity,
+		UnitPrice:     unitPrice,
+		CostBasis:     quantity.Mul(unitPrice)}
+}
+
+// forkUnitPrice returns the cost basis per coin of the fork event that
+// distributed the deposit's currency. The transaction's own fiat values are
+// ignored since an imported deposit carries the market price of the day.
+func (report *Report) forkUnitPrice(tx common.Transaction) decimal.Decimal {
+	for _, event := range report.forkEvents {
+		if event.GetNewCurrency() == tx.GetCurrencyPair().Base && event.GetCategory() == tx.GetCategory() {
+			return event.GetCostBasis()
+		}
+	}
+	return decimal.Zero
+}
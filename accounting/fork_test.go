@@ -0,0 +1,89 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createForkTestTransactions() []common.Transaction {
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:             "3",
+			Date:           time.Date(2017, 9, 01, 12, 0, 0, 0, time.UTC),
+			CurrencyPair:   &common.CurrencyPair{Base: "BCH", Quote: "USD", LocalCurrency: "USD"},
+			Type:           common.SELL_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "1",
+			Total:          "500",
+			FiatPrice:      "500",
+			FiatTotal:      "500",
+			QuoteFiatPrice: "1"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 8, 01, 13, 16, 14, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BCH", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_FORK,
+			Network:      "gdax",
+			Quantity:     "1",
+			FiatPrice:    "300",
+			FiatTotal:    "300"},
+		&dto.TransactionDTO{
+			Id:             "1",
+			Date:           time.Date(2017, 1, 01, 12, 0, 0, 0, time.UTC),
+			CurrencyPair:   &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+			Type:           common.BUY_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "1",
+			Total:          "1000",
+			FiatPrice:      "1000",
+			FiatTotal:      "1000",
+			QuoteFiatPrice: "1"}}
+}
+
+func TestReport_ForkZeroBasis(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	transactions := createForkTestTransactions()
+	report := NewFifoReport(ctx, transactions)
+
+	assert.True(t, IsFork(transactions[1]))
+	assert.False(t, IsIncome(transactions[1]))
+	assert.False(t, report.isTaxable(transactions[1]))
+
+	form := report.Run(time.Date(2017, 1, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(form.ShortHolds))
+	assert.Equal(t, "1 BCH", form.ShortHolds[0].Description)
+	assert.Equal(t, time.Date(2017, 8, 01, 13, 16, 14, 0, time.UTC), form.ShortHolds[0].DateAcquired)
+	assert.Equal(t, "0.00", form.ShortHolds[0].CostBasis)
+	assert.Equal(t, "500.00", form.ShortHolds[0].GainOrLoss)
+	assert.Equal(t, 0, len(report.Income))
+}
+
+func TestReport_ForkConfiguredBasis(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	report := NewFifoReport(ctx, createForkTestTransactions())
+	report.SetForkEvents([]common.ForkEvent{
+		&dto.ForkEventDTO{
+			Category:    common.TX_CATEGORY_AIRDROP,
+			Currency:    "BTC",
+			NewCurrency: "BCH",
+			CostBasis:   decimal.NewFromFloat(100)},
+		&dto.ForkEventDTO{
+			Category:    common.TX_CATEGORY_FORK,
+			Currency:    "BTC",
+			NewCurrency: "BCH",
+			Date:        time.Date(2017, 8, 01, 13, 16, 14, 0, time.UTC),
+			Ratio:       decimal.NewFromFloat(1),
+			CostBasis:   decimal.NewFromFloat(50)}})
+
+	form := report.Run(time.Date(2017, 1, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(form.ShortHolds))
+	assert.Equal(t, "50.00", form.ShortHolds[0].CostBasis)
+	assert.Equal(t, "450.00", form.ShortHolds[0].GainOrLoss)
+}
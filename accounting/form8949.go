@@ -2,16 +2,74 @@ package accounting
 
 import (
 	"encoding/csv"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/jeremyhahn/tradebot/common"
 )
 
+const (
+	TXF_VERSION              = "V042"
+	TXF_DATE_FORMAT          = "01/02/2006"
+	FORM_8949_CODE_WASH_SALE = "W"
+)
+
+// TXF (Tax Exchange Format) reference numbers of the Form 8949 boxes, which
+// TurboTax and H&R Block use to file each imported sale.
+var TXF_REF_NUMBERS = map[string]string{
+	FORM_8949_BOX_A: "321",
+	FORM_8949_BOX_B: "711",
+	FORM_8949_BOX_C: "712",
+	FORM_8949_BOX_D: "323",
+	FORM_8949_BOX_E: "713",
+	FORM_8949_BOX_F: "714"}
+
+// Form8949 lists the sales of a tax year. ReportedOn1099B and BasisReported
+// select the check box of the form; digital asset sales were not reported on
+// Form 1099-B, so they default to box C (short-term) and F (long-term).
+// Dates are written in Location, the time zone the tax year was requested in.
 type Form8949 struct {
-	LongHolds  []Form8949LineItem
-	ShortHolds []Form8949LineItem
+	LongHolds       []Form8949LineItem
+	ShortHolds      []Form8949LineItem
+	ReportedOn1099B bool
+	BasisReported   bool
+	Location        *time.Location `json:"-"`
+}
+
+// GetBox returns the check box the short-term or long-term sales are
+// reported under.
+func (form *Form8949) GetBox(holding string) string {
+	return Form8949Box(holding, form.ReportedOn1099B, form.BasisReported)
+}
+
+func (form *Form8949) getLineItems(holding string) []Form8949LineItem {
+	if holding == HOLDING_LONG {
+		return form.LongHolds
+	}
+	return form.ShortHolds
+}
+
+// getLocation returns the time zone the form's dates are written in, the
+// local time zone when none was requested.
+func (form *Form8949) getLocation() *time.Location {
+	return reportLocation(form.Location)
+}
+
+// taxYear returns the year of the latest sale, or zero when there are none.
+func (form *Form8949) taxYear() int {
+	year := 0
+	for _, lineItems := range [][]Form8949LineItem{form.ShortHolds, form.LongHolds} {
+		for _, lineItem := range lineItems {
+			if lineItem.DateSold.In(form.getLocation()).Year() > year {
+				year = lineItem.DateSold.In(form.getLocation()).Year()
+			}
+		}
+	}
+	return year
 }
 
 func (form *Form8949) sort() {
@@ -45,6 +103,7 @@ func (form *Form8949) WriteCSV(filename string) error {
 		"Cost Basis",
 		"Adjustment Code",
 		"Adjustment amount",
+		"Fees",
 		"Gain or loss"}
 
 	writer.Write(emptyRow)
@@ -52,7 +111,7 @@ func (form *Form8949) WriteCSV(filename string) error {
 	writer.Write(header)
 
 	for _, shortHold := range form.ShortHolds {
-		err := writer.Write(shortHold.Record())
+		err := writer.Write(shortHold.Record(form.getLocation()))
 		if err != nil {
 			return err
 		}
@@ -64,7 +123,7 @@ func (form *Form8949) WriteCSV(filename string) error {
 	writer.Write(header)
 
 	for _, longHold := range form.LongHolds {
-		err := writer.Write(longHold.Record())
+		err := writer.Write(longHold.Record(form.getLocation()))
 		if err != nil {
 			return err
 		}
@@ -73,7 +132,40 @@ func (form *Form8949) WriteCSV(filename string) error {
 	return nil
 }
 
+// WriteTXF writes the sales as TXF records, one per line item, which tax
+// software imports in place of typing in each sale.
+func (form *Form8949) WriteTXF(filename string) error {
+	var txf strings.Builder
+	writeLine := func(line string) {
+		txf.WriteString(line)
+		txf.WriteString("\r\n")
+	}
+	writeLine(TXF_VERSION)
+	writeLine(fmt.Sprintf("A%s", common.APPNAME))
+	writeLine(fmt.Sprintf("D%s", time.Now().Format(TXF_DATE_FORMAT)))
+	writeLine("^")
+	for _, holding := range []string{HOLDING_SHORT, HOLDING_LONG} {
+		for _, lineItem := range form.getLineItems(holding) {
+			writeLine("TD")
+			writeLine(fmt.Sprintf("N%s", TXF_REF_NUMBERS[form.GetBox(holding)]))
+			writeLine("C1")
+			writeLine("L1")
+			writeLine(fmt.Sprintf("P%s", lineItem.Description))
+			writeLine(fmt.Sprintf("D%s", lineItem.DateAcquired.In(form.getLocation()).Format(TXF_DATE_FORMAT)))
+			writeLine(fmt.Sprintf("D%s", lineItem.DateSold.In(form.getLocation()).Format(TXF_DATE_FORMAT)))
+			writeLine(fmt.Sprintf("$%s", parseAmount(lineItem.CostBasis).StringFixed(2)))
+			writeLine(fmt.Sprintf("$%s", parseAmount(lineItem.Proceeds).StringFixed(2)))
+			if lineItem.AdjustmentCode == FORM_8949_CODE_WASH_SALE {
+				writeLine(fmt.Sprintf("$%s", parseAmount(lineItem.AdjustmentAmount).StringFixed(2)))
+			}
+			writeLine("^")
+		}
+	}
+	return ioutil.WriteFile(filename, []byte(txf.String()), 0644)
+}
+
 type Form8949LineItem struct {
+	TransactionId    string
 	Currency         string
 	Description      string
 	DateAcquired     time.Time
@@ -82,17 +174,40 @@ type Form8949LineItem struct {
 	CostBasis        string
 	AdjustmentCode   string
 	AdjustmentAmount string
+	Fees             string
 	GainOrLoss       string
+	MissingBasis     string
+	Lots             []LotReference
+}
+
+// LotReference records the portion of an acquisition consumed by a sale.
+type LotReference struct {
+	TransactionId string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Quantity      string    `json:"quantity"`
+	CostBasis     string    `json:"cost_basis"`
+	Fee           string    `json:"fee"`
 }
 
-func (item *Form8949LineItem) Record() []string {
+// Record returns the line item as a CSV record with its dates in location.
+func (item *Form8949LineItem) Record(location *time.Location) []string {
 	return []string{
 		item.Description,
-		item.DateAcquired.Local().Format(common.TIME_DISPLAY_FORMAT),
-		item.DateSold.Local().Format(common.TIME_DISPLAY_FORMAT),
+		item.DateAcquired.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		item.DateSold.In(location).Format(common.TIME_DISPLAY_FORMAT),
 		item.Proceeds,
 		item.CostBasis,
 		item.AdjustmentCode,
 		item.AdjustmentAmount,
+		item.Fees,
 		item.GainOrLoss}
 }
+
+// reportLocation returns the time zone report dates are written in, the local
+// time zone when none was requested.
+func reportLocation(location *time.Location) *time.Location {
+	if location == nil {
+		return time.Local
+	}
+	return location
+}
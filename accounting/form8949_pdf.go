@@ -0,0 +1,193 @@
+package accounting
+
+import (
+	"fmt"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// The IRS form has room for 14 sales per page, each page totaling its own.
+const FORM_8949_ROWS_PER_PAGE = 14
+
+var form8949BoxDescriptions = map[string]string{
+	FORM_8949_BOX_A: "Short-term transactions reported on Form(s) 1099-B showing basis was reported to the IRS",
+	FORM_8949_BOX_B: "Short-term transactions reported on Form(s) 1099-B showing basis wasn't reported to the IRS",
+	FORM_8949_BOX_C: "Short-term transactions not reported to you on Form 1099-B",
+	FORM_8949_BOX_D: "Long-term transactions reported on Form(s) 1099-B showing basis was reported to the IRS",
+	FORM_8949_BOX_E: "Long-term transactions reported on Form(s) 1099-B showing basis wasn't reported to the IRS",
+	FORM_8949_BOX_F: "Long-term transactions not reported to you on Form 1099-B"}
+
+type pdfColumn struct {
+	heading string
+	width   float64
+	align   string
+}
+
+var form8949Columns = []pdfColumn{
+	pdfColumn{"(a) Description of property", 73.4, "L"},
+	pdfColumn{"(b) Date acquired", 26, "C"},
+	pdfColumn{"(c) Date sold", 26, "C"},
+	pdfColumn{"(d) Proceeds", 30, "R"},
+	pdfColumn{"(e) Cost or other basis", 30, "R"},
+	pdfColumn{"(f) Code(s)", 14, "C"},
+	pdfColumn{"(g) Adjustment", 30, "R"},
+	pdfColumn{"(h) Gain or (loss)", 30, "R"}}
+
+var scheduleDColumns = []pdfColumn{
+	pdfColumn{"Line", 15, "C"},
+	pdfColumn{"", 124.4, "L"},
+	pdfColumn{"(d) Proceeds", 30, "R"},
+	pdfColumn{"(e) Cost or other basis", 30, "R"},
+	pdfColumn{"(g) Adjustments", 30, "R"},
+	pdfColumn{"(h) Gain or (loss)", 30, "R"}}
+
+// WritePDF writes the form laid out as the IRS Form 8949, with the box of
+// each part checked, followed by a Schedule D summary page.
+func (form *Form8949) WritePDF(filename string) error {
+	pdf := gofpdf.New("L", "mm", "Letter", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	year := form.taxYear()
+	pages := 0
+	for _, holding := range []string{HOLDING_SHORT, HOLDING_LONG} {
+		lineItems := form.getLineItems(holding)
+		for start := 0; start < len(lineItems); start += FORM_8949_ROWS_PER_PAGE {
+			end := start + FORM_8949_ROWS_PER_PAGE
+			if end > len(lineItems) {
+				end = len(lineItems)
+			}
+			form.writePDFPage(pdf, year, holding, lineItems[start:end])
+			pages++
+		}
+	}
+	if pages == 0 {
+		form.writePDFPage(pdf, year, HOLDING_SHORT, nil)
+	}
+	NewScheduleD(form).writePDFPage(pdf, year)
+	return pdf.OutputFileAndClose(filename)
+}
+
+func (form *Form8949) writePDFPage(pdf *gofpdf.Fpdf, year int, holding string, lineItems []Form8949LineItem) {
+	pdf.AddPage()
+	writePDFTitle(pdf, "Form 8949", "Sales and Other Dispositions of Capital Assets", year)
+
+	part := "Part I    Short-Term. Transactions involving capital assets you held 1 year or less"
+	boxes := []string{FORM_8949_BOX_A, FORM_8949_BOX_B, FORM_8949_BOX_C}
+	if holding == HOLDING_LONG {
+		part = "Part II    Long-Term. Transactions involving capital assets you held more than 1 year"
+		boxes = []string{FORM_8949_BOX_D, FORM_8949_BOX_E, FORM_8949_BOX_F}
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 7, part, "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+	pdf.SetFont("Helvetica", "", 9)
+	checked := form.GetBox(holding)
+	for _, box := range boxes {
+		mark := ""
+		if box == checked {
+			mark = "X"
+		}
+		pdf.CellFormat(5, 5, mark, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("  (%s) %s", box, form8949BoxDescriptions[box]), "", 1, "L", false, 0, "")
+		pdf.Ln(1)
+	}
+	pdf.Ln(2)
+
+	writePDFHeadings(pdf, form8949Columns)
+	pdf.SetFont("Helvetica", "", 8)
+	for _, lineItem := range lineItems {
+		writePDFRow(pdf, form8949Columns, []string{
+			lineItem.Description,
+			lineItem.DateAcquired.In(form.getLocation()).Format(TXF_DATE_FORMAT),
+			lineItem.DateSold.In(form.getLocation()).Format(TXF_DATE_FORMAT),
+			lineItem.Proceeds,
+			lineItem.CostBasis,
+			lineItem.AdjustmentCode,
+			lineItem.AdjustmentAmount,
+			lineItem.GainOrLoss})
+	}
+	for i := len(lineItems); i < FORM_8949_ROWS_PER_PAGE; i++ {
+		writePDFRow(pdf, form8949Columns, make([]string, len(form8949Columns)))
+	}
+
+	totals := sumLineItems(lineItems)
+	pdf.SetFont("Helvetica", "B", 8)
+	writePDFRow(pdf, form8949Columns, []string{
+		"2  Totals (carry to Schedule D)",
+		"",
+		"",
+		totals.proceeds.StringFixed(2),
+		totals.costBasis.StringFixed(2),
+		"",
+		totals.adjustments.StringFixed(2),
+		totals.gainOrLoss.StringFixed(2)})
+}
+
+func (schedule *ScheduleD) writePDFPage(pdf *gofpdf.Fpdf, year int) {
+	pdf.AddPage()
+	writePDFTitle(pdf, "Schedule D", "Capital Gains and Losses (Summary)", year)
+	parts := []struct {
+		heading string
+		lines   []ScheduleDLine
+		net     ScheduleDLine
+	}{
+		{"Part I    Short-Term Capital Gains and Losses - Generally Assets Held One Year or Less",
+			schedule.ShortTerm,
+			ScheduleDLine{Line: "7", Description: "Net short-term capital gain or (loss)", GainOrLoss: schedule.NetShortTerm}},
+		{"Part II    Long-Term Capital Gains and Losses - Generally Assets Held More Than One Year",
+			schedule.LongTerm,
+			ScheduleDLine{Line: "15", Description: "Net long-term capital gain or (loss)", GainOrLoss: schedule.NetLongTerm}}}
+	for _, part := range parts {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(0, 7, part.heading, "B", 1, "L", false, 0, "")
+		pdf.Ln(2)
+		writePDFHeadings(pdf, scheduleDColumns)
+		pdf.SetFont("Helvetica", "", 8)
+		for _, line := range part.lines {
+			writePDFRow(pdf, scheduleDColumns, line.record())
+		}
+		pdf.SetFont("Helvetica", "B", 8)
+		writePDFRow(pdf, scheduleDColumns, part.net.record())
+		pdf.Ln(6)
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 7, "Part III    Summary", "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+	pdf.SetFont("Helvetica", "B", 8)
+	writePDFRow(pdf, scheduleDColumns, ScheduleDLine{
+		Line:        "16",
+		Description: "Combine lines 7 and 15",
+		GainOrLoss:  schedule.Total}.record())
+}
+
+func (line ScheduleDLine) record() []string {
+	return []string{line.Line, line.Description, line.Proceeds, line.CostBasis, line.Adjustments, line.GainOrLoss}
+}
+
+func writePDFTitle(pdf *gofpdf.Fpdf, form, title string, year int) {
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(40, 9, form, "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(180, 9, title, "", 0, "L", false, 0, "")
+	if year > 0 {
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(0, 9, fmt.Sprintf("%d", year), "", 0, "R", false, 0, "")
+	}
+	pdf.Ln(12)
+}
+
+func writePDFHeadings(pdf *gofpdf.Fpdf, columns []pdfColumn) {
+	pdf.SetFont("Helvetica", "B", 7)
+	pdf.SetFillColor(230, 230, 230)
+	for _, column := range columns {
+		pdf.CellFormat(column.width, 7, column.heading, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func writePDFRow(pdf *gofpdf.Fpdf, columns []pdfColumn, values []string) {
+	for i, column := range columns {
+		pdf.CellFormat(column.width, 7, values[i], "1", 0, column.align, false, 0, "")
+	}
+	pdf.Ln(-1)
+}
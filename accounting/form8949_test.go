@@ -0,0 +1,131 @@
+package accounting
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createForm8949() *Form8949 {
+	return &Form8949{
+		ShortHolds: []Form8949LineItem{
+			Form8949LineItem{
+				TransactionId: "2",
+				Description:   "1 BTC",
+				DateAcquired:  time.Date(2017, 01, 01, 12, 0, 0, 0, time.Local),
+				DateSold:      time.Date(2017, 06, 01, 12, 0, 0, 0, time.Local),
+				Proceeds:      "3000.00",
+				CostBasis:     "1000.00",
+				Fees:          "0.00",
+				GainOrLoss:    "2000.00"},
+			Form8949LineItem{
+				TransactionId:    "4",
+				Description:      "0.5 ETH",
+				DateAcquired:     time.Date(2017, 07, 01, 12, 0, 0, 0, time.Local),
+				DateSold:         time.Date(2017, 8, 01, 12, 0, 0, 0, time.Local),
+				Proceeds:         "100.00",
+				CostBasis:        "150.00",
+				AdjustmentCode:   FORM_8949_CODE_WASH_SALE,
+				AdjustmentAmount: "50.00",
+				Fees:             "0.00",
+				GainOrLoss:       "0.00"}},
+		LongHolds: []Form8949LineItem{
+			Form8949LineItem{
+				TransactionId: "3",
+				Description:   "1 BTC",
+				DateAcquired:  time.Date(2016, 01, 01, 12, 0, 0, 0, time.Local),
+				DateSold:      time.Date(2017, 12, 01, 12, 0, 0, 0, time.Local),
+				Proceeds:      "10000.00",
+				CostBasis:     "500.00",
+				Fees:          "0.00",
+				GainOrLoss:    "9500.00"}}}
+}
+
+func TestForm8949Box(t *testing.T) {
+	assert.Equal(t, FORM_8949_BOX_A, Form8949Box(HOLDING_SHORT, true, true))
+	assert.Equal(t, FORM_8949_BOX_B, Form8949Box(HOLDING_SHORT, true, false))
+	assert.Equal(t, FORM_8949_BOX_C, Form8949Box(HOLDING_SHORT, false, false))
+	assert.Equal(t, FORM_8949_BOX_D, Form8949Box(HOLDING_LONG, true, true))
+	assert.Equal(t, FORM_8949_BOX_E, Form8949Box(HOLDING_LONG, true, false))
+	assert.Equal(t, FORM_8949_BOX_F, Form8949Box(HOLDING_LONG, false, false))
+}
+
+func TestNewScheduleD(t *testing.T) {
+	schedule := NewScheduleD(createForm8949())
+
+	assert.Equal(t, 3, len(schedule.ShortTerm))
+	assert.Equal(t, ScheduleDLine{
+		Line:        "3",
+		Box:         FORM_8949_BOX_C,
+		Description: "Short-term totals from Form 8949 with Box C checked",
+		Proceeds:    "3100.00",
+		CostBasis:   "1150.00",
+		Adjustments: "50.00",
+		GainOrLoss:  "2000.00"}, schedule.ShortTerm[2])
+	assert.Equal(t, "0.00", schedule.ShortTerm[0].Proceeds)
+
+	assert.Equal(t, 3, len(schedule.LongTerm))
+	assert.Equal(t, "10", schedule.LongTerm[2].Line)
+	assert.Equal(t, "9500.00", schedule.LongTerm[2].GainOrLoss)
+	assert.Equal(t, "0.00", schedule.LongTerm[0].GainOrLoss)
+
+	assert.Equal(t, "2000.00", schedule.NetShortTerm)
+	assert.Equal(t, "9500.00", schedule.NetLongTerm)
+	assert.Equal(t, "11500.00", schedule.Total)
+}
+
+func TestForm8949_WriteTXF(t *testing.T) {
+	filename := "/tmp/tradebot-form8949-test.txf"
+	defer os.Remove(filename)
+
+	assert.Nil(t, createForm8949().WriteTXF(filename))
+	bytes, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	txf := string(bytes)
+
+	assert.True(t, strings.HasPrefix(txf, "V042\r\nAtradebot\r\nD"))
+	assert.Equal(t, 3, strings.Count(txf, "TD\r\n"))
+	assert.Contains(t, txf, "TD\r\nN712\r\nC1\r\nL1\r\nP1 BTC\r\nD01/01/2017\r\nD06/01/2017\r\n$1000.00\r\n$3000.00\r\n^\r\n")
+	assert.Contains(t, txf, "P0.5 ETH\r\nD07/01/2017\r\nD08/01/2017\r\n$150.00\r\n$100.00\r\n$50.00\r\n^\r\n")
+	assert.Contains(t, txf, "TD\r\nN714\r\nC1\r\nL1\r\nP1 BTC\r\nD01/01/2016\r\nD12/01/2017\r\n$500.00\r\n$10000.00\r\n^\r\n")
+}
+
+func TestForm8949_WriteTXFInLocation(t *testing.T) {
+	filename := "/tmp/tradebot-form8949-location-test.txf"
+	defer os.Remove(filename)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+	form := &Form8949{
+		ShortHolds: []Form8949LineItem{
+			Form8949LineItem{
+				Description:  "1 BTC",
+				DateAcquired: time.Date(2017, 06, 30, 18, 0, 0, 0, time.UTC),
+				DateSold:     time.Date(2017, 12, 31, 18, 0, 0, 0, time.UTC),
+				Proceeds:     "3000.00",
+				CostBasis:    "1000.00"}},
+		Location: tokyo}
+	assert.Equal(t, 2018, form.taxYear())
+
+	assert.Nil(t, form.WriteTXF(filename))
+	bytes, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Contains(t, string(bytes), "P1 BTC\r\nD07/01/2017\r\nD01/01/2018\r\n")
+	assert.Equal(t, "01-01-2018 03:00:00 JST", form.ShortHolds[0].Record(tokyo)[2])
+}
+
+func TestForm8949_WritePDF(t *testing.T) {
+	filename := "/tmp/tradebot-form8949-test.pdf"
+	defer os.Remove(filename)
+
+	assert.Nil(t, createForm8949().WritePDF(filename))
+	bytes, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(bytes), "%PDF"))
+
+	assert.Nil(t, (&Form8949{}).WritePDF(filename))
+}
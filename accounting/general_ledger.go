@@ -0,0 +1,531 @@
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ACCOUNT_TYPE_ASSET     = "asset"
+	ACCOUNT_TYPE_LIABILITY = "liability"
+	ACCOUNT_TYPE_EQUITY    = "equity"
+	ACCOUNT_TYPE_INCOME    = "income"
+	ACCOUNT_TYPE_EXPENSE   = "expense"
+	LEDGER_ACCOUNT_GAINS   = "Income:Realized-Gains"
+	// Net income of the periods before the balance sheet date
+	LEDGER_ACCOUNT_RETAINED_EARNINGS = "Equity:Retained-Earnings"
+)
+
+var accountTypes = map[string]string{
+	"Assets":      ACCOUNT_TYPE_ASSET,
+	"Liabilities": ACCOUNT_TYPE_LIABILITY,
+	"Equity":      ACCOUNT_TYPE_EQUITY,
+	"Income":      ACCOUNT_TYPE_INCOME,
+	"Expenses":    ACCOUNT_TYPE_EXPENSE}
+
+// GeneralLedger posts the transaction history as balanced double-entry
+// journal entries valued in the user's local currency. Coins are carried at
+// cost: acquisitions at the fiat value paid, disposals relieved at the cost of
+// the lots the report consumed, the difference to the value received posted
+// to realized gains. Fees are expensed when paid and transfers move coins
+// between accounts at the average cost of the sending account.
+type GeneralLedger struct {
+	ctx      common.Context
+	report   *Report
+	balances map[string]*ledgerBalance
+	Currency string
+	Entries  []LedgerEntry
+}
+
+type LedgerAccount struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type LedgerEntry struct {
+	TransactionId string          `json:"transaction_id"`
+	Date          time.Time       `json:"date"`
+	Description   string          `json:"description"`
+	Postings      []LedgerPosting `json:"postings"`
+}
+
+// LedgerPosting debits (positive Amount) or credits (negative Amount) an
+// account. Asset postings also move a quantity of the account's commodity.
+type LedgerPosting struct {
+	Account   string          `json:"account"`
+	Commodity string          `json:"commodity"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+type TrialBalance struct {
+	Date     time.Time          `json:"date"`
+	Currency string             `json:"currency"`
+	Accounts []TrialBalanceLine `json:"accounts"`
+	Debits   string             `json:"debits"`
+	Credits  string             `json:"credits"`
+}
+
+type TrialBalanceLine struct {
+	Account string `json:"account"`
+	Type    string `json:"type"`
+	Debit   string `json:"debit"`
+	Credit  string `json:"credit"`
+}
+
+type AccountRegister struct {
+	Account        string         `json:"account"`
+	Currency       string         `json:"currency"`
+	OpeningBalance string         `json:"opening_balance"`
+	Lines          []RegisterLine `json:"lines"`
+	ClosingBalance string         `json:"closing_balance"`
+}
+
+type RegisterLine struct {
+	TransactionId string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Description   string    `json:"description"`
+	Account       string    `json:"account"`
+	Commodity     string    `json:"commodity"`
+	Quantity      string    `json:"quantity"`
+	Debit         string    `json:"debit"`
+	Credit        string    `json:"credit"`
+	Balance       string    `json:"balance"`
+}
+
+type BalanceSheet struct {
+	Date                      time.Time          `json:"date"`
+	Currency                  string             `json:"currency"`
+	Assets                    []BalanceSheetLine `json:"assets"`
+	Liabilities               []BalanceSheetLine `json:"liabilities"`
+	Equity                    []BalanceSheetLine `json:"equity"`
+	TotalAssets               string             `json:"total_assets"`
+	TotalLiabilities          string             `json:"total_liabilities"`
+	TotalEquity               string             `json:"total_equity"`
+	TotalLiabilitiesAndEquity string             `json:"total_liabilities_and_equity"`
+}
+
+type BalanceSheetLine struct {
+	Account   string `json:"account"`
+	Commodity string `json:"commodity,omitempty"`
+	Quantity  string `json:"quantity,omitempty"`
+	Balance   string `json:"balance"`
+}
+
+type ledgerBalance struct {
+	quantity decimal.Decimal
+	amount   decimal.Decimal
+}
+
+// NewGeneralLedger runs the report over the full transaction history and
+// posts every transaction. The report's lot selection method determines the
+// cost relieved by each disposal.
+func NewGeneralLedger(ctx common.Context, report *Report) *GeneralLedger {
+	ledger := &GeneralLedger{
+		ctx:      ctx,
+		report:   report,
+		balances: make(map[string]*ledgerBalance),
+		Currency: ctx.GetUser().GetLocalCurrency()}
+	report.Run(time.Time{}, time.Now())
+	history := make(map[string]common.Transaction)
+	var sorted []common.Transaction
+	for _, tx := range report.Transactions {
+		if tx.IsDeleted() {
+			continue
+		}
+		history[tx.GetId()] = tx
+		sorted = append(sorted, tx)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetDate().Before(sorted[j].GetDate())
+	})
+	for _, tx := range sorted {
+		entry, ok := ledger.newEntry(tx, history)
+		if !ok {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			balance := ledger.getBalance(posting.Account)
+			balance.quantity = balance.quantity.Add(posting.Quantity)
+			balance.amount = balance.amount.Add(posting.Amount)
+		}
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+	return ledger
+}
+
+func (ledger *GeneralLedger) newEntry(tx common.Transaction, history map[string]common.Transaction) (LedgerEntry, bool) {
+	base := tx.GetCurrencyPair().Base
+	quote := tx.GetCurrencyPair().Quote
+	feeCurrency := tx.GetFeeCurrency()
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	total, _ := decimal.NewFromString(tx.GetTotal())
+	fee, _ := decimal.NewFromString(tx.GetFee())
+	fiatTotal, _ := decimal.NewFromString(tx.GetFiatTotal())
+	fiatFee, _ := decimal.NewFromString(tx.GetFiatFee())
+	if fiatTotal.IsZero() {
+		fiatQuantity, _ := decimal.NewFromString(tx.GetFiatQuantity())
+		fiatTotal = fiatQuantity
+	}
+	entry := &LedgerEntry{
+		TransactionId: tx.GetId(),
+		Date:          tx.GetDate()}
+	network := tx.GetNetwork()
+	if !fee.IsPositive() || feeCurrency == "" {
+		fee, fiatFee = decimal.Zero, decimal.Zero
+	}
+
+	switch tx.GetType() {
+	case common.BUY_ORDER_TYPE:
+		entry.Description = fmt.Sprintf("Buy %s %s for %s %s", quantity, base, total, quote)
+		received, value := quantity, fiatTotal
+		if feeCurrency == base {
+			received, value = quantity.Sub(fee), fiatTotal.Sub(fiatFee)
+		}
+		entry.debit(assetAccount(network, base), base, received, value)
+		spent := total
+		if feeCurrency == quote {
+			spent = total.Add(fee)
+		}
+		ledger.payFee(entry, tx, fee, fiatFee, base, quote)
+		if !ledger.relieve(entry, tx, quote, spent) {
+			entry.balance(assetAccount(network, quote), quote, spent.Neg())
+			return *entry, true
+		}
+	case common.SELL_ORDER_TYPE:
+		entry.Description = fmt.Sprintf("Sell %s %s for %s %s", quantity, base, total, quote)
+		received, value := total, fiatTotal
+		if feeCurrency == quote {
+			received, value = total.Sub(fee), fiatTotal.Sub(fiatFee)
+		}
+		ledger.relieve(entry, tx, base, quantity)
+		entry.debit(assetAccount(network, quote), quote, received, value)
+		ledger.payFee(entry, tx, fee, fiatFee, base, quote)
+	case common.DEPOSIT_ORDER_TYPE:
+		if withdrawal, ok := history[tx.GetTransferId()]; ok && withdrawal.GetType() == common.WITHDRAWAL_ORDER_TYPE {
+			// Posted with the withdrawal it was matched to
+			return *entry, false
+		}
+		entry.Description = fmt.Sprintf("Deposit %s %s", quantity, base)
+		counterpart := JOURNAL_ACCOUNT_TRANSFERS
+		if IsIncome(tx) {
+			_, fiatTotal = fairMarketValue(ledger.ctx, ledger.report.fiatPriceService, tx)
+			entry.Description = fmt.Sprintf("%s %s %s", strings.Title(tx.GetCategory()), quantity, base)
+			counterpart = fmt.Sprintf("%s:%s", JOURNAL_ACCOUNT_INCOME, strings.Title(tx.GetCategory()))
+		}
+		if IsFork(tx) {
+			fiatTotal = ledger.report.forkLot(tx).CostBasis
+			entry.Description = fmt.Sprintf("%s %s %s", strings.Title(tx.GetCategory()), quantity, base)
+		}
+		entry.debit(assetAccount(network, base), base, quantity, fiatTotal)
+		entry.balance(counterpart, "", decimal.Zero)
+		return *entry, true
+	case common.WITHDRAWAL_ORDER_TYPE:
+		if disposal, ok := GetDisposalType(tx); ok {
+			entry.Description = fmt.Sprintf("%s %s %s", strings.Title(tx.GetCategory()), quantity, base)
+			ledger.relieve(entry, tx, base, quantity)
+			expense := fmt.Sprintf("%s:%s", JOURNAL_ACCOUNT_EXPENSES, strings.Title(tx.GetCategory()))
+			if disposal != DISPOSAL_SPEND {
+				// Given away or lost at cost, realizing nothing
+				entry.balance(expense, "", decimal.Zero)
+				return *entry, true
+			}
+			_, fairMarketTotal := fairMarketValue(ledger.ctx, ledger.report.fiatPriceService, tx)
+			entry.debit(expense, "", decimal.Zero, fairMarketTotal)
+			break
+		}
+		entry.Description = fmt.Sprintf("Withdraw %s %s", quantity, base)
+		sent, networkFee, counterpart := quantity, decimal.Zero, JOURNAL_ACCOUNT_TRANSFERS
+		if feeCurrency == base {
+			sent, networkFee = quantity.Sub(fee), fee
+		}
+		if deposit, ok := history[tx.GetTransferId()]; ok && deposit.GetType() == common.DEPOSIT_ORDER_TYPE {
+			// Mirrors Report.disposeNetworkFee
+			sent, _ = decimal.NewFromString(deposit.GetQuantity())
+			networkFee = quantity.Sub(sent)
+			if networkFee.IsZero() && feeCurrency == base {
+				networkFee = fee
+			}
+			counterpart = assetAccount(deposit.GetNetwork(), base)
+			entry.Description = fmt.Sprintf("Transfer %s %s to %s", sent, base, deposit.GetNetwork())
+		}
+		if networkFee.IsPositive() {
+			ledger.relieve(entry, tx, base, networkFee)
+			entry.debit(feeAccount(network), "", decimal.Zero, entry.Postings[len(entry.Postings)-1].Amount.Neg())
+		}
+		if feeCurrency != base && fee.IsPositive() {
+			if !ledger.relieve(entry, tx, feeCurrency, fee) {
+				entry.credit(assetAccount(network, feeCurrency), feeCurrency, fee, fee)
+			}
+			entry.debit(feeAccount(network), "", decimal.Zero, entry.Postings[len(entry.Postings)-1].Amount.Neg())
+		}
+		// Coins moved between the user's own accounts keep their cost
+		source := assetAccount(network, base)
+		entry.credit(source, base, sent, ledger.averageCost(source, sent, entry))
+		if counterpart == JOURNAL_ACCOUNT_TRANSFERS {
+			sent = decimal.Zero
+		}
+		entry.balance(counterpart, base, sent)
+		return *entry, true
+	default:
+		ledger.ctx.GetLogger().Warningf("[GeneralLedger.newEntry] Skipping %s transaction %s", tx.GetType(), tx.GetId())
+		return *entry, false
+	}
+	entry.balance(LEDGER_ACCOUNT_GAINS, "", decimal.Zero)
+	return *entry, true
+}
+
+// relieve credits the asset account for the quantity disposed of at the cost
+// of the lots the report consumed, or at the account's average cost when the
+// report consumed none. Returns false for fiat, which is carried at face value.
+func (ledger *GeneralLedger) relieve(entry *LedgerEntry, tx common.Transaction, currency string, quantity decimal.Decimal) bool {
+	if _, ok := common.FiatCurrencies[currency]; ok {
+		return false
+	}
+	account := assetAccount(tx.GetNetwork(), currency)
+	var cost decimal.Decimal
+	consumed := false
+	for _, lineItem := range ledger.report.GetDispositions(tx.GetId()) {
+		if lineItem.Currency != currency {
+			continue
+		}
+		consumed = true
+		for _, lot := range lineItem.Lots {
+			cost = cost.Add(parseAmount(lot.CostBasis)).Sub(parseAmount(lot.Fee))
+		}
+	}
+	if !consumed {
+		cost = ledger.averageCost(account, quantity, entry)
+	}
+	entry.credit(account, currency, quantity, cost)
+	return true
+}
+
+// averageCost values a quantity at the average cost of the account, after the
+// postings already made by the entry. Emptying the account relieves whatever
+// cost remains so no value is stranded in an account holding no coins.
+func (ledger *GeneralLedger) averageCost(account string, quantity decimal.Decimal, entry *LedgerEntry) decimal.Decimal {
+	balance := *ledger.getBalance(account)
+	for _, posting := range entry.Postings {
+		if posting.Account == account {
+			balance.quantity = balance.quantity.Add(posting.Quantity)
+			balance.amount = balance.amount.Add(posting.Amount)
+		}
+	}
+	if !balance.quantity.IsPositive() {
+		return decimal.Zero
+	}
+	if quantity.GreaterThanOrEqual(balance.quantity) {
+		return balance.amount
+	}
+	return balance.amount.Mul(quantity).Div(balance.quantity)
+}
+
+// payFee expenses a trade fee. A fee paid in a currency other than the two
+// traded, such as BNB on Binance, is also paid out of that currency's account.
+func (ledger *GeneralLedger) payFee(entry *LedgerEntry, tx common.Transaction, fee, fiatFee decimal.Decimal, base, quote string) {
+	if fee.IsZero() {
+		return
+	}
+	entry.debit(feeAccount(tx.GetNetwork()), "", decimal.Zero, fiatFee)
+	feeCurrency := tx.GetFeeCurrency()
+	if feeCurrency == base || feeCurrency == quote {
+		return
+	}
+	if !ledger.relieve(entry, tx, feeCurrency, fee) {
+		entry.credit(assetAccount(tx.GetNetwork(), feeCurrency), feeCurrency, fee, fiatFee)
+	}
+}
+
+func (entry *LedgerEntry) debit(account, commodity string, quantity, amount decimal.Decimal) {
+	entry.Postings = append(entry.Postings, LedgerPosting{
+		Account:   account,
+		Commodity: commodity,
+		Quantity:  quantity,
+		Amount:    amount.Round(2)})
+}
+
+func (entry *LedgerEntry) credit(account, commodity string, quantity, amount decimal.Decimal) {
+	entry.Postings = append(entry.Postings, LedgerPosting{
+		Account:   account,
+		Commodity: commodity,
+		Quantity:  quantity.Neg(),
+		Amount:    amount.Round(2).Neg()})
+}
+
+// balance posts the amount that balances the entry to the account.
+func (entry *LedgerEntry) balance(account, commodity string, quantity decimal.Decimal) {
+	var sum decimal.Decimal
+	for _, posting := range entry.Postings {
+		sum = sum.Add(posting.Amount)
+	}
+	if sum.IsZero() && quantity.IsZero() {
+		return
+	}
+	entry.Postings = append(entry.Postings, LedgerPosting{
+		Account:   account,
+		Commodity: commodity,
+		Quantity:  quantity,
+		Amount:    sum.Neg()})
+}
+
+func (ledger *GeneralLedger) getBalance(account string) *ledgerBalance {
+	if _, ok := ledger.balances[account]; !ok {
+		ledger.balances[account] = &ledgerBalance{}
+	}
+	return ledger.balances[account]
+}
+
+// GetAccounts returns the chart of accounts posted to.
+func (ledger *GeneralLedger) GetAccounts() []LedgerAccount {
+	var accounts []LedgerAccount
+	for account := range ledger.balances {
+		accounts = append(accounts, LedgerAccount{Name: account, Type: GetAccountType(account)})
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts
+}
+
+// GetAccountType returns the type of an account from its top level name.
+func GetAccountType(account string) string {
+	return accountTypes[strings.Split(account, ":")[0]]
+}
+
+// TrialBalance lists the debit or credit balance of each account at the end
+// of date. Total debits equal total credits.
+func (ledger *GeneralLedger) TrialBalance(date time.Time) *TrialBalance {
+	balances := ledger.balancesAt(date)
+	trialBalance := &TrialBalance{Date: date, Currency: ledger.Currency}
+	var debits, credits decimal.Decimal
+	for _, account := range sortedAccounts(balances) {
+		amount := balances[account].amount
+		line := TrialBalanceLine{Account: account, Type: GetAccountType(account)}
+		if amount.IsNegative() {
+			line.Credit = amount.Neg().StringFixed(2)
+			credits = credits.Add(amount.Neg())
+		} else {
+			line.Debit = amount.StringFixed(2)
+			debits = debits.Add(amount)
+		}
+		trialBalance.Accounts = append(trialBalance.Accounts, line)
+	}
+	trialBalance.Debits = debits.StringFixed(2)
+	trialBalance.Credits = credits.StringFixed(2)
+	return trialBalance
+}
+
+// Register lists the postings to an account and its sub-accounts between
+// start and end with the running balance. Debits increase the balance.
+func (ledger *GeneralLedger) Register(account string, start, end time.Time) *AccountRegister {
+	var opening, balance decimal.Decimal
+	register := &AccountRegister{Account: account, Currency: ledger.Currency}
+	for _, entry := range ledger.Entries {
+		if entry.Date.After(end) {
+			break
+		}
+		for _, posting := range entry.Postings {
+			if posting.Account != account && !strings.HasPrefix(posting.Account, account+":") {
+				continue
+			}
+			balance = balance.Add(posting.Amount)
+			if entry.Date.Before(start) {
+				opening = balance
+				continue
+			}
+			line := RegisterLine{
+				TransactionId: entry.TransactionId,
+				Date:          entry.Date,
+				Description:   entry.Description,
+				Account:       posting.Account,
+				Commodity:     posting.Commodity,
+				Balance:       balance.StringFixed(2)}
+			if !posting.Quantity.IsZero() {
+				line.Quantity = posting.Quantity.String()
+			}
+			if posting.Amount.IsNegative() {
+				line.Credit = posting.Amount.Neg().StringFixed(2)
+			} else {
+				line.Debit = posting.Amount.StringFixed(2)
+			}
+			register.Lines = append(register.Lines, line)
+		}
+	}
+	register.OpeningBalance = opening.StringFixed(2)
+	register.ClosingBalance = balance.StringFixed(2)
+	return register
+}
+
+// BalanceSheet reports the assets, liabilities and equity at the end of date.
+// Income and expenses are closed to retained earnings.
+func (ledger *GeneralLedger) BalanceSheet(date time.Time) *BalanceSheet {
+	balances := ledger.balancesAt(date)
+	sheet := &BalanceSheet{Date: date, Currency: ledger.Currency}
+	var assets, liabilities, equity, retainedEarnings decimal.Decimal
+	for _, account := range sortedAccounts(balances) {
+		balance := balances[account]
+		switch GetAccountType(account) {
+		case ACCOUNT_TYPE_ASSET:
+			commodity := account[strings.LastIndex(account, ":")+1:]
+			sheet.Assets = append(sheet.Assets, BalanceSheetLine{
+				Account:   account,
+				Commodity: commodity,
+				Quantity:  balance.quantity.String(),
+				Balance:   balance.amount.StringFixed(2)})
+			assets = assets.Add(balance.amount)
+		case ACCOUNT_TYPE_LIABILITY:
+			sheet.Liabilities = append(sheet.Liabilities, BalanceSheetLine{
+				Account: account,
+				Balance: balance.amount.Neg().StringFixed(2)})
+			liabilities = liabilities.Add(balance.amount.Neg())
+		case ACCOUNT_TYPE_EQUITY:
+			sheet.Equity = append(sheet.Equity, BalanceSheetLine{
+				Account: account,
+				Balance: balance.amount.Neg().StringFixed(2)})
+			equity = equity.Add(balance.amount.Neg())
+		default:
+			retainedEarnings = retainedEarnings.Add(balance.amount.Neg())
+		}
+	}
+	sheet.Equity = append(sheet.Equity, BalanceSheetLine{
+		Account: LEDGER_ACCOUNT_RETAINED_EARNINGS,
+		Balance: retainedEarnings.StringFixed(2)})
+	equity = equity.Add(retainedEarnings)
+	sheet.TotalAssets = assets.StringFixed(2)
+	sheet.TotalLiabilities = liabilities.StringFixed(2)
+	sheet.TotalEquity = equity.StringFixed(2)
+	sheet.TotalLiabilitiesAndEquity = liabilities.Add(equity).StringFixed(2)
+	return sheet
+}
+
+func (ledger *GeneralLedger) balancesAt(date time.Time) map[string]*ledgerBalance {
+	balances := make(map[string]*ledgerBalance)
+	for _, entry := range ledger.Entries {
+		if entry.Date.After(date) {
+			break
+		}
+		for _, posting := range entry.Postings {
+			if _, ok := balances[posting.Account]; !ok {
+				balances[posting.Account] = &ledgerBalance{}
+			}
+			balances[posting.Account].quantity = balances[posting.Account].quantity.Add(posting.Quantity)
+			balances[posting.Account].amount = balances[posting.Account].amount.Add(posting.Amount)
+		}
+	}
+	return balances
+}
+
+func sortedAccounts(balances map[string]*ledgerBalance) []string {
+	var accounts []string
+	for account := range balances {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
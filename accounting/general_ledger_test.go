@@ -0,0 +1,221 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createGeneralLedgerTestTransactions() []common.Transaction {
+	btcusd := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:             "6",
+			Date:           time.Date(2017, 05, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair:   btcusd,
+			Type:           common.SELL_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "0.9",
+			Total:          "1350",
+			Fee:            "5",
+			FeeCurrency:    "USD",
+			FiatPrice:      "1500",
+			FiatTotal:      "1350",
+			FiatFee:        "5",
+			QuoteFiatPrice: "1"},
+		&dto.TransactionDTO{
+			Id:           "5",
+			Date:         time.Date(2017, 04, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_SPEND,
+			Network:      "BTC wallet",
+			Quantity:     "0.05",
+			FiatPrice:    "1300",
+			FiatTotal:    "65"},
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 03, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_MINING,
+			Network:      "BTC wallet",
+			Quantity:     "0.1",
+			FiatPrice:    "1200",
+			FiatTotal:    "120"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 02, 01, 13, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Network:      "BTC wallet",
+			Quantity:     "0.999",
+			TransferId:   "2"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Network:      "gdax",
+			Quantity:     "1",
+			TransferId:   "3"},
+		&dto.TransactionDTO{
+			Id:             "1",
+			Date:           time.Date(2017, 01, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair:   btcusd,
+			Type:           common.BUY_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "2",
+			Total:          "2000",
+			Fee:            "10",
+			FeeCurrency:    "USD",
+			FiatPrice:      "1000",
+			FiatTotal:      "2000",
+			FiatFee:        "10",
+			QuoteFiatPrice: "1"}}
+}
+
+func TestGeneralLedger_Balanced(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewGeneralLedger(ctx, NewFifoReport(ctx, createGeneralLedgerTestTransactions()))
+
+	// The matched deposit is posted with its withdrawal
+	assert.Equal(t, 5, len(ledger.Entries))
+	for _, entry := range ledger.Entries {
+		var sum decimal.Decimal
+		for _, posting := range entry.Postings {
+			sum = sum.Add(posting.Amount)
+		}
+		assert.True(t, sum.IsZero(), "entry %s is unbalanced by %s", entry.TransactionId, sum)
+	}
+
+	transfer := ledger.Entries[1]
+	assert.Equal(t, "2", transfer.TransactionId)
+	assert.Equal(t, 4, len(transfer.Postings))
+	assert.Equal(t, "Assets:Gdax:BTC", transfer.Postings[0].Account)
+	assert.Equal(t, "-0.001", transfer.Postings[0].Quantity.String())
+	assert.Equal(t, "-1", transfer.Postings[0].Amount.String())
+	assert.Equal(t, "Expenses:Fees:Gdax", transfer.Postings[1].Account)
+	assert.Equal(t, "Assets:BTC-wallet:BTC", transfer.Postings[3].Account)
+	assert.Equal(t, "0.999", transfer.Postings[3].Quantity.String())
+	assert.Equal(t, "999", transfer.Postings[3].Amount.String())
+
+	sale := ledger.Entries[4]
+	assert.Equal(t, "-900", sale.Postings[0].Amount.String())
+	assert.Equal(t, LEDGER_ACCOUNT_GAINS, sale.Postings[3].Account)
+	assert.Equal(t, "-450", sale.Postings[3].Amount.String())
+}
+
+func TestGeneralLedger_TrialBalance(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewGeneralLedger(ctx, NewFifoReport(ctx, createGeneralLedgerTestTransactions()))
+
+	trialBalance := ledger.TrialBalance(time.Now())
+	assert.Equal(t, trialBalance.Debits, trialBalance.Credits)
+	lines := make(map[string]TrialBalanceLine)
+	for _, line := range trialBalance.Accounts {
+		lines[line.Account] = line
+	}
+	assert.Equal(t, "465.00", lines[LEDGER_ACCOUNT_GAINS].Credit)
+	assert.Equal(t, "120.00", lines["Income:Mining"].Credit)
+	assert.Equal(t, "665.00", lines["Assets:Gdax:USD"].Credit)
+	assert.Equal(t, ACCOUNT_TYPE_EXPENSE, lines["Expenses:Spend"].Type)
+
+	assert.Equal(t, 3, len(ledger.TrialBalance(time.Date(2017, 01, 31, 0, 0, 0, 0, time.Local)).Accounts))
+}
+
+func TestGeneralLedger_FeesWithoutFiatTotal(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	btcusd := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btcusd,
+			Type:         common.SELL_ORDER_TYPE,
+			Network:      "gdax",
+			Quantity:     "1",
+			Total:        "2000",
+			FiatQuantity: "2000",
+			Fee:          "20",
+			FeeCurrency:  "USD",
+			FiatFee:      "20"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btcusd,
+			Type:         common.BUY_ORDER_TYPE,
+			Network:      "gdax",
+			Quantity:     "1",
+			Total:        "1000",
+			FiatQuantity: "1000",
+			Fee:          "10",
+			FeeCurrency:  "USD",
+			FiatFee:      "10"}}
+
+	ledger := NewGeneralLedger(ctx, NewFifoReport(ctx, transactions))
+	buy := ledger.Entries[0]
+	assert.Equal(t, "Assets:Gdax:USD", buy.Postings[2].Account)
+	assert.Equal(t, "-1010", buy.Postings[2].Quantity.String())
+	assert.Equal(t, "-1010", buy.Postings[2].Amount.String())
+
+	trialBalance := ledger.TrialBalance(time.Now())
+	assert.Equal(t, trialBalance.Debits, trialBalance.Credits)
+	lines := make(map[string]TrialBalanceLine)
+	for _, line := range trialBalance.Accounts {
+		lines[line.Account] = line
+	}
+	assert.Equal(t, "0.00", lines["Assets:Gdax:BTC"].Debit)
+	assert.Equal(t, "970.00", lines["Assets:Gdax:USD"].Debit)
+	assert.Equal(t, "1000.00", lines[LEDGER_ACCOUNT_GAINS].Credit)
+	assert.Equal(t, "30.00", lines[JOURNAL_ACCOUNT_FEES+":Gdax"].Debit)
+
+	// Gains less the fees expensed agree with the gain reported on Form 8949
+	form8949 := NewFifoReport(ctx, transactions).Run(
+		time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "970.00", form8949.ShortHolds[0].GainOrLoss)
+	sheet := ledger.BalanceSheet(time.Now())
+	assert.Equal(t, LEDGER_ACCOUNT_RETAINED_EARNINGS, sheet.Equity[len(sheet.Equity)-1].Account)
+	assert.Equal(t, "970.00", sheet.Equity[len(sheet.Equity)-1].Balance)
+}
+
+func TestGeneralLedger_Register(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewGeneralLedger(ctx, NewFifoReport(ctx, createGeneralLedgerTestTransactions()))
+
+	register := ledger.Register(JOURNAL_ACCOUNT_FEES, time.Date(2017, 02, 01, 0, 0, 0, 0, time.Local), time.Now())
+	assert.Equal(t, "10.00", register.OpeningBalance)
+	assert.Equal(t, 2, len(register.Lines))
+	assert.Equal(t, "1.00", register.Lines[0].Debit)
+	assert.Equal(t, "11.00", register.Lines[0].Balance)
+	assert.Equal(t, "16.00", register.ClosingBalance)
+}
+
+func TestGeneralLedger_BalanceSheet(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewGeneralLedger(ctx, NewFifoReport(ctx, createGeneralLedgerTestTransactions()))
+
+	sheet := ledger.BalanceSheet(time.Date(2017, 03, 15, 0, 0, 0, 0, time.Local))
+	assert.Equal(t, sheet.TotalAssets, sheet.TotalLiabilitiesAndEquity)
+	assert.Equal(t, BalanceSheetLine{
+		Account:   "Assets:BTC-wallet:BTC",
+		Commodity: "BTC",
+		Quantity:  "1.099",
+		Balance:   "1119.00"}, sheet.Assets[0])
+
+	sheet = ledger.BalanceSheet(time.Now())
+	assert.Equal(t, sheet.TotalAssets, sheet.TotalLiabilitiesAndEquity)
+	retainedEarnings := sheet.Equity[len(sheet.Equity)-1]
+	assert.Equal(t, LEDGER_ACCOUNT_RETAINED_EARNINGS, retainedEarnings.Account)
+	// Mining and gains less the fees and the spend
+	assert.Equal(t, "504.00", retainedEarnings.Balance)
+}
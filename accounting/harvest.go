@@ -0,0 +1,141 @@
+package accounting
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// Default federal rates used to estimate the tax impact of a harvest when the
+// caller does not provide its own.
+const (
+	DEFAULT_SHORT_TERM_RATE = "0.24"
+	DEFAULT_LONG_TERM_RATE  = "0.15"
+)
+
+// TaxLossHarvester recommends open lots to sell at a loss in order to offset
+// gains already realized during the year.
+type TaxLossHarvester struct {
+	ctx           common.Context
+	shortTermRate decimal.Decimal
+	longTermRate  decimal.Decimal
+}
+
+type HarvestPlan struct {
+	RealizedShortTerm string       `json:"realized_short_term"`
+	RealizedLongTerm  string       `json:"realized_long_term"`
+	Target            string       `json:"target"`
+	ShortTerm         []HarvestLot `json:"short_term"`
+	LongTerm          []HarvestLot `json:"long_term"`
+	ShortTermLoss     string       `json:"short_term_loss"`
+	LongTermLoss      string       `json:"long_term_loss"`
+	Harvested         string       `json:"harvested"`
+	Shortfall         string       `json:"shortfall"`
+	ShortTermRate     string       `json:"short_term_rate"`
+	LongTermRate      string       `json:"long_term_rate"`
+	EstimatedSavings  string       `json:"estimated_savings"`
+}
+
+type HarvestLot struct {
+	UnrealizedLot
+	SellQuantity string `json:"sell_quantity"`
+	Loss         string `json:"loss"`
+}
+
+func NewTaxLossHarvester(ctx common.Context, shortTermRate, longTermRate decimal.Decimal) *TaxLossHarvester {
+	return &TaxLossHarvester{
+		ctx:           ctx,
+		shortTermRate: shortTermRate,
+		longTermRate:  longTermRate}
+}
+
+// Recommend selects lots with unrealized losses until target is reached. A
+// zero target offsets the net gain realized on the year-to-date form. Short
+// term losses are taken first since they offset the gains taxed at the higher
+// rate, largest loss first within each holding period. The final lot is only
+// partially sold when a portion of it is enough to reach the target.
+func (harvester *TaxLossHarvester) Recommend(realized *Form8949, unrealized *UnrealizedGains, target decimal.Decimal) *HarvestPlan {
+	realizedShort := sumGainOrLoss(realized.ShortHolds)
+	realizedLong := sumGainOrLoss(realized.LongHolds)
+	if target.IsZero() {
+		target = realizedShort.Add(realizedLong)
+	}
+	harvester.ctx.GetLogger().Debugf("[TaxLossHarvester.Recommend] realized short: %s, long: %s, target: %s",
+		realizedShort, realizedLong, target)
+
+	var shorts, longs []UnrealizedLot
+	for _, lot := range unrealized.Lots {
+		gainOrLoss, _ := decimal.NewFromString(lot.GainOrLoss)
+		if !gainOrLoss.IsNegative() {
+			continue
+		}
+		if lot.Holding == HOLDING_SHORT {
+			shorts = append(shorts, lot)
+		} else {
+			longs = append(longs, lot)
+		}
+	}
+
+	remaining := target
+	shortTerm, shortLoss := harvester.harvest(shorts, &remaining)
+	longTerm, longLoss := harvester.harvest(longs, &remaining)
+	harvested := shortLoss.Add(longLoss)
+	shortfall := decimal.Zero
+	if remaining.IsPositive() {
+		shortfall = remaining
+	}
+	savings := shortLoss.Mul(harvester.shortTermRate).Add(longLoss.Mul(harvester.longTermRate))
+
+	return &HarvestPlan{
+		RealizedShortTerm: realizedShort.StringFixed(2),
+		RealizedLongTerm:  realizedLong.StringFixed(2),
+		Target:            target.StringFixed(2),
+		ShortTerm:         shortTerm,
+		LongTerm:          longTerm,
+		ShortTermLoss:     shortLoss.StringFixed(2),
+		LongTermLoss:      longLoss.StringFixed(2),
+		Harvested:         harvested.StringFixed(2),
+		Shortfall:         shortfall.StringFixed(2),
+		ShortTermRate:     harvester.shortTermRate.String(),
+		LongTermRate:      harvester.longTermRate.String(),
+		EstimatedSavings:  savings.StringFixed(2)}
+}
+
+func (harvester *TaxLossHarvester) harvest(lots []UnrealizedLot, remaining *decimal.Decimal) ([]HarvestLot, decimal.Decimal) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, _ := decimal.NewFromString(lots[i].GainOrLoss)
+		b, _ := decimal.NewFromString(lots[j].GainOrLoss)
+		return a.LessThan(b)
+	})
+	var harvested []HarvestLot
+	var total decimal.Decimal
+	for _, lot := range lots {
+		if !remaining.IsPositive() {
+			break
+		}
+		quantity, _ := decimal.NewFromString(lot.Quantity)
+		gainOrLoss, _ := decimal.NewFromString(lot.GainOrLoss)
+		loss := gainOrLoss.Neg()
+		if loss.GreaterThan(*remaining) {
+			quantity = quantity.Mul(*remaining).Div(loss).Round(8)
+			loss = *remaining
+		}
+		harvested = append(harvested, HarvestLot{
+			UnrealizedLot: lot,
+			SellQuantity:  quantity.String(),
+			Loss:          loss.StringFixed(2)})
+		total = total.Add(loss)
+		*remaining = remaining.Sub(loss)
+	}
+	return harvested, total
+}
+
+func sumGainOrLoss(lineItems []Form8949LineItem) decimal.Decimal {
+	var sum decimal.Decimal
+	for _, lineItem := range lineItems {
+		gainOrLoss, _ := decimal.NewFromString(lineItem.GainOrLoss)
+		sum = sum.Add(gainOrLoss)
+	}
+	return sum
+}
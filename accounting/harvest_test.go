@@ -0,0 +1,60 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createHarvestTestGains() *UnrealizedGains {
+	date := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	return &UnrealizedGains{
+		Lots: []UnrealizedLot{
+			UnrealizedLot{TransactionId: "1", Currency: "BTC", DateAcquired: date, Holding: HOLDING_LONG, Quantity: "1", GainOrLoss: "-400.00"},
+			UnrealizedLot{TransactionId: "2", Currency: "BTC", DateAcquired: date, Holding: HOLDING_SHORT, Quantity: "1", GainOrLoss: "-100.00"},
+			UnrealizedLot{TransactionId: "3", Currency: "ETH", DateAcquired: date, Holding: HOLDING_SHORT, Quantity: "2", GainOrLoss: "-300.00"},
+			UnrealizedLot{TransactionId: "4", Currency: "LTC", DateAcquired: date, Holding: HOLDING_SHORT, Quantity: "1", GainOrLoss: "250.00"}}}
+}
+
+func TestTaxLossHarvester_Recommend(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	harvester := NewTaxLossHarvester(ctx, decimal.NewFromFloat(0.3), decimal.NewFromFloat(0.1))
+
+	realized := &Form8949{
+		ShortHolds: []Form8949LineItem{Form8949LineItem{GainOrLoss: "500.00"}},
+		LongHolds:  []Form8949LineItem{Form8949LineItem{GainOrLoss: "100.00"}}}
+
+	plan := harvester.Recommend(realized, createHarvestTestGains(), decimal.Zero)
+	assert.Equal(t, "500.00", plan.RealizedShortTerm)
+	assert.Equal(t, "100.00", plan.RealizedLongTerm)
+	assert.Equal(t, "600.00", plan.Target)
+
+	assert.Equal(t, 2, len(plan.ShortTerm))
+	assert.Equal(t, "3", plan.ShortTerm[0].TransactionId)
+	assert.Equal(t, "2", plan.ShortTerm[0].SellQuantity)
+	assert.Equal(t, "2", plan.ShortTerm[1].TransactionId)
+	assert.Equal(t, "400.00", plan.ShortTermLoss)
+
+	assert.Equal(t, 1, len(plan.LongTerm))
+	assert.Equal(t, "1", plan.LongTerm[0].TransactionId)
+	assert.Equal(t, "0.5", plan.LongTerm[0].SellQuantity)
+	assert.Equal(t, "200.00", plan.LongTerm[0].Loss)
+
+	assert.Equal(t, "600.00", plan.Harvested)
+	assert.Equal(t, "0.00", plan.Shortfall)
+	assert.Equal(t, "140.00", plan.EstimatedSavings)
+}
+
+func TestTaxLossHarvester_Shortfall(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	harvester := NewTaxLossHarvester(ctx, decimal.NewFromFloat(0.3), decimal.NewFromFloat(0.1))
+
+	plan := harvester.Recommend(&Form8949{}, createHarvestTestGains(), decimal.NewFromFloat(1000))
+	assert.Equal(t, "800.00", plan.Harvested)
+	assert.Equal(t, "200.00", plan.Shortfall)
+	assert.Equal(t, 2, len(plan.ShortTerm))
+	assert.Equal(t, 1, len(plan.LongTerm))
+}
@@ -0,0 +1,206 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const INCOME_MONTH_FORMAT = "2006-01"
+
+// IncomeReport values mining, staking and other income receipts at their fair
+// market value on the day they were received, for Schedule 1 / Schedule C.
+type IncomeReport struct {
+	ctx              common.Context
+	fiatPriceService common.FiatPriceService
+	Transactions     []common.Transaction
+}
+
+// IncomeStatement totals the income receipts of a tax year. Dates are written
+// in Location, the time zone the tax year was requested in.
+type IncomeStatement struct {
+	Items      []IncomeLineItem `json:"items"`
+	Months     []IncomeTotal    `json:"months"`
+	Currencies []IncomeTotal    `json:"currencies"`
+	Total      string           `json:"total"`
+	Location   *time.Location   `json:"-"`
+}
+
+type IncomeLineItem struct {
+	TransactionId   string    `json:"transaction_id"`
+	Date            time.Time `json:"date"`
+	Category        string    `json:"category"`
+	Currency        string    `json:"currency"`
+	Quantity        string    `json:"quantity"`
+	FairMarketValue string    `json:"fair_market_value"`
+	Income          string    `json:"income"`
+}
+
+type IncomeTotal struct {
+	Month    string `json:"month,omitempty"`
+	Currency string `json:"currency"`
+	Quantity string `json:"quantity"`
+	Income   string `json:"income"`
+}
+
+func NewIncomeReport(ctx common.Context, transactions []common.Transaction, fiatPriceService common.FiatPriceService) *IncomeReport {
+	return &IncomeReport{
+		ctx:              ctx,
+		fiatPriceService: fiatPriceService,
+		Transactions:     transactions}
+}
+
+// IsIncome returns true when the transaction is a receipt taxed as ordinary
+// income rather than a purchase or transfer.
+func IsIncome(tx common.Transaction) bool {
+	if tx.GetType() != common.DEPOSIT_ORDER_TYPE {
+		return false
+	}
+	return tx.GetCategory() == common.TX_CATEGORY_INCOME ||
+		tx.GetCategory() == common.TX_CATEGORY_MINING ||
+		tx.GetCategory() == common.TX_CATEGORY_STAKING
+}
+
+// Run reports the income received between start and end inclusive, totalled
+// by month in the time zone of start.
+func (report *IncomeReport) Run(start, end time.Time) *IncomeStatement {
+	type totalKey struct {
+		month    string
+		currency string
+	}
+	var items []IncomeLineItem
+	var total decimal.Decimal
+	quantities := make(map[totalKey]decimal.Decimal)
+	incomes := make(map[totalKey]decimal.Decimal)
+	for _, tx := range report.Transactions {
+		if !IsIncome(tx) || tx.GetDate().Before(start) || tx.GetDate().After(end) {
+			continue
+		}
+		report.ctx.GetLogger().Debugf("[IncomeReport.Run] %s", tx)
+		currency := tx.GetCurrencyPair().Base
+		quantity, _ := decimal.NewFromString(tx.GetQuantity())
+		price, income := fairMarketValue(report.ctx, report.fiatPriceService, tx)
+		items = append(items, IncomeLineItem{
+			TransactionId:   tx.GetId(),
+			Date:            tx.GetDate(),
+			Category:        tx.GetCategory(),
+			Currency:        currency,
+			Quantity:        quantity.String(),
+			FairMarketValue: price.StringFixed(2),
+			Income:          income.StringFixed(2)})
+		for _, key := range []totalKey{
+			totalKey{month: tx.GetDate().In(start.Location()).Format(INCOME_MONTH_FORMAT), currency: currency},
+			totalKey{currency: currency}} {
+			quantities[key] = quantities[key].Add(quantity)
+			incomes[key] = incomes[key].Add(income)
+		}
+		total = total.Add(income)
+	}
+	statement := &IncomeStatement{
+		Items:    items,
+		Total:    total.StringFixed(2),
+		Location: start.Location()}
+	for key, income := range incomes {
+		incomeTotal := IncomeTotal{
+			Month:    key.month,
+			Currency: key.currency,
+			Quantity: quantities[key].String(),
+			Income:   income.StringFixed(2)}
+		if key.month == "" {
+			statement.Currencies = append(statement.Currencies, incomeTotal)
+		} else {
+			statement.Months = append(statement.Months, incomeTotal)
+		}
+	}
+	statement.sort()
+	return statement
+}
+
+func (statement *IncomeStatement) sort() {
+	sort.SliceStable(statement.Items, func(i, j int) bool {
+		return statement.Items[i].Date.Before(statement.Items[j].Date)
+	})
+	sort.Slice(statement.Months, func(i, j int) bool {
+		if statement.Months[i].Month == statement.Months[j].Month {
+			return statement.Months[i].Currency < statement.Months[j].Currency
+		}
+		return statement.Months[i].Month < statement.Months[j].Month
+	})
+	sort.Slice(statement.Currencies, func(i, j int) bool {
+		return statement.Currencies[i].Currency < statement.Currencies[j].Currency
+	})
+}
+
+func (statement *IncomeStatement) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	emptyRow := []string{}
+
+	writer.Write([]string{"Income By Month"})
+	writer.Write([]string{"Month", "Currency", "Quantity", "Income"})
+	for _, month := range statement.Months {
+		if err := writer.Write([]string{month.Month, month.Currency, month.Quantity, month.Income}); err != nil {
+			return err
+		}
+	}
+
+	writer.Write(emptyRow)
+	writer.Write([]string{"Income By Currency"})
+	writer.Write([]string{"Currency", "Quantity", "Income"})
+	for _, currency := range statement.Currencies {
+		if err := writer.Write([]string{currency.Currency, currency.Quantity, currency.Income}); err != nil {
+			return err
+		}
+	}
+	writer.Write([]string{"Total", "", statement.Total})
+
+	writer.Write(emptyRow)
+	writer.Write([]string{"Receipts"})
+	writer.Write([]string{"Date", "Category", "Currency", "Quantity", "Fair Market Value", "Income"})
+	for _, item := range statement.Items {
+		if err := writer.Write(item.Record(reportLocation(statement.Location))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record returns the receipt as a CSV record with its date in location.
+func (item *IncomeLineItem) Record(location *time.Location) []string {
+	return []string{
+		item.Date.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		item.Category,
+		item.Currency,
+		item.Quantity,
+		item.FairMarketValue,
+		item.Income}
+}
+
+// fairMarketValue returns the unit price and total value of an income receipt
+// on the day it was received. The transaction's own fiat values are used when
+// no price service is available or the price lookup fails.
+func fairMarketValue(ctx common.Context, fiatPriceService common.FiatPriceService, tx common.Transaction) (decimal.Decimal, decimal.Decimal) {
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	if fiatPriceService != nil {
+		candlestick, err := fiatPriceService.GetPriceAt(tx.GetCurrencyPair().Base, tx.GetDate())
+		if err == nil && candlestick != nil {
+			return candlestick.Close, candlestick.Close.Mul(quantity)
+		}
+		ctx.GetLogger().Warningf("[fairMarketValue] Unable to price %s %s received on %s, using transaction value",
+			tx.GetQuantity(), tx.GetCurrencyPair().Base, tx.GetDate())
+	}
+	fiatPrice, _ := decimal.NewFromString(tx.GetFiatPrice())
+	fiatTotal, _ := decimal.NewFromString(tx.GetFiatTotal())
+	return fiatPrice, fiatTotal
+}
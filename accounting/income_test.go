@@ -0,0 +1,142 @@
+package accounting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockFiatPriceService struct {
+	prices map[string]decimal.Decimal
+	common.FiatPriceService
+}
+
+func (mock *MockFiatPriceService) GetPriceAt(currency string, date time.Time) (*common.Candlestick, error) {
+	price, ok := mock.prices[currency+date.Format("2006-01-02")]
+	if !ok {
+		return nil, errors.New("price not found")
+	}
+	return &common.Candlestick{Date: date, Close: price}, nil
+}
+
+func createIncomeTestTransactions() []common.Transaction {
+	btc := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	eth := &common.CurrencyPair{Base: "ETH", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 02, 10, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: eth,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_STAKING,
+			Quantity:     "2",
+			FiatPrice:    "10",
+			FiatTotal:    "20"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_MINING,
+			Quantity:     "0.5",
+			FiatPrice:    "1000",
+			FiatTotal:    "500"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 01, 15, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_DEPOSIT,
+			Quantity:     "1",
+			FiatPrice:    "900",
+			FiatTotal:    "900"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 10, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_INCOME,
+			Quantity:     "0.5",
+			FiatPrice:    "800",
+			FiatTotal:    "400"}}
+}
+
+func createIncomeTestPriceService() common.FiatPriceService {
+	return &MockFiatPriceService{
+		prices: map[string]decimal.Decimal{
+			"BTC2017-01-10": decimal.NewFromFloat(1000),
+			"BTC2017-02-01": decimal.NewFromFloat(1200)}}
+}
+
+func TestIncomeReport_Run(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	report := NewIncomeReport(ctx, createIncomeTestTransactions(), createIncomeTestPriceService())
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+	statement := report.Run(start, end)
+
+	assert.Equal(t, 3, len(statement.Items))
+	assert.Equal(t, "1", statement.Items[0].TransactionId)
+	assert.Equal(t, "1000.00", statement.Items[0].FairMarketValue)
+	assert.Equal(t, "500.00", statement.Items[0].Income)
+	assert.Equal(t, "3", statement.Items[1].TransactionId)
+	assert.Equal(t, "600.00", statement.Items[1].Income)
+	assert.Equal(t, "4", statement.Items[2].TransactionId)
+	assert.Equal(t, "20.00", statement.Items[2].Income, "falls back to the transaction value")
+
+	assert.Equal(t, 3, len(statement.Months))
+	assert.Equal(t, IncomeTotal{Month: "2017-01", Currency: "BTC", Quantity: "0.5", Income: "500.00"}, statement.Months[0])
+	assert.Equal(t, IncomeTotal{Month: "2017-02", Currency: "BTC", Quantity: "0.5", Income: "600.00"}, statement.Months[1])
+	assert.Equal(t, IncomeTotal{Month: "2017-02", Currency: "ETH", Quantity: "2", Income: "20.00"}, statement.Months[2])
+
+	assert.Equal(t, 2, len(statement.Currencies))
+	assert.Equal(t, IncomeTotal{Currency: "BTC", Quantity: "1", Income: "1100.00"}, statement.Currencies[0])
+	assert.Equal(t, IncomeTotal{Currency: "ETH", Quantity: "2", Income: "20.00"}, statement.Currencies[1])
+	assert.Equal(t, "1120.00", statement.Total)
+
+	statement = report.Run(start, time.Date(2017, 01, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(statement.Items))
+	assert.Equal(t, "500.00", statement.Total)
+
+	// Months are those of the time zone the tax year is bounded in
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	statement = report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, newYork), time.Date(2017, 12, 31, 0, 0, 0, 0, newYork))
+	assert.Equal(t, IncomeTotal{Month: "2017-01", Currency: "BTC", Quantity: "1", Income: "1100.00"}, statement.Months[0])
+	assert.Equal(t, newYork, statement.Location)
+	assert.Equal(t, "01-09-2017 19:00:00 EST", statement.Items[0].Record(newYork)[0])
+}
+
+func TestReport_IncomeLots(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := append(createIncomeTestTransactions(), &dto.TransactionDTO{
+		Id:           "5",
+		Date:         time.Date(2017, 03, 01, 0, 0, 0, 0, time.UTC),
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		Type:         common.SELL_ORDER_TYPE,
+		Category:     common.TX_CATEGORY_TRADE,
+		Quantity:     "0.5",
+		FiatQuantity: "1500",
+		FiatTotal:    "1500"})
+
+	report := NewFifoReport(ctx, transactions)
+	report.SetFiatPriceService(createIncomeTestPriceService())
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+	form8949 := report.Run(start, end)
+
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "500.00", form8949.ShortHolds[0].CostBasis)
+	assert.Equal(t, "1000.00", form8949.ShortHolds[0].GainOrLoss)
+	assert.Equal(t, 3, len(report.Income))
+}
@@ -0,0 +1,365 @@
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	JOURNAL_FORMAT_BEANCOUNT = "beancount"
+	JOURNAL_FORMAT_LEDGER    = "ledger"
+	JOURNAL_ACCOUNT_ASSETS   = "Assets"
+	JOURNAL_ACCOUNT_FEES     = "Expenses:Fees"
+	JOURNAL_ACCOUNT_EXPENSES = "Expenses"
+	JOURNAL_ACCOUNT_INCOME   = "Income"
+	// Deposits and withdrawals whose counterpart is not in the history,
+	// such as a transfer to or from an account tradebot does not track.
+	JOURNAL_ACCOUNT_TRANSFERS = "Equity:Transfers"
+)
+
+var journalAccountPattern = regexp.MustCompile("[^A-Za-z0-9]+")
+var ledgerCommodityPattern = regexp.MustCompile("^[A-Za-z]+$")
+
+// Journal is the transaction history as balanced double-entry transactions
+// for plain-text accounting tools. Each exchange and wallet holds an asset
+// account per currency named after its network, trades exchange the two
+// currencies at the price paid, fees are expensed and income and disposals
+// are valued at their fiat price. Matched transfers move the coins between
+// the two accounts, expensing the network fee lost along the way.
+type Journal struct {
+	ctx          common.Context
+	Currency     string
+	Transactions []JournalTransaction
+	Prices       []JournalPrice
+}
+
+type JournalTransaction struct {
+	TransactionId string
+	Date          time.Time
+	Payee         string
+	Narration     string
+	Postings      []JournalPosting
+}
+
+// JournalPosting moves a quantity of a commodity into or out of an account.
+// Postings exchanged for another commodity carry the total price paid or
+// received in TotalPrice.
+type JournalPosting struct {
+	Account        string
+	Quantity       decimal.Decimal
+	Commodity      string
+	TotalPrice     decimal.Decimal
+	PriceCommodity string
+}
+
+type JournalPrice struct {
+	Date      time.Time
+	Commodity string
+	Price     decimal.Decimal
+	Currency  string
+}
+
+func NewJournal(ctx common.Context, transactions []common.Transaction) *Journal {
+	journal := &Journal{
+		ctx:      ctx,
+		Currency: ctx.GetUser().GetLocalCurrency()}
+	history := make(map[string]common.Transaction)
+	var sorted []common.Transaction
+	for _, tx := range transactions {
+		if tx.IsDeleted() {
+			continue
+		}
+		history[tx.GetId()] = tx
+		sorted = append(sorted, tx)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetDate().Before(sorted[j].GetDate())
+	})
+	prices := make(map[string]bool)
+	for _, tx := range sorted {
+		journal.addPrice(prices, tx.GetDate(), tx.GetCurrencyPair().Base, tx.GetFiatPrice(), tx.GetFiatPriceCurrency())
+		journal.addPrice(prices, tx.GetDate(), tx.GetCurrencyPair().Quote, tx.GetQuoteFiatPrice(), tx.GetQuoteFiatPriceCurrency())
+		if entry, ok := journal.newTransaction(tx, history); ok {
+			journal.Transactions = append(journal.Transactions, entry)
+		}
+	}
+	return journal
+}
+
+func (journal *Journal) newTransaction(tx common.Transaction, history map[string]common.Transaction) (JournalTransaction, bool) {
+	base := tx.GetCurrencyPair().Base
+	quote := tx.GetCurrencyPair().Quote
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	total, _ := decimal.NewFromString(tx.GetTotal())
+	fee, _ := decimal.NewFromString(tx.GetFee())
+	entry := JournalTransaction{
+		TransactionId: tx.GetId(),
+		Date:          tx.GetDate(),
+		Payee:         tx.GetNetworkDisplayName()}
+	if entry.Payee == "" {
+		entry.Payee = tx.GetNetwork()
+	}
+	switch tx.GetType() {
+	case common.BUY_ORDER_TYPE, common.SELL_ORDER_TYPE:
+		sign := decimal.New(1, 0)
+		if tx.GetType() == common.SELL_ORDER_TYPE {
+			sign = sign.Neg()
+		}
+		entry.Narration = fmt.Sprintf("%s %s %s for %s %s", strings.Title(tx.GetType()), quantity, base, total, quote)
+		entry.Postings = append(entry.Postings, JournalPosting{
+			Account:        assetAccount(tx.GetNetwork(), base),
+			Quantity:       quantity.Mul(sign),
+			Commodity:      base,
+			TotalPrice:     total,
+			PriceCommodity: quote})
+		if !total.IsZero() {
+			entry.Postings = append(entry.Postings, JournalPosting{
+				Account:   assetAccount(tx.GetNetwork(), quote),
+				Quantity:  total.Mul(sign).Neg(),
+				Commodity: quote})
+		}
+		entry.Postings = append(entry.Postings, feePostings(tx, fee)...)
+	case common.DEPOSIT_ORDER_TYPE:
+		if withdrawal, ok := history[tx.GetTransferId()]; ok && withdrawal.GetType() == common.WITHDRAWAL_ORDER_TYPE {
+			// Journaled with the withdrawal it was matched to
+			return entry, false
+		}
+		entry.Narration = fmt.Sprintf("Deposit %s %s", quantity, base)
+		deposit := JournalPosting{
+			Account:   assetAccount(tx.GetNetwork(), base),
+			Quantity:  quantity,
+			Commodity: base}
+		if IsIncome(tx) {
+			entry.Narration = fmt.Sprintf("%s %s %s", strings.Title(tx.GetCategory()), quantity, base)
+			income := journal.valuePosting(tx, &deposit)
+			income.Account = fmt.Sprintf("%s:%s", JOURNAL_ACCOUNT_INCOME, strings.Title(tx.GetCategory()))
+			entry.Postings = append(entry.Postings, deposit, income)
+			break
+		}
+		entry.Postings = append(entry.Postings, deposit, JournalPosting{
+			Account:   JOURNAL_ACCOUNT_TRANSFERS,
+			Quantity:  quantity.Neg(),
+			Commodity: base})
+	case common.WITHDRAWAL_ORDER_TYPE:
+		entry.Narration = fmt.Sprintf("Withdraw %s %s", quantity, base)
+		entry.Postings = append(entry.Postings, JournalPosting{
+			Account:   assetAccount(tx.GetNetwork(), base),
+			Quantity:  quantity.Neg(),
+			Commodity: base})
+		if _, ok := GetDisposalType(tx); ok {
+			entry.Narration = fmt.Sprintf("%s %s %s", strings.Title(tx.GetCategory()), quantity, base)
+			expense := journal.valuePosting(tx, &entry.Postings[0])
+			expense.Account = fmt.Sprintf("%s:%s", JOURNAL_ACCOUNT_EXPENSES, strings.Title(tx.GetCategory()))
+			entry.Postings = append(entry.Postings, expense)
+			if tx.GetFeeCurrency() != base {
+				entry.Postings = append(entry.Postings, feePostings(tx, fee)...)
+			}
+			break
+		}
+		sent := quantity
+		if tx.GetFeeCurrency() == base {
+			sent = quantity.Sub(fee)
+		}
+		if deposit, ok := history[tx.GetTransferId()]; ok && deposit.GetType() == common.DEPOSIT_ORDER_TYPE {
+			received, _ := decimal.NewFromString(deposit.GetQuantity())
+			entry.Narration = fmt.Sprintf("Transfer %s %s to %s", received, base, deposit.GetNetwork())
+			entry.Postings = append(entry.Postings, JournalPosting{
+				Account:   assetAccount(deposit.GetNetwork(), base),
+				Quantity:  received,
+				Commodity: base})
+			sent = received
+		} else {
+			entry.Postings = append(entry.Postings, JournalPosting{
+				Account:   JOURNAL_ACCOUNT_TRANSFERS,
+				Quantity:  sent,
+				Commodity: base})
+		}
+		if networkFee := quantity.Sub(sent); !networkFee.IsZero() {
+			entry.Postings = append(entry.Postings, JournalPosting{
+				Account:   feeAccount(tx.GetNetwork()),
+				Quantity:  networkFee,
+				Commodity: base})
+		}
+		if tx.GetFeeCurrency() != base {
+			entry.Postings = append(entry.Postings, feePostings(tx, fee)...)
+		}
+	default:
+		journal.ctx.GetLogger().Warningf("[Journal.newTransaction] Skipping %s transaction %s", tx.GetType(), tx.GetId())
+		return entry, false
+	}
+	return entry, true
+}
+
+// valuePosting prices an asset posting at the fiat value of the transaction
+// and returns the posting that balances it. Coins without a fiat value are
+// balanced in the coin itself.
+func (journal *Journal) valuePosting(tx common.Transaction, asset *JournalPosting) JournalPosting {
+	_, fiatTotal := fairMarketValue(journal.ctx, nil, tx)
+	if fiatTotal.IsZero() {
+		return JournalPosting{
+			Quantity:  asset.Quantity.Neg(),
+			Commodity: asset.Commodity}
+	}
+	asset.TotalPrice = fiatTotal
+	asset.PriceCommodity = journal.fiatCurrency(tx.GetFiatTotalCurrency())
+	balance := JournalPosting{
+		Quantity:  fiatTotal,
+		Commodity: asset.PriceCommodity}
+	if asset.Quantity.IsPositive() {
+		balance.Quantity = fiatTotal.Neg()
+	}
+	return balance
+}
+
+// feePostings pays a fee from the asset account of the fee currency.
+func feePostings(tx common.Transaction, fee decimal.Decimal) []JournalPosting {
+	if fee.IsZero() || tx.GetFeeCurrency() == "" {
+		return nil
+	}
+	return []JournalPosting{
+		JournalPosting{
+			Account:   feeAccount(tx.GetNetwork()),
+			Quantity:  fee,
+			Commodity: tx.GetFeeCurrency()},
+		JournalPosting{
+			Account:   assetAccount(tx.GetNetwork(), tx.GetFeeCurrency()),
+			Quantity:  fee.Neg(),
+			Commodity: tx.GetFeeCurrency()}}
+}
+
+// addPrice records the fiat price of a currency once per day.
+func (journal *Journal) addPrice(prices map[string]bool, date time.Time, commodity, price, currency string) {
+	if _, ok := common.FiatCurrencies[commodity]; ok || commodity == "" {
+		return
+	}
+	fiatPrice, err := decimal.NewFromString(price)
+	if err != nil || !fiatPrice.IsPositive() {
+		return
+	}
+	currency = journal.fiatCurrency(currency)
+	key := fmt.Sprintf("%s:%s:%s", date.Local().Format("2006-01-02"), commodity, currency)
+	if prices[key] {
+		return
+	}
+	prices[key] = true
+	journal.Prices = append(journal.Prices, JournalPrice{
+		Date:      date,
+		Commodity: commodity,
+		Price:     fiatPrice,
+		Currency:  currency})
+}
+
+func (journal *Journal) fiatCurrency(currency string) string {
+	if currency == "" {
+		return journal.Currency
+	}
+	return currency
+}
+
+// Write writes the journal in the beancount or ledger format.
+func (journal *Journal) Write(filename, format string) error {
+	switch format {
+	case JOURNAL_FORMAT_BEANCOUNT:
+		return journal.WriteBeancount(filename)
+	case JOURNAL_FORMAT_LEDGER:
+		return journal.WriteLedger(filename)
+	}
+	return errors.New(fmt.Sprintf("Unsupported journal format: %s", format))
+}
+
+// WriteBeancount writes the journal as a beancount file, opening each account
+// on the date of its first posting.
+func (journal *Journal) WriteBeancount(filename string) error {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("option \"operating_currency\" \"%s\"\n\n", journal.Currency))
+	opened := make(map[string]bool)
+	for _, tx := range journal.Transactions {
+		for _, posting := range tx.Postings {
+			if opened[posting.Account] {
+				continue
+			}
+			opened[posting.Account] = true
+			out.WriteString(fmt.Sprintf("%s open %s\n", tx.Date.Local().Format("2006-01-02"), posting.Account))
+		}
+	}
+	out.WriteString("\n")
+	for _, price := range journal.Prices {
+		out.WriteString(fmt.Sprintf("%s price %s %s %s\n", price.Date.Local().Format("2006-01-02"),
+			strings.ToUpper(price.Commodity), price.Price, strings.ToUpper(price.Currency)))
+	}
+	for _, tx := range journal.Transactions {
+		out.WriteString(fmt.Sprintf("\n%s * %s %s\n", tx.Date.Local().Format("2006-01-02"),
+			beancountString(tx.Payee), beancountString(tx.Narration)))
+		out.WriteString(fmt.Sprintf("  id: %s\n", beancountString(tx.TransactionId)))
+		for _, posting := range tx.Postings {
+			out.WriteString(fmt.Sprintf("  %s  %s %s", posting.Account, posting.Quantity, strings.ToUpper(posting.Commodity)))
+			if posting.PriceCommodity != "" {
+				out.WriteString(fmt.Sprintf(" @@ %s %s", posting.TotalPrice.Abs(), strings.ToUpper(posting.PriceCommodity)))
+			}
+			out.WriteString("\n")
+		}
+	}
+	return ioutil.WriteFile(filename, []byte(out.String()), 0644)
+}
+
+// WriteLedger writes the journal as a ledger-cli file.
+func (journal *Journal) WriteLedger(filename string) error {
+	var out strings.Builder
+	for _, price := range journal.Prices {
+		out.WriteString(fmt.Sprintf("P %s %s %s %s\n", price.Date.Local().Format("2006/01/02 15:04:05"),
+			ledgerCommodity(price.Commodity), price.Price, ledgerCommodity(price.Currency)))
+	}
+	for _, tx := range journal.Transactions {
+		out.WriteString(fmt.Sprintf("\n%s * %s\n", tx.Date.Local().Format("2006/01/02"), tx.Payee))
+		out.WriteString(fmt.Sprintf("    ; %s\n", tx.Narration))
+		out.WriteString(fmt.Sprintf("    ; id: %s\n", tx.TransactionId))
+		for _, posting := range tx.Postings {
+			out.WriteString(fmt.Sprintf("    %s  %s %s", posting.Account, posting.Quantity, ledgerCommodity(posting.Commodity)))
+			if posting.PriceCommodity != "" {
+				out.WriteString(fmt.Sprintf(" @@ %s %s", posting.TotalPrice.Abs(), ledgerCommodity(posting.PriceCommodity)))
+			}
+			out.WriteString("\n")
+		}
+	}
+	return ioutil.WriteFile(filename, []byte(out.String()), 0644)
+}
+
+// assetAccount returns the account holding a currency on an exchange or in a
+// wallet, such as Assets:Gdax:BTC.
+func assetAccount(network, currency string) string {
+	return fmt.Sprintf("%s:%s:%s", JOURNAL_ACCOUNT_ASSETS, accountName(network), accountName(currency))
+}
+
+func feeAccount(network string) string {
+	return fmt.Sprintf("%s:%s", JOURNAL_ACCOUNT_FEES, accountName(network))
+}
+
+// accountName returns a name usable as an account component by both tools:
+// letters, digits and dashes, starting with a capital letter or digit.
+func accountName(name string) string {
+	name = strings.Trim(journalAccountPattern.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func beancountString(s string) string {
+	return fmt.Sprintf("\"%s\"", strings.Replace(s, "\"", "'", -1))
+}
+
+// ledgerCommodity quotes commodities ledger would otherwise parse as amounts.
+func ledgerCommodity(commodity string) string {
+	if ledgerCommodityPattern.MatchString(commodity) {
+		return commodity
+	}
+	return fmt.Sprintf("\"%s\"", commodity)
+}
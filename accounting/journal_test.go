@@ -0,0 +1,153 @@
+package accounting
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createJournalTestTransactions() []common.Transaction {
+	btcusd := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:             "1",
+			Date:           time.Date(2017, 01, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair:   btcusd,
+			Type:           common.BUY_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "2",
+			Total:          "2000",
+			Fee:            "10",
+			FeeCurrency:    "USD",
+			FiatPrice:      "1000",
+			QuoteFiatPrice: "1"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Network:      "gdax",
+			Quantity:     "1",
+			Fee:          "0.001",
+			FeeCurrency:  "BTC",
+			TransferId:   "3"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 02, 01, 13, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Network:      "BTC wallet",
+			Quantity:     "0.999",
+			TransferId:   "2"},
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 03, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_MINING,
+			Network:      "BTC wallet",
+			Quantity:     "0.1",
+			FiatPrice:    "1200",
+			FiatTotal:    "120"},
+		&dto.TransactionDTO{
+			Id:           "5",
+			Date:         time.Date(2017, 04, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair: btcusd,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_SPEND,
+			Network:      "BTC wallet",
+			Quantity:     "0.05",
+			FiatPrice:    "1300",
+			FiatTotal:    "65"},
+		&dto.TransactionDTO{
+			Id:             "6",
+			Date:           time.Date(2017, 05, 01, 12, 0, 0, 0, time.Local),
+			CurrencyPair:   btcusd,
+			Type:           common.SELL_ORDER_TYPE,
+			Network:        "gdax",
+			Quantity:       "1",
+			Total:          "1500",
+			Fee:            "5",
+			FeeCurrency:    "USD",
+			FiatPrice:      "1500",
+			QuoteFiatPrice: "1"}}
+}
+
+func TestJournal_Balanced(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	journal := NewJournal(ctx, createJournalTestTransactions())
+
+	// The matched deposit is journaled with its withdrawal
+	assert.Equal(t, 5, len(journal.Transactions))
+	for _, tx := range journal.Transactions {
+		weights := make(map[string]decimal.Decimal)
+		for _, posting := range tx.Postings {
+			if posting.PriceCommodity != "" {
+				weight := posting.TotalPrice
+				if posting.Quantity.IsNegative() {
+					weight = weight.Neg()
+				}
+				weights[posting.PriceCommodity] = weights[posting.PriceCommodity].Add(weight)
+				continue
+			}
+			weights[posting.Commodity] = weights[posting.Commodity].Add(posting.Quantity)
+		}
+		for commodity, weight := range weights {
+			assert.True(t, weight.IsZero(), "transaction %s is unbalanced by %s %s", tx.TransactionId, weight, commodity)
+		}
+	}
+
+	transfer := journal.Transactions[1]
+	assert.Equal(t, "2", transfer.TransactionId)
+	assert.Equal(t, 3, len(transfer.Postings))
+	assert.Equal(t, "Assets:BTC-wallet:BTC", transfer.Postings[1].Account)
+	assert.Equal(t, "0.999", transfer.Postings[1].Quantity.String())
+	assert.Equal(t, "Expenses:Fees:Gdax", transfer.Postings[2].Account)
+	assert.Equal(t, "0.001", transfer.Postings[2].Quantity.String())
+
+	mining := journal.Transactions[2]
+	assert.Equal(t, "Income:Mining", mining.Postings[1].Account)
+	assert.Equal(t, "-120", mining.Postings[1].Quantity.String())
+
+	assert.Equal(t, 4, len(journal.Prices))
+}
+
+func TestJournal_Write(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	journal := NewJournal(ctx, createJournalTestTransactions())
+
+	filename := "/tmp/tradebot-journal-test"
+	defer os.Remove(filename)
+
+	assert.Nil(t, journal.Write(filename, JOURNAL_FORMAT_BEANCOUNT))
+	bytes, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	beancount := string(bytes)
+	assert.Contains(t, beancount, "option \"operating_currency\" \"USD\"\n")
+	assert.Contains(t, beancount, "2017-01-01 open Assets:Gdax:BTC\n")
+	assert.Contains(t, beancount, "2017-01-01 price BTC 1000 USD\n")
+	assert.Contains(t, beancount, "2017-01-01 * \"gdax\" \"Buy 2 BTC for 2000 USD\"\n  id: \"1\"\n"+
+		"  Assets:Gdax:BTC  2 BTC @@ 2000 USD\n"+
+		"  Assets:Gdax:USD  -2000 USD\n"+
+		"  Expenses:Fees:Gdax  10 USD\n"+
+		"  Assets:Gdax:USD  -10 USD\n")
+	assert.Contains(t, beancount, "  Assets:Gdax:BTC  -1 BTC @@ 1500 USD\n  Assets:Gdax:USD  1500 USD\n")
+
+	assert.Nil(t, journal.Write(filename, JOURNAL_FORMAT_LEDGER))
+	bytes, err = ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	ledger := string(bytes)
+	assert.Contains(t, ledger, "P 2017/01/01 12:00:00 BTC 1000 USD\n")
+	assert.Contains(t, ledger, "2017/04/01 * BTC wallet\n    ; Spend 0.05 BTC\n    ; id: 5\n"+
+		"    Assets:BTC-wallet:BTC  -0.05 BTC @@ 65 USD\n"+
+		"    Expenses:Spend  65 USD\n")
+
+	assert.NotNil(t, journal.Write(filename, "qif"))
+}
@@ -0,0 +1,102 @@
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+)
+
+const (
+	JURISDICTION_US = "us"
+	JURISDICTION_UK = "uk"
+	JURISDICTION_CA = "ca"
+)
+
+var Jurisdictions = []string{
+	JURISDICTION_US,
+	JURISDICTION_UK,
+	JURISDICTION_CA}
+
+// US assets held longer than a year are taxed at long term rates.
+const LONG_TERM_HOLDING_DAYS = 365
+
+// TaxStatement is the capital gains report a jurisdiction's tax authority
+// expects, such as the US Form 8949.
+type TaxStatement interface {
+	WriteCSV(filename string) error
+}
+
+// Jurisdiction applies a country's rules for matching disposals against
+// acquisitions to the lots built by a Report. All amounts are in the user's
+// local currency.
+type Jurisdiction interface {
+	GetCode() string
+	GetTaxYearBounds(year int, location *time.Location) (time.Time, time.Time)
+	Calculate(report *Report, start, end time.Time) TaxStatement
+}
+
+type UsJurisdiction struct {
+	Jurisdiction
+}
+
+func NewJurisdiction(code string) (Jurisdiction, error) {
+	switch code {
+	case "", JURISDICTION_US:
+		return &UsJurisdiction{}, nil
+	case JURISDICTION_UK:
+		return &UkJurisdiction{}, nil
+	case JURISDICTION_CA:
+		return &CanadaJurisdiction{}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("[NewJurisdiction] Unsupported jurisdiction: %s", code))
+}
+
+func (jurisdiction *UsJurisdiction) GetCode() string {
+	return JURISDICTION_US
+}
+
+func (jurisdiction *UsJurisdiction) GetTaxYearBounds(year int, location *time.Location) (time.Time, time.Time) {
+	return TaxYearBounds(year, location)
+}
+
+// Calculate returns the Form 8949 using the report's lot selection method.
+func (jurisdiction *UsJurisdiction) Calculate(report *Report, start, end time.Time) TaxStatement {
+	return report.Run(start, end)
+}
+
+// getHolding returns the US holding period of a lot acquired and disposed of
+// on the given dates.
+func getHolding(acquired, disposed time.Time) string {
+	if int(disposed.Sub(acquired).Hours()/24) < LONG_TERM_HOLDING_DAYS {
+		return HOLDING_SHORT
+	}
+	return HOLDING_LONG
+}
+
+// lotCurrencies returns the crypto currencies acquired or disposed of in
+// alphabetical order.
+func lotCurrencies(buyLots, saleLots map[string][]Coinlot) []string {
+	unique := make(map[string]bool)
+	for _, lots := range []map[string][]Coinlot{buyLots, saleLots} {
+		for currency, _ := range lots {
+			if _, ok := common.FiatCurrencies[currency]; !ok {
+				unique[currency] = true
+			}
+		}
+	}
+	var currencies []string
+	for currency, _ := range unique {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// isReportable returns true for disposals that realize a gain or loss. Gifts,
+// donations and lost coins leave the pool at cost without being reported.
+func isReportable(lot *Coinlot) bool {
+	return lot.Disposal == DISPOSAL_SALE || lot.Disposal == DISPOSAL_SPEND
+}
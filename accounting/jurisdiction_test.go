@@ -0,0 +1,193 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func createJurisdictionTestTransaction(id string, date time.Time, txType, quantity, fiatTotal string) common.Transaction {
+	return &dto.TransactionDTO{
+		Id:           id,
+		Date:         date,
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		Type:         txType,
+		Category:     common.TX_CATEGORY_TRADE,
+		Quantity:     quantity,
+		FiatTotal:    fiatTotal}
+}
+
+func TestNewJurisdiction(t *testing.T) {
+	for _, code := range Jurisdictions {
+		jurisdiction, err := NewJurisdiction(code)
+		assert.Nil(t, err)
+		assert.Equal(t, code, jurisdiction.GetCode())
+	}
+	jurisdiction, err := NewJurisdiction("")
+	assert.Nil(t, err)
+	assert.Equal(t, JURISDICTION_US, jurisdiction.GetCode())
+
+	_, err = NewJurisdiction("foo")
+	assert.NotNil(t, err)
+}
+
+func TestUkJurisdiction(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	london, err := time.LoadLocation(UK_TAX_TIMEZONE)
+	assert.Nil(t, err)
+
+	transactions := []common.Transaction{
+		createJurisdictionTestTransaction("4", time.Date(2017, 06, 15, 0, 0, 0, 0, london), common.BUY_ORDER_TYPE, "0.2", "1000"),
+		createJurisdictionTestTransaction("3", time.Date(2017, 06, 01, 12, 0, 0, 0, london), common.SELL_ORDER_TYPE, "1.5", "6000"),
+		createJurisdictionTestTransaction("2", time.Date(2017, 06, 01, 9, 0, 0, 0, london), common.BUY_ORDER_TYPE, "1", "3000"),
+		createJurisdictionTestTransaction("1", time.Date(2017, 01, 01, 0, 0, 0, 0, london), common.BUY_ORDER_TYPE, "2", "2000")}
+
+	jurisdiction := &UkJurisdiction{}
+	start, end := jurisdiction.GetTaxYearBounds(2017, london)
+	assert.Equal(t, time.Date(2017, 04, 06, 0, 0, 0, 0, london), start)
+	assert.Equal(t, time.Date(2018, 04, 06, 0, 0, 0, 0, london), end.Add(time.Nanosecond))
+
+	gains := jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*UkCapitalGains)
+	assert.Equal(t, 1, gains.NumberOfDisposals)
+
+	disposal := gains.Disposals[0]
+	assert.Equal(t, []string{"3"}, disposal.TransactionIds)
+	assert.Equal(t, "6000.00", disposal.Proceeds)
+	assert.Equal(t, "4300.00", disposal.AllowableCost)
+	assert.Equal(t, "1700.00", disposal.GainOrLoss)
+	assert.Equal(t, 3, len(disposal.Matches))
+	assert.Equal(t, UkMatch{
+		Rule:          UK_MATCH_SAME_DAY,
+		DateAcquired:  time.Date(2017, 06, 01, 0, 0, 0, 0, london),
+		Quantity:      "1",
+		AllowableCost: "3000.00"}, disposal.Matches[0])
+	assert.Equal(t, UK_MATCH_BED_AND_BREAKFAST, disposal.Matches[1].Rule)
+	assert.Equal(t, "0.2", disposal.Matches[1].Quantity)
+	assert.Equal(t, "1000.00", disposal.Matches[1].AllowableCost)
+	assert.Equal(t, UK_MATCH_SECTION_104, disposal.Matches[2].Rule)
+	assert.Equal(t, "0.3", disposal.Matches[2].Quantity)
+	assert.Equal(t, "300.00", disposal.Matches[2].AllowableCost)
+
+	assert.Equal(t, []Section104Pool{
+		Section104Pool{Currency: "BTC", Quantity: "1.7", AllowableCost: "1700.00"}}, gains.Pools)
+	assert.Equal(t, "6000.00", gains.DisposalProceeds)
+	assert.Equal(t, "4300.00", gains.AllowableCosts)
+	assert.Equal(t, "1700.00", gains.Gains)
+	assert.Equal(t, "0.00", gains.Losses)
+	assert.Equal(t, "1700.00", gains.NetGain)
+}
+
+func TestUkJurisdiction_SameDayInLondon(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	london, err := time.LoadLocation(UK_TAX_TIMEZONE)
+	assert.Nil(t, err)
+
+	// 23:30 UTC on the 1st is already the 2nd in London (BST)
+	transactions := []common.Transaction{
+		createJurisdictionTestTransaction("3", time.Date(2017, 06, 02, 12, 0, 0, 0, london), common.SELL_ORDER_TYPE, "1", "5000"),
+		createJurisdictionTestTransaction("2", time.Date(2017, 06, 01, 23, 30, 0, 0, time.UTC), common.BUY_ORDER_TYPE, "1", "3000"),
+		createJurisdictionTestTransaction("1", time.Date(2017, 01, 01, 0, 0, 0, 0, london), common.BUY_ORDER_TYPE, "1", "1000")}
+
+	jurisdiction := &UkJurisdiction{}
+	start, end := jurisdiction.GetTaxYearBounds(2017, london)
+	gains := jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*UkCapitalGains)
+	assert.Equal(t, 1, len(gains.Disposals))
+	assert.Equal(t, 1, len(gains.Disposals[0].Matches))
+	assert.Equal(t, UkMatch{
+		Rule:          UK_MATCH_SAME_DAY,
+		DateAcquired:  time.Date(2017, 06, 02, 0, 0, 0, 0, london),
+		Quantity:      "1",
+		AllowableCost: "3000.00"}, gains.Disposals[0].Matches[0])
+
+	// Disposals are written on their London date whatever zone the year is bounded in
+	start, end = jurisdiction.GetTaxYearBounds(2017, time.UTC)
+	gains = jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*UkCapitalGains)
+	assert.Equal(t, london, gains.Location)
+	assert.Equal(t, "06-02-2017 00:00:00 BST", gains.Disposals[0].Record(gains.Location)[1])
+}
+
+func TestCanadaJurisdiction(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		createJurisdictionTestTransaction("6", time.Date(2017, 06, 10, 0, 0, 0, 0, time.Local), common.BUY_ORDER_TYPE, "0.5", "1000"),
+		createJurisdictionTestTransaction("5", time.Date(2017, 06, 01, 0, 0, 0, 0, time.Local), common.SELL_ORDER_TYPE, "1", "1500"),
+		createJurisdictionTestTransaction("4", time.Date(2017, 03, 01, 0, 0, 0, 0, time.Local), common.SELL_ORDER_TYPE, "1", "2500"),
+		createJurisdictionTestTransaction("2", time.Date(2017, 02, 01, 0, 0, 0, 0, time.Local), common.BUY_ORDER_TYPE, "1", "3000"),
+		createJurisdictionTestTransaction("1", time.Date(2017, 01, 01, 0, 0, 0, 0, time.Local), common.BUY_ORDER_TYPE, "1", "1000")}
+
+	jurisdiction := &CanadaJurisdiction{}
+	start, end := jurisdiction.GetTaxYearBounds(2017, time.Local)
+	schedule := jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*Schedule3)
+	assert.Equal(t, "USD", schedule.Currency)
+	assert.Equal(t, 2, len(schedule.Dispositions))
+
+	assert.Equal(t, "2000.00", schedule.Dispositions[0].AdjustedCostBase)
+	assert.Equal(t, "0.00", schedule.Dispositions[0].SuperficialLoss)
+	assert.Equal(t, "500.00", schedule.Dispositions[0].GainOrLoss)
+
+	assert.Equal(t, "5", schedule.Dispositions[1].TransactionId)
+	assert.Equal(t, "2000.00", schedule.Dispositions[1].AdjustedCostBase)
+	assert.Equal(t, "250.00", schedule.Dispositions[1].SuperficialLoss)
+	assert.Equal(t, "-250.00", schedule.Dispositions[1].GainOrLoss)
+
+	assert.Equal(t, []AdjustedCostBase{
+		AdjustedCostBase{Currency: "BTC", Quantity: "0.5", Total: "1250.00", PerUnit: "2500.00"}}, schedule.Holdings)
+	assert.Equal(t, "4000.00", schedule.Proceeds)
+	assert.Equal(t, "4000.00", schedule.AdjustedCostBase)
+	assert.Equal(t, "250.00", schedule.SuperficialLosses)
+	assert.Equal(t, "250.00", schedule.GainOrLoss)
+	assert.Equal(t, "125.00", schedule.TaxableCapitalGain)
+
+	// Dispositions are written in the time zone the tax year is bounded in
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	start, end = jurisdiction.GetTaxYearBounds(2017, newYork)
+	schedule = jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*Schedule3)
+	assert.Equal(t, newYork, schedule.Location)
+	assert.Equal(t, schedule.Dispositions[0].DateDisposed.In(newYork).Format(common.TIME_DISPLAY_FORMAT),
+		schedule.Dispositions[0].Record(newYork)[1])
+}
+
+func TestCanadaJurisdiction_NoReplacement(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		createJurisdictionTestTransaction("2", time.Date(2017, 06, 01, 0, 0, 0, 0, time.Local), common.SELL_ORDER_TYPE, "1", "500"),
+		createJurisdictionTestTransaction("1", time.Date(2017, 05, 15, 0, 0, 0, 0, time.Local), common.BUY_ORDER_TYPE, "1", "1000")}
+
+	jurisdiction := &CanadaJurisdiction{}
+	start, end := jurisdiction.GetTaxYearBounds(2017, time.Local)
+	schedule := jurisdiction.Calculate(NewFifoReport(ctx, transactions), start, end).(*Schedule3)
+	assert.Equal(t, 1, len(schedule.Dispositions))
+	assert.Equal(t, "0.00", schedule.Dispositions[0].SuperficialLoss)
+	assert.Equal(t, "-500.00", schedule.Dispositions[0].GainOrLoss)
+	assert.Equal(t, "0.00", schedule.TaxableCapitalGain)
+	assert.Equal(t, 0, len(schedule.Holdings))
+}
+
+func TestJurisdiction_MissingBasis(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		createJurisdictionTestTransaction("2", time.Date(2017, 06, 01, 0, 0, 0, 0, time.Local), common.SELL_ORDER_TYPE, "1.5", "3000"),
+		createJurisdictionTestTransaction("1", time.Date(2017, 05, 01, 0, 0, 0, 0, time.Local), common.BUY_ORDER_TYPE, "1", "1000")}
+
+	uk := &UkJurisdiction{}
+	start, end := uk.GetTaxYearBounds(2017, time.Local)
+	gains := uk.Calculate(NewFifoReport(ctx, transactions), start, end).(*UkCapitalGains)
+	assert.Equal(t, 1, len(gains.Disposals))
+	assert.Equal(t, "1000.00", gains.Disposals[0].AllowableCost)
+	assert.Equal(t, "0.5", gains.Disposals[0].MissingBasis)
+
+	canada := &CanadaJurisdiction{}
+	start, end = canada.GetTaxYearBounds(2017, time.Local)
+	schedule := canada.Calculate(NewFifoReport(ctx, transactions), start, end).(*Schedule3)
+	assert.Equal(t, 1, len(schedule.Dispositions))
+	assert.Equal(t, "1000.00", schedule.Dispositions[0].AdjustedCostBase)
+	assert.Equal(t, "0.5", schedule.Dispositions[0].MissingBasis)
+}
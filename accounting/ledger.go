@@ -0,0 +1,294 @@
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	HOLDING_SHORT = "short"
+	HOLDING_LONG  = "long"
+)
+
+type TaxYear struct {
+	Year     int       `json:"year"`
+	Method   string    `json:"method"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+// TaxLotLedger persists the lots remaining at the end of each closed tax year
+// so later years start from the carried forward positions, and so the Form
+// 8949 of a closed year never changes once it has been filed.
+type TaxLotLedger interface {
+	GetTaxYears() ([]TaxYear, error)
+	IsClosed(year int) bool
+	GetOpeningLots(year int) (map[string][]Coinlot, time.Time, error)
+	GetForm8949(year int, report *Report) (*Form8949, error)
+	CloseYear(year int, report *Report) (*Form8949, error)
+	ReopenYear(year int) error
+	SetLocation(location *time.Location)
+}
+
+type DefaultTaxLotLedger struct {
+	ctx        common.Context
+	taxYearDAO dao.TaxYearDAO
+	location   *time.Location
+	TaxLotLedger
+}
+
+func NewTaxLotLedger(ctx common.Context, taxYearDAO dao.TaxYearDAO) TaxLotLedger {
+	return &DefaultTaxLotLedger{
+		ctx:        ctx,
+		taxYearDAO: taxYearDAO,
+		location:   time.Now().Location()}
+}
+
+// TaxYearBounds returns the first and last instant of the tax year.
+func TaxYearBounds(year int, location *time.Location) (time.Time, time.Time) {
+	start := time.Date(year, 01, 01, 0, 0, 0, 0, location)
+	return start, start.AddDate(1, 0, 0).Add(-time.Nanosecond)
+}
+
+// SetLocation sets the time zone whose calendar bounds the tax years. The
+// server's local time zone is used by default.
+func (ledger *DefaultTaxLotLedger) SetLocation(location *time.Location) {
+	ledger.location = location
+}
+
+func (ledger *DefaultTaxLotLedger) GetTaxYears() ([]TaxYear, error) {
+	entities, err := ledger.taxYearDAO.Find()
+	if err != nil {
+		return nil, err
+	}
+	taxYears := make([]TaxYear, len(entities))
+	for i, entity := range entities {
+		taxYears[i] = TaxYear{
+			Year:     entity.GetYear(),
+			Method:   entity.GetMethod(),
+			ClosedAt: entity.GetClosedAt()}
+	}
+	return taxYears, nil
+}
+
+func (ledger *DefaultTaxLotLedger) IsClosed(year int) bool {
+	_, err := ledger.taxYearDAO.Get(year)
+	return err == nil
+}
+
+// GetOpeningLots returns the lots carried forward from the most recent closed
+// year before the requested year, along with the date they were carried
+// forward to. A nil map is returned when no earlier year has been closed.
+func (ledger *DefaultTaxLotLedger) GetOpeningLots(year int) (map[string][]Coinlot, time.Time, error) {
+	var openingDate time.Time
+	closedYear, err := ledger.getLastClosedYear(year)
+	if err != nil || closedYear == 0 {
+		return nil, openingDate, err
+	}
+	entities, err := ledger.taxYearDAO.FindLots(closedYear)
+	if err != nil {
+		return nil, openingDate, err
+	}
+	lots := make(map[string][]Coinlot)
+	for _, entity := range entities {
+		quantity, err := decimal.NewFromString(entity.GetQuantity())
+		if err != nil {
+			ledger.ctx.GetLogger().Errorf("[TaxLotLedger.GetOpeningLots] Error parsing quantity decimal: %s", err.Error())
+		}
+		unitPrice, err := decimal.NewFromString(entity.GetUnitPrice())
+		if err != nil {
+			ledger.ctx.GetLogger().Errorf("[TaxLotLedger.GetOpeningLots] Error parsing unit price decimal: %s", err.Error())
+		}
+		costBasis, err := decimal.NewFromString(entity.GetCostBasis())
+		if err != nil {
+			ledger.ctx.GetLogger().Errorf("[TaxLotLedger.GetOpeningLots] Error parsing cost basis decimal: %s", err.Error())
+		}
+		fee, _ := decimal.NewFromString(entity.GetFee())
+		lots[entity.GetCurrency()] = append(lots[entity.GetCurrency()], Coinlot{
+			TransactionId: entity.GetTransactionId(),
+			Date:          entity.GetDate(),
+			Currency:      entity.GetCurrency(),
+			Quantity:      quantity,
+			UnitPrice:     unitPrice,
+			CostBasis:     costBasis,
+			Fee:           fee})
+	}
+	openingDate, _ = TaxYearBounds(closedYear+1, ledger.location)
+	return lots, openingDate, nil
+}
+
+// GetForm8949 returns the persisted form for a closed year. Otherwise the
+// report is run for the year starting from the carried forward lots.
+func (ledger *DefaultTaxLotLedger) GetForm8949(year int, report *Report) (*Form8949, error) {
+	if ledger.IsClosed(year) {
+		ledger.ctx.GetLogger().Debugf("[TaxLotLedger.GetForm8949] Loading closed tax year %d", year)
+		return ledger.loadForm8949(year)
+	}
+	openingLots, openingDate, err := ledger.GetOpeningLots(year)
+	if err != nil {
+		return nil, err
+	}
+	if openingLots != nil {
+		report.SetOpeningLots(openingLots, openingDate)
+	}
+	start, end := TaxYearBounds(year, ledger.location)
+	return report.Run(start, end), nil
+}
+
+func (ledger *DefaultTaxLotLedger) CloseYear(year int, report *Report) (*Form8949, error) {
+	ledger.ctx.GetLogger().Debugf("[TaxLotLedger.CloseYear] Closing %s's %d tax year using %s",
+		ledger.ctx.GetUser().GetUsername(), year, report.GetMethod())
+	if ledger.IsClosed(year) {
+		return nil, errors.New(fmt.Sprintf("Tax year %d is already closed", year))
+	}
+	lastClosed, err := ledger.getLastClosedYear(math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	if lastClosed > year {
+		return nil, errors.New(fmt.Sprintf("Tax year %d precedes closed tax year %d", year, lastClosed))
+	}
+	form, err := ledger.GetForm8949(year, report)
+	if err != nil {
+		return nil, err
+	}
+	userId := ledger.ctx.GetUser().GetId()
+	var lots []entity.TaxLot
+	for currency, openLots := range report.GetOpenLotsByCurrency() {
+		for _, lot := range openLots {
+			if !lot.Quantity.IsPositive() {
+				continue
+			}
+			lots = append(lots, entity.TaxLot{
+				UserId:        userId,
+				Year:          year,
+				TransactionId: lot.TransactionId,
+				Date:          lot.Date,
+				Currency:      currency,
+				Quantity:      lot.Quantity.String(),
+				UnitPrice:     lot.UnitPrice.String(),
+				CostBasis:     lot.CostBasis.String(),
+				Fee:           lot.Fee.String()})
+		}
+	}
+	var lineItems []entity.TaxLineItem
+	for _, lineItem := range form.ShortHolds {
+		lineItems = append(lineItems, ledger.mapLineItem(year, HOLDING_SHORT, &lineItem))
+	}
+	for _, lineItem := range form.LongHolds {
+		lineItems = append(lineItems, ledger.mapLineItem(year, HOLDING_LONG, &lineItem))
+	}
+	taxYear := &entity.TaxYear{
+		UserId:   userId,
+		Year:     year,
+		Method:   report.GetMethod(),
+		ClosedAt: time.Now()}
+	if err := ledger.taxYearDAO.Close(taxYear, lots, lineItems); err != nil {
+		return nil, err
+	}
+	return form, nil
+}
+
+func (ledger *DefaultTaxLotLedger) ReopenYear(year int) error {
+	ledger.ctx.GetLogger().Debugf("[TaxLotLedger.ReopenYear] Reopening %s's %d tax year",
+		ledger.ctx.GetUser().GetUsername(), year)
+	if !ledger.IsClosed(year) {
+		return errors.New(fmt.Sprintf("Tax year %d is not closed", year))
+	}
+	lastClosed, err := ledger.getLastClosedYear(math.MaxInt32)
+	if err != nil {
+		return err
+	}
+	if lastClosed > year {
+		return errors.New(fmt.Sprintf("Tax year %d must be reopened first", lastClosed))
+	}
+	return ledger.taxYearDAO.Reopen(year)
+}
+
+func (ledger *DefaultTaxLotLedger) getLastClosedYear(before int) (int, error) {
+	taxYears, err := ledger.taxYearDAO.Find()
+	if err != nil {
+		return 0, err
+	}
+	lastClosed := 0
+	for _, taxYear := range taxYears {
+		if taxYear.GetYear() < before && taxYear.GetYear() > lastClosed {
+			lastClosed = taxYear.GetYear()
+		}
+	}
+	return lastClosed, nil
+}
+
+func (ledger *DefaultTaxLotLedger) loadForm8949(year int) (*Form8949, error) {
+	entities, err := ledger.taxYearDAO.FindLineItems(year)
+	if err != nil {
+		return nil, err
+	}
+	form := &Form8949{Location: ledger.location}
+	for _, entity := range entities {
+		var lots []LotReference
+		for _, lot := range entity.GetLots() {
+			lots = append(lots, LotReference{
+				TransactionId: lot.GetTransactionId(),
+				Date:          lot.GetDate(),
+				Quantity:      lot.GetQuantity(),
+				CostBasis:     lot.GetCostBasis(),
+				Fee:           lot.GetFee()})
+		}
+		lineItem := Form8949LineItem{
+			TransactionId:    entity.GetTransactionId(),
+			Currency:         entity.GetCurrency(),
+			Description:      entity.GetDescription(),
+			DateAcquired:     entity.GetDateAcquired(),
+			DateSold:         entity.GetDateSold(),
+			Proceeds:         entity.GetProceeds(),
+			CostBasis:        entity.GetCostBasis(),
+			AdjustmentCode:   entity.GetAdjustmentCode(),
+			AdjustmentAmount: entity.GetAdjustmentAmount(),
+			Fees:             entity.GetFees(),
+			GainOrLoss:       entity.GetGainOrLoss(),
+			MissingBasis:     entity.GetMissingBasis(),
+			Lots:             lots}
+		if entity.GetHolding() == HOLDING_LONG {
+			form.LongHolds = append(form.LongHolds, lineItem)
+		} else {
+			form.ShortHolds = append(form.ShortHolds, lineItem)
+		}
+	}
+	return form, nil
+}
+
+func (ledger *DefaultTaxLotLedger) mapLineItem(year int, holding string, lineItem *Form8949LineItem) entity.TaxLineItem {
+	lots := make([]entity.TaxLineItemLot, len(lineItem.Lots))
+	for i, lot := range lineItem.Lots {
+		lots[i] = entity.TaxLineItemLot{
+			TransactionId: lot.TransactionId,
+			Date:          lot.Date,
+			Quantity:      lot.Quantity,
+			CostBasis:     lot.CostBasis,
+			Fee:           lot.Fee}
+	}
+	return entity.TaxLineItem{
+		UserId:           ledger.ctx.GetUser().GetId(),
+		Year:             year,
+		TransactionId:    lineItem.TransactionId,
+		Holding:          holding,
+		Currency:         lineItem.Currency,
+		Description:      lineItem.Description,
+		DateAcquired:     lineItem.DateAcquired,
+		DateSold:         lineItem.DateSold,
+		Proceeds:         lineItem.Proceeds,
+		CostBasis:        lineItem.CostBasis,
+		AdjustmentCode:   lineItem.AdjustmentCode,
+		AdjustmentAmount: lineItem.AdjustmentAmount,
+		Fees:             lineItem.Fees,
+		GainOrLoss:       lineItem.GainOrLoss,
+		MissingBasis:     lineItem.MissingBasis,
+		Lots:             lots}
+}
@@ -0,0 +1,204 @@
+package accounting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockTaxYearDAO struct {
+	taxYears  []entity.TaxYear
+	lots      []entity.TaxLot
+	lineItems []entity.TaxLineItem
+	dao.TaxYearDAO
+}
+
+func (mock *MockTaxYearDAO) Get(year int) (entity.TaxYearEntity, error) {
+	for _, taxYear := range mock.taxYears {
+		if taxYear.Year == year {
+			return &taxYear, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (mock *MockTaxYearDAO) Find() ([]entity.TaxYear, error) {
+	return mock.taxYears, nil
+}
+
+func (mock *MockTaxYearDAO) Close(taxYear entity.TaxYearEntity, lots []entity.TaxLot, lineItems []entity.TaxLineItem) error {
+	mock.taxYears = append(mock.taxYears, *taxYear.(*entity.TaxYear))
+	mock.lots = append(mock.lots, lots...)
+	mock.lineItems = append(mock.lineItems, lineItems...)
+	return nil
+}
+
+func (mock *MockTaxYearDAO) Reopen(year int) error {
+	var taxYears []entity.TaxYear
+	for _, taxYear := range mock.taxYears {
+		if taxYear.Year != year {
+			taxYears = append(taxYears, taxYear)
+		}
+	}
+	mock.taxYears = taxYears
+	return nil
+}
+
+func (mock *MockTaxYearDAO) FindLots(year int) ([]entity.TaxLot, error) {
+	var lots []entity.TaxLot
+	for _, lot := range mock.lots {
+		if lot.Year == year {
+			lots = append(lots, lot)
+		}
+	}
+	return lots, nil
+}
+
+func (mock *MockTaxYearDAO) FindLineItems(year int) ([]entity.TaxLineItem, error) {
+	var lineItems []entity.TaxLineItem
+	for _, lineItem := range mock.lineItems {
+		if lineItem.Year == year {
+			lineItems = append(lineItems, lineItem)
+		}
+	}
+	return lineItems, nil
+}
+
+func createLedgerTestTransactions() []common.Transaction {
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2018, 03, 01, 0, 0, 0, 0, time.Local),
+			CurrencyPair: currencyPair,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "1",
+			FiatQuantity: "8000",
+			FiatTotal:    "8000"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 06, 01, 0, 0, 0, 0, time.Local),
+			CurrencyPair: currencyPair,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "1",
+			FiatQuantity: "3000",
+			FiatTotal:    "3000"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.Local),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "2",
+			FiatTotal:    "2000"}}
+}
+
+func TestTaxLotLedger_CarryForward(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewTaxLotLedger(ctx, &MockTaxYearDAO{})
+
+	form2017, err := ledger.CloseYear(2017, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.Nil(t, err)
+	assert.True(t, ledger.IsClosed(2017))
+	assert.Equal(t, 1, len(form2017.ShortHolds))
+	assert.Equal(t, "2000.00", form2017.ShortHolds[0].GainOrLoss)
+
+	openingLots, openingDate, err := ledger.GetOpeningLots(2018)
+	assert.Nil(t, err)
+	assert.Equal(t, 2018, openingDate.Year())
+	assert.Equal(t, 1, len(openingLots["BTC"]))
+	assert.Equal(t, "1", openingLots["BTC"][0].Quantity.String())
+	assert.Equal(t, "1000", openingLots["BTC"][0].CostBasis.String())
+
+	// Re-categorizing the 2017 sale must not change the filed 2017 form
+	transactions := createLedgerTestTransactions()
+	transactions[1].(*dto.TransactionDTO).Category = common.TX_CATEGORY_TRANSFER
+
+	closed, err := ledger.GetForm8949(2017, NewFifoReport(ctx, transactions))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(closed.ShortHolds))
+	assert.Equal(t, "2000.00", closed.ShortHolds[0].GainOrLoss)
+
+	form2018, err := ledger.GetForm8949(2018, NewFifoReport(ctx, transactions))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(form2018.LongHolds))
+	assert.Equal(t, "1000.00", form2018.LongHolds[0].CostBasis)
+	assert.Equal(t, "7000.00", form2018.LongHolds[0].GainOrLoss)
+	assert.Equal(t, 0, len(form2018.ShortHolds))
+}
+
+func TestTaxLotLedger_CloseOrder(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewTaxLotLedger(ctx, &MockTaxYearDAO{})
+
+	_, err := ledger.CloseYear(2018, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.Nil(t, err)
+
+	_, err = ledger.CloseYear(2017, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.NotNil(t, err)
+
+	_, err = ledger.CloseYear(2018, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, ledger.ReopenYear(2018))
+	assert.False(t, ledger.IsClosed(2018))
+	assert.NotNil(t, ledger.ReopenYear(2018))
+}
+
+func TestTaxLotLedger_ClosedYearLotReferences(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	ledger := NewTaxLotLedger(ctx, &MockTaxYearDAO{})
+
+	_, err := ledger.CloseYear(2017, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.Nil(t, err)
+
+	form2017, err := ledger.GetForm8949(2017, NewFifoReport(ctx, createLedgerTestTransactions()))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(form2017.ShortHolds))
+	assert.Equal(t, "2", form2017.ShortHolds[0].TransactionId)
+	assert.Equal(t, 1, len(form2017.ShortHolds[0].Lots))
+	assert.Equal(t, "1", form2017.ShortHolds[0].Lots[0].TransactionId)
+	assert.Equal(t, "1000.00", form2017.ShortHolds[0].Lots[0].CostBasis)
+}
+
+func TestTaxLotLedger_SetLocation(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2018, 01, 01, 3, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "1",
+			FiatQuantity: "3000",
+			FiatTotal:    "3000"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "1",
+			FiatTotal:    "1000"}}
+
+	ledger := NewTaxLotLedger(ctx, &MockTaxYearDAO{})
+	ledger.SetLocation(time.UTC)
+	form, err := ledger.GetForm8949(2017, NewFifoReport(ctx, transactions))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(form.ShortHolds)+len(form.LongHolds))
+
+	// Still New Year's Eve in New York
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	ledger.SetLocation(newYork)
+	form, err = ledger.GetForm8949(2017, NewFifoReport(ctx, transactions))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(form.LongHolds))
+	assert.Equal(t, "2", form.LongHolds[0].TransactionId)
+}
@@ -0,0 +1,191 @@
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	COST_BASIS_FIFO     = "fifo"
+	COST_BASIS_LIFO     = "lifo"
+	COST_BASIS_HIFO     = "hifo"
+	COST_BASIS_AVERAGE  = "average"
+	COST_BASIS_SPECIFIC = "specific"
+)
+
+var CostBasisMethods = []string{
+	COST_BASIS_FIFO,
+	COST_BASIS_LIFO,
+	COST_BASIS_HIFO,
+	COST_BASIS_AVERAGE}
+
+// LotSelector orders the open buy lots available to a sale. The report
+// consumes the returned lots from the front until the sale is filled.
+type LotSelector interface {
+	GetMethod() string
+	Select(saleLot *Coinlot, lots []Coinlot) []Coinlot
+}
+
+type FifoLotSelector struct {
+	LotSelector
+}
+
+type LifoLotSelector struct {
+	LotSelector
+}
+
+type HifoLotSelector struct {
+	LotSelector
+}
+
+type AverageCostLotSelector struct {
+	LotSelector
+}
+
+type SpecificIdLotSelector struct {
+	fallback    LotSelector
+	assignments map[string][]common.LotSelection
+	LotSelector
+}
+
+func NewLotSelector(method string) (LotSelector, error) {
+	switch method {
+	case "", COST_BASIS_FIFO:
+		return &FifoLotSelector{}, nil
+	case COST_BASIS_LIFO:
+		return &LifoLotSelector{}, nil
+	case COST_BASIS_HIFO:
+		return &HifoLotSelector{}, nil
+	case COST_BASIS_AVERAGE:
+		return &AverageCostLotSelector{}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("[NewLotSelector] Unsupported cost basis method: %s", method))
+}
+
+// NewSpecificIdLotSelector consumes the lots assigned to each sale before
+// falling back to the given method for any unassigned quantity.
+func NewSpecificIdLotSelector(selections []common.LotSelection, fallback string) (LotSelector, error) {
+	fallbackSelector, err := NewLotSelector(fallback)
+	if err != nil {
+		return nil, err
+	}
+	assignments := make(map[string][]common.LotSelection)
+	for _, selection := range selections {
+		id := selection.GetSaleTransactionId()
+		assignments[id] = append(assignments[id], selection)
+	}
+	return &SpecificIdLotSelector{
+		fallback:    fallbackSelector,
+		assignments: assignments}, nil
+}
+
+func (selector *FifoLotSelector) GetMethod() string {
+	return COST_BASIS_FIFO
+}
+
+func (selector *FifoLotSelector) Select(saleLot *Coinlot, lots []Coinlot) []Coinlot {
+	selected := copyLots(lots)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Date.Before(selected[j].Date)
+	})
+	return selected
+}
+
+func (selector *LifoLotSelector) GetMethod() string {
+	return COST_BASIS_LIFO
+}
+
+func (selector *LifoLotSelector) Select(saleLot *Coinlot, lots []Coinlot) []Coinlot {
+	selected := copyLots(lots)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Date.After(selected[j].Date)
+	})
+	return selected
+}
+
+func (selector *HifoLotSelector) GetMethod() string {
+	return COST_BASIS_HIFO
+}
+
+func (selector *HifoLotSelector) Select(saleLot *Coinlot, lots []Coinlot) []Coinlot {
+	selected := copyLots(lots)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].UnitCost().GreaterThan(selected[j].UnitCost())
+	})
+	return selected
+}
+
+func (selector *AverageCostLotSelector) GetMethod() string {
+	return COST_BASIS_AVERAGE
+}
+
+// Select re-prices every open lot at the average unit cost of the pool. Lots
+// are still consumed oldest first so holding periods are preserved.
+func (selector *AverageCostLotSelector) Select(saleLot *Coinlot, lots []Coinlot) []Coinlot {
+	var quantity, costBasis decimal.Decimal
+	for _, lot := range lots {
+		quantity = quantity.Add(lot.Quantity)
+		costBasis = costBasis.Add(lot.CostBasis)
+	}
+	selected := copyLots(lots)
+	if quantity.IsZero() {
+		return selected
+	}
+	averageCost := costBasis.Div(quantity)
+	for i := range selected {
+		selected[i].UnitPrice = averageCost
+		selected[i].CostBasis = averageCost.Mul(selected[i].Quantity)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Date.Before(selected[j].Date)
+	})
+	return selected
+}
+
+func (selector *SpecificIdLotSelector) GetMethod() string {
+	return COST_BASIS_SPECIFIC
+}
+
+func (selector *SpecificIdLotSelector) Select(saleLot *Coinlot, lots []Coinlot) []Coinlot {
+	assignments, ok := selector.assignments[saleLot.TransactionId]
+	if !ok {
+		return selector.fallback.Select(saleLot, lots)
+	}
+	var selected []Coinlot
+	remaining := copyLots(lots)
+	for _, assignment := range assignments {
+		if assignment.GetCurrency() != saleLot.Currency {
+			continue
+		}
+		quantity := assignment.GetQuantity()
+		for i := range remaining {
+			if !quantity.IsPositive() {
+				break
+			}
+			if remaining[i].TransactionId != assignment.GetBuyTransactionId() || !remaining[i].Quantity.IsPositive() {
+				continue
+			}
+			portion, remainder := remaining[i].Split(decimal.Min(quantity, remaining[i].Quantity))
+			selected = append(selected, portion)
+			remaining[i] = remainder
+			quantity = quantity.Sub(portion.Quantity)
+		}
+	}
+	var unassigned []Coinlot
+	for _, lot := range remaining {
+		if lot.Quantity.IsPositive() {
+			unassigned = append(unassigned, lot)
+		}
+	}
+	return append(selected, selector.fallback.Select(saleLot, unassigned)...)
+}
+
+func copyLots(lots []Coinlot) []Coinlot {
+	_lots := make([]Coinlot, len(lots))
+	copy(_lots, lots)
+	return _lots
+}
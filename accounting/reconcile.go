@@ -0,0 +1,286 @@
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/service"
+	"github.com/shopspring/decimal"
+)
+
+// Differences smaller than the smallest unit exchanges report are rounding.
+const RECONCILIATION_TOLERANCE = "0.00000001"
+
+// ReconciliationReport replays the stored transaction history to compute the
+// balance each exchange and wallet should hold, and compares it to the live
+// balances they report. A discrepancy means transactions are missing from the
+// history and the tax reports built from it are wrong.
+type ReconciliationReport struct {
+	ctx          common.Context
+	transactions []common.Transaction
+}
+
+type Reconciliation struct {
+	Balances      []ReconciledBalance `json:"balances"`
+	Unverified    []ReconciledBalance `json:"unverified"`
+	Discrepancies int                 `json:"discrepancies"`
+}
+
+// ReconciledBalance compares the computed and live balance of a currency held
+// on an exchange or in a wallet. Difference is the live balance less the
+// computed balance. Reason explains why an unverified balance could not be
+// compared to a live balance.
+type ReconciledBalance struct {
+	Account             string               `json:"account"`
+	Currency            string               `json:"currency"`
+	Expected            string               `json:"expected"`
+	Actual              string               `json:"actual"`
+	Difference          string               `json:"difference"`
+	Reconciled          bool                 `json:"reconciled"`
+	Reason              string               `json:"reason,omitempty"`
+	MissingTransactions []MissingTransaction `json:"missing_transactions"`
+}
+
+// MissingTransaction describes a transaction that would explain a
+// discrepancy, along with the transaction recorded on another account that
+// suggests it, if any.
+type MissingTransaction struct {
+	Type          string    `json:"type"`
+	Quantity      string    `json:"quantity"`
+	Reason        string    `json:"reason"`
+	TransactionId string    `json:"transaction_id,omitempty"`
+	Network       string    `json:"network,omitempty"`
+	Date          time.Time `json:"date,omitempty"`
+}
+
+type accountBalance struct {
+	account  string
+	currency string
+	expected decimal.Decimal
+	actual   decimal.Decimal
+	verified bool
+	reason   string
+}
+
+func NewReconciliationReport(ctx common.Context, transactions []common.Transaction) *ReconciliationReport {
+	return &ReconciliationReport{
+		ctx:          ctx,
+		transactions: transactions}
+}
+
+// Run compares the computed balances to the exchanges' balances and to the
+// wallets' balances. Wallet transactions are attributed to the wallet holding
+// their currency, so transactions recorded on networks other than the given
+// exchanges count toward the wallets. Fiat balances are not reconciled, and an
+// exchange that reports no balances at all is treated as unavailable rather
+// than empty, leaving its balances unverified.
+func (report *ReconciliationReport) Run(exchanges []common.Exchange, wallets []common.Wallet) *Reconciliation {
+	exchangeNames := make(map[string]string)
+	for _, exchange := range exchanges {
+		exchangeNames[strings.ToLower(exchange.GetName())] = exchange.GetName()
+	}
+	balances := make(map[string]*accountBalance)
+	getBalance := func(account, currency string) *accountBalance {
+		key := account + ":" + currency
+		if _, ok := balances[key]; !ok {
+			balances[key] = &accountBalance{account: account, currency: currency}
+		}
+		return balances[key]
+	}
+	account := func(tx common.Transaction, currency string) string {
+		if name, ok := exchangeNames[strings.ToLower(tx.GetNetwork())]; ok {
+			return name
+		}
+		return walletAccount(currency)
+	}
+	adjust := func(tx common.Transaction, currency string, amount decimal.Decimal) {
+		if _, ok := common.FiatCurrencies[currency]; ok || currency == "" || amount.IsZero() {
+			return
+		}
+		balance := getBalance(account(tx, currency), currency)
+		balance.expected = balance.expected.Add(amount)
+	}
+
+	for _, tx := range report.transactions {
+		if tx.IsDeleted() {
+			continue
+		}
+		base := tx.GetCurrencyPair().Base
+		quote := tx.GetCurrencyPair().Quote
+		quantity, _ := decimal.NewFromString(tx.GetQuantity())
+		total, _ := decimal.NewFromString(tx.GetTotal())
+		fee, _ := decimal.NewFromString(tx.GetFee())
+		feeCurrency := tx.GetFeeCurrency()
+		switch tx.GetType() {
+		case common.BUY_ORDER_TYPE:
+			adjust(tx, base, quantity)
+			adjust(tx, quote, total.Neg())
+		case common.SELL_ORDER_TYPE:
+			adjust(tx, base, quantity.Neg())
+			adjust(tx, quote, total)
+		case common.DEPOSIT_ORDER_TYPE:
+			adjust(tx, base, quantity)
+			continue
+		case common.WITHDRAWAL_ORDER_TYPE:
+			adjust(tx, base, quantity.Neg())
+			if feeCurrency != base {
+				adjust(tx, feeCurrency, fee.Neg())
+			}
+			continue
+		default:
+			continue
+		}
+		adjust(tx, feeCurrency, fee.Neg())
+	}
+
+	for _, exchange := range exchanges {
+		coins, _ := exchange.GetBalances()
+		if len(coins) == 0 {
+			report.ctx.GetLogger().Warningf("[ReconciliationReport.Run] %s did not report any balances", exchange.GetName())
+			for _, balance := range balances {
+				if balance.account == exchange.GetName() {
+					balance.reason = fmt.Sprintf("%s did not report any balances", exchange.GetName())
+				}
+			}
+			continue
+		}
+		for _, coin := range coins {
+			if _, ok := common.FiatCurrencies[coin.GetCurrency()]; ok {
+				continue
+			}
+			balance := getBalance(exchange.GetName(), coin.GetCurrency())
+			balance.actual = balance.actual.Add(coin.GetBalance())
+		}
+		for _, balance := range balances {
+			if balance.account == exchange.GetName() {
+				balance.verified = true
+			}
+		}
+	}
+	for _, wallet := range wallets {
+		userWallet, err := wallet.GetWallet()
+		if err != nil {
+			report.ctx.GetLogger().Warningf("[ReconciliationReport.Run] Unable to retrieve wallet balance: %s", err.Error())
+			continue
+		}
+		balance := getBalance(walletAccount(userWallet.GetCurrency()), userWallet.GetCurrency())
+		balance.actual = balance.actual.Add(userWallet.GetBalance())
+		balance.verified = true
+	}
+
+	tolerance, _ := decimal.NewFromString(RECONCILIATION_TOLERANCE)
+	reconciliation := &Reconciliation{}
+	for _, balance := range balances {
+		if balance.expected.IsZero() && balance.actual.IsZero() {
+			continue
+		}
+		difference := balance.actual.Sub(balance.expected)
+		reconciled := difference.Abs().LessThanOrEqual(tolerance)
+		reconciledBalance := ReconciledBalance{
+			Account:    balance.account,
+			Currency:   balance.currency,
+			Expected:   balance.expected.String(),
+			Actual:     balance.actual.String(),
+			Difference: difference.String(),
+			Reconciled: reconciled}
+		if !balance.verified {
+			reconciledBalance.Actual = ""
+			reconciledBalance.Difference = ""
+			reconciledBalance.Reconciled = false
+			reconciledBalance.Reason = balance.reason
+			if reconciledBalance.Reason == "" {
+				reconciledBalance.Reason = "No exchange or wallet reported this balance"
+			}
+			reconciliation.Unverified = append(reconciliation.Unverified, reconciledBalance)
+			continue
+		}
+		if !reconciled {
+			reconciledBalance.MissingTransactions = report.findMissing(balance.account, balance.currency, difference, account)
+			reconciliation.Discrepancies++
+		}
+		reconciliation.Balances = append(reconciliation.Balances, reconciledBalance)
+	}
+	reconciliation.sort()
+	return reconciliation
+}
+
+// findMissing suggests the transactions that would explain a difference. More
+// coins than recorded points to a deposit whose withdrawal from another account
+// was recorded without its counterpart, fewer points to the reverse. Candidates
+// are ordered by how closely their quantity matches the difference.
+func (report *ReconciliationReport) findMissing(account, currency string, difference decimal.Decimal,
+	accountOf func(common.Transaction, string) string) []MissingTransaction {
+
+	missingType, counterpartType := common.DEPOSIT_ORDER_TYPE, common.WITHDRAWAL_ORDER_TYPE
+	if difference.IsNegative() {
+		missingType, counterpartType = common.WITHDRAWAL_ORDER_TYPE, common.DEPOSIT_ORDER_TYPE
+	}
+	tolerance, _ := decimal.NewFromString(RECONCILIATION_TOLERANCE)
+	remaining := difference.Abs()
+
+	type candidate struct {
+		missing  MissingTransaction
+		distance decimal.Decimal
+	}
+	var candidates []candidate
+	for _, tx := range report.transactions {
+		if tx.IsDeleted() || tx.GetType() != counterpartType || tx.GetTransferId() != "" ||
+			tx.GetCurrencyPair().Base != currency || accountOf(tx, currency) == account {
+			continue
+		}
+		if !service.IsTransferCategory(tx.GetCategory(), counterpartType) {
+			continue
+		}
+		// A withdrawal arrives as a deposit net of its fee.
+		quantity, _ := decimal.NewFromString(tx.GetQuantity())
+		if counterpartType == common.WITHDRAWAL_ORDER_TYPE && tx.GetFeeCurrency() == currency {
+			fee, _ := decimal.NewFromString(tx.GetFee())
+			quantity = quantity.Sub(fee)
+		}
+		if quantity.GreaterThan(remaining.Add(tolerance)) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			missing: MissingTransaction{
+				Type:          missingType,
+				Quantity:      quantity.String(),
+				Reason:        fmt.Sprintf("Unmatched %s of %s %s on %s", counterpartType, quantity, currency, tx.GetNetwork()),
+				TransactionId: tx.GetId(),
+				Network:       tx.GetNetwork(),
+				Date:          tx.GetDate()},
+			distance: remaining.Sub(quantity).Abs()})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance.LessThan(candidates[j].distance)
+	})
+	var missing []MissingTransaction
+	for _, c := range candidates {
+		missing = append(missing, c.missing)
+	}
+	if len(missing) == 0 {
+		missing = append(missing, MissingTransaction{
+			Type:     missingType,
+			Quantity: remaining.String(),
+			Reason: fmt.Sprintf("No recorded transaction accounts for the %s %s difference; a %s, trade or fee may be missing",
+				remaining, currency, missingType)})
+	}
+	return missing
+}
+
+func (reconciliation *Reconciliation) sort() {
+	for _, balances := range [][]ReconciledBalance{reconciliation.Balances, reconciliation.Unverified} {
+		sort.SliceStable(balances, func(i, j int) bool {
+			if balances[i].Account == balances[j].Account {
+				return balances[i].Currency < balances[j].Currency
+			}
+			return balances[i].Account < balances[j].Account
+		})
+	}
+}
+
+func walletAccount(currency string) string {
+	return fmt.Sprintf("%s wallet", currency)
+}
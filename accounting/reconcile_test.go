@@ -0,0 +1,189 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockExchange struct {
+	name  string
+	coins []common.Coin
+	common.Exchange
+}
+
+func (mock *MockExchange) GetName() string {
+	return mock.name
+}
+
+func (mock *MockExchange) GetBalances() ([]common.Coin, decimal.Decimal) {
+	return mock.coins, decimal.Zero
+}
+
+type MockWallet struct {
+	wallet common.UserCryptoWallet
+	common.Wallet
+}
+
+func (mock *MockWallet) GetWallet() (common.UserCryptoWallet, error) {
+	return mock.wallet, nil
+}
+
+func TestReconciliationReport_Run(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "5",
+			Date:         time.Date(2018, 01, 03, 0, 0, 0, 0, time.UTC),
+			Network:      "Bittrex",
+			CurrencyPair: &common.CurrencyPair{Base: "LTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Quantity:     "10"},
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2018, 01, 02, 0, 0, 0, 0, time.UTC),
+			Network:      "etherscan",
+			CurrencyPair: &common.CurrencyPair{Base: "ETH", Quote: "ETH", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Quantity:     "5"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2018, 01, 02, 0, 0, 0, 0, time.UTC),
+			Network:      "gdax",
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_WITHDRAWAL,
+			Quantity:     "1",
+			Fee:          "0.001",
+			FeeCurrency:  "BTC"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+			Network:      "gdax",
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "2",
+			Total:        "20000",
+			Fee:          "20",
+			FeeCurrency:  "USD"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 12, 01, 0, 0, 0, 0, time.UTC),
+			Network:      "gdax",
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "1",
+			Total:        "10000",
+			Deleted:      true}}
+
+	exchanges := []common.Exchange{
+		&MockExchange{
+			name: "GDAX",
+			coins: []common.Coin{
+				&dto.CoinDTO{Currency: "BTC", Balance: decimal.NewFromFloat(1)},
+				&dto.CoinDTO{Currency: "USD", Balance: decimal.NewFromFloat(500)}}},
+		&MockExchange{
+			name: "Binance",
+			coins: []common.Coin{
+				&dto.CoinDTO{Currency: "BTC", Balance: decimal.NewFromFloat(0.999)}}}}
+	wallets := []common.Wallet{
+		&MockWallet{wallet: &dto.UserCryptoWalletDTO{Currency: "ETH", Balance: decimal.NewFromFloat(5)}}}
+
+	reconciliation := NewReconciliationReport(ctx, transactions).Run(exchanges, wallets)
+	assert.Equal(t, 1, reconciliation.Discrepancies)
+	assert.Equal(t, 3, len(reconciliation.Balances))
+
+	binance := reconciliation.Balances[0]
+	assert.Equal(t, "Binance", binance.Account)
+	assert.Equal(t, "BTC", binance.Currency)
+	assert.Equal(t, "0", binance.Expected)
+	assert.Equal(t, "0.999", binance.Actual)
+	assert.Equal(t, "0.999", binance.Difference)
+	assert.Equal(t, false, binance.Reconciled)
+	assert.Equal(t, 1, len(binance.MissingTransactions))
+	assert.Equal(t, common.DEPOSIT_ORDER_TYPE, binance.MissingTransactions[0].Type)
+	assert.Equal(t, "0.999", binance.MissingTransactions[0].Quantity)
+	assert.Equal(t, "3", binance.MissingTransactions[0].TransactionId)
+
+	assert.Equal(t, ReconciledBalance{
+		Account:    "ETH wallet",
+		Currency:   "ETH",
+		Expected:   "5",
+		Actual:     "5",
+		Difference: "0",
+		Reconciled: true}, reconciliation.Balances[1])
+
+	assert.Equal(t, "GDAX", reconciliation.Balances[2].Account)
+	assert.Equal(t, "1", reconciliation.Balances[2].Expected)
+	assert.Equal(t, true, reconciliation.Balances[2].Reconciled)
+
+	assert.Equal(t, []ReconciledBalance{
+		ReconciledBalance{
+			Account:  "LTC wallet",
+			Currency: "LTC",
+			Expected: "10",
+			Reason:   "No exchange or wallet reported this balance"}}, reconciliation.Unverified)
+}
+
+func TestReconciliationReport_NoBalancesReported(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+			Network:      "Binance",
+			CurrencyPair: &common.CurrencyPair{Base: "BNB", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Quantity:     "10"}}
+	exchanges := []common.Exchange{&MockExchange{name: "Binance"}}
+
+	reconciliation := NewReconciliationReport(ctx, transactions).Run(exchanges, nil)
+	assert.Equal(t, 0, reconciliation.Discrepancies)
+	assert.Equal(t, 0, len(reconciliation.Balances))
+	assert.Equal(t, []ReconciledBalance{
+		ReconciledBalance{
+			Account:  "Binance",
+			Currency: "BNB",
+			Expected: "10",
+			Reason:   "Binance did not report any balances"}}, reconciliation.Unverified)
+}
+
+func TestReconciliationReport_NoCandidates(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+			Network:      "Binance",
+			CurrencyPair: &common.CurrencyPair{Base: "BNB", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "10",
+			Total:        "0.01"}}
+	exchanges := []common.Exchange{
+		&MockExchange{
+			name: "Binance",
+			coins: []common.Coin{
+				&dto.CoinDTO{Currency: "BNB", Balance: decimal.NewFromFloat(9.5)}}}}
+
+	reconciliation := NewReconciliationReport(ctx, transactions).Run(exchanges, nil)
+	assert.Equal(t, 2, reconciliation.Discrepancies)
+	assert.Equal(t, "BNB", reconciliation.Balances[0].Currency)
+	assert.Equal(t, "-0.5", reconciliation.Balances[0].Difference)
+	assert.Equal(t, []MissingTransaction{
+		MissingTransaction{
+			Type:     common.WITHDRAWAL_ORDER_TYPE,
+			Quantity: "0.5",
+			Reason:   "No recorded transaction accounts for the 0.5 BNB difference; a withdrawal, trade or fee may be missing"}},
+		reconciliation.Balances[0].MissingTransactions)
+	assert.Equal(t, "BTC", reconciliation.Balances[1].Currency)
+	assert.Equal(t, "-0.01", reconciliation.Balances[1].Expected)
+	assert.Equal(t, "0.01", reconciliation.Balances[1].Difference)
+}
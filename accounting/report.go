@@ -0,0 +1,658 @@
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+	"github.com/shopspring/decimal"
+)
+
+type Report struct {
+	ctx              common.Context
+	selector         LotSelector
+	fiatPriceService common.FiatPriceService
+	openingLots      map[string][]Coinlot
+	openingDate      time.Time
+	openLots         map[string][]Coinlot
+	disposals        *DisposalSchedule
+	dispositions     map[string][]Form8949LineItem
+	forkEvents       []common.ForkEvent
+	Transactions     []common.Transaction
+	Deposits         []common.Transaction
+	Withdrawals      []common.Transaction
+	Trades           []common.Transaction
+	Income           []common.Transaction
+	Spends           []common.Transaction
+}
+
+func NewReport(ctx common.Context, transactions []common.Transaction, method string) (*Report, error) {
+	selector, err := NewLotSelector(method)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		ctx:          ctx,
+		selector:     selector,
+		Transactions: transactions}, nil
+}
+
+func NewFifoReport(ctx common.Context, transactions []common.Transaction) *Report {
+	return &Report{
+		ctx:          ctx,
+		selector:     &FifoLotSelector{},
+		Transactions: transactions}
+}
+
+// NewSpecificIdentificationReport honors the user's lot selections and uses
+// the fallback method for sales that have not been assigned lots.
+func NewSpecificIdentificationReport(ctx common.Context, transactions []common.Transaction,
+	selections []common.LotSelection, fallback string) (*Report, error) {
+	selector, err := NewSpecificIdLotSelector(selections, fallback)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		ctx:          ctx,
+		selector:     selector,
+		Transactions: transactions}, nil
+}
+
+func (report *Report) GetMethod() string {
+	return report.selector.GetMethod()
+}
+
+// GetOpenLots returns the lots still held for the currency at the end of
+// the most recent call to Run.
+func (report *Report) GetOpenLots(currency string) []Coinlot {
+	return report.openLots[currency]
+}
+
+func (report *Report) GetOpenLotsByCurrency() map[string][]Coinlot {
+	return report.openLots
+}
+
+// GetDisposalSchedule returns the donations and casualty losses reported by
+// the most recent call to Run.
+func (report *Report) GetDisposalSchedule() *DisposalSchedule {
+	return report.disposals
+}
+
+// GetDispositions returns the lots consumed by a transaction during the most
+// recent call to Run, one line item per currency disposed of, including the
+// donations, gifts and network fees not reported on Form 8949.
+func (report *Report) GetDispositions(transactionId string) []Form8949LineItem {
+	return report.dispositions[transactionId]
+}
+
+// SetOpeningLots seeds the report with lots carried forward from a closed tax
+// year. Run then skips transactions dated before openingDate rather than
+// rebuilding the lots from the full history.
+func (report *Report) SetOpeningLots(lots map[string][]Coinlot, openingDate time.Time) {
+	report.openingLots = lots
+	report.openingDate = openingDate
+}
+
+// SetFiatPriceService enables valuing income receipts at their fair market
+// value on the day received. Without it the transaction's own fiat values are
+// used for the cost basis of income lots.
+func (report *Report) SetFiatPriceService(fiatPriceService common.FiatPriceService) {
+	report.fiatPriceService = fiatPriceService
+}
+
+func (report *Report) Run(start, end time.Time) *Form8949 {
+
+	buyLots, saleLots := report.collectLots(start, end)
+	var shorts, longs []Form8949LineItem
+
+	/*
+		fmt.Println("buys")
+		for _, buy := range buyLots["BTC"] {
+			fmt.Printf("%+v\n", buy)
+		}
+		fmt.Println("sales")
+		for _, sale := range saleLots["BTC"] {
+			fmt.Printf("%+v\n", sale)
+		}
+		_shorts, _longs := report.process(buyLots["BTC"], saleLots["BTC"])
+		shorts = append(shorts, _shorts...)
+		longs = append(longs, _longs...)
+	*/
+
+	report.openLots = make(map[string][]Coinlot)
+	report.disposals = &DisposalSchedule{Location: start.Location()}
+	report.dispositions = make(map[string][]Form8949LineItem)
+	// Sales of a currency that was never acquired are reported without a
+	// cost basis rather than left off the form.
+	for _, currency := range lotCurrencies(buyLots, saleLots) {
+		if _, ok := buyLots[currency]; !ok {
+			buyLots[currency] = nil
+		}
+	}
+	for currency, _ := range buyLots {
+		_shorts, _longs, _openLots := report.process(buyLots[currency], saleLots[currency])
+		shorts = append(shorts, _shorts...)
+		longs = append(longs, _longs...)
+		report.openLots[currency] = _openLots
+	}
+	report.disposals.filter(start)
+
+	form := &Form8949{
+		ShortHolds: report.filterLineItems(shorts, start),
+		LongHolds:  report.filterLineItems(longs, start),
+		Location:   start.Location()}
+	form.sort()
+	return form
+}
+
+// collectLots builds the acquisitions and disposals of each currency from the
+// transactions dated up to end. Income and spends received on or after start
+// are recorded on the report as a side effect.
+func (report *Report) collectLots(start, end time.Time) (map[string][]Coinlot, map[string][]Coinlot) {
+
+	buyLots := make(map[string][]Coinlot)
+	saleLots := make(map[string][]Coinlot)
+	report.Income = nil
+	report.Spends = nil
+
+	for currency, lots := range report.openingLots {
+		buyLots[currency] = append(buyLots[currency], lots...)
+	}
+
+	transfers := make(map[string]common.Transaction)
+	for _, tx := range report.Transactions {
+		if tx.GetTransferId() != "" {
+			transfers[tx.GetId()] = tx
+		}
+	}
+
+	for i := len(report.Transactions) - 1; i >= 0; i-- {
+		trade := report.Transactions[i]
+
+		//if trade.GetDate().After(end) || !report.isTaxable(trade) || !strings.Contains(trade.GetCurrencyPair().String(), "BTC") {
+		if trade.GetDate().After(end) {
+			continue
+		}
+
+		if report.openingLots != nil && trade.GetDate().Before(report.openingDate) {
+			continue
+		}
+
+		// Transfers move lots between the user's own accounts; only the
+		// network fee lost along the way leaves the user's holdings. Forks
+		// and airdrops add lots without a purchase or income.
+		if !report.isTaxable(trade) {
+			if IsFork(trade) {
+				buyLots[trade.GetCurrencyPair().Base] = append(buyLots[trade.GetCurrencyPair().Base], report.forkLot(trade))
+				continue
+			}
+			report.disposeNetworkFee(saleLots, trade, transfers[trade.GetTransferId()])
+			continue
+		}
+
+		report.ctx.GetLogger().Debugf("[Report.Run] (%s) %s", report.selector.GetMethod(), trade)
+
+		txType := trade.GetType()
+		quantity, _ := decimal.NewFromString(trade.GetQuantity())
+		total, _ := decimal.NewFromString(trade.GetTotal())
+		fiatQuantity, _ := decimal.NewFromString(trade.GetFiatQuantity())
+		fiatPrice, _ := decimal.NewFromString(trade.GetFiatPrice())
+		quoteFiatPrice, _ := decimal.NewFromString(trade.GetQuoteFiatPrice())
+		fiatTotal, _ := decimal.NewFromString(trade.GetFiatTotal())
+		fee, _ := decimal.NewFromString(trade.GetFee())
+		fiatFee, _ := decimal.NewFromString(trade.GetFiatFee())
+
+		baseCurrency := trade.GetCurrencyPair().Base
+		quoteCurrency := trade.GetCurrencyPair().Quote
+		feeCurrency := trade.GetFeeCurrency()
+
+		if fiatTotal.IsZero() {
+			fiatTotal = fiatQuantity
+		}
+
+		if IsIncome(trade) {
+			fiatPrice, fiatTotal = fairMarketValue(report.ctx, report.fiatPriceService, trade)
+			if !trade.GetDate().Before(start) {
+				report.Income = append(report.Income, trade)
+			}
+		}
+
+		if disposal, ok := GetDisposalType(trade); ok {
+			// The value given up is the proceeds of a spend and the
+			// contribution amount of a donation.
+			_, fairMarketTotal := fairMarketValue(report.ctx, report.fiatPriceService, trade)
+			if disposal == DISPOSAL_CASUALTY || disposal == DISPOSAL_GIFT {
+				fairMarketTotal = decimal.Zero
+			}
+			if disposal == DISPOSAL_SPEND && !trade.GetDate().Before(start) {
+				report.Spends = append(report.Spends, trade)
+			}
+			saleLots[baseCurrency] = append(saleLots[baseCurrency], Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      baseCurrency,
+				Quantity:      quantity,
+				UnitPrice:     fiatPrice,
+				SalePrice:     fairMarketTotal,
+				CostBasis:     fiatTotal,
+				Disposal:      disposal})
+			continue
+		}
+
+		if txType == common.DEPOSIT_ORDER_TYPE {
+			coinlot := Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      baseCurrency,
+				Quantity:      quantity,
+				UnitPrice:     fiatPrice,
+				CostBasis:     fiatTotal}
+			buyLots[baseCurrency] = append(buyLots[baseCurrency], coinlot)
+			continue
+		}
+
+		if txType == common.BUY_ORDER_TYPE {
+
+			// Fees are added to the cost basis. A fee taken from the coins
+			// received is already part of the price paid for fewer coins.
+			buyCoinlot := Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      baseCurrency,
+				Quantity:      quantity,
+				UnitPrice:     quoteFiatPrice,
+				CostBasis:     fiatTotal.Add(fiatFee),
+				Fee:           fiatFee}
+			if feeCurrency == baseCurrency {
+				buyCoinlot.Quantity = quantity.Sub(fee)
+				buyCoinlot.CostBasis = fiatTotal
+			}
+			buyLots[baseCurrency] = append(buyLots[baseCurrency], buyCoinlot)
+
+			report.disposeFee(saleLots, trade, baseCurrency, quoteCurrency)
+
+			if _, ok := common.FiatCurrencies[quoteCurrency]; ok {
+				continue
+			}
+			saleCoinlot := Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      quoteCurrency,
+				Quantity:      total,
+				UnitPrice:     quoteFiatPrice,
+				SalePrice:     fiatTotal,
+				CostBasis:     fiatTotal}
+			if feeCurrency == quoteCurrency {
+				saleCoinlot.Quantity = total.Add(fee)
+				saleCoinlot.SalePrice = fiatTotal.Add(fiatFee)
+			}
+			saleLots[quoteCurrency] = append(saleLots[quoteCurrency], saleCoinlot)
+		}
+
+		if txType == common.SELL_ORDER_TYPE {
+
+			// Fees are subtracted from the proceeds. A fee taken from the
+			// coins received reduces the quantity received as well.
+			saleCoinlot := Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      baseCurrency,
+				Quantity:      quantity,
+				UnitPrice:     quoteFiatPrice,
+				SalePrice:     fiatTotal.Sub(fiatFee),
+				CostBasis:     fiatTotal,
+				Fee:           fiatFee}
+			saleLots[baseCurrency] = append(saleLots[baseCurrency], saleCoinlot)
+
+			report.disposeFee(saleLots, trade, baseCurrency, quoteCurrency)
+
+			buyCoinlot := Coinlot{
+				TransactionId: trade.GetId(),
+				Date:          trade.GetDate(),
+				Currency:      quoteCurrency,
+				Quantity:      total,
+				UnitPrice:     quoteFiatPrice.Mul(total),
+				CostBasis:     fiatTotal}
+			if feeCurrency == quoteCurrency {
+				buyCoinlot.Quantity = total.Sub(fee)
+				buyCoinlot.CostBasis = fiatTotal.Sub(fiatFee)
+			}
+			buyLots[quoteCurrency] = append(buyLots[quoteCurrency], buyCoinlot)
+		}
+
+	}
+
+	return buyLots, saleLots
+}
+
+func (report *Report) process(buyLots, saleLots []Coinlot) ([]Form8949LineItem, []Form8949LineItem, []Coinlot) {
+
+	var shorts, longs []Form8949LineItem
+
+	openLots := copyLots(buyLots)
+	report.sortLots(openLots)
+	saleLots = copyLots(saleLots)
+	report.sortLots(saleLots)
+
+	for _, saleLot := range saleLots {
+
+		var available, pending []Coinlot
+		for _, lot := range openLots {
+			if lot.Date.After(saleLot.Date) {
+				pending = append(pending, lot)
+				continue
+			}
+			available = append(available, lot)
+		}
+
+		report.ctx.GetLogger().Debugf("[Report.process] -- New Sale Lot (%s) --", report.selector.GetMethod())
+		report.ctx.GetLogger().Debugf("[Report.process] saleLot=%+v\n", saleLot)
+
+		var lots []Coinlot
+		var sum decimal.Decimal
+		if len(available) > 0 {
+			available = report.selector.Select(&saleLot, available)
+		}
+		for len(available) > 0 && sum.LessThan(saleLot.Quantity) {
+			sum = sum.Add(available[0].Quantity)
+			lots = append(lots, available[0])
+			report.ctx.GetLogger().Debugf("[Report.process] sale quantity=%s, buy quantity=%s, sum=%s, costBasis:%s, lot size: %d",
+				saleLot.Quantity, available[0].Quantity, sum, available[0].CostBasis, len(lots))
+			available = available[1:]
+		}
+
+		var sublot *Coinlot
+		var lineItem Form8949LineItem
+		switch len(lots) {
+		case 0:
+			lineItem = report.calculateMissingBasis(&saleLot)
+		case 1:
+			sublot, lineItem = report.calculate(&lots[0], &saleLot)
+		default:
+			sublot, lineItem = report.calculateLots(&lots, &saleLot)
+		}
+
+		// The quantity sold beyond the lots held has no cost basis. The line
+		// is still reported, flagged so the missing acquisitions can be added.
+		if sum.LessThan(saleLot.Quantity) {
+			report.ctx.GetLogger().Warningf("[Report.process] Insufficient buy lots to cover %s %s sold on %s (%s available)",
+				saleLot.Quantity, saleLot.Currency, saleLot.Date, sum)
+			lineItem.MissingBasis = saleLot.Quantity.Sub(sum).String()
+		}
+		report.ctx.GetLogger().Debugf("[Report.process] lineItem: %+v\n", lineItem)
+		report.ctx.GetLogger().Debugf("[Report.process] sublot: %+v\n", sublot)
+
+		report.dispositions[saleLot.TransactionId] = append(report.dispositions[saleLot.TransactionId], lineItem)
+
+		holding := HOLDING_LONG
+		if report.isShortSale(&lineItem) {
+			holding = HOLDING_SHORT
+		}
+
+		switch saleLot.Disposal {
+		case DISPOSAL_DONATION:
+			report.disposals.CharitableContributions = append(report.disposals.CharitableContributions,
+				NewCharitableContribution(&lineItem, holding))
+		case DISPOSAL_CASUALTY:
+			report.disposals.CasualtyLosses = append(report.disposals.CasualtyLosses, NewCasualtyLoss(&lineItem))
+		case DISPOSAL_GIFT:
+			report.ctx.GetLogger().Debugf("[Report.process] Gift of %s %s carries its basis to the recipient",
+				saleLot.Quantity, saleLot.Currency)
+		case DISPOSAL_NETWORK_FEE:
+			report.ctx.GetLogger().Debugf("[Report.process] Network fee of %s %s consumed by transfer %s",
+				saleLot.Quantity, saleLot.Currency, saleLot.TransactionId)
+		default:
+			if holding == HOLDING_SHORT {
+				shorts = append(shorts, lineItem)
+			} else {
+				longs = append(longs, lineItem)
+			}
+		}
+
+		if sublot != nil {
+			available = append(available, *sublot)
+		}
+		openLots = append(available, pending...)
+		report.sortLots(openLots)
+	}
+
+	return shorts, longs, openLots
+}
+
+func (report *Report) calculate(buyLot *Coinlot, saleLot *Coinlot) (*Coinlot, Form8949LineItem) {
+	report.ctx.GetLogger().Debugf("[Report.calculate] Calculating single lot (%s) against sale: %s\n", buyLot.Quantity, saleLot.Quantity)
+	report.ctx.GetLogger().Debugf("[Report.calculate] buyLot: %s\n", buyLot)
+	report.ctx.GetLogger().Debugf("[Report.calculate] saleLot: %s\n", saleLot)
+
+	var sublot *Coinlot
+	quantity := buyLot.Quantity
+	costBasis := buyLot.CostBasis
+	fee := buyLot.Fee
+
+	if buyLot.Quantity.GreaterThan(saleLot.Quantity) {
+		subqty := buyLot.Quantity.Sub(saleLot.Quantity)
+		quantity = saleLot.Quantity
+		costBasis = buyLot.UnitCost().Mul(saleLot.Quantity)
+		fee = buyLot.UnitFee().Mul(saleLot.Quantity)
+		sublot = &Coinlot{
+			TransactionId: buyLot.TransactionId,
+			Date:          buyLot.Date,
+			Currency:      saleLot.Currency,
+			Quantity:      subqty,
+			UnitPrice:     buyLot.UnitPrice,
+			CostBasis:     buyLot.CostBasis.Sub(costBasis),
+			Fee:           buyLot.Fee.Sub(fee)}
+	}
+	lots := []LotReference{
+		LotReference{
+			TransactionId: buyLot.TransactionId,
+			Date:          buyLot.Date,
+			Quantity:      quantity.String(),
+			CostBasis:     costBasis.StringFixed(2),
+			Fee:           fee.StringFixed(2)}}
+	fee = fee.Add(saleLot.Fee)
+
+	return sublot, Form8949LineItem{
+		TransactionId:    saleLot.TransactionId,
+		Currency:         saleLot.Currency,
+		Description:      fmt.Sprintf("%s %s", saleLot.Quantity, saleLot.Currency),
+		DateAcquired:     buyLot.Date,
+		DateSold:         saleLot.Date,
+		Proceeds:         saleLot.SalePrice.StringFixed(2),
+		CostBasis:        costBasis.StringFixed(2),
+		AdjustmentCode:   "",
+		AdjustmentAmount: "",
+		Fees:             fee.StringFixed(2),
+		GainOrLoss:       saleLot.SalePrice.Sub(costBasis).StringFixed(2),
+		Lots:             lots}
+}
+
+func (report *Report) calculateLots(buyLots *[]Coinlot, saleLot *Coinlot) (*Coinlot, Form8949LineItem) {
+	report.ctx.GetLogger().Debugf("[Report.calculateLots] Calculating %d lots\n", len(*buyLots))
+
+	for _, l := range *buyLots {
+		report.ctx.GetLogger().Debugf("[Report.calculateLots] buyLot: %s\n", l)
+	}
+	report.ctx.GetLogger().Debugf("[Report.calculateLots] saleLot: %s\n", saleLot)
+
+	var sublot *Coinlot
+	var _lots = *buyLots
+	var deductedQty, costBasis decimal.Decimal
+	var lots []LotReference
+	fee := saleLot.Fee
+	dateAcquired := _lots[0].Date
+
+	for _, lot := range *buyLots {
+
+		deductedQty = deductedQty.Add(lot.Quantity)
+		quantity := lot.Quantity
+		basis := lot.CostBasis
+		lotFee := lot.Fee
+
+		if deductedQty.GreaterThan(saleLot.Quantity) {
+			subqty := deductedQty.Sub(saleLot.Quantity)
+			quantity = lot.Quantity.Sub(subqty)
+			basis = lot.UnitCost().Mul(quantity)
+			lotFee = lot.UnitFee().Mul(quantity)
+			sublot = &Coinlot{
+				TransactionId: lot.TransactionId,
+				Date:          lot.Date,
+				Currency:      saleLot.Currency,
+				Quantity:      subqty,
+				UnitPrice:     lot.UnitPrice,
+				CostBasis:     lot.CostBasis.Sub(basis),
+				Fee:           lot.Fee.Sub(lotFee)}
+		}
+
+		costBasis = costBasis.Add(basis)
+		fee = fee.Add(lotFee)
+		lots = append(lots, LotReference{
+			TransactionId: lot.TransactionId,
+			Date:          lot.Date,
+			Quantity:      quantity.String(),
+			CostBasis:     basis.StringFixed(2),
+			Fee:           lotFee.StringFixed(2)})
+
+		if sublot != nil {
+			break
+		}
+	}
+	return sublot, Form8949LineItem{
+		TransactionId:    saleLot.TransactionId,
+		Currency:         saleLot.Currency,
+		Description:      fmt.Sprintf("%s %s", saleLot.Quantity, saleLot.Currency),
+		DateAcquired:     dateAcquired,
+		DateSold:         saleLot.Date,
+		Proceeds:         saleLot.SalePrice.StringFixed(2),
+		CostBasis:        costBasis.StringFixed(2),
+		AdjustmentCode:   "",
+		AdjustmentAmount: "",
+		Fees:             fee.StringFixed(2),
+		GainOrLoss:       saleLot.SalePrice.Sub(costBasis).StringFixed(2),
+		Lots:             lots}
+}
+
+// calculateMissingBasis reports a sale made without any lots to match it
+// against at a cost basis of zero.
+func (report *Report) calculateMissingBasis(saleLot *Coinlot) Form8949LineItem {
+	report.ctx.GetLogger().Debugf("[Report.calculateMissingBasis] saleLot: %s\n", saleLot)
+	return Form8949LineItem{
+		TransactionId:    saleLot.TransactionId,
+		Currency:         saleLot.Currency,
+		Description:      fmt.Sprintf("%s %s", saleLot.Quantity, saleLot.Currency),
+		DateAcquired:     saleLot.Date,
+		DateSold:         saleLot.Date,
+		Proceeds:         saleLot.SalePrice.StringFixed(2),
+		CostBasis:        decimal.Zero.StringFixed(2),
+		AdjustmentCode:   "",
+		AdjustmentAmount: "",
+		Fees:             saleLot.Fee.StringFixed(2),
+		GainOrLoss:       saleLot.SalePrice.StringFixed(2)}
+}
+
+// disposeFee records a fee paid in a currency other than the two traded,
+// such as BNB on Binance, as a sale of the fee currency at its fiat value.
+func (report *Report) disposeFee(saleLots map[string][]Coinlot, trade common.Transaction, baseCurrency, quoteCurrency string) {
+	feeCurrency := trade.GetFeeCurrency()
+	if feeCurrency == "" || feeCurrency == baseCurrency || feeCurrency == quoteCurrency {
+		return
+	}
+	if _, ok := common.FiatCurrencies[feeCurrency]; ok {
+		return
+	}
+	fee, _ := decimal.NewFromString(trade.GetFee())
+	if !fee.IsPositive() {
+		return
+	}
+	fiatFee, _ := decimal.NewFromString(trade.GetFiatFee())
+	report.ctx.GetLogger().Debugf("[Report.disposeFee] %s %s fee paid on %s", fee, feeCurrency, trade.GetId())
+	saleLots[feeCurrency] = append(saleLots[feeCurrency], Coinlot{
+		TransactionId: trade.GetId(),
+		Date:          trade.GetDate(),
+		Currency:      feeCurrency,
+		Quantity:      fee,
+		UnitPrice:     fiatFee.Div(fee),
+		SalePrice:     fiatFee,
+		CostBasis:     fiatFee})
+}
+
+// disposeNetworkFee consumes the difference between the quantity withdrawn and
+// the quantity deposited by a matched transfer. A fee charged on top of the
+// quantity withdrawn is consumed when both sides carry the same quantity.
+func (report *Report) disposeNetworkFee(saleLots map[string][]Coinlot, withdrawal, deposit common.Transaction) {
+	if withdrawal.GetType() != common.WITHDRAWAL_ORDER_TYPE || deposit == nil {
+		return
+	}
+	currency := withdrawal.GetCurrencyPair().Base
+	withdrawn, _ := decimal.NewFromString(withdrawal.GetQuantity())
+	deposited, _ := decimal.NewFromString(deposit.GetQuantity())
+	networkFee := withdrawn.Sub(deposited)
+	if networkFee.IsZero() && withdrawal.GetFeeCurrency() == currency {
+		networkFee, _ = decimal.NewFromString(withdrawal.GetFee())
+	}
+	if !networkFee.IsPositive() {
+		return
+	}
+	report.ctx.GetLogger().Debugf("[Report.disposeNetworkFee] %s %s lost transferring %s to %s",
+		networkFee, currency, withdrawal.GetId(), deposit.GetId())
+	saleLots[currency] = append(saleLots[currency], Coinlot{
+		TransactionId: withdrawal.GetId(),
+		Date:          withdrawal.GetDate(),
+		Currency:      currency,
+		Quantity:      networkFee,
+		Disposal:      DISPOSAL_NETWORK_FEE})
+}
+
+// filterLineItems drops sales from before the start of the reporting period;
+// their lots are consumed but belong to an earlier year's form.
+func (report *Report) filterLineItems(lineItems []Form8949LineItem, start time.Time) []Form8949LineItem {
+	var filtered []Form8949LineItem
+	for _, lineItem := range lineItems {
+		if lineItem.DateSold.Before(start) {
+			continue
+		}
+		filtered = append(filtered, lineItem)
+	}
+	return filtered
+}
+
+func (report *Report) sortLots(lots []Coinlot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].Date.Before(lots[j].Date)
+	})
+}
+
+func (report *Report) isShortSale(lineItem *Form8949LineItem) bool {
+	return getHolding(lineItem.DateAcquired, lineItem.DateSold) == HOLDING_SHORT
+}
+
+// isTaxable returns false for transfers between the user's own accounts and
+// for coins received from forks and airdrops. Gifts, donations and losses
+// still consume lots; see GetDisposalType.
+func (report *Report) isTaxable(tx common.Transaction) bool {
+	return tx.GetCategory() != common.TX_CATEGORY_TRANSFER && !IsFork(tx)
+}
+
+func createTransactionService(ctx common.Context) service.TransactionService {
+	pluginDAO := dao.NewPluginDAO(ctx)
+	userDAO := dao.NewUserDAO(ctx)
+	transactionDAO := dao.NewTransactionDAO(ctx)
+	userMapper := mapper.NewUserMapper()
+	pluginMapper := mapper.NewPluginMapper()
+	transactionMapper := mapper.NewTransactionMapper(ctx)
+	userExchangeMapper := mapper.NewUserExchangeMapper()
+	marketcapService := service.NewMarketCapService(ctx)
+	pluginService := service.CreatePluginService(ctx, "../plugins/", pluginDAO, pluginMapper)
+	exchangeService := service.NewExchangeService(ctx, userDAO, userMapper, userExchangeMapper, pluginService)
+	ethereumService, _ := service.NewEthereumService(ctx, userDAO, userMapper, marketcapService, exchangeService)
+	fiatPriceService, _ := service.NewFiatPriceService(ctx, exchangeService)
+	walletService := service.NewWalletService(ctx, pluginService, fiatPriceService)
+	userService := service.NewUserService(ctx, userDAO, userMapper, userExchangeMapper, marketcapService, ethereumService, exchangeService, walletService)
+	csvTemplateService := service.NewCSVTemplateService(ctx, dao.NewCSVTemplateDAO(ctx), mapper.NewCSVTemplateMapper(ctx))
+	return service.NewTransactionService(ctx, transactionDAO, dao.NewTransactionRevisionDAO(ctx), transactionMapper,
+		exchangeService, userService, ethereumService, fiatPriceService, csvTemplateService)
+}
@@ -0,0 +1,384 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createReportTestTransactions() []common.Transaction {
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	return []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 06, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.SELL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRADE,
+			Quantity:     "1",
+			FiatQuantity: "5000",
+			Total:        "5000",
+			FiatTotal:    "5000"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 05, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRADE,
+			Quantity:     "1",
+			Total:        "2000",
+			FiatTotal:    "2000"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 03, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRADE,
+			Quantity:     "1",
+			Total:        "3000",
+			FiatTotal:    "3000"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: currencyPair,
+			Type:         common.BUY_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRADE,
+			Quantity:     "1",
+			Total:        "1000",
+			FiatTotal:    "1000"}}
+}
+
+func TestReport_CostBasisMethods(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	expected := map[string][]string{
+		COST_BASIS_FIFO:    []string{"1000.00", "4000.00"},
+		COST_BASIS_LIFO:    []string{"2000.00", "3000.00"},
+		COST_BASIS_HIFO:    []string{"3000.00", "2000.00"},
+		COST_BASIS_AVERAGE: []string{"2000.00", "3000.00"}}
+
+	for method, values := range expected {
+		report, err := NewReport(ctx, createReportTestTransactions(), method)
+		assert.Nil(t, err)
+		assert.Equal(t, method, report.GetMethod())
+
+		form8949 := report.Run(start, end)
+		assert.Equal(t, 1, len(form8949.ShortHolds), method)
+		assert.Equal(t, 0, len(form8949.LongHolds), method)
+		assert.Equal(t, "5000.00", form8949.ShortHolds[0].Proceeds, method)
+		assert.Equal(t, values[0], form8949.ShortHolds[0].CostBasis, method)
+		assert.Equal(t, values[1], form8949.ShortHolds[0].GainOrLoss, method)
+	}
+}
+
+func TestReport_PartialLots(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := createReportTestTransactions()
+	transactions[0].(*dto.TransactionDTO).Quantity = "1.5"
+	transactions[0].(*dto.TransactionDTO).FiatQuantity = "7500"
+	transactions[0].(*dto.TransactionDTO).FiatTotal = "7500"
+
+	report, err := NewReport(ctx, transactions, COST_BASIS_FIFO)
+	assert.Nil(t, err)
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+	form8949 := report.Run(start, end)
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "2500.00", form8949.ShortHolds[0].CostBasis)
+	assert.Equal(t, "5000.00", form8949.ShortHolds[0].GainOrLoss)
+}
+
+func TestReport_InvalidMethod(t *testing.T) {
+	report, err := NewReport(test.NewUnitTestContext(), nil, "foo")
+	assert.Nil(t, report)
+	assert.NotNil(t, err)
+}
+
+func TestReport_SpecificIdentification(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	selections := []common.LotSelection{
+		&dto.LotSelectionDTO{
+			SaleTransactionId: "4",
+			BuyTransactionId:  "2",
+			Currency:          "BTC",
+			Quantity:          decimal.NewFromFloat(0.5)},
+		&dto.LotSelectionDTO{
+			SaleTransactionId: "4",
+			BuyTransactionId:  "3",
+			Currency:          "BTC",
+			Quantity:          decimal.NewFromFloat(0.5)}}
+
+	report, err := NewSpecificIdentificationReport(ctx, createReportTestTransactions(), selections, COST_BASIS_FIFO)
+	assert.Nil(t, err)
+	assert.Equal(t, COST_BASIS_SPECIFIC, report.GetMethod())
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+	form8949 := report.Run(start, end)
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "2500.00", form8949.ShortHolds[0].CostBasis)
+	assert.Equal(t, "2500.00", form8949.ShortHolds[0].GainOrLoss)
+
+	openLots := report.GetOpenLots("BTC")
+	assert.Equal(t, 3, len(openLots))
+	assert.Equal(t, "1", openLots[0].TransactionId)
+	assert.Equal(t, "1", openLots[0].Quantity.String())
+	assert.Equal(t, "2", openLots[1].TransactionId)
+	assert.Equal(t, "0.5", openLots[1].Quantity.String())
+	assert.Equal(t, "3", openLots[2].TransactionId)
+	assert.Equal(t, "0.5", openLots[2].Quantity.String())
+}
+
+func TestReport_SpecificIdentificationFallback(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	report, err := NewSpecificIdentificationReport(ctx, createReportTestTransactions(), nil, COST_BASIS_HIFO)
+	assert.Nil(t, err)
+
+	start := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)
+	form8949 := report.Run(start, end)
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "3000.00", form8949.ShortHolds[0].CostBasis)
+}
+
+func TestReport_Fees(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	btc := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 03, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "0.5",
+			FiatTotal:    "1000",
+			Fee:          "20",
+			FeeCurrency:  "USD",
+			FiatFee:      "20"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "0.5",
+			FiatTotal:    "1000",
+			Fee:          "0.5",
+			FeeCurrency:  "BNB",
+			FiatFee:      "5"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 01, 02, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BNB", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "10",
+			FiatTotal:    "10"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "1",
+			FiatTotal:    "1000",
+			Fee:          "10",
+			FeeCurrency:  "USD",
+			FiatFee:      "10"}}
+
+	report := NewFifoReport(ctx, transactions)
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 3, len(form8949.ShortHolds))
+
+	lineItems := make(map[string]Form8949LineItem)
+	for _, lineItem := range form8949.ShortHolds {
+		lineItems[lineItem.Currency+lineItem.DateSold.Format("01")] = lineItem
+	}
+	assert.Equal(t, Form8949LineItem{
+		TransactionId: "3",
+		Currency:      "BTC",
+		Description:   "0.5 BTC",
+		DateAcquired:  time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+		DateSold:      time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+		Proceeds:      "995.00",
+		CostBasis:     "505.00",
+		Fees:          "10.00",
+		GainOrLoss:    "490.00",
+		Lots: []LotReference{
+			LotReference{
+				TransactionId: "1",
+				Date:          time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+				Quantity:      "0.5",
+				CostBasis:     "505.00",
+				Fee:           "5.00"}}}, lineItems["BTC02"])
+	assert.Equal(t, "980.00", lineItems["BTC03"].Proceeds)
+	assert.Equal(t, "505.00", lineItems["BTC03"].CostBasis)
+	assert.Equal(t, "25.00", lineItems["BTC03"].Fees)
+	assert.Equal(t, "475.00", lineItems["BTC03"].GainOrLoss)
+	assert.Equal(t, "5.00", lineItems["BNB02"].Proceeds)
+	assert.Equal(t, "0.50", lineItems["BNB02"].CostBasis)
+	assert.Equal(t, "4.50", lineItems["BNB02"].GainOrLoss)
+	assert.Equal(t, "9.5", report.GetOpenLots("BNB")[0].Quantity.String())
+}
+
+func TestReport_FeesWithoutFiatTotal(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	btc := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "1",
+			FiatQuantity: "2000",
+			Fee:          "20",
+			FeeCurrency:  "USD",
+			FiatFee:      "20"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "1",
+			FiatQuantity: "1000",
+			Fee:          "10",
+			FeeCurrency:  "USD",
+			FiatFee:      "10"}}
+
+	report := NewFifoReport(ctx, transactions)
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1, len(form8949.ShortHolds))
+	assert.Equal(t, "1980.00", form8949.ShortHolds[0].Proceeds)
+	assert.Equal(t, "1010.00", form8949.ShortHolds[0].CostBasis)
+	assert.Equal(t, "30.00", form8949.ShortHolds[0].Fees)
+	assert.Equal(t, "970.00", form8949.ShortHolds[0].GainOrLoss)
+}
+
+func TestReport_MissingBasis(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 03, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "ETH", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "2",
+			FiatTotal:    "600"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "1",
+			FiatTotal:    "2000"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "0.5",
+			FiatTotal:    "500"}}
+
+	report := NewFifoReport(ctx, transactions)
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 2, len(form8949.ShortHolds))
+
+	lineItems := make(map[string]Form8949LineItem)
+	for _, lineItem := range form8949.ShortHolds {
+		lineItems[lineItem.Currency] = lineItem
+	}
+	assert.Equal(t, "2000.00", lineItems["BTC"].Proceeds)
+	assert.Equal(t, "500.00", lineItems["BTC"].CostBasis)
+	assert.Equal(t, "0.5", lineItems["BTC"].MissingBasis)
+	assert.Equal(t, "600.00", lineItems["ETH"].Proceeds)
+	assert.Equal(t, "0.00", lineItems["ETH"].CostBasis)
+	assert.Equal(t, "600.00", lineItems["ETH"].GainOrLoss)
+	assert.Equal(t, "2", lineItems["ETH"].MissingBasis)
+	assert.Equal(t, 0, len(lineItems["ETH"].Lots))
+}
+
+func TestReport_FeeInReceivedCurrency(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:             "1",
+			Date:           time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair:   &common.CurrencyPair{Base: "ETH", Quote: "BTC", LocalCurrency: "USD"},
+			Type:           common.BUY_ORDER_TYPE,
+			Quantity:       "10",
+			Total:          "0.1",
+			QuoteFiatPrice: "1000",
+			FiatTotal:      "100",
+			Fee:            "0.01",
+			FeeCurrency:    "ETH",
+			FiatFee:        "0.1"}}
+
+	report := NewFifoReport(ctx, transactions)
+	report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	openLots := report.GetOpenLots("ETH")
+	assert.Equal(t, 1, len(openLots))
+	assert.Equal(t, "9.99", openLots[0].Quantity.String())
+	assert.Equal(t, "100", openLots[0].CostBasis.String())
+	assert.Equal(t, "0.1", openLots[0].Fee.String())
+}
+
+func TestReport_Transfer(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	btc := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "4",
+			Date:         time.Date(2017, 06, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.SELL_ORDER_TYPE,
+			Quantity:     "0.99",
+			FiatTotal:    "4950"},
+		&dto.TransactionDTO{
+			Id:           "3",
+			Date:         time.Date(2017, 02, 01, 1, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Quantity:     "0.99",
+			FiatTotal:    "990",
+			TransferId:   "2"},
+		&dto.TransactionDTO{
+			Id:           "2",
+			Date:         time.Date(2017, 02, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Quantity:     "1",
+			TransferId:   "3"},
+		&dto.TransactionDTO{
+			Id:           "1",
+			Date:         time.Date(2016, 01, 01, 0, 0, 0, 0, time.UTC),
+			CurrencyPair: btc,
+			Type:         common.BUY_ORDER_TYPE,
+			Quantity:     "1",
+			FiatTotal:    "1000"}}
+
+	report := NewFifoReport(ctx, transactions)
+	form8949 := report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0, len(form8949.ShortHolds))
+	assert.Equal(t, 1, len(form8949.LongHolds))
+	assert.Equal(t, time.Date(2016, 01, 01, 0, 0, 0, 0, time.UTC), form8949.LongHolds[0].DateAcquired)
+	assert.Equal(t, "990.00", form8949.LongHolds[0].CostBasis)
+	assert.Equal(t, "3960.00", form8949.LongHolds[0].GainOrLoss)
+	assert.Equal(t, 0, len(report.GetOpenLots("BTC")))
+}
@@ -0,0 +1,115 @@
+package accounting
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Form 8949 check boxes. Short-term sales are reported in A through C and
+// long-term sales in D through F, depending on whether they appeared on a
+// Form 1099-B and whether the broker reported their basis to the IRS.
+const (
+	FORM_8949_BOX_A = "A"
+	FORM_8949_BOX_B = "B"
+	FORM_8949_BOX_C = "C"
+	FORM_8949_BOX_D = "D"
+	FORM_8949_BOX_E = "E"
+	FORM_8949_BOX_F = "F"
+)
+
+// ScheduleD summarizes the Form 8949 totals of each box on the Schedule D
+// lines they carry to.
+type ScheduleD struct {
+	ShortTerm    []ScheduleDLine `json:"short_term"`
+	LongTerm     []ScheduleDLine `json:"long_term"`
+	NetShortTerm string          `json:"net_short_term"`
+	NetLongTerm  string          `json:"net_long_term"`
+	Total        string          `json:"total"`
+}
+
+type ScheduleDLine struct {
+	Line        string `json:"line"`
+	Box         string `json:"box"`
+	Description string `json:"description"`
+	Proceeds    string `json:"proceeds"`
+	CostBasis   string `json:"cost_basis"`
+	Adjustments string `json:"adjustments"`
+	GainOrLoss  string `json:"gain_or_loss"`
+}
+
+type form8949Totals struct {
+	proceeds    decimal.Decimal
+	costBasis   decimal.Decimal
+	adjustments decimal.Decimal
+	gainOrLoss  decimal.Decimal
+}
+
+// Form8949Box returns the check box a sale is reported under.
+func Form8949Box(holding string, reportedOn1099B, basisReported bool) string {
+	shortBox, longBox := FORM_8949_BOX_C, FORM_8949_BOX_F
+	if reportedOn1099B && basisReported {
+		shortBox, longBox = FORM_8949_BOX_A, FORM_8949_BOX_D
+	} else if reportedOn1099B {
+		shortBox, longBox = FORM_8949_BOX_B, FORM_8949_BOX_E
+	}
+	if holding == HOLDING_LONG {
+		return longBox
+	}
+	return shortBox
+}
+
+// NewScheduleD carries the totals of the form to Schedule D lines 1b through
+// 3 (short-term) and 8b through 10 (long-term). Carryovers and gains from
+// other forms are not included.
+func NewScheduleD(form *Form8949) *ScheduleD {
+	shortBox := form.GetBox(HOLDING_SHORT)
+	longBox := form.GetBox(HOLDING_LONG)
+	shortTotals := sumLineItems(form.ShortHolds)
+	longTotals := sumLineItems(form.LongHolds)
+	schedule := &ScheduleD{
+		ShortTerm: []ScheduleDLine{
+			newScheduleDLine("1b", FORM_8949_BOX_A, "Short-term totals from Form 8949 with Box A checked", shortBox, shortTotals),
+			newScheduleDLine("2", FORM_8949_BOX_B, "Short-term totals from Form 8949 with Box B checked", shortBox, shortTotals),
+			newScheduleDLine("3", FORM_8949_BOX_C, "Short-term totals from Form 8949 with Box C checked", shortBox, shortTotals)},
+		LongTerm: []ScheduleDLine{
+			newScheduleDLine("8b", FORM_8949_BOX_D, "Long-term totals from Form 8949 with Box D checked", longBox, longTotals),
+			newScheduleDLine("9", FORM_8949_BOX_E, "Long-term totals from Form 8949 with Box E checked", longBox, longTotals),
+			newScheduleDLine("10", FORM_8949_BOX_F, "Long-term totals from Form 8949 with Box F checked", longBox, longTotals)},
+		NetShortTerm: shortTotals.gainOrLoss.StringFixed(2),
+		NetLongTerm:  longTotals.gainOrLoss.StringFixed(2),
+		Total:        shortTotals.gainOrLoss.Add(longTotals.gainOrLoss).StringFixed(2)}
+	return schedule
+}
+
+func newScheduleDLine(line, box, description, formBox string, totals form8949Totals) ScheduleDLine {
+	if box != formBox {
+		totals = form8949Totals{}
+	}
+	return ScheduleDLine{
+		Line:        line,
+		Box:         box,
+		Description: description,
+		Proceeds:    totals.proceeds.StringFixed(2),
+		CostBasis:   totals.costBasis.StringFixed(2),
+		Adjustments: totals.adjustments.StringFixed(2),
+		GainOrLoss:  totals.gainOrLoss.StringFixed(2)}
+}
+
+func sumLineItems(lineItems []Form8949LineItem) form8949Totals {
+	var totals form8949Totals
+	for _, lineItem := range lineItems {
+		totals.proceeds = totals.proceeds.Add(parseAmount(lineItem.Proceeds))
+		totals.costBasis = totals.costBasis.Add(parseAmount(lineItem.CostBasis))
+		totals.adjustments = totals.adjustments.Add(parseAmount(lineItem.AdjustmentAmount))
+		totals.gainOrLoss = totals.gainOrLoss.Add(parseAmount(lineItem.GainOrLoss))
+	}
+	return totals
+}
+
+// parseAmount parses a formatted amount, treating a blank amount as zero.
+func parseAmount(amount string) decimal.Decimal {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
@@ -0,0 +1,377 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// HMRC matches a disposal first against acquisitions made the same day, then
+// against acquisitions made in the following 30 days and finally against the
+// Section 104 pool holding the average cost of everything else.
+const (
+	UK_MATCH_SAME_DAY          = "same-day"
+	UK_MATCH_BED_AND_BREAKFAST = "bed-and-breakfast"
+	UK_MATCH_SECTION_104       = "section-104"
+	UK_BED_AND_BREAKFAST_DAYS  = 30
+	UK_TAX_TIMEZONE            = "Europe/London"
+)
+
+type UkJurisdiction struct {
+	Jurisdiction
+}
+
+// UkCapitalGains lists the disposals of a UK tax year along with the totals
+// entered on the SA108 capital gains summary. Dates are written in Location,
+// the UK time zone the disposals were matched in.
+type UkCapitalGains struct {
+	Currency          string           `json:"currency"`
+	Disposals         []UkDisposal     `json:"disposals"`
+	Pools             []Section104Pool `json:"pools"`
+	NumberOfDisposals int              `json:"number_of_disposals"`
+	DisposalProceeds  string           `json:"disposal_proceeds"`
+	AllowableCosts    string           `json:"allowable_costs"`
+	Gains             string           `json:"gains"`
+	Losses            string           `json:"losses"`
+	NetGain           string           `json:"net_gain"`
+	Location          *time.Location   `json:"-"`
+}
+
+// UkDisposal combines every disposal of a currency made on the same day, which
+// HMRC treats as a single disposal.
+type UkDisposal struct {
+	TransactionIds []string  `json:"transaction_ids"`
+	Currency       string    `json:"currency"`
+	Description    string    `json:"description"`
+	Date           time.Time `json:"date"`
+	Quantity       string    `json:"quantity"`
+	Proceeds       string    `json:"proceeds"`
+	Fees           string    `json:"fees"`
+	AllowableCost  string    `json:"allowable_cost"`
+	GainOrLoss     string    `json:"gain_or_loss"`
+	MissingBasis   string    `json:"missing_basis,omitempty"`
+	Matches        []UkMatch `json:"matches"`
+}
+
+type UkMatch struct {
+	Rule          string    `json:"rule"`
+	DateAcquired  time.Time `json:"date_acquired"`
+	Quantity      string    `json:"quantity"`
+	AllowableCost string    `json:"allowable_cost"`
+}
+
+type Section104Pool struct {
+	Currency      string `json:"currency"`
+	Quantity      string `json:"quantity"`
+	AllowableCost string `json:"allowable_cost"`
+}
+
+// ukDay holds the acquisitions and disposals of a currency made on one day.
+type ukDay struct {
+	date           time.Time
+	acquired       decimal.Decimal
+	cost           decimal.Decimal
+	unmatched      decimal.Decimal
+	disposed       decimal.Decimal
+	proceeds       decimal.Decimal
+	fees           decimal.Decimal
+	remaining      decimal.Decimal
+	missing        decimal.Decimal
+	transferred    decimal.Decimal
+	transactionIds []string
+	matches        []ukMatch
+}
+
+type ukMatch struct {
+	rule         string
+	dateAcquired time.Time
+	quantity     decimal.Decimal
+	cost         decimal.Decimal
+}
+
+func (jurisdiction *UkJurisdiction) GetCode() string {
+	return JURISDICTION_UK
+}
+
+// GetTaxYearBounds returns the UK tax year starting 6 April of the given year,
+// so 2017 is the 2017-18 tax year.
+func (jurisdiction *UkJurisdiction) GetTaxYearBounds(year int, location *time.Location) (time.Time, time.Time) {
+	start := time.Date(year, 04, 06, 0, 0, 0, 0, location)
+	return start, start.AddDate(1, 0, 0).Add(-time.Nanosecond)
+}
+
+// Calculate matches disposals using the same day, bed and breakfast and
+// Section 104 rules. Acquisitions made in the 30 days after end are read so
+// disposals late in the year can be matched against them.
+func (jurisdiction *UkJurisdiction) Calculate(report *Report, start, end time.Time) TaxStatement {
+	buyLots, saleLots := report.collectLots(start, end.AddDate(0, 0, UK_BED_AND_BREAKFAST_DAYS))
+	gains := &UkCapitalGains{Currency: report.ctx.GetUser().GetLocalCurrency()}
+	var proceeds, costs, totalGains, totalLosses decimal.Decimal
+	london, err := time.LoadLocation(UK_TAX_TIMEZONE)
+	if err != nil {
+		report.ctx.GetLogger().Errorf("[UkJurisdiction.Calculate] Unable to load %s time zone, matching on UTC days: %s",
+			UK_TAX_TIMEZONE, err.Error())
+		london = time.UTC
+	}
+	gains.Location = london
+	for _, currency := range lotCurrencies(buyLots, saleLots) {
+		days := newUkDays(buyLots[currency], saleLots[currency], london)
+		matchSameDay(days)
+		matchBedAndBreakfast(days)
+		pool := jurisdiction.matchSection104(report.ctx, currency, days, end)
+		if pool.Quantity != "0" {
+			gains.Pools = append(gains.Pools, pool)
+		}
+		for _, day := range days {
+			if !day.disposed.IsPositive() || day.date.Before(start) || day.date.After(end) {
+				continue
+			}
+			disposal := day.disposal(currency)
+			gains.Disposals = append(gains.Disposals, disposal)
+			gainOrLoss, _ := decimal.NewFromString(disposal.GainOrLoss)
+			allowableCost, _ := decimal.NewFromString(disposal.AllowableCost)
+			proceeds = proceeds.Add(day.proceeds)
+			costs = costs.Add(allowableCost)
+			if gainOrLoss.IsNegative() {
+				totalLosses = totalLosses.Add(gainOrLoss.Neg())
+			} else {
+				totalGains = totalGains.Add(gainOrLoss)
+			}
+		}
+	}
+	sort.SliceStable(gains.Disposals, func(i, j int) bool {
+		return gains.Disposals[i].Date.Before(gains.Disposals[j].Date)
+	})
+	gains.NumberOfDisposals = len(gains.Disposals)
+	gains.DisposalProceeds = proceeds.StringFixed(2)
+	gains.AllowableCosts = costs.StringFixed(2)
+	gains.Gains = totalGains.StringFixed(2)
+	gains.Losses = totalLosses.StringFixed(2)
+	gains.NetGain = totalGains.Sub(totalLosses).StringFixed(2)
+	return gains
+}
+
+// matchSection104 matches what the same day and bed and breakfast rules left
+// over against the pool and returns the pool as it stood at end.
+func (jurisdiction *UkJurisdiction) matchSection104(ctx common.Context, currency string, days []*ukDay, end time.Time) Section104Pool {
+	var quantity, cost decimal.Decimal
+	take := func(amount decimal.Decimal) decimal.Decimal {
+		if !quantity.IsPositive() {
+			return decimal.Zero
+		}
+		share := cost.Mul(amount).Div(quantity)
+		quantity = quantity.Sub(amount)
+		cost = cost.Sub(share)
+		return share
+	}
+	for _, day := range days {
+		if day.date.After(end) {
+			break
+		}
+		if day.unmatched.IsPositive() {
+			quantity = quantity.Add(day.unmatched)
+			cost = cost.Add(day.unitCost().Mul(day.unmatched))
+			day.unmatched = decimal.Zero
+		}
+		if day.transferred.IsPositive() {
+			take(decimal.Min(day.transferred, quantity))
+		}
+		if !day.remaining.IsPositive() {
+			continue
+		}
+		// What the pool cannot cover has no allowable cost and is flagged on
+		// the disposal so the missing acquisitions can be added.
+		matched := decimal.Min(day.remaining, quantity)
+		if matched.LessThan(day.remaining) {
+			ctx.GetLogger().Warningf("[UkJurisdiction.matchSection104] Insufficient %s pool to cover %s disposed on %s (%s available)",
+				currency, day.remaining, day.date, quantity)
+			day.missing = day.remaining.Sub(matched)
+		}
+		day.matches = append(day.matches, ukMatch{
+			rule:     UK_MATCH_SECTION_104,
+			quantity: matched,
+			cost:     take(matched)})
+		day.remaining = decimal.Zero
+	}
+	return Section104Pool{
+		Currency:      currency,
+		Quantity:      quantity.String(),
+		AllowableCost: cost.StringFixed(2)}
+}
+
+// newUkDays groups the lots by the UK calendar day, in the given location, they
+// were acquired or disposed of on.
+func newUkDays(buyLots, saleLots []Coinlot, location *time.Location) []*ukDay {
+	days := make(map[time.Time]*ukDay)
+	getDay := func(date time.Time) *ukDay {
+		local := date.In(location)
+		key := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		if _, ok := days[key]; !ok {
+			days[key] = &ukDay{date: key}
+		}
+		return days[key]
+	}
+	for _, lot := range buyLots {
+		day := getDay(lot.Date)
+		day.acquired = day.acquired.Add(lot.Quantity)
+		day.unmatched = day.unmatched.Add(lot.Quantity)
+		day.cost = day.cost.Add(lot.CostBasis)
+	}
+	for _, lot := range saleLots {
+		day := getDay(lot.Date)
+		if !isReportable(&lot) {
+			day.transferred = day.transferred.Add(lot.Quantity)
+			continue
+		}
+		day.disposed = day.disposed.Add(lot.Quantity)
+		day.remaining = day.remaining.Add(lot.Quantity)
+		day.proceeds = day.proceeds.Add(lot.SalePrice.Add(lot.Fee))
+		day.fees = day.fees.Add(lot.Fee)
+		day.transactionIds = append(day.transactionIds, lot.TransactionId)
+	}
+	var sorted []*ukDay
+	for _, day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].date.Before(sorted[j].date)
+	})
+	return sorted
+}
+
+func matchSameDay(days []*ukDay) {
+	for _, day := range days {
+		if day.remaining.IsPositive() && day.unmatched.IsPositive() {
+			day.match(day, UK_MATCH_SAME_DAY)
+		}
+	}
+}
+
+// matchBedAndBreakfast matches each disposal, earliest first, against the
+// acquisitions made in the 30 days that follow it.
+func matchBedAndBreakfast(days []*ukDay) {
+	for i, day := range days {
+		cutoff := day.date.AddDate(0, 0, UK_BED_AND_BREAKFAST_DAYS)
+		for _, acquisition := range days[i+1:] {
+			if !day.remaining.IsPositive() || acquisition.date.After(cutoff) {
+				break
+			}
+			if acquisition.unmatched.IsPositive() {
+				day.match(acquisition, UK_MATCH_BED_AND_BREAKFAST)
+			}
+		}
+	}
+}
+
+// match disposes of as much of the day's remaining quantity as the unmatched
+// acquisitions of the given day cover.
+func (day *ukDay) match(acquisition *ukDay, rule string) {
+	quantity := decimal.Min(day.remaining, acquisition.unmatched)
+	day.matches = append(day.matches, ukMatch{
+		rule:         rule,
+		dateAcquired: acquisition.date,
+		quantity:     quantity,
+		cost:         acquisition.unitCost().Mul(quantity)})
+	day.remaining = day.remaining.Sub(quantity)
+	acquisition.unmatched = acquisition.unmatched.Sub(quantity)
+}
+
+func (day *ukDay) unitCost() decimal.Decimal {
+	if day.acquired.IsZero() {
+		return decimal.Zero
+	}
+	return day.cost.Div(day.acquired)
+}
+
+func (day *ukDay) disposal(currency string) UkDisposal {
+	allowableCost := day.fees
+	matches := make([]UkMatch, len(day.matches))
+	for i, match := range day.matches {
+		allowableCost = allowableCost.Add(match.cost)
+		matches[i] = UkMatch{
+			Rule:          match.rule,
+			DateAcquired:  match.dateAcquired,
+			Quantity:      match.quantity.String(),
+			AllowableCost: match.cost.StringFixed(2)}
+	}
+	disposal := UkDisposal{
+		TransactionIds: day.transactionIds,
+		Currency:       currency,
+		Description:    fmt.Sprintf("%s %s", day.disposed, currency),
+		Date:           day.date,
+		Quantity:       day.disposed.String(),
+		Proceeds:       day.proceeds.StringFixed(2),
+		Fees:           day.fees.StringFixed(2),
+		AllowableCost:  allowableCost.StringFixed(2),
+		GainOrLoss:     day.proceeds.Sub(allowableCost).StringFixed(2),
+		Matches:        matches}
+	if day.missing.IsPositive() {
+		disposal.MissingBasis = day.missing.String()
+	}
+	return disposal
+}
+
+func (gains *UkCapitalGains) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{fmt.Sprintf("Disposals (%s)", gains.Currency)})
+	writer.Write([]string{
+		"Description",
+		"Date",
+		"Proceeds",
+		"Fees",
+		"Allowable Cost",
+		"Gain or loss",
+		"Matching"})
+	for _, disposal := range gains.Disposals {
+		if err := writer.Write(disposal.Record(reportLocation(gains.Location))); err != nil {
+			return err
+		}
+	}
+
+	writer.Write([]string{})
+	writer.Write([]string{"Section 104 Pools"})
+	writer.Write([]string{"Currency", "Quantity", "Allowable Cost"})
+	for _, pool := range gains.Pools {
+		writer.Write([]string{pool.Currency, pool.Quantity, pool.AllowableCost})
+	}
+
+	writer.Write([]string{})
+	writer.Write([]string{"Capital Gains Summary (SA108)"})
+	writer.Write([]string{"Number of disposals", fmt.Sprintf("%d", gains.NumberOfDisposals)})
+	writer.Write([]string{"Disposal proceeds", gains.DisposalProceeds})
+	writer.Write([]string{"Allowable costs", gains.AllowableCosts})
+	writer.Write([]string{"Gains in the year, before losses", gains.Gains})
+	writer.Write([]string{"Losses in the year", gains.Losses})
+	writer.Write([]string{"Net gain", gains.NetGain})
+
+	return nil
+}
+
+// Record returns the disposal as a CSV record with its date in location.
+func (disposal *UkDisposal) Record(location *time.Location) []string {
+	var matches []string
+	for _, match := range disposal.Matches {
+		matches = append(matches, fmt.Sprintf("%s %s @ %s", match.Rule, match.Quantity, match.AllowableCost))
+	}
+	return []string{
+		disposal.Description,
+		disposal.Date.In(location).Format(common.TIME_DISPLAY_FORMAT),
+		disposal.Proceeds,
+		disposal.Fees,
+		disposal.AllowableCost,
+		disposal.GainOrLoss,
+		strings.Join(matches, "; ")}
+}
@@ -0,0 +1,135 @@
+package accounting
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+// UnrealizedGainsReport prices the lots still held after a report run at
+// current market prices to show the gain or loss that selling them would
+// realize.
+type UnrealizedGainsReport struct {
+	ctx              common.Context
+	marketcapService common.MarketCapService
+}
+
+type UnrealizedGains struct {
+	Lots       []UnrealizedLot   `json:"lots"`
+	Currencies []UnrealizedTotal `json:"currencies"`
+	Unpriced   []string          `json:"unpriced"`
+	ShortTerm  string            `json:"short_term"`
+	LongTerm   string            `json:"long_term"`
+	Total      string            `json:"total"`
+}
+
+type UnrealizedLot struct {
+	TransactionId string    `json:"transaction_id"`
+	Currency      string    `json:"currency"`
+	DateAcquired  time.Time `json:"date_acquired"`
+	Holding       string    `json:"holding"`
+	Quantity      string    `json:"quantity"`
+	CostBasis     string    `json:"cost_basis"`
+	Price         string    `json:"price"`
+	MarketValue   string    `json:"market_value"`
+	GainOrLoss    string    `json:"gain_or_loss"`
+}
+
+type UnrealizedTotal struct {
+	Currency    string `json:"currency"`
+	Quantity    string `json:"quantity"`
+	Price       string `json:"price"`
+	CostBasis   string `json:"cost_basis"`
+	MarketValue string `json:"market_value"`
+	ShortTerm   string `json:"short_term"`
+	LongTerm    string `json:"long_term"`
+	GainOrLoss  string `json:"gain_or_loss"`
+}
+
+func NewUnrealizedGainsReport(ctx common.Context, marketcapService common.MarketCapService) *UnrealizedGainsReport {
+	return &UnrealizedGainsReport{
+		ctx:              ctx,
+		marketcapService: marketcapService}
+}
+
+// Run prices the open lots returned by Report.GetOpenLotsByCurrency. The
+// holding period of each lot is measured up to asOf.
+func (report *UnrealizedGainsReport) Run(openLots map[string][]Coinlot, asOf time.Time) *UnrealizedGains {
+	gains := &UnrealizedGains{}
+	var shortTerm, longTerm decimal.Decimal
+	for currency, lots := range openLots {
+		if _, ok := common.FiatCurrencies[currency]; ok {
+			continue
+		}
+		var quantity, costBasis, currencyShort, currencyLong decimal.Decimal
+		for _, lot := range lots {
+			if lot.Quantity.IsPositive() {
+				quantity = quantity.Add(lot.Quantity)
+			}
+		}
+		if quantity.IsZero() {
+			continue
+		}
+		price, err := decimal.NewFromString(report.marketcapService.GetMarket(currency).PriceUSD)
+		if err != nil {
+			report.ctx.GetLogger().Warningf("[UnrealizedGainsReport.Run] Unable to price %s: %s", currency, err.Error())
+			gains.Unpriced = append(gains.Unpriced, currency)
+			continue
+		}
+		for _, lot := range lots {
+			if !lot.Quantity.IsPositive() {
+				continue
+			}
+			marketValue := lot.Quantity.Mul(price)
+			gainOrLoss := marketValue.Sub(lot.CostBasis)
+			holding := getHolding(lot.Date, asOf)
+			if holding == HOLDING_SHORT {
+				currencyShort = currencyShort.Add(gainOrLoss)
+			} else {
+				currencyLong = currencyLong.Add(gainOrLoss)
+			}
+			costBasis = costBasis.Add(lot.CostBasis)
+			gains.Lots = append(gains.Lots, UnrealizedLot{
+				TransactionId: lot.TransactionId,
+				Currency:      currency,
+				DateAcquired:  lot.Date,
+				Holding:       holding,
+				Quantity:      lot.Quantity.String(),
+				CostBasis:     lot.CostBasis.StringFixed(2),
+				Price:         price.StringFixed(2),
+				MarketValue:   marketValue.StringFixed(2),
+				GainOrLoss:    gainOrLoss.StringFixed(2)})
+		}
+		gains.Currencies = append(gains.Currencies, UnrealizedTotal{
+			Currency:    currency,
+			Quantity:    quantity.String(),
+			Price:       price.StringFixed(2),
+			CostBasis:   costBasis.StringFixed(2),
+			MarketValue: quantity.Mul(price).StringFixed(2),
+			ShortTerm:   currencyShort.StringFixed(2),
+			LongTerm:    currencyLong.StringFixed(2),
+			GainOrLoss:  currencyShort.Add(currencyLong).StringFixed(2)})
+		shortTerm = shortTerm.Add(currencyShort)
+		longTerm = longTerm.Add(currencyLong)
+	}
+	gains.ShortTerm = shortTerm.StringFixed(2)
+	gains.LongTerm = longTerm.StringFixed(2)
+	gains.Total = shortTerm.Add(longTerm).StringFixed(2)
+	gains.sort()
+	return gains
+}
+
+func (gains *UnrealizedGains) sort() {
+	sort.SliceStable(gains.Lots, func(i, j int) bool {
+		if gains.Lots[i].Currency == gains.Lots[j].Currency {
+			return gains.Lots[i].DateAcquired.Before(gains.Lots[j].DateAcquired)
+		}
+		return gains.Lots[i].Currency < gains.Lots[j].Currency
+	})
+	sort.Slice(gains.Currencies, func(i, j int) bool {
+		return gains.Currencies[i].Currency < gains.Currencies[j].Currency
+	})
+	sort.Strings(gains.Unpriced)
+}
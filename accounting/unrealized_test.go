@@ -0,0 +1,56 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockMarketCapService struct {
+	prices map[string]string
+	common.MarketCapService
+}
+
+func (mock *MockMarketCapService) GetMarket(symbol string) common.MarketCap {
+	return common.MarketCap{Symbol: symbol, PriceUSD: mock.prices[symbol]}
+}
+
+func TestUnrealizedGainsReport_Run(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+
+	report, err := NewReport(ctx, createReportTestTransactions(), COST_BASIS_FIFO)
+	assert.Nil(t, err)
+	report.Run(time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC))
+
+	openLots := report.GetOpenLotsByCurrency()
+	openLots["LTC"] = []Coinlot{Coinlot{Currency: "LTC", Quantity: decimal.NewFromFloat(1), CostBasis: decimal.NewFromFloat(50)}}
+
+	marketcapService := &MockMarketCapService{prices: map[string]string{"BTC": "2500"}}
+	asOf := time.Date(2018, 04, 01, 0, 0, 0, 0, time.UTC)
+	gains := NewUnrealizedGainsReport(ctx, marketcapService).Run(openLots, asOf)
+
+	assert.Equal(t, 2, len(gains.Lots))
+	assert.Equal(t, "2", gains.Lots[0].TransactionId)
+	assert.Equal(t, HOLDING_LONG, gains.Lots[0].Holding)
+	assert.Equal(t, "-500.00", gains.Lots[0].GainOrLoss)
+	assert.Equal(t, "3", gains.Lots[1].TransactionId)
+	assert.Equal(t, HOLDING_SHORT, gains.Lots[1].Holding)
+	assert.Equal(t, "500.00", gains.Lots[1].GainOrLoss)
+
+	assert.Equal(t, 1, len(gains.Currencies))
+	assert.Equal(t, UnrealizedTotal{
+		Currency:    "BTC",
+		Quantity:    "2",
+		Price:       "2500.00",
+		CostBasis:   "5000.00",
+		MarketValue: "5000.00",
+		ShortTerm:   "500.00",
+		LongTerm:    "-500.00",
+		GainOrLoss:  "0.00"}, gains.Currencies[0])
+	assert.Equal(t, []string{"LTC"}, gains.Unpriced)
+	assert.Equal(t, "0.00", gains.Total)
+}
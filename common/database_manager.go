@@ -52,6 +52,20 @@ func (database *DatabaseImpl) MigrateCoreDB() {
 	coreDB.AutoMigrate(&entity.MarketCap{})
 	coreDB.AutoMigrate(&entity.GlobalMarketCap{})
 	coreDB.AutoMigrate(&entity.Transaction{})
+	coreDB.AutoMigrate(&entity.TransactionRevision{})
+	coreDB.AutoMigrate(&entity.LotSelection{})
+	coreDB.AutoMigrate(&entity.ForkEvent{})
+	coreDB.AutoMigrate(&entity.CSVTemplate{})
+	coreDB.AutoMigrate(&entity.TaxYear{})
+	coreDB.AutoMigrate(&entity.TaxLot{})
+	coreDB.AutoMigrate(&entity.TaxLineItem{})
+	coreDB.AutoMigrate(&entity.TaxLineItemLot{})
+	coreDB.AutoMigrate(&entity.PaperAccount{})
+	coreDB.AutoMigrate(&entity.PaperBalance{})
+	coreDB.AutoMigrate(&entity.PaperOrder{})
+	coreDB.AutoMigrate(&entity.RiskLimit{})
+	coreDB.AutoMigrate(&entity.RiskExposure{})
+	coreDB.AutoMigrate(&entity.RiskRejection{})
 	coreDB.AutoMigrate(&entity.Trade{})
 	coreDB.AutoMigrate(&entity.User{})
 	coreDB.AutoMigrate(&entity.UserWallet{})
@@ -31,10 +31,30 @@ const (
 	TX_CATEGORY_INCOME     = "income"
 	TX_CATEGORY_GIFT       = "gift"
 	TX_CATEGORY_MINING     = "mining"
+	TX_CATEGORY_STAKING    = "staking"
 	TX_CATEGORY_SPEND      = "spend"
 	TX_CATEGORY_DONATION   = "donation"
 	TX_CATEGORY_LOST       = "lost"
 	TX_CATEGORY_TRANSFER   = "transfer"
+	TX_CATEGORY_FORK       = "fork"
+	TX_CATEGORY_AIRDROP    = "airdrop"
+	TX_REVISION_CREATE     = "create"
+	TX_REVISION_UPDATE     = "update"
+	TX_REVISION_SPLIT      = "split"
+	TX_REVISION_DELETE     = "delete"
+	TX_REVISION_RESTORE    = "restore"
+	ORDER_TYPE_MARKET      = "market"
+	ORDER_TYPE_LIMIT       = "limit"
+	ORDER_STATUS_OPEN      = "open"
+	ORDER_STATUS_FILLED    = "filled"
+	ORDER_STATUS_CANCELLED = "cancelled"
+	AUTOTRADE_DISABLED     = 0
+	AUTOTRADE_LIVE         = 1
+	AUTOTRADE_PAPER        = 2
+	PAPER_EXCHANGE         = "Paper"
+	KILL_SWITCH_OFF        = "off"
+	KILL_SWITCH_HALT       = "halt"
+	KILL_SWITCH_FLATTEN    = "flatten"
 )
 
 type Transaction interface {
@@ -64,10 +84,32 @@ type Transaction interface {
 	GetFiatFeeCurrency() string
 	GetFiatTotal() string
 	GetFiatTotalCurrency() string
+	GetTransferId() string
 	IsDeleted() bool
 	String() string
 }
 
+// TransactionRevision records a change made to a transaction along with its
+// state before and after the change. Previous is nil for a transaction the
+// change created.
+type TransactionRevision interface {
+	GetId() uint
+	GetUserId() uint
+	GetTransactionId() string
+	GetAction() string
+	GetDate() time.Time
+	GetPrevious() Transaction
+	GetCurrent() Transaction
+}
+
+// Transfer pairs a withdrawal with the deposit it arrived as on another
+// exchange or wallet.
+type Transfer interface {
+	GetWithdrawal() Transaction
+	GetDeposit() Transaction
+	GetNetworkFee() string
+}
+
 type EthereumToken interface {
 	GetName() string
 	GetSymbol() string
@@ -102,6 +144,7 @@ type Chart interface {
 	GetPrice() float64
 	GetAutoTrade() uint
 	IsAutoTrade() bool
+	IsPaperTrade() bool
 	GetIndicators() []ChartIndicator
 	GetStrategies() []ChartStrategy
 	GetTrades() []Trade
@@ -149,6 +192,52 @@ type Profit interface {
 	GetTotal() decimal.Decimal
 }
 
+// ForkEvent distributes a new currency to the holders of an existing one, by
+// a chain split or an airdrop, in proportion to their holdings on Date.
+type ForkEvent interface {
+	GetId() uint
+	GetUserId() uint
+	GetCategory() string
+	GetCurrency() string
+	GetNewCurrency() string
+	GetDate() time.Time
+	GetRatio() decimal.Decimal
+	GetCostBasis() decimal.Decimal
+}
+
+type CSVTemplate interface {
+	GetId() uint
+	GetUserId() uint
+	GetName() string
+	GetNetwork() string
+	GetIdColumn() string
+	GetDateColumn() string
+	GetDateFormat() string
+	GetTimeZone() string
+	GetTypeColumn() string
+	GetTypes() map[string]string
+	GetCurrencyPairColumn() string
+	GetCurrencyPairSeparator() string
+	IsQuoteFirst() bool
+	GetBaseColumn() string
+	GetQuoteColumn() string
+	GetQuantityColumn() string
+	GetPriceColumn() string
+	GetTotalColumn() string
+	GetFeeColumn() string
+	GetFeeCurrencyColumn() string
+	GetFeeCurrency() string
+}
+
+type LotSelection interface {
+	GetId() uint
+	GetUserId() uint
+	GetSaleTransactionId() string
+	GetBuyTransactionId() string
+	GetCurrency() string
+	GetQuantity() decimal.Decimal
+}
+
 type FinancialIndicator interface {
 	GetDefaultParameters() []string
 	GetParameters() []string
@@ -158,6 +247,7 @@ type FinancialIndicator interface {
 }
 
 type TradingStrategy interface {
+	GetDefaultParameters() []string
 	GetRequiredIndicators() []string
 	Analyze() (bool, bool, map[string]string, error)
 	CalculateFeeAndTax(price decimal.Decimal) (decimal.Decimal, decimal.Decimal)
@@ -205,6 +295,75 @@ type Exchange interface {
 	ParseImport(file string) ([]Transaction, error)
 }
 
+// OrderExecutor is implemented by exchanges that can place orders on behalf of
+// the user. Side is BUY_ORDER_TYPE or SELL_ORDER_TYPE and quantities are in the
+// base currency.
+type OrderExecutor interface {
+	PlaceMarketOrder(currencyPair *CurrencyPair, side string, quantity decimal.Decimal) (Order, error)
+	PlaceLimitOrder(currencyPair *CurrencyPair, side string, quantity, price decimal.Decimal) (Order, error)
+	CancelOrder(currencyPair *CurrencyPair, orderId string) error
+	GetOrder(currencyPair *CurrencyPair, orderId string) (Order, error)
+	GetOpenOrders(currencyPair *CurrencyPair) ([]Order, error)
+}
+
+// Order is an order placed on an exchange. The fill price is the average price
+// of the quantity filled so far, and the fee what the exchange charged for it.
+type Order interface {
+	GetId() string
+	GetExchange() string
+	GetCurrencyPair() *CurrencyPair
+	GetSide() string
+	GetType() string
+	GetStatus() string
+	GetDate() time.Time
+	GetQuantity() decimal.Decimal
+	GetPrice() decimal.Decimal
+	GetFilledQuantity() decimal.Decimal
+	GetFillPrice() decimal.Decimal
+	GetFee() decimal.Decimal
+	GetFeeCurrency() string
+}
+
+// PaperAccount holds the simulated balances and the fee and slippage rates
+// used to fill paper trading orders placed on an exchange.
+type PaperAccount interface {
+	GetId() uint
+	GetUserId() uint
+	GetExchange() string
+	GetFee() decimal.Decimal
+	GetSlippage() decimal.Decimal
+	GetBalances() map[string]decimal.Decimal
+}
+
+// RiskLimit caps what the trading bot may trade. ChartId 0 holds the user's
+// global limits, kill switch and per currency exposure caps; any other id
+// limits a single chart. Zero values are unlimited.
+type RiskLimit interface {
+	GetId() uint
+	GetUserId() uint
+	GetChartId() uint
+	GetMaxPositionSize() decimal.Decimal
+	GetMaxDailyLoss() decimal.Decimal
+	GetMaxTradesPerHour() int
+	GetMinBalanceReserve() decimal.Decimal
+	GetKillSwitch() string
+	GetExposures() map[string]decimal.Decimal
+}
+
+// RiskRejection records a trading signal the risk engine refused to act on.
+type RiskRejection interface {
+	GetId() uint
+	GetUserId() uint
+	GetChartId() uint
+	GetDate() time.Time
+	GetBase() string
+	GetQuote() string
+	GetType() string
+	GetPrice() decimal.Decimal
+	GetQuantity() decimal.Decimal
+	GetReason() string
+}
+
 type KeyPair interface {
 	GetDirectory() string
 	GetPrivateKey() *rsa.PrivateKey
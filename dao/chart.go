@@ -51,7 +51,7 @@ func (chartDAO *ChartDAOImpl) Find(user common.UserContext, autoTradeonly bool)
 	daoUser := &entity.User{Id: user.GetId()}
 	var err error
 	if autoTradeonly {
-		err = chartDAO.ctx.GetCoreDB().Where("auto_trade = ?", 1).Related(&charts).Error
+		err = chartDAO.ctx.GetCoreDB().Where("auto_trade IN (?)", []uint{common.AUTOTRADE_LIVE, common.AUTOTRADE_PAPER}).Related(&charts).Error
 	} else {
 		err = chartDAO.ctx.GetCoreDB().Model(daoUser).Related(&charts).Error
 	}
@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type CSVTemplateDAO interface {
+	Create(template entity.CSVTemplateEntity) error
+	Save(template entity.CSVTemplateEntity) error
+	Get(name string) (entity.CSVTemplateEntity, error)
+	Find() ([]entity.CSVTemplate, error)
+	Delete(name string) error
+}
+
+type CSVTemplateDAOImpl struct {
+	ctx common.Context
+	CSVTemplateDAO
+}
+
+func NewCSVTemplateDAO(ctx common.Context) CSVTemplateDAO {
+	return &CSVTemplateDAOImpl{ctx: ctx}
+}
+
+func (dao *CSVTemplateDAOImpl) Create(template entity.CSVTemplateEntity) error {
+	return dao.ctx.GetCoreDB().Create(template).Error
+}
+
+func (dao *CSVTemplateDAOImpl) Save(template entity.CSVTemplateEntity) error {
+	return dao.ctx.GetCoreDB().Save(template).Error
+}
+
+func (dao *CSVTemplateDAOImpl) Get(name string) (entity.CSVTemplateEntity, error) {
+	var template entity.CSVTemplate
+	if err := dao.ctx.GetCoreDB().Where("user_id = ? AND name = ?",
+		dao.ctx.GetUser().GetId(), name).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (dao *CSVTemplateDAOImpl) Find() ([]entity.CSVTemplate, error) {
+	var templates []entity.CSVTemplate
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Order("name asc").Model(daoUser).Related(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (dao *CSVTemplateDAOImpl) Delete(name string) error {
+	return dao.ctx.GetCoreDB().Where("user_id = ? AND name = ?",
+		dao.ctx.GetUser().GetId(), name).Delete(&entity.CSVTemplate{}).Error
+}
@@ -0,0 +1,54 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVTemplateDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	csvTemplateDAO := NewCSVTemplateDAO(ctx)
+	otc := &entity.CSVTemplate{
+		UserId:         1,
+		Name:           "otc",
+		DateColumn:     "Time",
+		TypeColumn:     "Side",
+		Types:          `{"BOUGHT":"buy"}`,
+		BaseColumn:     "Asset",
+		QuantityColumn: "Amount",
+		TotalColumn:    "Total"}
+	kraken := &entity.CSVTemplate{
+		UserId:             1,
+		Name:               "kraken",
+		DateColumn:         "time",
+		TypeColumn:         "type",
+		Types:              `{"buy":"buy","sell":"sell"}`,
+		CurrencyPairColumn: "pair",
+		QuantityColumn:     "vol",
+		TotalColumn:        "cost"}
+
+	assert.Nil(t, csvTemplateDAO.Create(otc))
+	assert.Nil(t, csvTemplateDAO.Create(kraken))
+
+	templates, err := csvTemplateDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(templates))
+	assert.Equal(t, "kraken", templates[0].GetName())
+
+	otc.TotalColumn = "Net"
+	assert.Nil(t, csvTemplateDAO.Save(otc))
+	persisted, err := csvTemplateDAO.Get("otc")
+	assert.Nil(t, err)
+	assert.Equal(t, "Net", persisted.GetTotalColumn())
+
+	assert.Nil(t, csvTemplateDAO.Delete("otc"))
+	_, err = csvTemplateDAO.Get("otc")
+	assert.NotNil(t, err)
+
+	CleanupIntegrationTest()
+}
@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type ForkEventDAO interface {
+	Create(event entity.ForkEventEntity) error
+	Find() ([]entity.ForkEvent, error)
+	Delete(event entity.ForkEventEntity) error
+}
+
+type ForkEventDAOImpl struct {
+	ctx common.Context
+	ForkEventDAO
+}
+
+func NewForkEventDAO(ctx common.Context) ForkEventDAO {
+	return &ForkEventDAOImpl{ctx: ctx}
+}
+
+func (dao *ForkEventDAOImpl) Create(event entity.ForkEventEntity) error {
+	return dao.ctx.GetCoreDB().Create(event).Error
+}
+
+func (dao *ForkEventDAOImpl) Find() ([]entity.ForkEvent, error) {
+	var events []entity.ForkEvent
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Order("date asc, id asc").Model(daoUser).Related(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (dao *ForkEventDAOImpl) Delete(event entity.ForkEventEntity) error {
+	return dao.ctx.GetCoreDB().Where("user_id = ?", dao.ctx.GetUser().GetId()).Delete(event).Error
+}
@@ -0,0 +1,54 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkEventDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	forkEventDAO := NewForkEventDAO(ctx)
+	airdrop := &entity.ForkEvent{
+		UserId:      1,
+		Category:    common.TX_CATEGORY_AIRDROP,
+		Currency:    "XLM",
+		NewCurrency: "KIN",
+		Date:        time.Date(2017, 12, 01, 0, 0, 0, 0, time.UTC),
+		Ratio:       "0.1",
+		CostBasis:   "0"}
+	fork := &entity.ForkEvent{
+		UserId:      1,
+		Category:    common.TX_CATEGORY_FORK,
+		Currency:    "BTC",
+		NewCurrency: "BCH",
+		Date:        time.Date(2017, 8, 1, 13, 16, 14, 0, time.UTC),
+		Ratio:       "1",
+		CostBasis:   "0"}
+
+	assert.Nil(t, forkEventDAO.Create(airdrop))
+	assert.Nil(t, forkEventDAO.Create(fork))
+	assert.Equal(t, uint(2), fork.GetId())
+
+	events, err := forkEventDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "BCH", events[0].GetNewCurrency())
+	assert.Equal(t, common.TX_CATEGORY_FORK, events[0].GetCategory())
+	assert.Equal(t, "KIN", events[1].GetNewCurrency())
+	assert.Equal(t, "0.1", events[1].GetRatio())
+
+	assert.Nil(t, forkEventDAO.Delete(fork))
+	events, err = forkEventDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "KIN", events[0].GetNewCurrency())
+
+	CleanupIntegrationTest()
+}
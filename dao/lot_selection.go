@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type LotSelectionDAO interface {
+	Create(selection entity.LotSelectionEntity) error
+	Find() ([]entity.LotSelection, error)
+	FindBySale(saleTransactionId string) ([]entity.LotSelection, error)
+	DeleteBySale(saleTransactionId string) error
+}
+
+type LotSelectionDAOImpl struct {
+	ctx common.Context
+	LotSelectionDAO
+}
+
+func NewLotSelectionDAO(ctx common.Context) LotSelectionDAO {
+	return &LotSelectionDAOImpl{ctx: ctx}
+}
+
+func (dao *LotSelectionDAOImpl) Create(selection entity.LotSelectionEntity) error {
+	return dao.ctx.GetCoreDB().Create(selection).Error
+}
+
+func (dao *LotSelectionDAOImpl) Find() ([]entity.LotSelection, error) {
+	var selections []entity.LotSelection
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Order("id asc").Model(daoUser).Related(&selections).Error; err != nil {
+		return nil, err
+	}
+	return selections, nil
+}
+
+func (dao *LotSelectionDAOImpl) FindBySale(saleTransactionId string) ([]entity.LotSelection, error) {
+	var selections []entity.LotSelection
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Where("sale_transaction_id = ?", saleTransactionId).
+		Order("id asc").Model(daoUser).Related(&selections).Error; err != nil {
+		return nil, err
+	}
+	return selections, nil
+}
+
+func (dao *LotSelectionDAOImpl) DeleteBySale(saleTransactionId string) error {
+	return dao.ctx.GetCoreDB().Where("user_id = ? AND sale_transaction_id = ?",
+		dao.ctx.GetUser().GetId(), saleTransactionId).Delete(&entity.LotSelection{}).Error
+}
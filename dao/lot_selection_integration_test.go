@@ -0,0 +1,60 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLotSelectionDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	lotSelectionDAO := NewLotSelectionDAO(ctx)
+	selection1 := &entity.LotSelection{
+		UserId:            1,
+		SaleTransactionId: "sale-1",
+		BuyTransactionId:  "buy-1",
+		Currency:          "BTC",
+		Quantity:          "0.5"}
+	selection2 := &entity.LotSelection{
+		UserId:            1,
+		SaleTransactionId: "sale-1",
+		BuyTransactionId:  "buy-2",
+		Currency:          "BTC",
+		Quantity:          "0.25"}
+	selection3 := &entity.LotSelection{
+		UserId:            1,
+		SaleTransactionId: "sale-2",
+		BuyTransactionId:  "buy-2",
+		Currency:          "BTC",
+		Quantity:          "1"}
+
+	assert.Nil(t, lotSelectionDAO.Create(selection1))
+	assert.Nil(t, lotSelectionDAO.Create(selection2))
+	assert.Nil(t, lotSelectionDAO.Create(selection3))
+
+	selections, err := lotSelectionDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(selections))
+
+	selections, err = lotSelectionDAO.FindBySale("sale-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(selections))
+	assert.Equal(t, "buy-1", selections[0].GetBuyTransactionId())
+	assert.Equal(t, "0.5", selections[0].GetQuantity())
+	assert.Equal(t, "buy-2", selections[1].GetBuyTransactionId())
+	assert.Equal(t, "0.25", selections[1].GetQuantity())
+
+	err = lotSelectionDAO.DeleteBySale("sale-1")
+	assert.Nil(t, err)
+
+	selections, err = lotSelectionDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(selections))
+	assert.Equal(t, "sale-2", selections[0].GetSaleTransactionId())
+
+	CleanupIntegrationTest()
+}
@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type PaperAccountDAO interface {
+	Get(exchange string) (entity.PaperAccountEntity, error)
+	Save(account entity.PaperAccountEntity, orders ...entity.PaperOrderEntity) error
+	Delete(account entity.PaperAccountEntity) error
+	FindOrders(account entity.PaperAccountEntity) ([]entity.PaperOrder, error)
+}
+
+type PaperAccountDAOImpl struct {
+	ctx common.Context
+	PaperAccountDAO
+}
+
+func NewPaperAccountDAO(ctx common.Context) PaperAccountDAO {
+	return &PaperAccountDAOImpl{ctx: ctx}
+}
+
+func (dao *PaperAccountDAOImpl) Get(exchange string) (entity.PaperAccountEntity, error) {
+	var account entity.PaperAccount
+	if err := dao.ctx.GetCoreDB().Where("user_id = ? AND exchange = ?", dao.ctx.GetUser().GetId(), exchange).
+		First(&account).Error; err != nil {
+		return nil, err
+	}
+	var balances []entity.PaperBalance
+	if err := dao.ctx.GetCoreDB().Where("account_id = ?", account.Id).Order("currency asc").
+		Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	account.Balances = balances
+	return &account, nil
+}
+
+// Save persists the account settings, replaces its balances and saves the orders
+// that changed them in a single database transaction, so a fill can never be
+// recorded without the balances it moved.
+func (dao *PaperAccountDAOImpl) Save(account entity.PaperAccountEntity, orders ...entity.PaperOrderEntity) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Set("gorm:save_associations", false).Save(account).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("account_id = ?", account.GetId()).Delete(&entity.PaperBalance{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	for _, balance := range account.GetBalances() {
+		balance.Id = 0
+		balance.AccountId = account.GetId()
+		if err := db.Create(&balance).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	for _, order := range orders {
+		if err := db.Save(order).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	return db.Commit().Error
+}
+
+func (dao *PaperAccountDAOImpl) Delete(account entity.PaperAccountEntity) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Where("account_id = ?", account.GetId()).Delete(&entity.PaperOrder{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("account_id = ?", account.GetId()).Delete(&entity.PaperBalance{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Delete(account).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	return db.Commit().Error
+}
+
+func (dao *PaperAccountDAOImpl) FindOrders(account entity.PaperAccountEntity) ([]entity.PaperOrder, error) {
+	var orders []entity.PaperOrder
+	if err := dao.ctx.GetCoreDB().Where("account_id = ?", account.GetId()).Order("id asc").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,77 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaperAccountDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	paperAccountDAO := NewPaperAccountDAO(ctx)
+	account := &entity.PaperAccount{
+		UserId:   1,
+		Exchange: "GDAX",
+		Fee:      "0.0025",
+		Slippage: "0.001",
+		Balances: []entity.PaperBalance{
+			{Currency: "USD", Amount: "10000"}}}
+	assert.Nil(t, paperAccountDAO.Save(account))
+	assert.Equal(t, uint(1), account.GetId())
+
+	persisted, err := paperAccountDAO.Get("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0025", persisted.GetFee())
+	assert.Equal(t, 1, len(persisted.GetBalances()))
+	assert.Equal(t, "10000", persisted.GetBalances()[0].GetAmount())
+
+	// Filling an order replaces the balances and saves the order together
+	order := &entity.PaperOrder{
+		AccountId:      account.GetId(),
+		Date:           time.Now(),
+		Base:           "BTC",
+		Quote:          "USD",
+		Side:           common.BUY_ORDER_TYPE,
+		Type:           common.ORDER_TYPE_MARKET,
+		Status:         common.ORDER_STATUS_FILLED,
+		Quantity:       "1",
+		Price:          "5000",
+		FilledQuantity: "1",
+		FillPrice:      "5005",
+		Fee:            "12.5125",
+		FeeCurrency:    "USD"}
+	account.Balances = []entity.PaperBalance{
+		{Currency: "BTC", Amount: "1"},
+		{Currency: "USD", Amount: "4982.4875"}}
+	assert.Nil(t, paperAccountDAO.Save(account, order))
+	assert.Equal(t, uint(1), order.GetId())
+
+	persisted, err = paperAccountDAO.Get("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(persisted.GetBalances()))
+	assert.Equal(t, "BTC", persisted.GetBalances()[0].GetCurrency())
+	assert.Equal(t, "4982.4875", persisted.GetBalances()[1].GetAmount())
+
+	orders, err := paperAccountDAO.FindOrders(persisted)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(orders))
+	assert.Equal(t, "5005", orders[0].GetFillPrice())
+
+	_, err = paperAccountDAO.Get("Binance")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, paperAccountDAO.Delete(persisted))
+	_, err = paperAccountDAO.Get("GDAX")
+	assert.NotNil(t, err)
+	orders, err = paperAccountDAO.FindOrders(persisted)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(orders))
+
+	CleanupIntegrationTest()
+}
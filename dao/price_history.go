@@ -1,11 +1,15 @@
 package dao
 
 import (
+	"time"
+
 	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/entity"
 )
 
 type PriceHistoryDAO interface {
+	Create(priceHistory entity.PriceHistoryEntity) error
+	Find(exchange string, currencyPair *common.CurrencyPair, period int, start, end time.Time) ([]entity.PriceHistory, error)
 }
 
 type PriceHistoryDAOImpl struct {
@@ -28,3 +32,15 @@ func (phDAO *PriceHistoryDAOImpl) Save(priceHistory entity.PriceHistoryEntity) e
 func (phDAO *PriceHistoryDAOImpl) Update(priceHistory entity.PriceHistoryEntity) error {
 	return phDAO.ctx.GetPriceDB().Update(priceHistory).Error
 }
+
+func (phDAO *PriceHistoryDAOImpl) Find(exchange string, currencyPair *common.CurrencyPair, period int,
+	start, end time.Time) ([]entity.PriceHistory, error) {
+	var history []entity.PriceHistory
+	if err := phDAO.ctx.GetPriceDB().
+		Where("exchange = ? AND base = ? AND quote = ? AND period = ? AND time BETWEEN ? AND ?",
+			exchange, currencyPair.Base, currencyPair.Quote, period, start.Unix(), end.Unix()).
+		Order("time asc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
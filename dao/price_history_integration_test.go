@@ -0,0 +1,48 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceHistoryDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	priceHistoryDAO := NewPriceHistoryDAO(ctx)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, priceHistoryDAO.Create(&entity.PriceHistory{
+			Exchange: "GDAX",
+			Base:     "BTC",
+			Quote:    "USD",
+			Period:   900,
+			Time:     start.Add(time.Duration(i*900) * time.Second).Unix(),
+			Close:    float64(10000 + i)}))
+	}
+	assert.Nil(t, priceHistoryDAO.Create(&entity.PriceHistory{
+		Exchange: "GDAX",
+		Base:     "ETH",
+		Quote:    "USD",
+		Period:   900,
+		Time:     start.Unix(),
+		Close:    800}))
+
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD"}
+	history, err := priceHistoryDAO.Find("GDAX", currencyPair, 900, start.Add(15*time.Minute), start.Add(45*time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, float64(10001), history[0].GetClose())
+	assert.Equal(t, float64(10003), history[2].GetClose())
+
+	history, err = priceHistoryDAO.Find("Binance", currencyPair, 900, start, start.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(history))
+
+	CleanupIntegrationTest()
+}
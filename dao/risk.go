@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type RiskDAO interface {
+	GetLimit(chartId uint) (entity.RiskLimitEntity, error)
+	FindLimits() ([]entity.RiskLimit, error)
+	SaveLimit(limit entity.RiskLimitEntity) error
+	DeleteLimit(limit entity.RiskLimitEntity) error
+	CreateRejection(rejection entity.RiskRejectionEntity) error
+	FindRejections(limit int) ([]entity.RiskRejection, error)
+	GetRecentRejection(chartId uint, tradeType, reason string, since time.Time) (entity.RiskRejectionEntity, error)
+}
+
+type RiskDAOImpl struct {
+	ctx common.Context
+	RiskDAO
+}
+
+func NewRiskDAO(ctx common.Context) RiskDAO {
+	return &RiskDAOImpl{ctx: ctx}
+}
+
+// GetLimit returns the user's risk limits for the chart, or the global limits
+// when chartId is 0. A nil limit is returned when none have been saved.
+func (dao *RiskDAOImpl) GetLimit(chartId uint) (entity.RiskLimitEntity, error) {
+	var limits []entity.RiskLimit
+	if err := dao.ctx.GetCoreDB().Where("user_id = ? AND chart_id = ?", dao.ctx.GetUser().GetId(), chartId).
+		Limit(1).Find(&limits).Error; err != nil {
+		return nil, err
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	limit := &limits[0]
+	if err := dao.ctx.GetCoreDB().Where("limit_id = ?", limit.Id).Order("currency asc").
+		Find(&limit.Exposures).Error; err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+func (dao *RiskDAOImpl) FindLimits() ([]entity.RiskLimit, error) {
+	var limits []entity.RiskLimit
+	if err := dao.ctx.GetCoreDB().Where("user_id = ?", dao.ctx.GetUser().GetId()).Order("chart_id asc").
+		Find(&limits).Error; err != nil {
+		return nil, err
+	}
+	for i := range limits {
+		if err := dao.ctx.GetCoreDB().Where("limit_id = ?", limits[i].Id).Order("currency asc").
+			Find(&limits[i].Exposures).Error; err != nil {
+			return nil, err
+		}
+	}
+	return limits, nil
+}
+
+// SaveLimit persists the limits and replaces their exposure caps in a single
+// database transaction.
+func (dao *RiskDAOImpl) SaveLimit(limit entity.RiskLimitEntity) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Set("gorm:save_associations", false).Save(limit).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("limit_id = ?", limit.GetId()).Delete(&entity.RiskExposure{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	for _, exposure := range limit.GetExposures() {
+		exposure.Id = 0
+		exposure.LimitId = limit.GetId()
+		if err := db.Create(&exposure).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	return db.Commit().Error
+}
+
+func (dao *RiskDAOImpl) DeleteLimit(limit entity.RiskLimitEntity) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Where("limit_id = ?", limit.GetId()).Delete(&entity.RiskExposure{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Delete(limit).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	return db.Commit().Error
+}
+
+func (dao *RiskDAOImpl) CreateRejection(rejection entity.RiskRejectionEntity) error {
+	return dao.ctx.GetCoreDB().Create(rejection).Error
+}
+
+// FindRejections returns the user's most recent rejected signals, newest first.
+func (dao *RiskDAOImpl) FindRejections(limit int) ([]entity.RiskRejection, error) {
+	var rejections []entity.RiskRejection
+	if err := dao.ctx.GetCoreDB().Where("user_id = ?", dao.ctx.GetUser().GetId()).Order("date desc, id desc").
+		Limit(limit).Find(&rejections).Error; err != nil {
+		return nil, err
+	}
+	return rejections, nil
+}
+
+// GetRecentRejection returns the latest rejection of a signal of the same type
+// on the chart for the same reason made since the given time, or nil when
+// there is none.
+func (dao *RiskDAOImpl) GetRecentRejection(chartId uint, tradeType, reason string, since time.Time) (entity.RiskRejectionEntity, error) {
+	var rejections []entity.RiskRejection
+	if err := dao.ctx.GetCoreDB().Where("user_id = ? AND chart_id = ? AND type = ? AND reason = ? AND date >= ?",
+		dao.ctx.GetUser().GetId(), chartId, tradeType, reason, since).Order("date desc, id desc").
+		Limit(1).Find(&rejections).Error; err != nil {
+		return nil, err
+	}
+	if len(rejections) == 0 {
+		return nil, nil
+	}
+	return &rejections[0], nil
+}
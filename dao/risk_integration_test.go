@@ -0,0 +1,111 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskDAO_Limits(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	riskDAO := NewRiskDAO(ctx)
+	global := &entity.RiskLimit{
+		UserId:           1,
+		MaxPositionSize:  "5000",
+		MaxTradesPerHour: 4,
+		KillSwitch:       common.KILL_SWITCH_HALT,
+		Exposures: []entity.RiskExposure{
+			{Currency: "BTC", MaxQuantity: "1.5"},
+			{Currency: "ETH", MaxQuantity: "20"}}}
+	assert.Nil(t, riskDAO.SaveLimit(global))
+	assert.Equal(t, uint(1), global.GetId())
+	chart := &entity.RiskLimit{
+		UserId:       1,
+		ChartId:      1,
+		MaxDailyLoss: "100"}
+	assert.Nil(t, riskDAO.SaveLimit(chart))
+
+	persisted, err := riskDAO.GetLimit(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "5000", persisted.GetMaxPositionSize())
+	assert.Equal(t, 4, persisted.GetMaxTradesPerHour())
+	assert.Equal(t, common.KILL_SWITCH_HALT, persisted.GetKillSwitch())
+	assert.Equal(t, 2, len(persisted.GetExposures()))
+	assert.Equal(t, "BTC", persisted.GetExposures()[0].GetCurrency())
+	assert.Equal(t, "1.5", persisted.GetExposures()[0].GetMaxQuantity())
+
+	// Saving replaces the exposure caps
+	global.KillSwitch = common.KILL_SWITCH_OFF
+	global.Exposures = []entity.RiskExposure{{Currency: "ETH", MaxQuantity: "10"}}
+	assert.Nil(t, riskDAO.SaveLimit(global))
+	persisted, err = riskDAO.GetLimit(0)
+	assert.Nil(t, err)
+	assert.Equal(t, common.KILL_SWITCH_OFF, persisted.GetKillSwitch())
+	assert.Equal(t, 1, len(persisted.GetExposures()))
+	assert.Equal(t, "10", persisted.GetExposures()[0].GetMaxQuantity())
+
+	limits, err := riskDAO.FindLimits()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(limits))
+	assert.Equal(t, uint(0), limits[0].GetChartId())
+	assert.Equal(t, 1, len(limits[0].GetExposures()))
+	assert.Equal(t, "100", limits[1].GetMaxDailyLoss())
+
+	persisted, err = riskDAO.GetLimit(2)
+	assert.Nil(t, err)
+	assert.Nil(t, persisted)
+
+	assert.Nil(t, riskDAO.DeleteLimit(global))
+	persisted, err = riskDAO.GetLimit(0)
+	assert.Nil(t, err)
+	assert.Nil(t, persisted)
+	limits, err = riskDAO.FindLimits()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(limits))
+
+	CleanupIntegrationTest()
+}
+
+func TestRiskDAO_Rejections(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	riskDAO := NewRiskDAO(ctx)
+	now := time.Now()
+	for i, reason := range []string{"first", "second", "third"} {
+		assert.Nil(t, riskDAO.CreateRejection(&entity.RiskRejection{
+			UserId:   1,
+			ChartId:  1,
+			Date:     now.Add(time.Duration(i) * time.Minute),
+			Base:     "BTC",
+			Quote:    "USD",
+			Type:     common.BUY_ORDER_TYPE,
+			Price:    "10000",
+			Quantity: "0.5",
+			Reason:   reason}))
+	}
+
+	rejections, err := riskDAO.FindRejections(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rejections))
+	assert.Equal(t, "third", rejections[0].GetReason())
+	assert.Equal(t, "second", rejections[1].GetReason())
+	assert.Equal(t, "0.5", rejections[0].GetQuantity())
+
+	rejection, err := riskDAO.GetRecentRejection(1, common.BUY_ORDER_TYPE, "second", now)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", rejection.GetReason())
+	rejection, err = riskDAO.GetRecentRejection(1, common.BUY_ORDER_TYPE, "first", now.Add(time.Second))
+	assert.Nil(t, err)
+	assert.Nil(t, rejection)
+	rejection, err = riskDAO.GetRecentRejection(1, common.SELL_ORDER_TYPE, "third", now)
+	assert.Nil(t, err)
+	assert.Nil(t, rejection)
+
+	CleanupIntegrationTest()
+}
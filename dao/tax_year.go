@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type TaxYearDAO interface {
+	Get(year int) (entity.TaxYearEntity, error)
+	Find() ([]entity.TaxYear, error)
+	Close(taxYear entity.TaxYearEntity, lots []entity.TaxLot, lineItems []entity.TaxLineItem) error
+	Reopen(year int) error
+	FindLots(year int) ([]entity.TaxLot, error)
+	FindLineItems(year int) ([]entity.TaxLineItem, error)
+}
+
+type TaxYearDAOImpl struct {
+	ctx common.Context
+	TaxYearDAO
+}
+
+func NewTaxYearDAO(ctx common.Context) TaxYearDAO {
+	return &TaxYearDAOImpl{ctx: ctx}
+}
+
+func (dao *TaxYearDAOImpl) Get(year int) (entity.TaxYearEntity, error) {
+	var taxYear entity.TaxYear
+	if err := dao.ctx.GetCoreDB().Where("user_id = ? AND year = ?", dao.ctx.GetUser().GetId(), year).
+		First(&taxYear).Error; err != nil {
+		return nil, err
+	}
+	return &taxYear, nil
+}
+
+func (dao *TaxYearDAOImpl) Find() ([]entity.TaxYear, error) {
+	var taxYears []entity.TaxYear
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Order("year asc").Model(daoUser).Related(&taxYears).Error; err != nil {
+		return nil, err
+	}
+	return taxYears, nil
+}
+
+// Close persists the year end lot snapshot and Form 8949 line items along with
+// the closed tax year in a single database transaction.
+func (dao *TaxYearDAOImpl) Close(taxYear entity.TaxYearEntity, lots []entity.TaxLot, lineItems []entity.TaxLineItem) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Create(taxYear).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	for i := range lots {
+		if err := db.Create(&lots[i]).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	for i := range lineItems {
+		if err := db.Create(&lineItems[i]).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	return db.Commit().Error
+}
+
+func (dao *TaxYearDAOImpl) Reopen(year int) error {
+	userId := dao.ctx.GetUser().GetId()
+	db := dao.ctx.GetCoreDB().Begin()
+	if err := db.Where("tax_line_item_id IN (SELECT id FROM tax_line_items WHERE user_id = ? AND year = ?)", userId, year).
+		Delete(&entity.TaxLineItemLot{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("user_id = ? AND year = ?", userId, year).Delete(&entity.TaxLineItem{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("user_id = ? AND year = ?", userId, year).Delete(&entity.TaxLot{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Where("user_id = ? AND year = ?", userId, year).Delete(&entity.TaxYear{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	return db.Commit().Error
+}
+
+func (dao *TaxYearDAOImpl) FindLots(year int) ([]entity.TaxLot, error) {
+	var lots []entity.TaxLot
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Where("year = ?", year).Order("date asc, id asc").
+		Model(daoUser).Related(&lots).Error; err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (dao *TaxYearDAOImpl) FindLineItems(year int) ([]entity.TaxLineItem, error) {
+	var lineItems []entity.TaxLineItem
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := dao.ctx.GetCoreDB().Where("year = ?", year).Order("id asc").
+		Model(daoUser).Related(&lineItems).Error; err != nil {
+		return nil, err
+	}
+	for i, lineItem := range lineItems {
+		var lots []entity.TaxLineItemLot
+		if err := dao.ctx.GetCoreDB().Order("id asc").Model(&lineItem).Related(&lots).Error; err != nil {
+			return nil, err
+		}
+		lineItems[i].SetLots(lots)
+	}
+	return lineItems, nil
+}
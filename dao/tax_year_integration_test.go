@@ -0,0 +1,92 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxYearDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	taxYearDAO := NewTaxYearDAO(ctx)
+	taxYear := &entity.TaxYear{
+		UserId:   1,
+		Year:     2017,
+		Method:   "fifo",
+		ClosedAt: time.Now()}
+	lots := []entity.TaxLot{
+		entity.TaxLot{
+			UserId:        1,
+			Year:          2017,
+			TransactionId: "buy-1",
+			Date:          time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC),
+			Currency:      "BTC",
+			Quantity:      "0.5",
+			UnitPrice:     "1000",
+			CostBasis:     "500"}}
+	lineItems := []entity.TaxLineItem{
+		entity.TaxLineItem{
+			UserId:        1,
+			Year:          2017,
+			TransactionId: "sell-1",
+			Holding:       "short",
+			Currency:      "BTC",
+			Proceeds:      "5000.00",
+			CostBasis:     "500.00",
+			GainOrLoss:    "4500.00",
+			Lots: []entity.TaxLineItemLot{
+				entity.TaxLineItemLot{
+					TransactionId: "buy-0",
+					Date:          time.Date(2016, 12, 01, 0, 0, 0, 0, time.UTC),
+					Quantity:      "0.5",
+					CostBasis:     "500.00",
+					Fee:           "0.00"}}}}
+
+	_, err := taxYearDAO.Get(2017)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, taxYearDAO.Close(taxYear, lots, lineItems))
+
+	persisted, err := taxYearDAO.Get(2017)
+	assert.Nil(t, err)
+	assert.Equal(t, "fifo", persisted.GetMethod())
+
+	taxYears, err := taxYearDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(taxYears))
+
+	persistedLots, err := taxYearDAO.FindLots(2017)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(persistedLots))
+	assert.Equal(t, "buy-1", persistedLots[0].GetTransactionId())
+	assert.Equal(t, "500", persistedLots[0].GetCostBasis())
+
+	persistedLineItems, err := taxYearDAO.FindLineItems(2017)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(persistedLineItems))
+	assert.Equal(t, "4500.00", persistedLineItems[0].GetGainOrLoss())
+	assert.Equal(t, "sell-1", persistedLineItems[0].GetTransactionId())
+	assert.Equal(t, 1, len(persistedLineItems[0].GetLots()))
+	assert.Equal(t, "buy-0", persistedLineItems[0].GetLots()[0].GetTransactionId())
+	assert.Equal(t, "500.00", persistedLineItems[0].GetLots()[0].GetCostBasis())
+
+	assert.Nil(t, taxYearDAO.Reopen(2017))
+
+	_, err = taxYearDAO.Get(2017)
+	assert.NotNil(t, err)
+
+	persistedLots, err = taxYearDAO.FindLots(2017)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(persistedLots))
+
+	persistedLineItems, err = taxYearDAO.FindLineItems(2017)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(persistedLineItems))
+
+	CleanupIntegrationTest()
+}
@@ -6,7 +6,7 @@ import (
 )
 
 type TradeDAO interface {
-	Create(trade entity.TradeEntity)
+	Create(trade entity.TradeEntity) error
 	Save(trade entity.TradeEntity)
 	Update(trade entity.TradeEntity)
 	Find(user common.UserContext) []entity.Trade
@@ -23,10 +23,12 @@ func NewTradeDAO(ctx common.Context) TradeDAO {
 	return &TradeDAOImpl{ctx: ctx}
 }
 
-func (dao *TradeDAOImpl) Create(trade entity.TradeEntity) {
+func (dao *TradeDAOImpl) Create(trade entity.TradeEntity) error {
 	if err := dao.ctx.GetCoreDB().Create(trade).Error; err != nil {
 		dao.ctx.GetLogger().Errorf("[TradeDAOImpl.Create] Error:%s", err.Error())
+		return err
 	}
+	return nil
 }
 
 func (dao *TradeDAOImpl) Save(trade entity.TradeEntity) {
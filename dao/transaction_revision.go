@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type TransactionRevisionDAO interface {
+	Create(revision entity.TransactionRevisionEntity) error
+	Find(transactionId string) ([]entity.TransactionRevision, error)
+	Save(transactions []entity.TransactionEntity, revisions []entity.TransactionRevisionEntity) error
+}
+
+type TransactionRevisionDAOImpl struct {
+	ctx common.Context
+	TransactionRevisionDAO
+}
+
+func NewTransactionRevisionDAO(ctx common.Context) TransactionRevisionDAO {
+	return &TransactionRevisionDAOImpl{ctx: ctx}
+}
+
+func (dao *TransactionRevisionDAOImpl) Create(revision entity.TransactionRevisionEntity) error {
+	return dao.ctx.GetCoreDB().Create(revision).Error
+}
+
+// Find returns the revisions of a transaction in the order they were made, or
+// every revision the user has made when no transaction id is given.
+func (dao *TransactionRevisionDAOImpl) Find(transactionId string) ([]entity.TransactionRevision, error) {
+	var revisions []entity.TransactionRevision
+	db := dao.ctx.GetCoreDB()
+	if transactionId != "" {
+		db = db.Where("transaction_id = ?", transactionId)
+	}
+	daoUser := &entity.User{Id: dao.ctx.GetUser().GetId()}
+	if err := db.Order("id asc").Model(daoUser).Related(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// Save persists changes to existing transactions along with the revisions
+// recording them in a single database transaction.
+func (dao *TransactionRevisionDAOImpl) Save(transactions []entity.TransactionEntity, revisions []entity.TransactionRevisionEntity) error {
+	db := dao.ctx.GetCoreDB().Begin()
+	for _, tx := range transactions {
+		if err := db.Save(tx).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	for _, revision := range revisions {
+		if err := db.Create(revision).Error; err != nil {
+			db.Rollback()
+			return err
+		}
+	}
+	return db.Commit().Error
+}
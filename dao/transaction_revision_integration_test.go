@@ -0,0 +1,57 @@
+// +build integration
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionRevisionDAO(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	revisionDAO := NewTransactionRevisionDAO(ctx)
+	create := &entity.TransactionRevision{
+		UserId:        1,
+		TransactionId: "manual-1",
+		Action:        common.TX_REVISION_CREATE,
+		Date:          time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+		Current:       `{"id":"manual-1","quantity":"1"}`}
+	update := &entity.TransactionRevision{
+		UserId:        1,
+		TransactionId: "manual-2",
+		Action:        common.TX_REVISION_UPDATE,
+		Date:          time.Date(2018, 01, 02, 0, 0, 0, 0, time.UTC),
+		Previous:      `{"id":"manual-2","quantity":"1"}`,
+		Current:       `{"id":"manual-2","quantity":"2"}`}
+	deleted := &entity.TransactionRevision{
+		UserId:        1,
+		TransactionId: "manual-1",
+		Action:        common.TX_REVISION_DELETE,
+		Date:          time.Date(2018, 01, 03, 0, 0, 0, 0, time.UTC),
+		Previous:      `{"id":"manual-1","quantity":"1"}`,
+		Current:       `{"id":"manual-1","quantity":"1","deleted":true}`}
+
+	assert.Nil(t, revisionDAO.Create(create))
+	assert.Nil(t, revisionDAO.Create(update))
+	assert.Nil(t, revisionDAO.Create(deleted))
+	assert.Equal(t, uint(3), deleted.GetId())
+
+	revisions, err := revisionDAO.Find("manual-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, common.TX_REVISION_CREATE, revisions[0].GetAction())
+	assert.Equal(t, "", revisions[0].GetPrevious())
+	assert.Equal(t, common.TX_REVISION_DELETE, revisions[1].GetAction())
+
+	revisions, err = revisionDAO.Find("")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, "manual-2", revisions[1].GetTransactionId())
+
+	CleanupIntegrationTest()
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BacktestOptionsDTO describes the date range, candle source and simulated
+// account used to backtest a chart. Zero values fall back to the paper trading
+// defaults, except for the fee and slippage rates, which only fall back when
+// they are not given so that a backtest can be run without either.
+type BacktestOptionsDTO struct {
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Source   string           `json:"source"`
+	Balance  decimal.Decimal  `json:"balance"`
+	Fee      *decimal.Decimal `json:"fee,omitempty"`
+	Slippage *decimal.Decimal `json:"slippage,omitempty"`
+	Warmup   int              `json:"warmup"`
+}
+
+type BacktestTradeDTO struct {
+	Date     time.Time       `json:"date"`
+	Type     string          `json:"type"`
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Fee      decimal.Decimal `json:"fee"`
+	Profit   decimal.Decimal `json:"profit"`
+}
+
+type BacktestEquityDTO struct {
+	Date   time.Time       `json:"date"`
+	Price  decimal.Decimal `json:"price"`
+	Equity decimal.Decimal `json:"equity"`
+}
+
+// BacktestResultDTO holds the simulated trades, the equity curve valued in the
+// quote currency at each candle close, and the summary stats. Return, MaxDrawdown
+// and WinRate are ratios (0.05 = 5%). Sharpe is annualized from per-candle returns
+// with a risk free rate of zero.
+type BacktestResultDTO struct {
+	ChartId         uint                `json:"chart_id"`
+	Exchange        string              `json:"exchange"`
+	Base            string              `json:"base"`
+	Quote           string              `json:"quote"`
+	Period          int                 `json:"period"`
+	Start           time.Time           `json:"start"`
+	End             time.Time           `json:"end"`
+	Candles         int                 `json:"candles"`
+	StartingBalance decimal.Decimal     `json:"starting_balance"`
+	EndingBalance   decimal.Decimal     `json:"ending_balance"`
+	Fees            decimal.Decimal     `json:"fees"`
+	Return          decimal.Decimal     `json:"return"`
+	MaxDrawdown     decimal.Decimal     `json:"max_drawdown"`
+	WinRate         decimal.Decimal     `json:"win_rate"`
+	Sharpe          decimal.Decimal     `json:"sharpe"`
+	Trades          []BacktestTradeDTO  `json:"trades"`
+	Equity          []BacktestEquityDTO `json:"equity"`
+}
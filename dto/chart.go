@@ -53,7 +53,11 @@ func (chart ChartDTO) GetAutoTrade() uint {
 }
 
 func (chart ChartDTO) IsAutoTrade() bool {
-	return chart.AutoTrade == 1
+	return chart.AutoTrade != common.AUTOTRADE_DISABLED
+}
+
+func (chart ChartDTO) IsPaperTrade() bool {
+	return chart.AutoTrade == common.AUTOTRADE_PAPER
 }
 
 func (chart ChartDTO) GetIndicators() []common.ChartIndicator {
@@ -0,0 +1,118 @@
+package dto
+
+import "github.com/jeremyhahn/tradebot/common"
+
+// CSVTemplateDTO maps the columns of an exchange's CSV export to transaction
+// fields. Columns are referenced by their header names.
+type CSVTemplateDTO struct {
+	Id                    uint              `json:"id,omitempty"`
+	UserId                uint              `json:"user_id,omitempty"`
+	Name                  string            `json:"name"`
+	Network               string            `json:"network"`
+	IdColumn              string            `json:"id_column"`
+	DateColumn            string            `json:"date_column"`
+	DateFormat            string            `json:"date_format"`
+	TimeZone              string            `json:"time_zone"`
+	TypeColumn            string            `json:"type_column"`
+	Types                 map[string]string `json:"types"`
+	CurrencyPairColumn    string            `json:"currency_pair_column"`
+	CurrencyPairSeparator string            `json:"currency_pair_separator"`
+	QuoteFirst            bool              `json:"quote_first"`
+	BaseColumn            string            `json:"base_column"`
+	QuoteColumn           string            `json:"quote_column"`
+	QuantityColumn        string            `json:"quantity_column"`
+	PriceColumn           string            `json:"price_column"`
+	TotalColumn           string            `json:"total_column"`
+	FeeColumn             string            `json:"fee_column"`
+	FeeCurrencyColumn     string            `json:"fee_currency_column"`
+	FeeCurrency           string            `json:"fee_currency"`
+	common.CSVTemplate    `json:"-"`
+}
+
+func NewCSVTemplateDTO() common.CSVTemplate {
+	return &CSVTemplateDTO{}
+}
+
+func (dto *CSVTemplateDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *CSVTemplateDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *CSVTemplateDTO) GetName() string {
+	return dto.Name
+}
+
+func (dto *CSVTemplateDTO) GetNetwork() string {
+	return dto.Network
+}
+
+func (dto *CSVTemplateDTO) GetIdColumn() string {
+	return dto.IdColumn
+}
+
+func (dto *CSVTemplateDTO) GetDateColumn() string {
+	return dto.DateColumn
+}
+
+func (dto *CSVTemplateDTO) GetDateFormat() string {
+	return dto.DateFormat
+}
+
+func (dto *CSVTemplateDTO) GetTimeZone() string {
+	return dto.TimeZone
+}
+
+func (dto *CSVTemplateDTO) GetTypeColumn() string {
+	return dto.TypeColumn
+}
+
+func (dto *CSVTemplateDTO) GetTypes() map[string]string {
+	return dto.Types
+}
+
+func (dto *CSVTemplateDTO) GetCurrencyPairColumn() string {
+	return dto.CurrencyPairColumn
+}
+
+func (dto *CSVTemplateDTO) GetCurrencyPairSeparator() string {
+	return dto.CurrencyPairSeparator
+}
+
+func (dto *CSVTemplateDTO) IsQuoteFirst() bool {
+	return dto.QuoteFirst
+}
+
+func (dto *CSVTemplateDTO) GetBaseColumn() string {
+	return dto.BaseColumn
+}
+
+func (dto *CSVTemplateDTO) GetQuoteColumn() string {
+	return dto.QuoteColumn
+}
+
+func (dto *CSVTemplateDTO) GetQuantityColumn() string {
+	return dto.QuantityColumn
+}
+
+func (dto *CSVTemplateDTO) GetPriceColumn() string {
+	return dto.PriceColumn
+}
+
+func (dto *CSVTemplateDTO) GetTotalColumn() string {
+	return dto.TotalColumn
+}
+
+func (dto *CSVTemplateDTO) GetFeeColumn() string {
+	return dto.FeeColumn
+}
+
+func (dto *CSVTemplateDTO) GetFeeCurrencyColumn() string {
+	return dto.FeeCurrencyColumn
+}
+
+func (dto *CSVTemplateDTO) GetFeeCurrency() string {
+	return dto.FeeCurrency
+}
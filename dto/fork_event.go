@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+type ForkEventDTO struct {
+	Id               uint            `json:"id"`
+	UserId           uint            `json:"user_id"`
+	Category         string          `json:"category"`
+	Currency         string          `json:"currency"`
+	NewCurrency      string          `json:"new_currency"`
+	Date             time.Time       `json:"date"`
+	Ratio            decimal.Decimal `json:"ratio"`
+	CostBasis        decimal.Decimal `json:"cost_basis"`
+	common.ForkEvent `json:"-"`
+}
+
+func NewForkEventDTO() common.ForkEvent {
+	return &ForkEventDTO{}
+}
+
+func (dto *ForkEventDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *ForkEventDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *ForkEventDTO) GetCategory() string {
+	return dto.Category
+}
+
+func (dto *ForkEventDTO) GetCurrency() string {
+	return dto.Currency
+}
+
+func (dto *ForkEventDTO) GetNewCurrency() string {
+	return dto.NewCurrency
+}
+
+func (dto *ForkEventDTO) GetDate() time.Time {
+	return dto.Date
+}
+
+func (dto *ForkEventDTO) GetRatio() decimal.Decimal {
+	return dto.Ratio
+}
+
+func (dto *ForkEventDTO) GetCostBasis() decimal.Decimal {
+	return dto.CostBasis
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+type LotSelectionDTO struct {
+	Id                  uint            `json:"id"`
+	UserId              uint            `json:"user_id"`
+	SaleTransactionId   string          `json:"sale_transaction_id"`
+	BuyTransactionId    string          `json:"buy_transaction_id"`
+	Currency            string          `json:"currency"`
+	Quantity            decimal.Decimal `json:"quantity"`
+	common.LotSelection `json:"-"`
+}
+
+func NewLotSelectionDTO() common.LotSelection {
+	return &LotSelectionDTO{}
+}
+
+func (dto *LotSelectionDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *LotSelectionDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *LotSelectionDTO) GetSaleTransactionId() string {
+	return dto.SaleTransactionId
+}
+
+func (dto *LotSelectionDTO) GetBuyTransactionId() string {
+	return dto.BuyTransactionId
+}
+
+func (dto *LotSelectionDTO) GetCurrency() string {
+	return dto.Currency
+}
+
+func (dto *LotSelectionDTO) GetQuantity() decimal.Decimal {
+	return dto.Quantity
+}
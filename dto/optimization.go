@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OptimizationParameterDTO declares the values to search for one positional
+// parameter of the strategy or one of its required indicators. Plugin is the
+// strategy or indicator name and Index the parameter's position. Values lists
+// the candidates explicitly, otherwise they are stepped from Min to Max.
+type OptimizationParameterDTO struct {
+	Plugin string          `json:"plugin"`
+	Index  int             `json:"index"`
+	Min    decimal.Decimal `json:"min"`
+	Max    decimal.Decimal `json:"max"`
+	Step   decimal.Decimal `json:"step"`
+	Values []string        `json:"values"`
+}
+
+// OptimizationOptionsDTO describes an optimization of one of a chart's
+// strategies. The embedded backtest options select the candles and simulated
+// account used to evaluate each parameter set.
+type OptimizationOptionsDTO struct {
+	BacktestOptionsDTO
+	Strategy   string                     `json:"strategy"`
+	Parameters []OptimizationParameterDTO `json:"parameters"`
+	Method     string                     `json:"method"`
+	Objective  string                     `json:"objective"`
+	Iterations int                        `json:"iterations"`
+	Folds      int                        `json:"folds"`
+	Seed       int64                      `json:"seed"`
+	Top        int                        `json:"top"`
+	Save       bool                       `json:"save"`
+}
+
+// OptimizationParameterSetDTO holds the comma separated parameters of a strategy
+// and its required indicators, keyed by indicator name.
+type OptimizationParameterSetDTO struct {
+	Strategy   string              `json:"strategy"`
+	Parameters []string            `json:"parameters"`
+	Indicators map[string][]string `json:"indicators"`
+}
+
+// OptimizationCandidateDTO scores a parameter set on the last walk-forward fold.
+// TrainScore is the objective over the fold's in-sample window and TestScore
+// and Trades over the out-of-sample window that follows it.
+type OptimizationCandidateDTO struct {
+	Parameters OptimizationParameterSetDTO `json:"parameters"`
+	TrainScore decimal.Decimal             `json:"train_score"`
+	TestScore  decimal.Decimal             `json:"test_score"`
+	Trades     int                         `json:"trades"`
+}
+
+// OptimizationFoldDTO records the parameter set that scored best on a fold's
+// in-sample window and how it performed on the out-of-sample window after it.
+type OptimizationFoldDTO struct {
+	Fold       int                         `json:"fold"`
+	TrainStart time.Time                   `json:"train_start"`
+	TrainEnd   time.Time                   `json:"train_end"`
+	TestStart  time.Time                   `json:"test_start"`
+	TestEnd    time.Time                   `json:"test_end"`
+	Parameters OptimizationParameterSetDTO `json:"parameters"`
+	TrainScore decimal.Decimal             `json:"train_score"`
+	TestScore  decimal.Decimal             `json:"test_score"`
+}
+
+// OptimizationResultDTO ranks the evaluated parameter sets by their score on the
+// most recent in-sample window. OutOfSampleScore is the mean score of each
+// fold's winner on the window after the one it was chosen on. Scores are the
+// objective's value, negated for max_drawdown so a higher score is always better.
+type OptimizationResultDTO struct {
+	ChartId          uint                       `json:"chart_id"`
+	Strategy         string                     `json:"strategy"`
+	Method           string                     `json:"method"`
+	Objective        string                     `json:"objective"`
+	Folds            int                        `json:"folds"`
+	Candidates       int                        `json:"candidates"`
+	Failed           int                        `json:"failed"`
+	Saved            bool                       `json:"saved"`
+	Best             OptimizationCandidateDTO   `json:"best"`
+	Ranking          []OptimizationCandidateDTO `json:"ranking"`
+	WalkForward      []OptimizationFoldDTO      `json:"walk_forward"`
+	OutOfSampleScore decimal.Decimal            `json:"out_of_sample_score"`
+}
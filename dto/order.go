@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+type OrderDTO struct {
+	Id             string               `json:"id"`
+	Exchange       string               `json:"exchange"`
+	CurrencyPair   *common.CurrencyPair `json:"currency_pair"`
+	Side           string               `json:"side"`
+	Type           string               `json:"type"`
+	Status         string               `json:"status"`
+	Date           time.Time            `json:"date"`
+	Quantity       decimal.Decimal      `json:"quantity"`
+	Price          decimal.Decimal      `json:"price"`
+	FilledQuantity decimal.Decimal      `json:"filled_quantity"`
+	FillPrice      decimal.Decimal      `json:"fill_price"`
+	Fee            decimal.Decimal      `json:"fee"`
+	FeeCurrency    string               `json:"fee_currency"`
+	common.Order   `json:"-"`
+}
+
+func NewOrderDTO() common.Order {
+	return &OrderDTO{}
+}
+
+func (dto *OrderDTO) GetId() string {
+	return dto.Id
+}
+
+func (dto *OrderDTO) GetExchange() string {
+	return dto.Exchange
+}
+
+func (dto *OrderDTO) GetCurrencyPair() *common.CurrencyPair {
+	return dto.CurrencyPair
+}
+
+func (dto *OrderDTO) GetSide() string {
+	return dto.Side
+}
+
+func (dto *OrderDTO) GetType() string {
+	return dto.Type
+}
+
+func (dto *OrderDTO) GetStatus() string {
+	return dto.Status
+}
+
+func (dto *OrderDTO) GetDate() time.Time {
+	return dto.Date
+}
+
+func (dto *OrderDTO) GetQuantity() decimal.Decimal {
+	return dto.Quantity
+}
+
+func (dto *OrderDTO) GetPrice() decimal.Decimal {
+	return dto.Price
+}
+
+func (dto *OrderDTO) GetFilledQuantity() decimal.Decimal {
+	return dto.FilledQuantity
+}
+
+func (dto *OrderDTO) GetFillPrice() decimal.Decimal {
+	return dto.FillPrice
+}
+
+func (dto *OrderDTO) GetFee() decimal.Decimal {
+	return dto.Fee
+}
+
+func (dto *OrderDTO) GetFeeCurrency() string {
+	return dto.FeeCurrency
+}
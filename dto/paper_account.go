@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+type PaperAccountDTO struct {
+	Id                  uint                       `json:"id"`
+	UserId              uint                       `json:"user_id"`
+	Exchange            string                     `json:"exchange"`
+	Fee                 decimal.Decimal            `json:"fee"`
+	Slippage            decimal.Decimal            `json:"slippage"`
+	Balances            map[string]decimal.Decimal `json:"balances"`
+	common.PaperAccount `json:"-"`
+}
+
+func NewPaperAccountDTO() common.PaperAccount {
+	return &PaperAccountDTO{}
+}
+
+func (dto *PaperAccountDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *PaperAccountDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *PaperAccountDTO) GetExchange() string {
+	return dto.Exchange
+}
+
+func (dto *PaperAccountDTO) GetFee() decimal.Decimal {
+	return dto.Fee
+}
+
+func (dto *PaperAccountDTO) GetSlippage() decimal.Decimal {
+	return dto.Slippage
+}
+
+func (dto *PaperAccountDTO) GetBalances() map[string]decimal.Decimal {
+	return dto.Balances
+}
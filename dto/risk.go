@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+type RiskLimitDTO struct {
+	Id                uint                       `json:"id"`
+	UserId            uint                       `json:"user_id"`
+	ChartId           uint                       `json:"chart_id"`
+	MaxPositionSize   decimal.Decimal            `json:"max_position_size"`
+	MaxDailyLoss      decimal.Decimal            `json:"max_daily_loss"`
+	MaxTradesPerHour  int                        `json:"max_trades_per_hour"`
+	MinBalanceReserve decimal.Decimal            `json:"min_balance_reserve"`
+	KillSwitch        string                     `json:"kill_switch"`
+	Exposures         map[string]decimal.Decimal `json:"exposures"`
+	common.RiskLimit  `json:"-"`
+}
+
+func NewRiskLimitDTO() common.RiskLimit {
+	return &RiskLimitDTO{}
+}
+
+func (dto *RiskLimitDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *RiskLimitDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *RiskLimitDTO) GetChartId() uint {
+	return dto.ChartId
+}
+
+func (dto *RiskLimitDTO) GetMaxPositionSize() decimal.Decimal {
+	return dto.MaxPositionSize
+}
+
+func (dto *RiskLimitDTO) GetMaxDailyLoss() decimal.Decimal {
+	return dto.MaxDailyLoss
+}
+
+func (dto *RiskLimitDTO) GetMaxTradesPerHour() int {
+	return dto.MaxTradesPerHour
+}
+
+func (dto *RiskLimitDTO) GetMinBalanceReserve() decimal.Decimal {
+	return dto.MinBalanceReserve
+}
+
+func (dto *RiskLimitDTO) GetKillSwitch() string {
+	return dto.KillSwitch
+}
+
+func (dto *RiskLimitDTO) GetExposures() map[string]decimal.Decimal {
+	return dto.Exposures
+}
+
+type RiskRejectionDTO struct {
+	Id                   uint            `json:"id"`
+	UserId               uint            `json:"user_id"`
+	ChartId              uint            `json:"chart_id"`
+	Date                 time.Time       `json:"date"`
+	Base                 string          `json:"base"`
+	Quote                string          `json:"quote"`
+	Type                 string          `json:"type"`
+	Price                decimal.Decimal `json:"price"`
+	Quantity             decimal.Decimal `json:"quantity"`
+	Reason               string          `json:"reason"`
+	common.RiskRejection `json:"-"`
+}
+
+func (dto *RiskRejectionDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *RiskRejectionDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *RiskRejectionDTO) GetChartId() uint {
+	return dto.ChartId
+}
+
+func (dto *RiskRejectionDTO) GetDate() time.Time {
+	return dto.Date
+}
+
+func (dto *RiskRejectionDTO) GetBase() string {
+	return dto.Base
+}
+
+func (dto *RiskRejectionDTO) GetQuote() string {
+	return dto.Quote
+}
+
+func (dto *RiskRejectionDTO) GetType() string {
+	return dto.Type
+}
+
+func (dto *RiskRejectionDTO) GetPrice() decimal.Decimal {
+	return dto.Price
+}
+
+func (dto *RiskRejectionDTO) GetQuantity() decimal.Decimal {
+	return dto.Quantity
+}
+
+func (dto *RiskRejectionDTO) GetReason() string {
+	return dto.Reason
+}
@@ -34,6 +34,7 @@ type TransactionDTO struct {
 	TotalCurrency          string               `json:"total_currency"`
 	FiatTotal              string               `json:"fiat_total"`
 	FiatTotalCurrency      string               `json:"fiat_total_currency"`
+	TransferId             string               `json:"transfer_id"`
 	Deleted                bool                 `json:"deleted"`
 	common.Transaction     `json:"-"`
 }
@@ -146,6 +147,10 @@ func (t *TransactionDTO) GetFiatTotalCurrency() string {
 	return t.FiatTotalCurrency
 }
 
+func (t *TransactionDTO) GetTransferId() string {
+	return t.TransferId
+}
+
 func (t *TransactionDTO) IsDeleted() bool {
 	return t.Deleted
 }
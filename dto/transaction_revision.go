@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+)
+
+type TransactionRevisionDTO struct {
+	Id                         uint               `json:"id"`
+	UserId                     uint               `json:"user_id"`
+	TransactionId              string             `json:"transaction_id"`
+	Action                     string             `json:"action"`
+	Date                       time.Time          `json:"date"`
+	Previous                   common.Transaction `json:"previous"`
+	Current                    common.Transaction `json:"current"`
+	common.TransactionRevision `json:"-"`
+}
+
+func NewTransactionRevisionDTO() common.TransactionRevision {
+	return &TransactionRevisionDTO{}
+}
+
+func (dto *TransactionRevisionDTO) GetId() uint {
+	return dto.Id
+}
+
+func (dto *TransactionRevisionDTO) GetUserId() uint {
+	return dto.UserId
+}
+
+func (dto *TransactionRevisionDTO) GetTransactionId() string {
+	return dto.TransactionId
+}
+
+func (dto *TransactionRevisionDTO) GetAction() string {
+	return dto.Action
+}
+
+func (dto *TransactionRevisionDTO) GetDate() time.Time {
+	return dto.Date
+}
+
+func (dto *TransactionRevisionDTO) GetPrevious() common.Transaction {
+	return dto.Previous
+}
+
+func (dto *TransactionRevisionDTO) GetCurrent() common.Transaction {
+	return dto.Current
+}
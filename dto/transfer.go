@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/jeremyhahn/tradebot/common"
+
+type TransferDTO struct {
+	Withdrawal      common.Transaction `json:"withdrawal"`
+	Deposit         common.Transaction `json:"deposit"`
+	NetworkFee      string             `json:"network_fee"`
+	common.Transfer `json:"-"`
+}
+
+func (dto *TransferDTO) GetWithdrawal() common.Transaction {
+	return dto.Withdrawal
+}
+
+func (dto *TransferDTO) GetDeposit() common.Transaction {
+	return dto.Deposit
+}
+
+func (dto *TransferDTO) GetNetworkFee() string {
+	return dto.NetworkFee
+}
@@ -80,5 +80,5 @@ func (entity *Chart) GetAutoTrade() uint {
 }
 
 func (entity *Chart) IsAutoTrade() bool {
-	return entity.AutoTrade == 1
+	return entity.AutoTrade != 0
 }
@@ -0,0 +1,110 @@
+package entity
+
+type CSVTemplate struct {
+	Id                    uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId                uint   `gorm:"unique_index:idx_csv_template_user_name"`
+	Name                  string `gorm:"type:varchar(64);unique_index:idx_csv_template_user_name"`
+	Network               string `gorm:"type:varchar(64)"`
+	IdColumn              string `gorm:"type:varchar(64)"`
+	DateColumn            string `gorm:"type:varchar(64)"`
+	DateFormat            string `gorm:"type:varchar(64)"`
+	TimeZone              string `gorm:"type:varchar(64)"`
+	TypeColumn            string `gorm:"type:varchar(64)"`
+	Types                 string `gorm:"type:text"`
+	CurrencyPairColumn    string `gorm:"type:varchar(64)"`
+	CurrencyPairSeparator string `gorm:"type:varchar(8)"`
+	QuoteFirst            bool
+	BaseColumn            string `gorm:"type:varchar(64)"`
+	QuoteColumn           string `gorm:"type:varchar(64)"`
+	QuantityColumn        string `gorm:"type:varchar(64)"`
+	PriceColumn           string `gorm:"type:varchar(64)"`
+	TotalColumn           string `gorm:"type:varchar(64)"`
+	FeeColumn             string `gorm:"type:varchar(64)"`
+	FeeCurrencyColumn     string `gorm:"type:varchar(64)"`
+	FeeCurrency           string `gorm:"type:varchar(6)"`
+	CSVTemplateEntity
+}
+
+func (entity *CSVTemplate) GetId() uint {
+	return entity.Id
+}
+
+func (entity *CSVTemplate) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *CSVTemplate) GetName() string {
+	return entity.Name
+}
+
+func (entity *CSVTemplate) GetNetwork() string {
+	return entity.Network
+}
+
+func (entity *CSVTemplate) GetIdColumn() string {
+	return entity.IdColumn
+}
+
+func (entity *CSVTemplate) GetDateColumn() string {
+	return entity.DateColumn
+}
+
+func (entity *CSVTemplate) GetDateFormat() string {
+	return entity.DateFormat
+}
+
+func (entity *CSVTemplate) GetTimeZone() string {
+	return entity.TimeZone
+}
+
+func (entity *CSVTemplate) GetTypeColumn() string {
+	return entity.TypeColumn
+}
+
+func (entity *CSVTemplate) GetTypes() string {
+	return entity.Types
+}
+
+func (entity *CSVTemplate) GetCurrencyPairColumn() string {
+	return entity.CurrencyPairColumn
+}
+
+func (entity *CSVTemplate) GetCurrencyPairSeparator() string {
+	return entity.CurrencyPairSeparator
+}
+
+func (entity *CSVTemplate) IsQuoteFirst() bool {
+	return entity.QuoteFirst
+}
+
+func (entity *CSVTemplate) GetBaseColumn() string {
+	return entity.BaseColumn
+}
+
+func (entity *CSVTemplate) GetQuoteColumn() string {
+	return entity.QuoteColumn
+}
+
+func (entity *CSVTemplate) GetQuantityColumn() string {
+	return entity.QuantityColumn
+}
+
+func (entity *CSVTemplate) GetPriceColumn() string {
+	return entity.PriceColumn
+}
+
+func (entity *CSVTemplate) GetTotalColumn() string {
+	return entity.TotalColumn
+}
+
+func (entity *CSVTemplate) GetFeeColumn() string {
+	return entity.FeeColumn
+}
+
+func (entity *CSVTemplate) GetFeeCurrencyColumn() string {
+	return entity.FeeCurrencyColumn
+}
+
+func (entity *CSVTemplate) GetFeeCurrency() string {
+	return entity.FeeCurrency
+}
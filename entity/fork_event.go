@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+type ForkEvent struct {
+	Id          uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId      uint   `gorm:"index"`
+	Category    string `gorm:"type:varchar(20)"`
+	Currency    string `gorm:"type:varchar(6)"`
+	NewCurrency string `gorm:"type:varchar(6)"`
+	Date        time.Time
+	Ratio       string `gorm:"type:varchar(64)"`
+	CostBasis   string `gorm:"type:varchar(64)"`
+	ForkEventEntity
+}
+
+func (entity *ForkEvent) GetId() uint {
+	return entity.Id
+}
+
+func (entity *ForkEvent) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *ForkEvent) GetCategory() string {
+	return entity.Category
+}
+
+func (entity *ForkEvent) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *ForkEvent) GetNewCurrency() string {
+	return entity.NewCurrency
+}
+
+func (entity *ForkEvent) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *ForkEvent) GetRatio() string {
+	return entity.Ratio
+}
+
+func (entity *ForkEvent) GetCostBasis() string {
+	return entity.CostBasis
+}
@@ -0,0 +1,35 @@
+package entity
+
+type LotSelection struct {
+	Id                uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId            uint   `gorm:"index"`
+	SaleTransactionId string `gorm:"type:varchar(200);index"`
+	BuyTransactionId  string `gorm:"type:varchar(200)"`
+	Currency          string `gorm:"type:varchar(6)"`
+	Quantity          string `gorm:"type:varchar(64)"`
+	LotSelectionEntity
+}
+
+func (entity *LotSelection) GetId() uint {
+	return entity.Id
+}
+
+func (entity *LotSelection) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *LotSelection) GetSaleTransactionId() string {
+	return entity.SaleTransactionId
+}
+
+func (entity *LotSelection) GetBuyTransactionId() string {
+	return entity.BuyTransactionId
+}
+
+func (entity *LotSelection) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *LotSelection) GetQuantity() string {
+	return entity.Quantity
+}
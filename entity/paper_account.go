@@ -0,0 +1,35 @@
+package entity
+
+type PaperAccount struct {
+	Id       uint           `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId   uint           `gorm:"unique_index:idx_paper_account"`
+	Exchange string         `gorm:"type:varchar(200);unique_index:idx_paper_account"`
+	Fee      string         `gorm:"type:varchar(64)"`
+	Slippage string         `gorm:"type:varchar(64)"`
+	Balances []PaperBalance `gorm:"ForeignKey:AccountId"`
+	PaperAccountEntity
+}
+
+func (entity *PaperAccount) GetId() uint {
+	return entity.Id
+}
+
+func (entity *PaperAccount) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *PaperAccount) GetExchange() string {
+	return entity.Exchange
+}
+
+func (entity *PaperAccount) GetFee() string {
+	return entity.Fee
+}
+
+func (entity *PaperAccount) GetSlippage() string {
+	return entity.Slippage
+}
+
+func (entity *PaperAccount) GetBalances() []PaperBalance {
+	return entity.Balances
+}
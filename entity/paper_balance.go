@@ -0,0 +1,25 @@
+package entity
+
+type PaperBalance struct {
+	Id        uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	AccountId uint   `gorm:"unique_index:idx_paper_balance"`
+	Currency  string `gorm:"type:varchar(6);unique_index:idx_paper_balance"`
+	Amount    string `gorm:"type:varchar(64)"`
+	PaperBalanceEntity
+}
+
+func (entity *PaperBalance) GetId() uint {
+	return entity.Id
+}
+
+func (entity *PaperBalance) GetAccountId() uint {
+	return entity.AccountId
+}
+
+func (entity *PaperBalance) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *PaperBalance) GetAmount() string {
+	return entity.Amount
+}
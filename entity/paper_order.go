@@ -0,0 +1,77 @@
+package entity
+
+import "time"
+
+type PaperOrder struct {
+	Id             uint `gorm:"primary_key;AUTO_INCREMENT"`
+	AccountId      uint `gorm:"index"`
+	Date           time.Time
+	Base           string `gorm:"type:varchar(6)"`
+	Quote          string `gorm:"type:varchar(6)"`
+	Side           string `gorm:"type:varchar(4)"`
+	Type           string `gorm:"type:varchar(6)"`
+	Status         string `gorm:"type:varchar(9)"`
+	Quantity       string `gorm:"type:varchar(64)"`
+	Price          string `gorm:"type:varchar(64)"`
+	FilledQuantity string `gorm:"type:varchar(64)"`
+	FillPrice      string `gorm:"type:varchar(64)"`
+	Fee            string `gorm:"type:varchar(64)"`
+	FeeCurrency    string `gorm:"type:varchar(6)"`
+	PaperOrderEntity
+}
+
+func (entity *PaperOrder) GetId() uint {
+	return entity.Id
+}
+
+func (entity *PaperOrder) GetAccountId() uint {
+	return entity.AccountId
+}
+
+func (entity *PaperOrder) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *PaperOrder) GetBase() string {
+	return entity.Base
+}
+
+func (entity *PaperOrder) GetQuote() string {
+	return entity.Quote
+}
+
+func (entity *PaperOrder) GetSide() string {
+	return entity.Side
+}
+
+func (entity *PaperOrder) GetType() string {
+	return entity.Type
+}
+
+func (entity *PaperOrder) GetStatus() string {
+	return entity.Status
+}
+
+func (entity *PaperOrder) GetQuantity() string {
+	return entity.Quantity
+}
+
+func (entity *PaperOrder) GetPrice() string {
+	return entity.Price
+}
+
+func (entity *PaperOrder) GetFilledQuantity() string {
+	return entity.FilledQuantity
+}
+
+func (entity *PaperOrder) GetFillPrice() string {
+	return entity.FillPrice
+}
+
+func (entity *PaperOrder) GetFee() string {
+	return entity.Fee
+}
+
+func (entity *PaperOrder) GetFeeCurrency() string {
+	return entity.FeeCurrency
+}
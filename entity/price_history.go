@@ -1,6 +1,10 @@
 package entity
 
 type PriceHistory struct {
+	Exchange  string  `gorm:"index:idx_price_history_market" json:"exchange"`
+	Base      string  `gorm:"index:idx_price_history_market" json:"base"`
+	Quote     string  `gorm:"index:idx_price_history_market" json:"quote"`
+	Period    int     `gorm:"index:idx_price_history_market" json:"period"`
 	Time      int64   `json:"time"`
 	Open      float64 `json:"open"`
 	High      float64 `json:"high"`
@@ -11,6 +15,22 @@ type PriceHistory struct {
 	PriceHistoryEntity
 }
 
+func (ph *PriceHistory) GetExchange() string {
+	return ph.Exchange
+}
+
+func (ph *PriceHistory) GetBase() string {
+	return ph.Base
+}
+
+func (ph *PriceHistory) GetQuote() string {
+	return ph.Quote
+}
+
+func (ph *PriceHistory) GetPeriod() int {
+	return ph.Period
+}
+
 func (ph *PriceHistory) GetTime() int64 {
 	return ph.Time
 }
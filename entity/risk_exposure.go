@@ -0,0 +1,25 @@
+package entity
+
+type RiskExposure struct {
+	Id          uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	LimitId     uint   `gorm:"unique_index:idx_risk_exposure"`
+	Currency    string `gorm:"type:varchar(6);unique_index:idx_risk_exposure"`
+	MaxQuantity string `gorm:"type:varchar(64)"`
+	RiskExposureEntity
+}
+
+func (entity *RiskExposure) GetId() uint {
+	return entity.Id
+}
+
+func (entity *RiskExposure) GetLimitId() uint {
+	return entity.LimitId
+}
+
+func (entity *RiskExposure) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *RiskExposure) GetMaxQuantity() string {
+	return entity.MaxQuantity
+}
@@ -0,0 +1,50 @@
+package entity
+
+type RiskLimit struct {
+	Id                uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId            uint   `gorm:"unique_index:idx_risk_limit"`
+	ChartId           uint   `gorm:"unique_index:idx_risk_limit"`
+	MaxPositionSize   string `gorm:"type:varchar(64)"`
+	MaxDailyLoss      string `gorm:"type:varchar(64)"`
+	MaxTradesPerHour  int
+	MinBalanceReserve string         `gorm:"type:varchar(64)"`
+	KillSwitch        string         `gorm:"type:varchar(7)"`
+	Exposures         []RiskExposure `gorm:"ForeignKey:LimitId"`
+	RiskLimitEntity
+}
+
+func (entity *RiskLimit) GetId() uint {
+	return entity.Id
+}
+
+func (entity *RiskLimit) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *RiskLimit) GetChartId() uint {
+	return entity.ChartId
+}
+
+func (entity *RiskLimit) GetMaxPositionSize() string {
+	return entity.MaxPositionSize
+}
+
+func (entity *RiskLimit) GetMaxDailyLoss() string {
+	return entity.MaxDailyLoss
+}
+
+func (entity *RiskLimit) GetMaxTradesPerHour() int {
+	return entity.MaxTradesPerHour
+}
+
+func (entity *RiskLimit) GetMinBalanceReserve() string {
+	return entity.MinBalanceReserve
+}
+
+func (entity *RiskLimit) GetKillSwitch() string {
+	return entity.KillSwitch
+}
+
+func (entity *RiskLimit) GetExposures() []RiskExposure {
+	return entity.Exposures
+}
@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+type RiskRejection struct {
+	Id       uint `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId   uint `gorm:"index"`
+	ChartId  uint
+	Date     time.Time
+	Base     string `gorm:"type:varchar(6)"`
+	Quote    string `gorm:"type:varchar(6)"`
+	Type     string `gorm:"type:varchar(4)"`
+	Price    string `gorm:"type:varchar(64)"`
+	Quantity string `gorm:"type:varchar(64)"`
+	Reason   string
+	RiskRejectionEntity
+}
+
+func (entity *RiskRejection) GetId() uint {
+	return entity.Id
+}
+
+func (entity *RiskRejection) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *RiskRejection) GetChartId() uint {
+	return entity.ChartId
+}
+
+func (entity *RiskRejection) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *RiskRejection) GetBase() string {
+	return entity.Base
+}
+
+func (entity *RiskRejection) GetQuote() string {
+	return entity.Quote
+}
+
+func (entity *RiskRejection) GetType() string {
+	return entity.Type
+}
+
+func (entity *RiskRejection) GetPrice() string {
+	return entity.Price
+}
+
+func (entity *RiskRejection) GetQuantity() string {
+	return entity.Quantity
+}
+
+func (entity *RiskRejection) GetReason() string {
+	return entity.Reason
+}
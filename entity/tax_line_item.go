@@ -0,0 +1,96 @@
+package entity
+
+import "time"
+
+type TaxLineItem struct {
+	Id               uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId           uint   `gorm:"index:idx_tax_line_item"`
+	Year             int    `gorm:"index:idx_tax_line_item"`
+	TransactionId    string `gorm:"type:varchar(200)"`
+	Holding          string `gorm:"type:varchar(10)"`
+	Currency         string `gorm:"type:varchar(6)"`
+	Description      string `gorm:"type:varchar(200)"`
+	DateAcquired     time.Time
+	DateSold         time.Time
+	Proceeds         string           `gorm:"type:varchar(64)"`
+	CostBasis        string           `gorm:"type:varchar(64)"`
+	AdjustmentCode   string           `gorm:"type:varchar(10)"`
+	AdjustmentAmount string           `gorm:"type:varchar(64)"`
+	Fees             string           `gorm:"type:varchar(64)"`
+	GainOrLoss       string           `gorm:"type:varchar(64)"`
+	MissingBasis     string           `gorm:"type:varchar(64)"`
+	Lots             []TaxLineItemLot `gorm:"ForeignKey:TaxLineItemId"`
+	TaxLineItemEntity
+}
+
+func (entity *TaxLineItem) GetId() uint {
+	return entity.Id
+}
+
+func (entity *TaxLineItem) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *TaxLineItem) GetYear() int {
+	return entity.Year
+}
+
+func (entity *TaxLineItem) GetTransactionId() string {
+	return entity.TransactionId
+}
+
+func (entity *TaxLineItem) GetHolding() string {
+	return entity.Holding
+}
+
+func (entity *TaxLineItem) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *TaxLineItem) GetDescription() string {
+	return entity.Description
+}
+
+func (entity *TaxLineItem) GetDateAcquired() time.Time {
+	return entity.DateAcquired
+}
+
+func (entity *TaxLineItem) GetDateSold() time.Time {
+	return entity.DateSold
+}
+
+func (entity *TaxLineItem) GetProceeds() string {
+	return entity.Proceeds
+}
+
+func (entity *TaxLineItem) GetCostBasis() string {
+	return entity.CostBasis
+}
+
+func (entity *TaxLineItem) GetAdjustmentCode() string {
+	return entity.AdjustmentCode
+}
+
+func (entity *TaxLineItem) GetAdjustmentAmount() string {
+	return entity.AdjustmentAmount
+}
+
+func (entity *TaxLineItem) GetFees() string {
+	return entity.Fees
+}
+
+func (entity *TaxLineItem) GetGainOrLoss() string {
+	return entity.GainOrLoss
+}
+
+func (entity *TaxLineItem) GetMissingBasis() string {
+	return entity.MissingBasis
+}
+
+func (entity *TaxLineItem) SetLots(lots []TaxLineItemLot) {
+	entity.Lots = lots
+}
+
+func (entity *TaxLineItem) GetLots() []TaxLineItemLot {
+	return entity.Lots
+}
@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+type TaxLineItemLot struct {
+	Id            uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	TaxLineItemId uint   `gorm:"index"`
+	TransactionId string `gorm:"type:varchar(200)"`
+	Date          time.Time
+	Quantity      string `gorm:"type:varchar(64)"`
+	CostBasis     string `gorm:"type:varchar(64)"`
+	Fee           string `gorm:"type:varchar(64)"`
+	TaxLineItemLotEntity
+}
+
+func (entity *TaxLineItemLot) GetId() uint {
+	return entity.Id
+}
+
+func (entity *TaxLineItemLot) GetTaxLineItemId() uint {
+	return entity.TaxLineItemId
+}
+
+func (entity *TaxLineItemLot) GetTransactionId() string {
+	return entity.TransactionId
+}
+
+func (entity *TaxLineItemLot) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *TaxLineItemLot) GetQuantity() string {
+	return entity.Quantity
+}
+
+func (entity *TaxLineItemLot) GetCostBasis() string {
+	return entity.CostBasis
+}
+
+func (entity *TaxLineItemLot) GetFee() string {
+	return entity.Fee
+}
@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+type TaxLot struct {
+	Id            uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId        uint   `gorm:"index:idx_tax_lot"`
+	Year          int    `gorm:"index:idx_tax_lot"`
+	TransactionId string `gorm:"type:varchar(200)"`
+	Date          time.Time
+	Currency      string `gorm:"type:varchar(6)"`
+	Quantity      string `gorm:"type:varchar(64)"`
+	UnitPrice     string `gorm:"type:varchar(64)"`
+	CostBasis     string `gorm:"type:varchar(64)"`
+	Fee           string `gorm:"type:varchar(64)"`
+	TaxLotEntity
+}
+
+func (entity *TaxLot) GetId() uint {
+	return entity.Id
+}
+
+func (entity *TaxLot) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *TaxLot) GetYear() int {
+	return entity.Year
+}
+
+func (entity *TaxLot) GetTransactionId() string {
+	return entity.TransactionId
+}
+
+func (entity *TaxLot) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *TaxLot) GetCurrency() string {
+	return entity.Currency
+}
+
+func (entity *TaxLot) GetQuantity() string {
+	return entity.Quantity
+}
+
+func (entity *TaxLot) GetUnitPrice() string {
+	return entity.UnitPrice
+}
+
+func (entity *TaxLot) GetCostBasis() string {
+	return entity.CostBasis
+}
+
+func (entity *TaxLot) GetFee() string {
+	return entity.Fee
+}
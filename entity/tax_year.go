@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+type TaxYear struct {
+	Id       uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId   uint   `gorm:"unique_index:idx_tax_year"`
+	Year     int    `gorm:"unique_index:idx_tax_year"`
+	Method   string `gorm:"type:varchar(20)"`
+	ClosedAt time.Time
+	TaxYearEntity
+}
+
+func (entity *TaxYear) GetId() uint {
+	return entity.Id
+}
+
+func (entity *TaxYear) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *TaxYear) GetYear() int {
+	return entity.Year
+}
+
+func (entity *TaxYear) GetMethod() string {
+	return entity.Method
+}
+
+func (entity *TaxYear) GetClosedAt() time.Time {
+	return entity.ClosedAt
+}
@@ -30,6 +30,7 @@ type Transaction struct {
 	TotalCurrency          string `gorm:"type:varchar(6)"`
 	FiatTotal              string `gorm:"type:varchar(64)"`
 	FiatTotalCurrency      string `gorm:"type:varchar(6)"`
+	TransferId             string `gorm:"type:varchar(200)"`
 	Deleted                int    `gorm:"type:integer;default:0"`
 	TransactionEntity
 }
@@ -146,6 +147,10 @@ func (tx *Transaction) GetFiatTotalCurrency() string {
 	return tx.FiatTotalCurrency
 }
 
+func (tx *Transaction) GetTransferId() string {
+	return tx.TransferId
+}
+
 func (tx *Transaction) IsDeleted() bool {
 	return tx.Deleted > 0
 }
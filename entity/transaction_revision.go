@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// TransactionRevision stores the previous and current states of the changed
+// transaction as JSON.
+type TransactionRevision struct {
+	Id            uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	UserId        uint   `gorm:"index"`
+	TransactionId string `gorm:"type:varchar(200);index"`
+	Action        string `gorm:"type:varchar(20)"`
+	Date          time.Time
+	Previous      string `gorm:"type:text"`
+	Current       string `gorm:"type:text"`
+	TransactionRevisionEntity
+}
+
+func (entity *TransactionRevision) GetId() uint {
+	return entity.Id
+}
+
+func (entity *TransactionRevision) GetUserId() uint {
+	return entity.UserId
+}
+
+func (entity *TransactionRevision) GetTransactionId() string {
+	return entity.TransactionId
+}
+
+func (entity *TransactionRevision) GetAction() string {
+	return entity.Action
+}
+
+func (entity *TransactionRevision) GetDate() time.Time {
+	return entity.Date
+}
+
+func (entity *TransactionRevision) GetPrevious() string {
+	return entity.Previous
+}
+
+func (entity *TransactionRevision) GetCurrent() string {
+	return entity.Current
+}
@@ -52,10 +52,182 @@ type TransactionEntity interface {
 	GetFiatFeeCurrency() string
 	GetFiatTotal() string
 	GetFiatTotalCurrency() string
+	GetTransferId() string
 	IsDeleted() bool
 	SetDeleted(value int)
 }
 
+type LotSelectionEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetSaleTransactionId() string
+	GetBuyTransactionId() string
+	GetCurrency() string
+	GetQuantity() string
+}
+
+type TransactionRevisionEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetTransactionId() string
+	GetAction() string
+	GetDate() time.Time
+	GetPrevious() string
+	GetCurrent() string
+}
+
+type ForkEventEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetCategory() string
+	GetCurrency() string
+	GetNewCurrency() string
+	GetDate() time.Time
+	GetRatio() string
+	GetCostBasis() string
+}
+
+type CSVTemplateEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetName() string
+	GetNetwork() string
+	GetIdColumn() string
+	GetDateColumn() string
+	GetDateFormat() string
+	GetTimeZone() string
+	GetTypeColumn() string
+	GetTypes() string
+	GetCurrencyPairColumn() string
+	GetCurrencyPairSeparator() string
+	IsQuoteFirst() bool
+	GetBaseColumn() string
+	GetQuoteColumn() string
+	GetQuantityColumn() string
+	GetPriceColumn() string
+	GetTotalColumn() string
+	GetFeeColumn() string
+	GetFeeCurrencyColumn() string
+	GetFeeCurrency() string
+}
+
+type TaxYearEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetYear() int
+	GetMethod() string
+	GetClosedAt() time.Time
+}
+
+type TaxLotEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetYear() int
+	GetTransactionId() string
+	GetDate() time.Time
+	GetCurrency() string
+	GetQuantity() string
+	GetUnitPrice() string
+	GetCostBasis() string
+	GetFee() string
+}
+
+type TaxLineItemEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetYear() int
+	GetTransactionId() string
+	GetHolding() string
+	GetCurrency() string
+	GetDescription() string
+	GetDateAcquired() time.Time
+	GetDateSold() time.Time
+	GetProceeds() string
+	GetCostBasis() string
+	GetAdjustmentCode() string
+	GetAdjustmentAmount() string
+	GetFees() string
+	GetGainOrLoss() string
+	GetMissingBasis() string
+	SetLots(lots []TaxLineItemLot)
+	GetLots() []TaxLineItemLot
+}
+
+type TaxLineItemLotEntity interface {
+	GetId() uint
+	GetTaxLineItemId() uint
+	GetTransactionId() string
+	GetDate() time.Time
+	GetQuantity() string
+	GetCostBasis() string
+	GetFee() string
+}
+
+type PaperAccountEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetExchange() string
+	GetFee() string
+	GetSlippage() string
+	GetBalances() []PaperBalance
+}
+
+type PaperBalanceEntity interface {
+	GetId() uint
+	GetAccountId() uint
+	GetCurrency() string
+	GetAmount() string
+}
+
+type PaperOrderEntity interface {
+	GetId() uint
+	GetAccountId() uint
+	GetDate() time.Time
+	GetBase() string
+	GetQuote() string
+	GetSide() string
+	GetType() string
+	GetStatus() string
+	GetQuantity() string
+	GetPrice() string
+	GetFilledQuantity() string
+	GetFillPrice() string
+	GetFee() string
+	GetFeeCurrency() string
+}
+
+type RiskLimitEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetChartId() uint
+	GetMaxPositionSize() string
+	GetMaxDailyLoss() string
+	GetMaxTradesPerHour() int
+	GetMinBalanceReserve() string
+	GetKillSwitch() string
+	GetExposures() []RiskExposure
+}
+
+type RiskExposureEntity interface {
+	GetId() uint
+	GetLimitId() uint
+	GetCurrency() string
+	GetMaxQuantity() string
+}
+
+type RiskRejectionEntity interface {
+	GetId() uint
+	GetUserId() uint
+	GetChartId() uint
+	GetDate() time.Time
+	GetBase() string
+	GetQuote() string
+	GetType() string
+	GetPrice() string
+	GetQuantity() string
+	GetReason() string
+}
+
 type UserEntity interface {
 	GetId() uint
 	GetUsername() string
@@ -138,6 +310,10 @@ type TradeEntity interface {
 }
 
 type PriceHistoryEntity interface {
+	GetExchange() string
+	GetBase() string
+	GetQuote() string
+	GetPeriod() int
 	GetTime() int64
 	GetOpen() float64
 	GetHigh() float64
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
 	"github.com/jeremyhahn/tradebot/entity"
 	"github.com/jeremyhahn/tradebot/mapper"
 	"github.com/jeremyhahn/tradebot/service"
@@ -15,6 +18,7 @@ import (
 	//_ "github.com/jinzhu/gorm/dialects/mysql"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/op/go-logging"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
@@ -31,6 +35,13 @@ func main() {
 	keystoreFlag := flag.String("keystore", defaultKeystore, "Path to default Ethereum keystore")
 	debugFlag := flag.Bool("debug", false, "Enable debug level logging")
 	ethereumModeFlag := flag.String("mode", defaultMode, "Ethereum mode [native | etherscan] (default: etherscan)")
+	backtestFlag := flag.Uint("backtest", 0, "Backtest the chart with the given id, print the results as JSON and exit")
+	backtestStartFlag := flag.String("backtest-start", "", "Backtest start date as YYYY-MM-DD (default: 7 days before the end date)")
+	backtestEndFlag := flag.String("backtest-end", "", "Backtest end date as YYYY-MM-DD (default: now)")
+	backtestSourceFlag := flag.String("backtest-source", service.BACKTEST_SOURCE_EXCHANGE, "Backtest candlestick source [exchange | db]")
+	backtestBalanceFlag := flag.Float64("backtest-balance", service.PAPER_DEFAULT_BALANCE, "Backtest starting quote currency balance")
+	backtestFeeFlag := flag.Float64("backtest-fee", service.PAPER_DEFAULT_FEE, "Backtest fee rate charged per trade")
+	backtestSlippageFlag := flag.Float64("backtest-slippage", service.PAPER_DEFAULT_SLIPPAGE, "Backtest slippage rate applied to each fill")
 	flag.Parse()
 
 	f, err := os.OpenFile("tradebot.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
@@ -68,6 +79,34 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *backtestFlag > 0 {
+		end := time.Now()
+		if *backtestEndFlag != "" {
+			if end, err = time.Parse("2006-01-02", *backtestEndFlag); err != nil {
+				ctx.Logger.Fatalf(fmt.Sprintf("Invalid backtest end date: %s", *backtestEndFlag))
+			}
+		}
+		start := end.AddDate(0, 0, -7)
+		if *backtestStartFlag != "" {
+			if start, err = time.Parse("2006-01-02", *backtestStartFlag); err != nil {
+				ctx.Logger.Fatalf(fmt.Sprintf("Invalid backtest start date: %s", *backtestStartFlag))
+			}
+		}
+		fee := decimal.NewFromFloat(*backtestFeeFlag)
+		slippage := decimal.NewFromFloat(*backtestSlippageFlag)
+		err = Backtest(ctx, *backtestFlag, &dto.BacktestOptionsDTO{
+			Start:    start,
+			End:      end,
+			Source:   *backtestSourceFlag,
+			Balance:  decimal.NewFromFloat(*backtestBalanceFlag),
+			Fee:      &fee,
+			Slippage: &slippage})
+		if err != nil {
+			ctx.Logger.Fatalf(fmt.Sprintf("Error: %s", err.Error()))
+		}
+		os.Exit(0)
+	}
+
 	userDAO := dao.NewUserDAO(ctx)
 	pluginDAO := dao.NewPluginDAO(ctx)
 	userMapper := mapper.NewUserMapper()
@@ -98,9 +137,11 @@ func main() {
 		profitDAO := dao.NewProfitDAO(ctx)
 		tradeDAO := dao.NewTradeDAO(ctx)
 		chartStrategyDAO := dao.NewChartStrategyDAO(ctx)
+		riskDAO := dao.NewRiskDAO(ctx)
 
 		chartMapper := mapper.NewChartMapper(ctx)
 		tradeMapper := mapper.NewTradeMapper()
+		riskMapper := mapper.NewRiskMapper(ctx)
 		pluginMapper := mapper.NewPluginMapper()
 		userExchangeMapper := mapper.NewUserExchangeMapper()
 
@@ -111,7 +152,8 @@ func main() {
 		profitService := service.NewProfitService(ctx, profitDAO)
 		tradeService := service.NewTradeService(ctx, tradeDAO, tradeMapper)
 		strategyService := service.NewStrategyService(ctx, chartStrategyDAO, pluginService, indicatorService, chartMapper)
-		autoTradeService := service.NewAutoTradeService(ctx, exchangeService, chartService, profitService, tradeService, strategyService, userMapper)
+		riskService := service.NewRiskService(ctx, riskDAO, tradeDAO, chartDAO, riskMapper)
+		autoTradeService := service.NewAutoTradeService(ctx, exchangeService, chartService, profitService, tradeService, strategyService, riskService, userMapper)
 
 		err = autoTradeService.EndWorldHunger()
 		if err != nil {
@@ -120,6 +162,37 @@ func main() {
 	*/
 }
 
+// Backtest runs the chart's strategies over historical candlesticks as the
+// chart's owner and prints the results to stdout as JSON.
+func Backtest(ctx common.Context, chartId uint, options *dto.BacktestOptionsDTO) error {
+	chartDAO := dao.NewChartDAO(ctx)
+	userDAO := dao.NewUserDAO(ctx)
+	userMapper := mapper.NewUserMapper()
+	chart, err := chartDAO.Get(chartId)
+	if err != nil {
+		return err
+	}
+	user, err := userDAO.GetById(chart.GetUserId())
+	if err != nil {
+		return err
+	}
+	ctx.SetUser(userMapper.MapUserEntityToDto(user))
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, userDAO, userMapper, mapper.NewUserExchangeMapper(), pluginService)
+	backtestService := service.NewBacktestService(ctx, chartDAO, dao.NewPriceHistoryDAO(ctx), exchangeService,
+		pluginService, mapper.NewChartMapper(ctx), mapper.NewPriceHistoryMapper())
+	result, err := backtestService.Backtest(chartId, options)
+	if err != nil {
+		return err
+	}
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
 func InitDB(databaseManager common.DatabaseManager, ctx common.Context) {
 	databaseManager.MigrateCoreDB()
 	databaseManager.MigratePriceDB()
@@ -144,6 +217,11 @@ func InitDB(databaseManager common.DatabaseManager, ctx common.Context) {
 		Filename: "binance.so",
 		Version:  "0.0.1a",
 		Type:     common.EXCHANGE_PLUGIN_TYPE})
+	pluginDAO.Create(&entity.Plugin{
+		Name:     "Paper",
+		Filename: "paper.so",
+		Version:  "0.0.1a",
+		Type:     common.EXCHANGE_PLUGIN_TYPE})
 	pluginDAO.Create(&entity.Plugin{
 		Name:     "BTC",
 		Filename: "btc.so",
@@ -0,0 +1,83 @@
+package mapper
+
+import (
+	"encoding/json"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+)
+
+type CSVTemplateMapper interface {
+	MapCSVTemplateEntityToDto(entity entity.CSVTemplateEntity) common.CSVTemplate
+	MapCSVTemplateDtoToEntity(dto common.CSVTemplate) entity.CSVTemplateEntity
+}
+
+type DefaultCSVTemplateMapper struct {
+	ctx common.Context
+	CSVTemplateMapper
+}
+
+func NewCSVTemplateMapper(ctx common.Context) CSVTemplateMapper {
+	return &DefaultCSVTemplateMapper{ctx: ctx}
+}
+
+func (mapper *DefaultCSVTemplateMapper) MapCSVTemplateEntityToDto(entity entity.CSVTemplateEntity) common.CSVTemplate {
+	types := make(map[string]string)
+	if entity.GetTypes() != "" {
+		if err := json.Unmarshal([]byte(entity.GetTypes()), &types); err != nil {
+			mapper.ctx.GetLogger().Errorf("[CSVTemplateMapper.MapCSVTemplateEntityToDto] Error parsing types: %s", err.Error())
+		}
+	}
+	return &dto.CSVTemplateDTO{
+		Id:                    entity.GetId(),
+		UserId:                entity.GetUserId(),
+		Name:                  entity.GetName(),
+		Network:               entity.GetNetwork(),
+		IdColumn:              entity.GetIdColumn(),
+		DateColumn:            entity.GetDateColumn(),
+		DateFormat:            entity.GetDateFormat(),
+		TimeZone:              entity.GetTimeZone(),
+		TypeColumn:            entity.GetTypeColumn(),
+		Types:                 types,
+		CurrencyPairColumn:    entity.GetCurrencyPairColumn(),
+		CurrencyPairSeparator: entity.GetCurrencyPairSeparator(),
+		QuoteFirst:            entity.IsQuoteFirst(),
+		BaseColumn:            entity.GetBaseColumn(),
+		QuoteColumn:           entity.GetQuoteColumn(),
+		QuantityColumn:        entity.GetQuantityColumn(),
+		PriceColumn:           entity.GetPriceColumn(),
+		TotalColumn:           entity.GetTotalColumn(),
+		FeeColumn:             entity.GetFeeColumn(),
+		FeeCurrencyColumn:     entity.GetFeeCurrencyColumn(),
+		FeeCurrency:           entity.GetFeeCurrency()}
+}
+
+func (mapper *DefaultCSVTemplateMapper) MapCSVTemplateDtoToEntity(dto common.CSVTemplate) entity.CSVTemplateEntity {
+	types, err := json.Marshal(dto.GetTypes())
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[CSVTemplateMapper.MapCSVTemplateDtoToEntity] Error encoding types: %s", err.Error())
+	}
+	return &entity.CSVTemplate{
+		Id:                    dto.GetId(),
+		UserId:                dto.GetUserId(),
+		Name:                  dto.GetName(),
+		Network:               dto.GetNetwork(),
+		IdColumn:              dto.GetIdColumn(),
+		DateColumn:            dto.GetDateColumn(),
+		DateFormat:            dto.GetDateFormat(),
+		TimeZone:              dto.GetTimeZone(),
+		TypeColumn:            dto.GetTypeColumn(),
+		Types:                 string(types),
+		CurrencyPairColumn:    dto.GetCurrencyPairColumn(),
+		CurrencyPairSeparator: dto.GetCurrencyPairSeparator(),
+		QuoteFirst:            dto.IsQuoteFirst(),
+		BaseColumn:            dto.GetBaseColumn(),
+		QuoteColumn:           dto.GetQuoteColumn(),
+		QuantityColumn:        dto.GetQuantityColumn(),
+		PriceColumn:           dto.GetPriceColumn(),
+		TotalColumn:           dto.GetTotalColumn(),
+		FeeColumn:             dto.GetFeeColumn(),
+		FeeCurrencyColumn:     dto.GetFeeCurrencyColumn(),
+		FeeCurrency:           dto.GetFeeCurrency()}
+}
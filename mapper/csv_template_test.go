@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVTemplateMapper(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewCSVTemplateMapper(ctx)
+	dto := &dto.CSVTemplateDTO{
+		Id:                    1,
+		UserId:                1,
+		Name:                  "otc-desk",
+		Network:               "otc",
+		DateColumn:            "Time",
+		DateFormat:            "2006-01-02 15:04",
+		TimeZone:              "America/New_York",
+		TypeColumn:            "Side",
+		Types:                 map[string]string{"BOUGHT": common.BUY_ORDER_TYPE, "SOLD": common.SELL_ORDER_TYPE},
+		CurrencyPairColumn:    "Market",
+		CurrencyPairSeparator: "-",
+		QuoteFirst:            true,
+		QuantityColumn:        "Amount",
+		PriceColumn:           "Rate",
+		FeeColumn:             "Commission",
+		FeeCurrency:           "USD"}
+
+	entity := mapper.MapCSVTemplateDtoToEntity(dto)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, dto.GetName(), entity.GetName())
+	assert.Equal(t, dto.IsQuoteFirst(), entity.IsQuoteFirst())
+	assert.Equal(t, `{"BOUGHT":"buy","SOLD":"sell"}`, entity.GetTypes())
+
+	mapped := mapper.MapCSVTemplateEntityToDto(entity)
+	assert.Equal(t, dto, mapped)
+}
@@ -0,0 +1,54 @@
+package mapper
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+type ForkEventMapper interface {
+	MapForkEventEntityToDto(entity entity.ForkEventEntity) common.ForkEvent
+	MapForkEventDtoToEntity(dto common.ForkEvent) entity.ForkEventEntity
+}
+
+type DefaultForkEventMapper struct {
+	ctx common.Context
+	ForkEventMapper
+}
+
+func NewForkEventMapper(ctx common.Context) ForkEventMapper {
+	return &DefaultForkEventMapper{ctx: ctx}
+}
+
+func (mapper *DefaultForkEventMapper) MapForkEventEntityToDto(entity entity.ForkEventEntity) common.ForkEvent {
+	ratio, err := decimal.NewFromString(entity.GetRatio())
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[ForkEventMapper.MapForkEventEntityToDto] Error parsing ratio decimal: %s", err.Error())
+	}
+	costBasis, err := decimal.NewFromString(entity.GetCostBasis())
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[ForkEventMapper.MapForkEventEntityToDto] Error parsing cost basis decimal: %s", err.Error())
+	}
+	return &dto.ForkEventDTO{
+		Id:          entity.GetId(),
+		UserId:      entity.GetUserId(),
+		Category:    entity.GetCategory(),
+		Currency:    entity.GetCurrency(),
+		NewCurrency: entity.GetNewCurrency(),
+		Date:        entity.GetDate(),
+		Ratio:       ratio,
+		CostBasis:   costBasis}
+}
+
+func (mapper *DefaultForkEventMapper) MapForkEventDtoToEntity(dto common.ForkEvent) entity.ForkEventEntity {
+	return &entity.ForkEvent{
+		Id:          dto.GetId(),
+		UserId:      dto.GetUserId(),
+		Category:    dto.GetCategory(),
+		Currency:    dto.GetCurrency(),
+		NewCurrency: dto.GetNewCurrency(),
+		Date:        dto.GetDate(),
+		Ratio:       dto.GetRatio().String(),
+		CostBasis:   dto.GetCostBasis().String()}
+}
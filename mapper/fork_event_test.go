@@ -0,0 +1,48 @@
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkEventMapper(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewForkEventMapper(ctx)
+	dto := &dto.ForkEventDTO{
+		Id:          1,
+		UserId:      1,
+		Category:    common.TX_CATEGORY_FORK,
+		Currency:    "BTC",
+		NewCurrency: "BCH",
+		Date:        time.Date(2017, 8, 1, 13, 16, 14, 0, time.UTC),
+		Ratio:       decimal.NewFromFloat(1),
+		CostBasis:   decimal.NewFromFloat(0.5)}
+
+	entity := mapper.MapForkEventDtoToEntity(dto)
+	assert.NotNil(t, entity)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, dto.GetUserId(), entity.GetUserId())
+	assert.Equal(t, dto.GetCategory(), entity.GetCategory())
+	assert.Equal(t, dto.GetCurrency(), entity.GetCurrency())
+	assert.Equal(t, dto.GetNewCurrency(), entity.GetNewCurrency())
+	assert.Equal(t, dto.GetDate(), entity.GetDate())
+	assert.Equal(t, dto.GetRatio().String(), entity.GetRatio())
+	assert.Equal(t, dto.GetCostBasis().String(), entity.GetCostBasis())
+
+	mappedDTO := mapper.MapForkEventEntityToDto(entity)
+	assert.NotNil(t, mappedDTO)
+	assert.Equal(t, entity.GetId(), mappedDTO.GetId())
+	assert.Equal(t, entity.GetUserId(), mappedDTO.GetUserId())
+	assert.Equal(t, entity.GetCategory(), mappedDTO.GetCategory())
+	assert.Equal(t, entity.GetCurrency(), mappedDTO.GetCurrency())
+	assert.Equal(t, entity.GetNewCurrency(), mappedDTO.GetNewCurrency())
+	assert.Equal(t, entity.GetDate(), mappedDTO.GetDate())
+	assert.Equal(t, entity.GetRatio(), mappedDTO.GetRatio().String())
+	assert.Equal(t, entity.GetCostBasis(), mappedDTO.GetCostBasis().String())
+}
@@ -0,0 +1,46 @@
+package mapper
+
+import (
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+type LotSelectionMapper interface {
+	MapLotSelectionEntityToDto(entity entity.LotSelectionEntity) common.LotSelection
+	MapLotSelectionDtoToEntity(dto common.LotSelection) entity.LotSelectionEntity
+}
+
+type DefaultLotSelectionMapper struct {
+	ctx common.Context
+	LotSelectionMapper
+}
+
+func NewLotSelectionMapper(ctx common.Context) LotSelectionMapper {
+	return &DefaultLotSelectionMapper{ctx: ctx}
+}
+
+func (mapper *DefaultLotSelectionMapper) MapLotSelectionEntityToDto(entity entity.LotSelectionEntity) common.LotSelection {
+	quantity, err := decimal.NewFromString(entity.GetQuantity())
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[LotSelectionMapper.MapLotSelectionEntityToDto] Error parsing quantity decimal: %s", err.Error())
+	}
+	return &dto.LotSelectionDTO{
+		Id:                entity.GetId(),
+		UserId:            entity.GetUserId(),
+		SaleTransactionId: entity.GetSaleTransactionId(),
+		BuyTransactionId:  entity.GetBuyTransactionId(),
+		Currency:          entity.GetCurrency(),
+		Quantity:          quantity}
+}
+
+func (mapper *DefaultLotSelectionMapper) MapLotSelectionDtoToEntity(dto common.LotSelection) entity.LotSelectionEntity {
+	return &entity.LotSelection{
+		Id:                dto.GetId(),
+		UserId:            dto.GetUserId(),
+		SaleTransactionId: dto.GetSaleTransactionId(),
+		BuyTransactionId:  dto.GetBuyTransactionId(),
+		Currency:          dto.GetCurrency(),
+		Quantity:          dto.GetQuantity().String()}
+}
@@ -0,0 +1,40 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLotSelectionMapper(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewLotSelectionMapper(ctx)
+	dto := &dto.LotSelectionDTO{
+		Id:                1,
+		UserId:            1,
+		SaleTransactionId: "sale-1",
+		BuyTransactionId:  "buy-1",
+		Currency:          "BTC",
+		Quantity:          decimal.NewFromFloat(0.25)}
+
+	entity := mapper.MapLotSelectionDtoToEntity(dto)
+	assert.NotNil(t, entity)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, dto.GetUserId(), entity.GetUserId())
+	assert.Equal(t, dto.GetSaleTransactionId(), entity.GetSaleTransactionId())
+	assert.Equal(t, dto.GetBuyTransactionId(), entity.GetBuyTransactionId())
+	assert.Equal(t, dto.GetCurrency(), entity.GetCurrency())
+	assert.Equal(t, dto.GetQuantity().String(), entity.GetQuantity())
+
+	mappedDTO := mapper.MapLotSelectionEntityToDto(entity)
+	assert.NotNil(t, mappedDTO)
+	assert.Equal(t, entity.GetId(), mappedDTO.GetId())
+	assert.Equal(t, entity.GetUserId(), mappedDTO.GetUserId())
+	assert.Equal(t, entity.GetSaleTransactionId(), mappedDTO.GetSaleTransactionId())
+	assert.Equal(t, entity.GetBuyTransactionId(), mappedDTO.GetBuyTransactionId())
+	assert.Equal(t, entity.GetCurrency(), mappedDTO.GetCurrency())
+	assert.Equal(t, entity.GetQuantity(), mappedDTO.GetQuantity().String())
+}
@@ -0,0 +1,120 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+type PaperAccountMapper interface {
+	MapPaperAccountEntityToDto(entity entity.PaperAccountEntity) common.PaperAccount
+	MapPaperAccountDtoToEntity(dto common.PaperAccount) entity.PaperAccountEntity
+	MapPaperOrderEntityToDto(entity entity.PaperOrderEntity, exchange string) common.Order
+	MapPaperOrderDtoToEntity(dto common.Order, accountId uint) entity.PaperOrderEntity
+}
+
+type DefaultPaperAccountMapper struct {
+	ctx common.Context
+	PaperAccountMapper
+}
+
+func NewPaperAccountMapper(ctx common.Context) PaperAccountMapper {
+	return &DefaultPaperAccountMapper{ctx: ctx}
+}
+
+func (mapper *DefaultPaperAccountMapper) MapPaperAccountEntityToDto(entity entity.PaperAccountEntity) common.PaperAccount {
+	balances := make(map[string]decimal.Decimal)
+	for _, balance := range entity.GetBalances() {
+		balances[balance.GetCurrency()] = mapper.parseDecimal("balance", balance.GetAmount())
+	}
+	return &dto.PaperAccountDTO{
+		Id:       entity.GetId(),
+		UserId:   entity.GetUserId(),
+		Exchange: entity.GetExchange(),
+		Fee:      mapper.parseDecimal("fee", entity.GetFee()),
+		Slippage: mapper.parseDecimal("slippage", entity.GetSlippage()),
+		Balances: balances}
+}
+
+func (mapper *DefaultPaperAccountMapper) MapPaperAccountDtoToEntity(dto common.PaperAccount) entity.PaperAccountEntity {
+	currencies := make([]string, 0, len(dto.GetBalances()))
+	for currency := range dto.GetBalances() {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	balances := make([]entity.PaperBalance, len(currencies))
+	for i, currency := range currencies {
+		balances[i] = entity.PaperBalance{
+			AccountId: dto.GetId(),
+			Currency:  currency,
+			Amount:    dto.GetBalances()[currency].String()}
+	}
+	return &entity.PaperAccount{
+		Id:       dto.GetId(),
+		UserId:   dto.GetUserId(),
+		Exchange: dto.GetExchange(),
+		Fee:      dto.GetFee().String(),
+		Slippage: dto.GetSlippage().String(),
+		Balances: balances}
+}
+
+func (mapper *DefaultPaperAccountMapper) MapPaperOrderEntityToDto(entity entity.PaperOrderEntity, exchange string) common.Order {
+	return &dto.OrderDTO{
+		Id:       fmt.Sprintf("%d", entity.GetId()),
+		Exchange: exchange,
+		CurrencyPair: &common.CurrencyPair{
+			Base:          entity.GetBase(),
+			Quote:         entity.GetQuote(),
+			LocalCurrency: mapper.ctx.GetUser().GetLocalCurrency()},
+		Side:           entity.GetSide(),
+		Type:           entity.GetType(),
+		Status:         entity.GetStatus(),
+		Date:           entity.GetDate(),
+		Quantity:       mapper.parseDecimal("quantity", entity.GetQuantity()),
+		Price:          mapper.parseDecimal("price", entity.GetPrice()),
+		FilledQuantity: mapper.parseDecimal("filled quantity", entity.GetFilledQuantity()),
+		FillPrice:      mapper.parseDecimal("fill price", entity.GetFillPrice()),
+		Fee:            mapper.parseDecimal("fee", entity.GetFee()),
+		FeeCurrency:    entity.GetFeeCurrency()}
+}
+
+func (mapper *DefaultPaperAccountMapper) MapPaperOrderDtoToEntity(dto common.Order, accountId uint) entity.PaperOrderEntity {
+	var id uint64
+	if dto.GetId() != "" {
+		var err error
+		if id, err = strconv.ParseUint(dto.GetId(), 10, 64); err != nil {
+			mapper.ctx.GetLogger().Errorf("[PaperAccountMapper.MapPaperOrderDtoToEntity] Invalid order id: %s", dto.GetId())
+		}
+	}
+	return &entity.PaperOrder{
+		Id:             uint(id),
+		AccountId:      accountId,
+		Date:           dto.GetDate(),
+		Base:           dto.GetCurrencyPair().Base,
+		Quote:          dto.GetCurrencyPair().Quote,
+		Side:           dto.GetSide(),
+		Type:           dto.GetType(),
+		Status:         dto.GetStatus(),
+		Quantity:       dto.GetQuantity().String(),
+		Price:          dto.GetPrice().String(),
+		FilledQuantity: dto.GetFilledQuantity().String(),
+		FillPrice:      dto.GetFillPrice().String(),
+		Fee:            dto.GetFee().String(),
+		FeeCurrency:    dto.GetFeeCurrency()}
+}
+
+func (mapper *DefaultPaperAccountMapper) parseDecimal(name, value string) decimal.Decimal {
+	if value == "" {
+		return decimal.NewFromFloat(0)
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[PaperAccountMapper.parseDecimal] Error parsing %s decimal: %s", name, err.Error())
+	}
+	return d
+}
@@ -0,0 +1,85 @@
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaperAccountMapper(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewPaperAccountMapper(ctx)
+	dto := &dto.PaperAccountDTO{
+		Id:       1,
+		UserId:   1,
+		Exchange: "GDAX",
+		Fee:      decimal.NewFromFloat(0.0025),
+		Slippage: decimal.NewFromFloat(0.001),
+		Balances: map[string]decimal.Decimal{
+			"USD": decimal.NewFromFloat(10000),
+			"BTC": decimal.NewFromFloat(1.5)}}
+
+	entity := mapper.MapPaperAccountDtoToEntity(dto)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, "GDAX", entity.GetExchange())
+	assert.Equal(t, "0.0025", entity.GetFee())
+	assert.Equal(t, "0.001", entity.GetSlippage())
+	assert.Equal(t, 2, len(entity.GetBalances()))
+	assert.Equal(t, "BTC", entity.GetBalances()[0].GetCurrency())
+	assert.Equal(t, "1.5", entity.GetBalances()[0].GetAmount())
+	assert.Equal(t, uint(1), entity.GetBalances()[1].GetAccountId())
+
+	mapped := mapper.MapPaperAccountEntityToDto(entity)
+	assert.Equal(t, dto.GetUserId(), mapped.GetUserId())
+	assert.True(t, dto.GetFee().Equal(mapped.GetFee()))
+	assert.True(t, dto.GetSlippage().Equal(mapped.GetSlippage()))
+	assert.True(t, decimal.NewFromFloat(10000).Equal(mapped.GetBalances()["USD"]))
+	assert.True(t, decimal.NewFromFloat(1.5).Equal(mapped.GetBalances()["BTC"]))
+}
+
+func TestPaperAccountMapper_Order(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewPaperAccountMapper(ctx)
+	order := &dto.OrderDTO{
+		Id:             "7",
+		Exchange:       common.PAPER_EXCHANGE,
+		CurrencyPair:   &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		Side:           common.SELL_ORDER_TYPE,
+		Type:           common.ORDER_TYPE_LIMIT,
+		Status:         common.ORDER_STATUS_FILLED,
+		Date:           time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC),
+		Quantity:       decimal.NewFromFloat(0.5),
+		Price:          decimal.NewFromFloat(11000),
+		FilledQuantity: decimal.NewFromFloat(0.5),
+		FillPrice:      decimal.NewFromFloat(11000),
+		Fee:            decimal.NewFromFloat(13.75),
+		FeeCurrency:    "USD"}
+
+	entity := mapper.MapPaperOrderDtoToEntity(order, 3)
+	assert.Equal(t, uint(7), entity.GetId())
+	assert.Equal(t, uint(3), entity.GetAccountId())
+	assert.Equal(t, "BTC", entity.GetBase())
+	assert.Equal(t, "USD", entity.GetQuote())
+	assert.Equal(t, "0.5", entity.GetQuantity())
+	assert.Equal(t, "13.75", entity.GetFee())
+
+	mapped := mapper.MapPaperOrderEntityToDto(entity, common.PAPER_EXCHANGE)
+	assert.Equal(t, order.GetId(), mapped.GetId())
+	assert.Equal(t, common.PAPER_EXCHANGE, mapped.GetExchange())
+	assert.Equal(t, order.GetCurrencyPair(), mapped.GetCurrencyPair())
+	assert.Equal(t, order.GetSide(), mapped.GetSide())
+	assert.Equal(t, order.GetType(), mapped.GetType())
+	assert.Equal(t, order.GetStatus(), mapped.GetStatus())
+	assert.Equal(t, order.GetDate(), mapped.GetDate())
+	assert.True(t, order.GetPrice().Equal(mapped.GetPrice()))
+	assert.True(t, order.GetFillPrice().Equal(mapped.GetFillPrice()))
+	assert.Equal(t, "USD", mapped.GetFeeCurrency())
+
+	order.Id = ""
+	assert.Equal(t, uint(0), mapper.MapPaperOrderDtoToEntity(order, 3).GetId())
+}
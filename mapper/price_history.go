@@ -0,0 +1,56 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+type PriceHistoryMapper interface {
+	MapPriceHistoryEntityToCandlestick(entity entity.PriceHistoryEntity) common.Candlestick
+	MapCandlestickToPriceHistoryEntity(candlestick *common.Candlestick) entity.PriceHistoryEntity
+}
+
+type DefaultPriceHistoryMapper struct {
+	PriceHistoryMapper
+}
+
+func NewPriceHistoryMapper() PriceHistoryMapper {
+	return &DefaultPriceHistoryMapper{}
+}
+
+func (mapper *DefaultPriceHistoryMapper) MapPriceHistoryEntityToCandlestick(entity entity.PriceHistoryEntity) common.Candlestick {
+	return common.Candlestick{
+		Exchange: entity.GetExchange(),
+		CurrencyPair: &common.CurrencyPair{
+			Base:  entity.GetBase(),
+			Quote: entity.GetQuote()},
+		Period: entity.GetPeriod(),
+		Date:   time.Unix(entity.GetTime(), 0),
+		Open:   decimal.NewFromFloat(entity.GetOpen()),
+		Close:  decimal.NewFromFloat(entity.GetClose()),
+		High:   decimal.NewFromFloat(entity.GetHigh()),
+		Low:    decimal.NewFromFloat(entity.GetLow()),
+		Volume: decimal.NewFromFloat(entity.GetVolume())}
+}
+
+func (mapper *DefaultPriceHistoryMapper) MapCandlestickToPriceHistoryEntity(candlestick *common.Candlestick) entity.PriceHistoryEntity {
+	openPrice, _ := candlestick.Open.Float64()
+	closePrice, _ := candlestick.Close.Float64()
+	highPrice, _ := candlestick.High.Float64()
+	lowPrice, _ := candlestick.Low.Float64()
+	volume, _ := candlestick.Volume.Float64()
+	return &entity.PriceHistory{
+		Exchange: candlestick.Exchange,
+		Base:     candlestick.CurrencyPair.Base,
+		Quote:    candlestick.CurrencyPair.Quote,
+		Period:   candlestick.Period,
+		Time:     candlestick.Date.Unix(),
+		Open:     openPrice,
+		Close:    closePrice,
+		High:     highPrice,
+		Low:      lowPrice,
+		Volume:   volume}
+}
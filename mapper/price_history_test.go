@@ -0,0 +1,48 @@
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceHistoryMapper(t *testing.T) {
+	mapper := NewPriceHistoryMapper()
+	date := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	candlestick := &common.Candlestick{
+		Exchange: "GDAX",
+		CurrencyPair: &common.CurrencyPair{
+			Base:  "BTC",
+			Quote: "USD"},
+		Period: 900,
+		Date:   date,
+		Open:   decimal.NewFromFloat(13000.5),
+		Close:  decimal.NewFromFloat(13100.25),
+		High:   decimal.NewFromFloat(13250),
+		Low:    decimal.NewFromFloat(12900),
+		Volume: decimal.NewFromFloat(42.5)}
+
+	entity := mapper.MapCandlestickToPriceHistoryEntity(candlestick)
+	assert.Equal(t, "GDAX", entity.GetExchange())
+	assert.Equal(t, "BTC", entity.GetBase())
+	assert.Equal(t, "USD", entity.GetQuote())
+	assert.Equal(t, 900, entity.GetPeriod())
+	assert.Equal(t, date.Unix(), entity.GetTime())
+	assert.Equal(t, 13000.5, entity.GetOpen())
+	assert.Equal(t, 13100.25, entity.GetClose())
+
+	mapped := mapper.MapPriceHistoryEntityToCandlestick(entity)
+	assert.Equal(t, "GDAX", mapped.Exchange)
+	assert.Equal(t, "BTC", mapped.CurrencyPair.Base)
+	assert.Equal(t, "USD", mapped.CurrencyPair.Quote)
+	assert.Equal(t, 900, mapped.Period)
+	assert.True(t, date.Equal(mapped.Date))
+	assert.True(t, candlestick.Open.Equal(mapped.Open))
+	assert.True(t, candlestick.Close.Equal(mapped.Close))
+	assert.True(t, candlestick.High.Equal(mapped.High))
+	assert.True(t, candlestick.Low.Equal(mapped.Low))
+	assert.True(t, candlestick.Volume.Equal(mapped.Volume))
+}
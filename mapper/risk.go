@@ -0,0 +1,107 @@
+package mapper
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+type RiskMapper interface {
+	MapRiskLimitEntityToDto(entity entity.RiskLimitEntity) common.RiskLimit
+	MapRiskLimitDtoToEntity(dto common.RiskLimit) entity.RiskLimitEntity
+	MapRiskRejectionEntityToDto(entity entity.RiskRejectionEntity) common.RiskRejection
+	MapRiskRejectionDtoToEntity(dto common.RiskRejection) entity.RiskRejectionEntity
+}
+
+type DefaultRiskMapper struct {
+	ctx common.Context
+	RiskMapper
+}
+
+func NewRiskMapper(ctx common.Context) RiskMapper {
+	return &DefaultRiskMapper{ctx: ctx}
+}
+
+func (mapper *DefaultRiskMapper) MapRiskLimitEntityToDto(entity entity.RiskLimitEntity) common.RiskLimit {
+	exposures := make(map[string]decimal.Decimal)
+	for _, exposure := range entity.GetExposures() {
+		exposures[exposure.GetCurrency()] = mapper.parseDecimal("exposure", exposure.GetMaxQuantity())
+	}
+	return &dto.RiskLimitDTO{
+		Id:                entity.GetId(),
+		UserId:            entity.GetUserId(),
+		ChartId:           entity.GetChartId(),
+		MaxPositionSize:   mapper.parseDecimal("max position size", entity.GetMaxPositionSize()),
+		MaxDailyLoss:      mapper.parseDecimal("max daily loss", entity.GetMaxDailyLoss()),
+		MaxTradesPerHour:  entity.GetMaxTradesPerHour(),
+		MinBalanceReserve: mapper.parseDecimal("min balance reserve", entity.GetMinBalanceReserve()),
+		KillSwitch:        entity.GetKillSwitch(),
+		Exposures:         exposures}
+}
+
+func (mapper *DefaultRiskMapper) MapRiskLimitDtoToEntity(dto common.RiskLimit) entity.RiskLimitEntity {
+	currencies := make([]string, 0, len(dto.GetExposures()))
+	for currency := range dto.GetExposures() {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	exposures := make([]entity.RiskExposure, len(currencies))
+	for i, currency := range currencies {
+		exposures[i] = entity.RiskExposure{
+			LimitId:     dto.GetId(),
+			Currency:    currency,
+			MaxQuantity: dto.GetExposures()[currency].String()}
+	}
+	return &entity.RiskLimit{
+		Id:                dto.GetId(),
+		UserId:            dto.GetUserId(),
+		ChartId:           dto.GetChartId(),
+		MaxPositionSize:   dto.GetMaxPositionSize().String(),
+		MaxDailyLoss:      dto.GetMaxDailyLoss().String(),
+		MaxTradesPerHour:  dto.GetMaxTradesPerHour(),
+		MinBalanceReserve: dto.GetMinBalanceReserve().String(),
+		KillSwitch:        dto.GetKillSwitch(),
+		Exposures:         exposures}
+}
+
+func (mapper *DefaultRiskMapper) MapRiskRejectionEntityToDto(entity entity.RiskRejectionEntity) common.RiskRejection {
+	return &dto.RiskRejectionDTO{
+		Id:       entity.GetId(),
+		UserId:   entity.GetUserId(),
+		ChartId:  entity.GetChartId(),
+		Date:     entity.GetDate(),
+		Base:     entity.GetBase(),
+		Quote:    entity.GetQuote(),
+		Type:     entity.GetType(),
+		Price:    mapper.parseDecimal("price", entity.GetPrice()),
+		Quantity: mapper.parseDecimal("quantity", entity.GetQuantity()),
+		Reason:   entity.GetReason()}
+}
+
+func (mapper *DefaultRiskMapper) MapRiskRejectionDtoToEntity(dto common.RiskRejection) entity.RiskRejectionEntity {
+	return &entity.RiskRejection{
+		Id:       dto.GetId(),
+		UserId:   dto.GetUserId(),
+		ChartId:  dto.GetChartId(),
+		Date:     dto.GetDate(),
+		Base:     dto.GetBase(),
+		Quote:    dto.GetQuote(),
+		Type:     dto.GetType(),
+		Price:    dto.GetPrice().String(),
+		Quantity: dto.GetQuantity().String(),
+		Reason:   dto.GetReason()}
+}
+
+func (mapper *DefaultRiskMapper) parseDecimal(name, value string) decimal.Decimal {
+	if value == "" {
+		return decimal.NewFromFloat(0)
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[RiskMapper.parseDecimal] Error parsing %s decimal: %s", name, err.Error())
+	}
+	return d
+}
@@ -0,0 +1,80 @@
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskMapper_Limit(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewRiskMapper(ctx)
+	dto := &dto.RiskLimitDTO{
+		Id:                1,
+		UserId:            1,
+		MaxPositionSize:   decimal.NewFromFloat(5000),
+		MaxDailyLoss:      decimal.NewFromFloat(250.5),
+		MaxTradesPerHour:  6,
+		MinBalanceReserve: decimal.NewFromFloat(1000),
+		KillSwitch:        common.KILL_SWITCH_FLATTEN,
+		Exposures: map[string]decimal.Decimal{
+			"ETH": decimal.NewFromFloat(20),
+			"BTC": decimal.NewFromFloat(1.5)}}
+
+	entity := mapper.MapRiskLimitDtoToEntity(dto)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, uint(0), entity.GetChartId())
+	assert.Equal(t, "5000", entity.GetMaxPositionSize())
+	assert.Equal(t, "250.5", entity.GetMaxDailyLoss())
+	assert.Equal(t, 6, entity.GetMaxTradesPerHour())
+	assert.Equal(t, "1000", entity.GetMinBalanceReserve())
+	assert.Equal(t, common.KILL_SWITCH_FLATTEN, entity.GetKillSwitch())
+	assert.Equal(t, 2, len(entity.GetExposures()))
+	assert.Equal(t, "BTC", entity.GetExposures()[0].GetCurrency())
+	assert.Equal(t, "1.5", entity.GetExposures()[0].GetMaxQuantity())
+	assert.Equal(t, uint(1), entity.GetExposures()[1].GetLimitId())
+
+	mapped := mapper.MapRiskLimitEntityToDto(entity)
+	assert.Equal(t, dto.GetUserId(), mapped.GetUserId())
+	assert.True(t, dto.GetMaxPositionSize().Equal(mapped.GetMaxPositionSize()))
+	assert.True(t, dto.GetMaxDailyLoss().Equal(mapped.GetMaxDailyLoss()))
+	assert.True(t, dto.GetMinBalanceReserve().Equal(mapped.GetMinBalanceReserve()))
+	assert.Equal(t, common.KILL_SWITCH_FLATTEN, mapped.GetKillSwitch())
+	assert.True(t, decimal.NewFromFloat(20).Equal(mapped.GetExposures()["ETH"]))
+	assert.True(t, decimal.NewFromFloat(1.5).Equal(mapped.GetExposures()["BTC"]))
+}
+
+func TestRiskMapper_Rejection(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewRiskMapper(ctx)
+	dto := &dto.RiskRejectionDTO{
+		Id:       1,
+		UserId:   1,
+		ChartId:  2,
+		Date:     time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		Base:     "BTC",
+		Quote:    "USD",
+		Type:     common.BUY_ORDER_TYPE,
+		Price:    decimal.NewFromFloat(13000),
+		Quantity: decimal.NewFromFloat(0.25),
+		Reason:   "Kill switch engaged: halt"}
+
+	entity := mapper.MapRiskRejectionDtoToEntity(dto)
+	assert.Equal(t, uint(2), entity.GetChartId())
+	assert.Equal(t, "13000", entity.GetPrice())
+	assert.Equal(t, "0.25", entity.GetQuantity())
+	assert.Equal(t, dto.GetReason(), entity.GetReason())
+
+	mapped := mapper.MapRiskRejectionEntityToDto(entity)
+	assert.True(t, dto.GetDate().Equal(mapped.GetDate()))
+	assert.Equal(t, "BTC", mapped.GetBase())
+	assert.Equal(t, common.BUY_ORDER_TYPE, mapped.GetType())
+	assert.True(t, dto.GetPrice().Equal(mapped.GetPrice()))
+	assert.True(t, dto.GetQuantity().Equal(mapped.GetQuantity()))
+	assert.Equal(t, dto.GetReason(), mapped.GetReason())
+}
@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	"encoding/json"
 	"strings"
 
 	"github.com/jeremyhahn/tradebot/common"
@@ -13,6 +14,8 @@ type TransactionMapper interface {
 	MapTransactionEntityToDto(entity entity.TransactionEntity) common.Transaction
 	MapTransactionDtoToEntity(dto common.Transaction) entity.TransactionEntity
 	MapTransactionDtoToViewModel(dto common.Transaction) viewmodel.Transaction
+	MapTransactionRevisionEntityToDto(entity entity.TransactionRevisionEntity) common.TransactionRevision
+	MapTransactionRevisionDtoToEntity(dto common.TransactionRevision) entity.TransactionRevisionEntity
 }
 
 type DefaultTransactionMapper struct {
@@ -54,6 +57,7 @@ func (mapper *DefaultTransactionMapper) MapTransactionEntityToDto(entity entity.
 		TotalCurrency:     entity.GetTotalCurrency(),
 		FiatTotal:         entity.GetFiatTotal(),
 		FiatTotalCurrency: entity.GetFiatTotalCurrency(),
+		TransferId:        entity.GetTransferId(),
 		Deleted:           entity.IsDeleted()}
 }
 
@@ -91,6 +95,7 @@ func (mapper *DefaultTransactionMapper) MapTransactionDtoToEntity(dto common.Tra
 		TotalCurrency:     dto.GetTotalCurrency(),
 		FiatTotal:         dto.GetFiatTotal(),
 		FiatTotalCurrency: dto.GetFiatTotalCurrency(),
+		TransferId:        dto.GetTransferId(),
 		Deleted:           deleted}
 }
 
@@ -120,5 +125,53 @@ func (mapper *DefaultTransactionMapper) MapTransactionDtoToViewModel(dto common.
 		Total:             dto.GetTotal(),
 		TotalCurrency:     dto.GetTotalCurrency(),
 		FiatTotal:         dto.GetFiatTotal(),
-		FiatTotalCurrency: dto.GetFiatTotalCurrency()}
+		FiatTotalCurrency: dto.GetFiatTotalCurrency(),
+		TransferId:        dto.GetTransferId()}
+}
+
+func (mapper *DefaultTransactionMapper) MapTransactionRevisionEntityToDto(entity entity.TransactionRevisionEntity) common.TransactionRevision {
+	return &dto.TransactionRevisionDTO{
+		Id:            entity.GetId(),
+		UserId:        entity.GetUserId(),
+		TransactionId: entity.GetTransactionId(),
+		Action:        entity.GetAction(),
+		Date:          entity.GetDate(),
+		Previous:      mapper.unmarshalTransaction(entity.GetPrevious()),
+		Current:       mapper.unmarshalTransaction(entity.GetCurrent())}
+}
+
+func (mapper *DefaultTransactionMapper) MapTransactionRevisionDtoToEntity(dto common.TransactionRevision) entity.TransactionRevisionEntity {
+	return &entity.TransactionRevision{
+		Id:            dto.GetId(),
+		UserId:        mapper.ctx.GetUser().GetId(),
+		TransactionId: dto.GetTransactionId(),
+		Action:        dto.GetAction(),
+		Date:          dto.GetDate(),
+		Previous:      mapper.marshalTransaction(dto.GetPrevious()),
+		Current:       mapper.marshalTransaction(dto.GetCurrent())}
+}
+
+func (mapper *DefaultTransactionMapper) marshalTransaction(tx common.Transaction) string {
+	if tx == nil {
+		return ""
+	}
+	bytes, err := json.Marshal(tx)
+	if err != nil {
+		mapper.ctx.GetLogger().Errorf("[TransactionMapper.marshalTransaction] Error marshalling transaction %s: %s",
+			tx.GetId(), err.Error())
+		return ""
+	}
+	return string(bytes)
+}
+
+func (mapper *DefaultTransactionMapper) unmarshalTransaction(data string) common.Transaction {
+	if data == "" {
+		return nil
+	}
+	var tx dto.TransactionDTO
+	if err := json.Unmarshal([]byte(data), &tx); err != nil {
+		mapper.ctx.GetLogger().Errorf("[TransactionMapper.unmarshalTransaction] Error unmarshalling transaction: %s", err.Error())
+		return nil
+	}
+	return &tx
 }
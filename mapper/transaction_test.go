@@ -0,0 +1,56 @@
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionMapper_Revision(t *testing.T) {
+	ctx := test.NewUnitTestContext()
+	mapper := NewTransactionMapper(ctx)
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	previous := &dto.TransactionDTO{
+		Id:           "manual-1",
+		Date:         time.Date(2018, 01, 01, 12, 0, 0, 0, time.UTC),
+		MarketPair:   currencyPair,
+		CurrencyPair: currencyPair,
+		Type:         common.BUY_ORDER_TYPE,
+		Category:     common.TX_CATEGORY_TRADE,
+		Quantity:     "1",
+		Total:        "1000"}
+	current := *previous
+	current.Quantity = "2"
+	dto := &dto.TransactionRevisionDTO{
+		Id:            1,
+		TransactionId: "manual-1",
+		Action:        common.TX_REVISION_UPDATE,
+		Date:          time.Date(2018, 02, 01, 0, 0, 0, 0, time.UTC),
+		Previous:      previous,
+		Current:       &current}
+
+	entity := mapper.MapTransactionRevisionDtoToEntity(dto)
+	assert.Equal(t, dto.GetId(), entity.GetId())
+	assert.Equal(t, ctx.GetUser().GetId(), entity.GetUserId())
+	assert.Equal(t, dto.GetTransactionId(), entity.GetTransactionId())
+	assert.Equal(t, dto.GetAction(), entity.GetAction())
+	assert.Equal(t, dto.GetDate(), entity.GetDate())
+	assert.Contains(t, entity.GetPrevious(), `"quantity":"1"`)
+	assert.Contains(t, entity.GetCurrent(), `"quantity":"2"`)
+
+	mapped := mapper.MapTransactionRevisionEntityToDto(entity)
+	assert.Equal(t, dto.GetAction(), mapped.GetAction())
+	assert.Equal(t, previous.GetDate(), mapped.GetPrevious().GetDate())
+	assert.Equal(t, previous.GetCurrencyPair(), mapped.GetPrevious().GetCurrencyPair())
+	assert.Equal(t, "2", mapped.GetCurrent().GetQuantity())
+
+	// Created transactions have no previous state
+	dto.Previous = nil
+	entity = mapper.MapTransactionRevisionDtoToEntity(dto)
+	assert.Equal(t, "", entity.GetPrevious())
+	assert.Nil(t, mapper.MapTransactionRevisionEntityToDto(entity).GetPrevious())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -502,6 +503,150 @@ func (b *Binance) GetSummary() common.CryptoExchangeSummary {
 	return exchange
 }
 
+func (b *Binance) PlaceMarketOrder(currencyPair *common.CurrencyPair, side string, quantity decimal.Decimal) (common.Order, error) {
+	symbol := b.FormattedCurrencyPair(currencyPair)
+	b.logger.Debugf("[Binance.PlaceMarketOrder] Placing market %s order for %s %s", side, quantity, symbol)
+	placed, err := b.client.NewCreateOrderService().Symbol(symbol).
+		Side(b.sideType(side)).Type(binance.OrderTypeMarket).
+		Quantity(quantity.String()).Do(context.Background())
+	if err != nil {
+		b.logger.Errorf("[Binance.PlaceMarketOrder] Error placing order: %s", err.Error())
+		return nil, err
+	}
+	return b.GetOrder(currencyPair, fmt.Sprintf("%d", placed.OrderID))
+}
+
+func (b *Binance) PlaceLimitOrder(currencyPair *common.CurrencyPair, side string, quantity, price decimal.Decimal) (common.Order, error) {
+	symbol := b.FormattedCurrencyPair(currencyPair)
+	b.logger.Debugf("[Binance.PlaceLimitOrder] Placing limit %s order for %s %s at %s", side, quantity, symbol, price)
+	placed, err := b.client.NewCreateOrderService().Symbol(symbol).
+		Side(b.sideType(side)).Type(binance.OrderTypeLimit).TimeInForce(binance.TimeInForceGTC).
+		Quantity(quantity.String()).Price(price.String()).Do(context.Background())
+	if err != nil {
+		b.logger.Errorf("[Binance.PlaceLimitOrder] Error placing order: %s", err.Error())
+		return nil, err
+	}
+	return b.GetOrder(currencyPair, fmt.Sprintf("%d", placed.OrderID))
+}
+
+func (b *Binance) CancelOrder(currencyPair *common.CurrencyPair, orderId string) error {
+	b.logger.Debugf("[Binance.CancelOrder] Cancelling order %s", orderId)
+	id, err := strconv.ParseInt(orderId, 10, 64)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid Binance order id: %s", orderId))
+	}
+	_, err = b.client.NewCancelOrderService().Symbol(b.FormattedCurrencyPair(currencyPair)).
+		OrderID(id).Do(context.Background())
+	return err
+}
+
+func (b *Binance) GetOrder(currencyPair *common.CurrencyPair, orderId string) (common.Order, error) {
+	id, err := strconv.ParseInt(orderId, 10, 64)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid Binance order id: %s", orderId))
+	}
+	order, err := b.client.NewGetOrderService().Symbol(b.FormattedCurrencyPair(currencyPair)).
+		OrderID(id).Do(context.Background())
+	if err != nil {
+		b.logger.Errorf("[Binance.GetOrder] Error retrieving order %s: %s", orderId, err.Error())
+		return nil, err
+	}
+	return b.newOrder(currencyPair, order)
+}
+
+func (b *Binance) GetOpenOrders(currencyPair *common.CurrencyPair) ([]common.Order, error) {
+	orders, err := b.client.NewListOpenOrdersService().Symbol(b.FormattedCurrencyPair(currencyPair)).
+		Do(context.Background())
+	if err != nil {
+		b.logger.Errorf("[Binance.GetOpenOrders] Error retrieving open orders: %s", err.Error())
+		return nil, err
+	}
+	var openOrders []common.Order
+	for _, o := range orders {
+		order, err := b.newOrder(currencyPair, o)
+		if err != nil {
+			return nil, err
+		}
+		openOrders = append(openOrders, order)
+	}
+	return openOrders, nil
+}
+
+func (b *Binance) newOrder(currencyPair *common.CurrencyPair, order *binance.Order) (common.Order, error) {
+	quantity, _ := decimal.NewFromString(order.OrigQuantity)
+	price, _ := decimal.NewFromString(order.Price)
+	filled, _ := decimal.NewFromString(order.ExecutedQuantity)
+	status := common.ORDER_STATUS_CANCELLED
+	switch string(order.Status) {
+	case "NEW", "PARTIALLY_FILLED":
+		status = common.ORDER_STATUS_OPEN
+	case "FILLED":
+		status = common.ORDER_STATUS_FILLED
+	}
+	fillPrice, fee, feeCurrency, err := b.getFills(currencyPair, order, filled)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.OrderDTO{
+		Id:             fmt.Sprintf("%d", order.OrderID),
+		Exchange:       b.name,
+		CurrencyPair:   currencyPair,
+		Side:           strings.ToLower(string(order.Side)),
+		Type:           strings.ToLower(string(order.Type)),
+		Status:         status,
+		Date:           time.Unix(order.Time/1000, 0).UTC(),
+		Quantity:       quantity,
+		Price:          price,
+		FilledQuantity: filled,
+		FillPrice:      fillPrice,
+		Fee:            fee,
+		FeeCurrency:    feeCurrency}, nil
+}
+
+// getFills returns the average price and the commission of the trades that
+// filled an order. Orders nothing was executed for are returned at their limit
+// price without looking up the account's trades.
+func (b *Binance) getFills(currencyPair *common.CurrencyPair, order *binance.Order,
+	executed decimal.Decimal) (decimal.Decimal, decimal.Decimal, string, error) {
+	zero := decimal.NewFromFloat(0)
+	if !executed.GreaterThan(zero) {
+		price, _ := decimal.NewFromString(order.Price)
+		return price, zero, currencyPair.Quote, nil
+	}
+	trades, err := b.client.NewListTradesService().Symbol(b.FormattedCurrencyPair(currencyPair)).
+		Do(context.Background())
+	if err != nil {
+		b.logger.Errorf("[Binance.getFills] Error retrieving trades: %s", err.Error())
+		return zero, zero, "", err
+	}
+	filled, cost, fee := zero, zero, zero
+	feeCurrency := currencyPair.Quote
+	for _, trade := range trades {
+		if trade.OrderID != order.OrderID {
+			continue
+		}
+		quantity, _ := decimal.NewFromString(trade.Quantity)
+		price, _ := decimal.NewFromString(trade.Price)
+		commission, _ := decimal.NewFromString(trade.Commission)
+		filled = filled.Add(quantity)
+		cost = cost.Add(quantity.Mul(price))
+		fee = fee.Add(commission)
+		feeCurrency = trade.CommissionAsset
+	}
+	if filled.Equals(zero) {
+		price, _ := decimal.NewFromString(order.Price)
+		return price, zero, feeCurrency, nil
+	}
+	return cost.Div(filled), fee, feeCurrency, nil
+}
+
+func (b *Binance) sideType(side string) binance.SideType {
+	if side == common.SELL_ORDER_TYPE {
+		return binance.SideTypeSell
+	}
+	return binance.SideTypeBuy
+}
+
 func (b *Binance) FormattedCurrencyPair(currencyPair *common.CurrencyPair) string {
 	cp := b.localizedCurrencyPair(currencyPair)
 	return fmt.Sprintf("%s%s", cp.Base, cp.Quote)
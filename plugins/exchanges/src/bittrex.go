@@ -529,6 +529,111 @@ func (b *Bittrex) ParseImport(file string) ([]common.Transaction, error) {
 	return orders, nil
 }
 
+// PlaceMarketOrder places a limit order at the current ask (buy) or bid (sell);
+// the Bittrex API does not support market orders.
+func (b *Bittrex) PlaceMarketOrder(marketPair *common.CurrencyPair, side string, quantity decimal.Decimal) (common.Order, error) {
+	BITTREX_RATE_LIMITER.RespectRateLimit()
+	ticker, err := b.client.GetTicker(b.FormattedCurrencyPair(marketPair))
+	if err != nil {
+		b.logger.Errorf("[Bittrex.PlaceMarketOrder] Error retrieving ticker: %s", err.Error())
+		return nil, err
+	}
+	price := ticker.Ask
+	if side == common.SELL_ORDER_TYPE {
+		price = ticker.Bid
+	}
+	return b.PlaceLimitOrder(marketPair, side, quantity, price)
+}
+
+func (b *Bittrex) PlaceLimitOrder(marketPair *common.CurrencyPair, side string, quantity, price decimal.Decimal) (common.Order, error) {
+	BITTREX_RATE_LIMITER.RespectRateLimit()
+	market := b.FormattedCurrencyPair(marketPair)
+	b.logger.Debugf("[Bittrex.PlaceLimitOrder] Placing limit %s order for %s %s at %s", side, quantity, market, price)
+	var uuid string
+	var err error
+	if side == common.SELL_ORDER_TYPE {
+		uuid, err = b.client.SellLimit(market, quantity, price)
+	} else {
+		uuid, err = b.client.BuyLimit(market, quantity, price)
+	}
+	if err != nil {
+		b.logger.Errorf("[Bittrex.PlaceLimitOrder] Error placing order: %s", err.Error())
+		return nil, err
+	}
+	return b.GetOrder(marketPair, uuid)
+}
+
+func (b *Bittrex) CancelOrder(marketPair *common.CurrencyPair, orderId string) error {
+	BITTREX_RATE_LIMITER.RespectRateLimit()
+	b.logger.Debugf("[Bittrex.CancelOrder] Cancelling order %s", orderId)
+	return b.client.CancelOrder(orderId)
+}
+
+func (b *Bittrex) GetOrder(marketPair *common.CurrencyPair, orderId string) (common.Order, error) {
+	BITTREX_RATE_LIMITER.RespectRateLimit()
+	order, err := b.client.GetOrder(orderId)
+	if err != nil {
+		b.logger.Errorf("[Bittrex.GetOrder] Error retrieving order %s: %s", orderId, err.Error())
+		return nil, err
+	}
+	filled := order.Quantity.Sub(order.QuantityRemaining)
+	status := common.ORDER_STATUS_CANCELLED
+	if order.IsOpen {
+		status = common.ORDER_STATUS_OPEN
+	} else if order.QuantityRemaining.Equals(decimal.NewFromFloat(0)) {
+		status = common.ORDER_STATUS_FILLED
+	}
+	side := common.SELL_ORDER_TYPE
+	if strings.Contains(order.Type, "BUY") {
+		side = common.BUY_ORDER_TYPE
+	}
+	return &dto.OrderDTO{
+		Id:             order.OrderUuid,
+		Exchange:       b.name,
+		CurrencyPair:   marketPair,
+		Side:           side,
+		Type:           common.ORDER_TYPE_LIMIT,
+		Status:         status,
+		Date:           time.Now(),
+		Quantity:       order.Quantity,
+		Price:          order.Limit,
+		FilledQuantity: filled,
+		FillPrice:      order.PricePerUnit,
+		Fee:            order.CommissionPaid,
+		FeeCurrency:    b.localizedCurrencyPair(marketPair).Base}, nil
+}
+
+func (b *Bittrex) GetOpenOrders(marketPair *common.CurrencyPair) ([]common.Order, error) {
+	BITTREX_RATE_LIMITER.RespectRateLimit()
+	openOrders, err := b.client.GetOpenOrders(b.FormattedCurrencyPair(marketPair))
+	if err != nil {
+		b.logger.Errorf("[Bittrex.GetOpenOrders] Error retrieving open orders: %s", err.Error())
+		return nil, err
+	}
+	var orders []common.Order
+	for _, o := range openOrders {
+		side := common.SELL_ORDER_TYPE
+		if strings.Contains(o.OrderType, "BUY") {
+			side = common.BUY_ORDER_TYPE
+		}
+		orders = append(orders, &dto.OrderDTO{
+			Id:             o.OrderUuid,
+			Exchange:       b.name,
+			CurrencyPair:   marketPair,
+			Side:           side,
+			Type:           common.ORDER_TYPE_LIMIT,
+			Status:         common.ORDER_STATUS_OPEN,
+			Date:           o.TimeStamp.Time,
+			Quantity:       o.Quantity,
+			Price:          o.Limit,
+			FilledQuantity: o.Quantity.Sub(o.QuantityRemaining),
+			FillPrice:      o.PricePerUnit,
+			Fee:            o.Commission,
+			FeeCurrency:    b.localizedCurrencyPair(marketPair).Base})
+	}
+	return orders, nil
+}
+
 func (b *Bittrex) FormattedCurrencyPair(marketPair *common.CurrencyPair) string {
 	cp := b.localizedCurrencyPair(marketPair)
 	return fmt.Sprintf("%s-%s", cp.Base, cp.Quote)
@@ -681,6 +681,113 @@ func (_gdax *GDAX) GetSummary() common.CryptoExchangeSummary {
 	return exchange
 }
 
+func (_gdax *GDAX) PlaceMarketOrder(currencyPair *common.CurrencyPair, side string, quantity decimal.Decimal) (common.Order, error) {
+	size, _ := quantity.Float64()
+	return _gdax.placeOrder(&gdax.Order{
+		Type:      common.ORDER_TYPE_MARKET,
+		Side:      side,
+		ProductId: _gdax.FormattedCurrencyPair(currencyPair),
+		Size:      size})
+}
+
+func (_gdax *GDAX) PlaceLimitOrder(currencyPair *common.CurrencyPair, side string, quantity, price decimal.Decimal) (common.Order, error) {
+	size, _ := quantity.Float64()
+	limit, _ := price.Float64()
+	return _gdax.placeOrder(&gdax.Order{
+		Type:      common.ORDER_TYPE_LIMIT,
+		Side:      side,
+		ProductId: _gdax.FormattedCurrencyPair(currencyPair),
+		Size:      size,
+		Price:     limit})
+}
+
+func (_gdax *GDAX) placeOrder(order *gdax.Order) (common.Order, error) {
+	_gdax.logger.Debugf("[GDAX.placeOrder] Placing %s %s order for %f %s", order.Type, order.Side, order.Size, order.ProductId)
+	GDAX_RATELIMITER.RespectRateLimit()
+	placed, err := _gdax.gdax.CreateOrder(order)
+	if err != nil {
+		_gdax.logger.Errorf("[GDAX.placeOrder] Error placing order: %s", err.Error())
+		return nil, err
+	}
+	return _gdax.newOrder(placed)
+}
+
+func (_gdax *GDAX) CancelOrder(currencyPair *common.CurrencyPair, orderId string) error {
+	_gdax.logger.Debugf("[GDAX.CancelOrder] Cancelling order %s", orderId)
+	GDAX_RATELIMITER.RespectRateLimit()
+	return _gdax.gdax.CancelOrder(orderId)
+}
+
+func (_gdax *GDAX) GetOrder(currencyPair *common.CurrencyPair, orderId string) (common.Order, error) {
+	GDAX_RATELIMITER.RespectRateLimit()
+	order, err := _gdax.gdax.GetOrder(orderId)
+	if err != nil {
+		_gdax.logger.Errorf("[GDAX.GetOrder] Error retrieving order %s: %s", orderId, err.Error())
+		return nil, err
+	}
+	return _gdax.newOrder(order)
+}
+
+func (_gdax *GDAX) GetOpenOrders(currencyPair *common.CurrencyPair) ([]common.Order, error) {
+	productId := _gdax.FormattedCurrencyPair(currencyPair)
+	var openOrders []common.Order
+	var orders []gdax.Order
+	GDAX_RATELIMITER.RespectRateLimit()
+	cursor := _gdax.gdax.ListOrders(gdax.ListOrdersParams{Status: "open"})
+	for cursor.HasMore {
+		if err := cursor.NextPage(&orders); err != nil {
+			_gdax.logger.Errorf("[GDAX.GetOpenOrders] ListOrders pagination error: %s", err.Error())
+			return nil, err
+		}
+		for _, o := range orders {
+			if o.ProductId != productId {
+				continue
+			}
+			order, err := _gdax.newOrder(o)
+			if err != nil {
+				return nil, err
+			}
+			openOrders = append(openOrders, order)
+		}
+	}
+	return openOrders, nil
+}
+
+// newOrder converts a GDAX order. Orders are pending or open until done, when
+// the done reason tells whether they filled or were cancelled.
+func (_gdax *GDAX) newOrder(order gdax.Order) (common.Order, error) {
+	currencyPair, err := common.NewCurrencyPair(order.ProductId, _gdax.ctx.GetUser().GetLocalCurrency())
+	if err != nil {
+		return nil, err
+	}
+	status := common.ORDER_STATUS_OPEN
+	switch {
+	case order.Status == "done" && order.DoneReason == "filled":
+		status = common.ORDER_STATUS_FILLED
+	case order.Status == "done" || order.Status == "rejected":
+		status = common.ORDER_STATUS_CANCELLED
+	}
+	filled := decimal.NewFromFloat(order.FilledSize)
+	fillPrice := decimal.NewFromFloat(0)
+	if filled.GreaterThan(fillPrice) {
+		fillPrice = decimal.NewFromFloat(order.ExecutedValue).Div(filled)
+	}
+	return &dto.OrderDTO{
+		Id:             order.Id,
+		Exchange:       _gdax.name,
+		CurrencyPair:   currencyPair,
+		Side:           order.Side,
+		Type:           order.Type,
+		Status:         status,
+		Date:           order.CreatedAt.Time(),
+		Quantity:       decimal.NewFromFloat(order.Size),
+		Price:          decimal.NewFromFloat(order.Price),
+		FilledQuantity: filled,
+		FillPrice:      fillPrice,
+		Fee:            decimal.NewFromFloat(order.FillFees),
+		FeeCurrency:    currencyPair.Quote}, nil
+}
+
 func (_gdax *GDAX) ParseImport(file string) ([]common.Transaction, error) {
 	var orders []common.Transaction
 	_gdax.ctx.GetLogger().Error("[GDAX.ParseImport] Unsupported!")
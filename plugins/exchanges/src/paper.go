@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+	"github.com/op/go-logging"
+	"github.com/shopspring/decimal"
+)
+
+// Paper trades against simulated balances kept in the database, using the
+// wrapped exchange (named by the user exchange key) for market data only.
+type Paper struct {
+	ctx          common.Context
+	logger       *logging.Logger
+	exchange     common.Exchange
+	paperService service.PaperAccountService
+	name         string
+	prices       map[string]decimal.Decimal
+	mutex        sync.Mutex
+	common.Exchange
+}
+
+func CreatePaper(ctx common.Context, userExchangeEntity entity.UserExchangeEntity) common.Exchange {
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, dao.NewUserDAO(ctx), mapper.NewUserMapper(),
+		mapper.NewUserExchangeMapper(), pluginService)
+	exchange, err := exchangeService.CreateExchange(userExchangeEntity.GetKey())
+	if err != nil {
+		ctx.GetLogger().Errorf("[Paper.CreatePaper] Error creating %s exchange: %s", userExchangeEntity.GetKey(), err.Error())
+		return nil
+	}
+	return newPaper(ctx, exchange, service.NewPaperAccountService(ctx,
+		dao.NewPaperAccountDAO(ctx), mapper.NewPaperAccountMapper(ctx)))
+}
+
+func newPaper(ctx common.Context, exchange common.Exchange, paperService service.PaperAccountService) *Paper {
+	return &Paper{
+		ctx:          ctx,
+		logger:       ctx.GetLogger(),
+		exchange:     exchange,
+		paperService: paperService,
+		name:         common.PAPER_EXCHANGE,
+		prices:       make(map[string]decimal.Decimal)}
+}
+
+func (p *Paper) GetName() string {
+	return p.name
+}
+
+func (p *Paper) GetDisplayName() string {
+	return fmt.Sprintf("%s %s", p.name, p.exchange.GetDisplayName())
+}
+
+func (p *Paper) GetTradingFee() decimal.Decimal {
+	account, err := p.paperService.GetAccount(p.exchange.GetName())
+	if err != nil {
+		p.logger.Errorf("[Paper.GetTradingFee] Error: %s", err.Error())
+		return p.exchange.GetTradingFee()
+	}
+	return account.GetFee()
+}
+
+// SubscribeToLiveFeed relays the wrapped exchange's live prices, filling the
+// open limit orders each price crosses before passing it on.
+func (p *Paper) SubscribeToLiveFeed(currencyPair *common.CurrencyPair, priceChange chan common.PriceChange) {
+	feed := make(chan common.PriceChange, common.BUFFERED_CHANNEL_SIZE)
+	go p.exchange.SubscribeToLiveFeed(currencyPair, feed)
+	for change := range feed {
+		p.setPrice(currencyPair, change.Price)
+		if _, err := p.paperService.FillOrders(p.exchange.GetName(), currencyPair, change.Price); err != nil {
+			p.logger.Errorf("[Paper.SubscribeToLiveFeed] Error filling orders: %s", err.Error())
+		}
+		change.Exchange = p.name
+		priceChange <- change
+	}
+}
+
+// GetPrice returns the last live feed price for the currency pair, or the close
+// of the wrapped exchange's most recent one minute candle.
+func (p *Paper) GetPrice(currencyPair *common.CurrencyPair) decimal.Decimal {
+	p.mutex.Lock()
+	price, ok := p.prices[p.FormattedCurrencyPair(currencyPair)]
+	p.mutex.Unlock()
+	if ok {
+		return price
+	}
+	now := time.Now()
+	candlesticks, err := p.exchange.GetPriceHistory(currencyPair, now.Add(-10*time.Minute), now, 60)
+	if err != nil {
+		p.logger.Errorf("[Paper.GetPrice] Error retrieving %s price: %s", currencyPair, err.Error())
+		return decimal.NewFromFloat(0)
+	}
+	var latest common.Candlestick
+	for _, candlestick := range candlesticks {
+		if candlestick.Date.After(latest.Date) {
+			latest = candlestick
+		}
+	}
+	return latest.Close
+}
+
+func (p *Paper) GetPriceHistory(currencyPair *common.CurrencyPair,
+	start, end time.Time, granularity int) ([]common.Candlestick, error) {
+	return p.exchange.GetPriceHistory(currencyPair, start, end, granularity)
+}
+
+func (p *Paper) GetCurrencies() (map[string]*common.Currency, error) {
+	return p.exchange.GetCurrencies()
+}
+
+func (p *Paper) FormattedCurrencyPair(currencyPair *common.CurrencyPair) string {
+	return p.exchange.FormattedCurrencyPair(currencyPair)
+}
+
+func (p *Paper) GetBalances() ([]common.Coin, decimal.Decimal) {
+	var coins []common.Coin
+	sum := decimal.NewFromFloat(0)
+	account, err := p.paperService.GetAccount(p.exchange.GetName())
+	if err != nil {
+		p.logger.Errorf("[Paper.GetBalances] Error: %s", err.Error())
+		return coins, sum
+	}
+	localCurrency := p.ctx.GetUser().GetLocalCurrency()
+	for currency, balance := range account.GetBalances() {
+		price := decimal.NewFromFloat(1)
+		if currency != localCurrency {
+			price = p.GetPrice(&common.CurrencyPair{
+				Base:          currency,
+				Quote:         localCurrency,
+				LocalCurrency: localCurrency})
+		}
+		var decimalPlaces int32 = 8
+		if _, exists := common.FiatCurrencies[currency]; exists {
+			decimalPlaces = 2
+		}
+		total := balance.Mul(price)
+		sum = sum.Add(total)
+		coins = append(coins, &dto.CoinDTO{
+			Currency:  currency,
+			Exchange:  p.name,
+			Balance:   balance.Truncate(decimalPlaces),
+			Available: balance.Truncate(decimalPlaces),
+			Pending:   decimal.NewFromFloat(0),
+			Price:     price.Truncate(decimalPlaces),
+			Total:     total.Truncate(decimalPlaces)})
+	}
+	return coins, sum.Truncate(2)
+}
+
+func (p *Paper) GetSummary() common.CryptoExchangeSummary {
+	satoshis := decimal.NewFromFloat(0)
+	balances, total := p.GetBalances()
+	for _, c := range balances {
+		if c.IsBitcoin() {
+			satoshis = satoshis.Add(c.GetBalance())
+		}
+	}
+	return &dto.CryptoExchangeSummaryDTO{
+		Name:     p.GetDisplayName(),
+		Total:    total,
+		Satoshis: satoshis.Truncate(8),
+		Coins:    balances}
+}
+
+func (p *Paper) GetNetWorth() decimal.Decimal {
+	_, total := p.GetBalances()
+	return total
+}
+
+// GetOrderHistory returns the currency pair's filled paper orders as trades.
+func (p *Paper) GetOrderHistory(currencyPair *common.CurrencyPair) []common.Transaction {
+	var transactions []common.Transaction
+	orders, err := p.paperService.GetOrders(p.exchange.GetName())
+	if err != nil {
+		p.logger.Errorf("[Paper.GetOrderHistory] Error: %s", err.Error())
+		return transactions
+	}
+	localCurrency := p.ctx.GetUser().GetLocalCurrency()
+	for _, order := range orders {
+		pair := order.GetCurrencyPair()
+		if order.GetStatus() != common.ORDER_STATUS_FILLED || pair.Base != currencyPair.Base ||
+			pair.Quote != currencyPair.Quote {
+			continue
+		}
+		quantity := order.GetFilledQuantity()
+		price := order.GetFillPrice()
+		fee := order.GetFee()
+		total := quantity.Mul(price)
+		fiatPrice, fiatFee, fiatTotal, fiatCurrency := "0.00", "0.00", "0.00", "N/A"
+		if pair.Quote == localCurrency {
+			fiatPrice, fiatFee, fiatTotal = price.StringFixed(2), fee.StringFixed(2), total.StringFixed(2)
+			fiatCurrency = localCurrency
+		}
+		transactions = append(transactions, &dto.TransactionDTO{
+			Id:                     fmt.Sprintf("paper-%s", order.GetId()),
+			Type:                   order.GetSide(),
+			Category:               common.TX_CATEGORY_TRADE,
+			Date:                   order.GetDate(),
+			Network:                p.name,
+			NetworkDisplayName:     p.GetDisplayName(),
+			MarketPair:             pair,
+			CurrencyPair:           pair,
+			Quantity:               quantity.StringFixed(8),
+			QuantityCurrency:       pair.Base,
+			FiatQuantity:           fiatTotal,
+			FiatQuantityCurrency:   fiatCurrency,
+			Price:                  price.StringFixed(8),
+			PriceCurrency:          pair.Quote,
+			FiatPrice:              fiatPrice,
+			FiatPriceCurrency:      fiatCurrency,
+			QuoteFiatPrice:         fiatPrice,
+			QuoteFiatPriceCurrency: fiatCurrency,
+			Fee:                    fee.StringFixed(8),
+			FeeCurrency:            order.GetFeeCurrency(),
+			FiatFee:                fiatFee,
+			FiatFeeCurrency:        fiatCurrency,
+			Total:                  total.StringFixed(8),
+			TotalCurrency:          pair.Quote,
+			FiatTotal:              fiatTotal,
+			FiatTotalCurrency:      fiatCurrency})
+	}
+	return transactions
+}
+
+func (p *Paper) GetDepositHistory() ([]common.Transaction, error) {
+	return []common.Transaction{}, nil
+}
+
+func (p *Paper) GetWithdrawalHistory() ([]common.Transaction, error) {
+	return []common.Transaction{}, nil
+}
+
+func (p *Paper) ParseImport(file string) ([]common.Transaction, error) {
+	return nil, errors.New("Paper trading accounts do not support imports")
+}
+
+func (p *Paper) PlaceMarketOrder(currencyPair *common.CurrencyPair, side string, quantity decimal.Decimal) (common.Order, error) {
+	return p.paperService.PlaceOrder(p.exchange.GetName(), &dto.OrderDTO{
+		CurrencyPair: currencyPair,
+		Side:         side,
+		Type:         common.ORDER_TYPE_MARKET,
+		Quantity:     quantity}, p.GetPrice(currencyPair))
+}
+
+func (p *Paper) PlaceLimitOrder(currencyPair *common.CurrencyPair, side string, quantity, price decimal.Decimal) (common.Order, error) {
+	return p.paperService.PlaceOrder(p.exchange.GetName(), &dto.OrderDTO{
+		CurrencyPair: currencyPair,
+		Side:         side,
+		Type:         common.ORDER_TYPE_LIMIT,
+		Quantity:     quantity,
+		Price:        price}, p.GetPrice(currencyPair))
+}
+
+func (p *Paper) CancelOrder(currencyPair *common.CurrencyPair, orderId string) error {
+	_, err := p.paperService.CancelOrder(p.exchange.GetName(), orderId)
+	return err
+}
+
+func (p *Paper) GetOrder(currencyPair *common.CurrencyPair, orderId string) (common.Order, error) {
+	return p.paperService.GetOrder(p.exchange.GetName(), orderId)
+}
+
+func (p *Paper) GetOpenOrders(currencyPair *common.CurrencyPair) ([]common.Order, error) {
+	orders, err := p.paperService.GetOrders(p.exchange.GetName())
+	if err != nil {
+		return nil, err
+	}
+	var openOrders []common.Order
+	for _, order := range orders {
+		if order.GetStatus() == common.ORDER_STATUS_OPEN && order.GetCurrencyPair().Base == currencyPair.Base &&
+			order.GetCurrencyPair().Quote == currencyPair.Quote {
+			openOrders = append(openOrders, order)
+		}
+	}
+	return openOrders, nil
+}
+
+func (p *Paper) setPrice(currencyPair *common.CurrencyPair, price decimal.Decimal) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.prices[p.FormattedCurrencyPair(currencyPair)] = price
+}
@@ -0,0 +1,56 @@
+// +build integration
+
+package main
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+	"github.com/jeremyhahn/tradebot/test"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaper_PlaceMarketOrder(t *testing.T) {
+	ctx := test.CreateIntegrationTestContext("../../../.env", "../../../")
+	userDAO := dao.NewUserDAO(ctx)
+	userEntity := &entity.User{Id: ctx.GetUser().GetId()}
+
+	cryptoExchange, err := userDAO.GetExchange(userEntity, "GDAX")
+	assert.Nil(t, err)
+
+	paper := newPaper(ctx, CreateGDAX(ctx, cryptoExchange), service.NewPaperAccountService(ctx,
+		dao.NewPaperAccountDAO(ctx), mapper.NewPaperAccountMapper(ctx)))
+	assert.Equal(t, "Paper", paper.GetName())
+	assert.Equal(t, "0.0025", paper.GetTradingFee().String())
+
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	price := paper.GetPrice(currencyPair)
+	assert.True(t, price.GreaterThan(decimal.NewFromFloat(0)))
+
+	order, err := paper.PlaceMarketOrder(currencyPair, common.BUY_ORDER_TYPE, decimal.NewFromFloat(0.1))
+	assert.Nil(t, err)
+	assert.Equal(t, common.ORDER_STATUS_FILLED, order.GetStatus())
+	assert.True(t, order.GetFillPrice().GreaterThan(price))
+
+	coins, total := paper.GetBalances()
+	assert.Equal(t, 2, len(coins))
+	assert.True(t, total.LessThan(decimal.NewFromFloat(10000)))
+
+	history := paper.GetOrderHistory(currencyPair)
+	assert.Equal(t, 1, len(history))
+	assert.Equal(t, common.BUY_ORDER_TYPE, history[0].GetType())
+	assert.Equal(t, "0.10000000", history[0].GetQuantity())
+	history = paper.GetOrderHistory(&common.CurrencyPair{Base: "ETH", Quote: "USD", LocalCurrency: "USD"})
+	assert.Equal(t, 0, len(history))
+
+	openOrders, err := paper.GetOpenOrders(currencyPair)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(openOrders))
+
+	test.CleanupIntegrationTest()
+}
@@ -38,15 +38,7 @@ func CreateDefaultTradingStrategy(params *common.TradingStrategyParams) (common.
 	expectedConfigCount := 8
 	var strategyConfig *DefaultTradingStrategyConfig
 	if params.Config == nil {
-		strategyConfig = &DefaultTradingStrategyConfig{
-			Tax:                    decimal.NewFromFloat(.40),
-			TradeSize:              decimal.NewFromFloat(1),
-			ProfitMarginMin:        decimal.NewFromFloat(0),
-			ProfitMarginMinPercent: decimal.NewFromFloat(.10),
-			StopLoss:               decimal.NewFromFloat(0),
-			StopLossPercent:        decimal.NewFromFloat(.20),
-			RequiredBuySignals:     2,
-			RequiredSellSignals:    2}
+		strategyConfig = defaultConfig()
 	} else if len(params.Config) == expectedConfigCount {
 		tax, _ := strconv.ParseFloat(params.Config[0], 64)
 		tradeSize, _ := strconv.ParseFloat(params.Config[1], 64)
@@ -82,6 +74,22 @@ func CreateDefaultTradingStrategy(params *common.TradingStrategyParams) (common.
 	return strategy, nil
 }
 
+func defaultConfig() *DefaultTradingStrategyConfig {
+	return &DefaultTradingStrategyConfig{
+		Tax:                    decimal.NewFromFloat(.40),
+		TradeSize:              decimal.NewFromFloat(1),
+		ProfitMarginMin:        decimal.NewFromFloat(0),
+		ProfitMarginMinPercent: decimal.NewFromFloat(.10),
+		StopLoss:               decimal.NewFromFloat(0),
+		StopLossPercent:        decimal.NewFromFloat(.20),
+		RequiredBuySignals:     2,
+		RequiredSellSignals:    2}
+}
+
+func (strategy *DefaultTradingStrategy) GetDefaultParameters() []string {
+	return defaultConfig().ToSlice()
+}
+
 func (strategy *DefaultTradingStrategy) GetRequiredIndicators() []string {
 	return []string{"RelativeStrengthIndex", "BollingerBands", "MovingAverageConvergenceDivergence"}
 }
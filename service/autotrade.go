@@ -1,6 +1,8 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jeremyhahn/tradebot/common"
@@ -9,6 +11,11 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	ORDER_FILL_TIMEOUT       = 1 * time.Minute
+	ORDER_FILL_POLL_INTERVAL = 2 * time.Second
+)
+
 type DefaultAutoTradeService struct {
 	ctx             common.Context
 	exchangeService ExchangeService
@@ -16,13 +23,14 @@ type DefaultAutoTradeService struct {
 	tradeService    TradeService
 	profitService   ProfitService
 	strategyService StrategyService
+	riskService     RiskService
 	userMapper      mapper.UserMapper
 	AutoTradeService
 }
 
 func NewAutoTradeService(ctx common.Context, exchangeService ExchangeService, chartService ChartService,
 	profitService ProfitService, tradeService TradeService, strategyService StrategyService,
-	userMapper mapper.UserMapper) AutoTradeService {
+	riskService RiskService, userMapper mapper.UserMapper) AutoTradeService {
 	return &DefaultAutoTradeService{
 		ctx:             ctx,
 		exchangeService: exchangeService,
@@ -30,6 +38,7 @@ func NewAutoTradeService(ctx common.Context, exchangeService ExchangeService, ch
 		tradeService:    tradeService,
 		profitService:   profitService,
 		strategyService: strategyService,
+		riskService:     riskService,
 		userMapper:      userMapper}
 }
 
@@ -47,7 +56,12 @@ func (ats *DefaultAutoTradeService) EndWorldHunger() error {
 			autoTradeChart.GetBase(), autoTradeChart.GetQuote())
 
 		//userEntity := ats.userMapper.MapUserDtoToEntity(ats.ctx.GetUser())
-		exchange, err := ats.exchangeService.CreateExchange(autoTradeChart.GetExchange())
+		var exchange common.Exchange
+		if autoTradeChart.IsPaperTrade() {
+			exchange, err = ats.exchangeService.CreatePaperExchange(autoTradeChart.GetExchange())
+		} else {
+			exchange, err = ats.exchangeService.CreateExchange(autoTradeChart.GetExchange())
+		}
 		if err != nil {
 			return err
 		}
@@ -74,6 +88,27 @@ func (ats *DefaultAutoTradeService) EndWorldHunger() error {
 
 			streamErr := ats.chartService.Stream(chart, candlesticks, func(currentPrice decimal.Decimal) error {
 
+				killSwitch, err := ats.riskService.GetKillSwitch()
+				if err != nil {
+					ats.ctx.GetLogger().Errorf("[DefaultAutoTradeService.EndWorldHunger] Error reading kill switch: %s", err.Error())
+					return nil
+				}
+				switch killSwitch {
+				case common.KILL_SWITCH_HALT:
+					return nil
+				case common.KILL_SWITCH_FLATTEN:
+					thisTrade, err := ats.flatten(exchange, chart, currencyPair, lastTrade)
+					if thisTrade != nil {
+						lastTrade = thisTrade
+						coins, _ = exchange.GetBalances()
+					}
+					if err != nil {
+						ats.ctx.GetLogger().Errorf("[DefaultAutoTradeService.EndWorldHunger] Error flattening chart %d: %s",
+							chart.GetId(), err.Error())
+					}
+					return nil
+				}
+
 				params := common.TradingStrategyParams{
 					CurrencyPair: currencyPair,
 					Balances:     coins,
@@ -103,33 +138,31 @@ func (ats *DefaultAutoTradeService) EndWorldHunger() error {
 							ats.ctx.GetLogger().Debug("[DefaultAutoTradeService.EndWorldHunger] $$$ SELL SIGNAL $$$")
 							tradeType = "sell"
 						}
-						_, quoteAmount := strategy.GetTradeAmounts()
-						fee, tax := strategy.CalculateFeeAndTax(currentPrice)
-						chartJSON, err := chart.ToJSON()
+						baseAmount, quoteAmount := strategy.GetTradeAmounts()
+						quantity := baseAmount
+						if buy {
+							quantity = quoteAmount.Div(currentPrice)
+						}
+						quantity = quantity.Truncate(8)
+						if quantity.LessThanOrEqual(decimal.NewFromFloat(0)) {
+							ats.ctx.GetLogger().Warningf("[DefaultAutoTradeService.EndWorldHunger] Insufficient %s balance to %s",
+								chart.GetQuote(), tradeType)
+							continue
+						}
+						// Rejections are logged and recorded by the risk service
+						quantity, err = ats.riskService.Evaluate(chart, tradeType, currentPrice, quantity, coins)
+						if err != nil {
+							continue
+						}
+						thisTrade, err := ats.trade(exchange, chart, currencyPair, tradeType, quantity, lastTrade, strategy)
+						if thisTrade != nil {
+							lastTrade = thisTrade
+							coins, _ = exchange.GetBalances()
+						}
 						if err != nil {
-							return err
+							ats.ctx.GetLogger().Errorf("[DefaultAutoTradeService.EndWorldHunger] Error placing %s order: %s",
+								tradeType, err.Error())
 						}
-						thisTrade := &dto.TradeDTO{
-							UserId:    ats.ctx.GetUser().GetId(),
-							Exchange:  exchange.GetName(),
-							Base:      chart.GetBase(),
-							Quote:     chart.GetQuote(),
-							Date:      time.Now(),
-							Type:      tradeType,
-							Price:     currentPrice,
-							Amount:    quoteAmount,
-							ChartData: chartJSON}
-						thisProfit := &dto.ProfitDTO{
-							UserId:   ats.ctx.GetUser().GetId(),
-							TradeId:  thisTrade.GetId(),
-							Quantity: quoteAmount,
-							Bought:   lastTrade.GetPrice(),
-							Sold:     currentPrice,
-							Fee:      fee,
-							Tax:      tax,
-							Total:    currentPrice.Sub(lastTrade.GetPrice()).Sub(fee).Sub(tax)}
-						ats.tradeService.Save(thisTrade)
-						ats.profitService.Save(thisProfit)
 					}
 				}
 				return nil
@@ -142,3 +175,124 @@ func (ats *DefaultAutoTradeService) EndWorldHunger() error {
 	}
 	return nil
 }
+
+// flatten sells the trading bot's open position on the chart while the kill
+// switch is set to KILL_SWITCH_FLATTEN. A nil trade is returned once the
+// position is closed.
+func (ats *DefaultAutoTradeService) flatten(exchange common.Exchange, chart common.Chart,
+	currencyPair *common.CurrencyPair, lastTrade common.Trade) (common.Trade, error) {
+	position, err := ats.riskService.GetPosition(chart)
+	if err != nil {
+		return nil, err
+	}
+	coins, _ := exchange.GetBalances()
+	available := decimal.NewFromFloat(0)
+	for _, coin := range coins {
+		if coin.GetCurrency() == chart.GetBase() {
+			available = coin.GetAvailable()
+		}
+	}
+	if available.LessThan(position) {
+		position = available
+	}
+	quantity := position.Truncate(8)
+	if !quantity.IsPositive() {
+		return nil, nil
+	}
+	ats.ctx.GetLogger().Warningf("[DefaultAutoTradeService.flatten] Kill switch engaged, selling %s %s on chart %d",
+		quantity, chart.GetBase(), chart.GetId())
+	return ats.trade(exchange, chart, currencyPair, common.SELL_ORDER_TYPE, quantity, lastTrade, nil)
+}
+
+// trade places the order and records the resulting trade and profit. Without a
+// strategy there is no fee or tax estimate to fall back on. The saved trade is
+// returned along with the error when only the profit could not be recorded.
+func (ats *DefaultAutoTradeService) trade(exchange common.Exchange, chart common.Chart, currencyPair *common.CurrencyPair,
+	tradeType string, quantity decimal.Decimal, lastTrade common.Trade, strategy common.TradingStrategy) (common.Trade, error) {
+	order, err := ats.placeOrder(exchange, currencyPair, tradeType, quantity)
+	if err != nil {
+		return nil, err
+	}
+	estimatedFee, tax := decimal.NewFromFloat(0), decimal.NewFromFloat(0)
+	if strategy != nil {
+		estimatedFee, tax = strategy.CalculateFeeAndTax(order.GetFillPrice())
+	}
+	fee := ats.quoteFee(order, estimatedFee)
+	chartJSON, err := chart.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	thisTrade, err := ats.tradeService.Save(&dto.TradeDTO{
+		ChartId:   chart.GetId(),
+		UserId:    ats.ctx.GetUser().GetId(),
+		Exchange:  exchange.GetName(),
+		Base:      chart.GetBase(),
+		Quote:     chart.GetQuote(),
+		Date:      order.GetDate(),
+		Type:      tradeType,
+		Price:     order.GetFillPrice(),
+		Amount:    order.GetFilledQuantity(),
+		ChartData: chartJSON})
+	if err != nil {
+		return nil, err
+	}
+	err = ats.profitService.Save(&dto.ProfitDTO{
+		UserId:   ats.ctx.GetUser().GetId(),
+		TradeId:  thisTrade.GetId(),
+		Quantity: order.GetFilledQuantity(),
+		Bought:   lastTrade.GetPrice(),
+		Sold:     order.GetFillPrice(),
+		Fee:      fee,
+		Tax:      tax,
+		Total:    order.GetFillPrice().Sub(lastTrade.GetPrice()).Sub(fee).Sub(tax)})
+	return thisTrade, err
+}
+
+// placeOrder submits a market order and waits for it to fill. Orders still open
+// after ORDER_FILL_TIMEOUT are cancelled, keeping whatever quantity was filled.
+func (ats *DefaultAutoTradeService) placeOrder(exchange common.Exchange, currencyPair *common.CurrencyPair,
+	side string, quantity decimal.Decimal) (common.Order, error) {
+	executor, ok := exchange.(common.OrderExecutor)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("%s does not support placing orders", exchange.GetName()))
+	}
+	ats.ctx.GetLogger().Debugf("[DefaultAutoTradeService.placeOrder] Placing %s order for %s %s on %s",
+		side, quantity, currencyPair.Base, exchange.GetName())
+	order, err := executor.PlaceMarketOrder(currencyPair, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(ORDER_FILL_TIMEOUT)
+	for order.GetStatus() == common.ORDER_STATUS_OPEN && time.Now().Before(deadline) {
+		time.Sleep(ORDER_FILL_POLL_INTERVAL)
+		if order, err = executor.GetOrder(currencyPair, order.GetId()); err != nil {
+			return nil, err
+		}
+	}
+	if order.GetStatus() == common.ORDER_STATUS_OPEN {
+		ats.ctx.GetLogger().Warningf("[DefaultAutoTradeService.placeOrder] Cancelling unfilled order %s", order.GetId())
+		if err := executor.CancelOrder(currencyPair, order.GetId()); err != nil {
+			return nil, err
+		}
+		if order, err = executor.GetOrder(currencyPair, order.GetId()); err != nil {
+			return nil, err
+		}
+	}
+	if order.GetFilledQuantity().LessThanOrEqual(decimal.NewFromFloat(0)) {
+		return nil, errors.New(fmt.Sprintf("Order %s was not filled", order.GetId()))
+	}
+	return order, nil
+}
+
+// quoteFee returns the fee charged for an order in the quote currency. Fees
+// charged in another currency (ie: BNB on Binance) fall back to the strategy's
+// estimate.
+func (ats *DefaultAutoTradeService) quoteFee(order common.Order, estimatedFee decimal.Decimal) decimal.Decimal {
+	switch order.GetFeeCurrency() {
+	case order.GetCurrencyPair().Quote:
+		return order.GetFee()
+	case order.GetCurrencyPair().Base:
+		return order.GetFee().Mul(order.GetFillPrice())
+	}
+	return estimatedFee
+}
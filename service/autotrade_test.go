@@ -3,7 +3,15 @@
 package service
 
 import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -27,6 +35,125 @@ type MockProfitService struct {
 	mock.Mock
 }
 
+type MockReadOnlyExchange struct {
+	common.Exchange
+}
+
+func (mroe *MockReadOnlyExchange) GetName() string {
+	return "readonly"
+}
+
+type MockOrderExchange struct {
+	common.Exchange
+	order     *dto.OrderDTO
+	cancelled bool
+}
+
+func (moe *MockOrderExchange) GetName() string {
+	return "mock"
+}
+
+func (moe *MockOrderExchange) PlaceMarketOrder(currencyPair *common.CurrencyPair, side string, quantity decimal.Decimal) (common.Order, error) {
+	moe.order.CurrencyPair = currencyPair
+	moe.order.Side = side
+	moe.order.Quantity = quantity
+	return moe.order, nil
+}
+
+func (moe *MockOrderExchange) PlaceLimitOrder(currencyPair *common.CurrencyPair, side string, quantity, price decimal.Decimal) (common.Order, error) {
+	moe.order.Type = common.ORDER_TYPE_LIMIT
+	moe.order.Price = price
+	return moe.PlaceMarketOrder(currencyPair, side, quantity)
+}
+
+func (moe *MockOrderExchange) CancelOrder(currencyPair *common.CurrencyPair, orderId string) error {
+	moe.cancelled = true
+	moe.order.Status = common.ORDER_STATUS_CANCELLED
+	return nil
+}
+
+func (moe *MockOrderExchange) GetOrder(currencyPair *common.CurrencyPair, orderId string) (common.Order, error) {
+	return moe.order, nil
+}
+
+func (moe *MockOrderExchange) GetOpenOrders(currencyPair *common.CurrencyPair) ([]common.Order, error) {
+	if moe.order.Status != common.ORDER_STATUS_OPEN {
+		return []common.Order{}, nil
+	}
+	return []common.Order{moe.order}, nil
+}
+
+func TestAutoTradeService_PlaceOrder(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	autoTradeService := NewAutoTradeService(ctx, nil, nil, nil, nil, nil, nil, nil).(*DefaultAutoTradeService)
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+
+	exchange := &MockOrderExchange{order: &dto.OrderDTO{
+		Id:             "1",
+		Type:           common.ORDER_TYPE_MARKET,
+		Status:         common.ORDER_STATUS_FILLED,
+		Date:           time.Now(),
+		FilledQuantity: decimal.NewFromFloat(2),
+		FillPrice:      decimal.NewFromFloat(10010),
+		Fee:            decimal.NewFromFloat(0.001),
+		FeeCurrency:    "BTC"}}
+	order, err := autoTradeService.placeOrder(exchange, currencyPair, common.BUY_ORDER_TYPE, decimal.NewFromFloat(2))
+	assert.Nil(t, err)
+	assert.False(t, exchange.cancelled)
+	assert.Equal(t, common.BUY_ORDER_TYPE, order.GetSide())
+	assert.Equal(t, "10010", order.GetFillPrice().String())
+	assert.Equal(t, "10.01", autoTradeService.quoteFee(order, decimal.NewFromFloat(25)).String())
+
+	exchange.order.FeeCurrency = "USD"
+	assert.Equal(t, "0.001", autoTradeService.quoteFee(order, decimal.NewFromFloat(25)).String())
+	exchange.order.FeeCurrency = "BNB"
+	assert.Equal(t, "25", autoTradeService.quoteFee(order, decimal.NewFromFloat(25)).String())
+
+	exchange.order.FilledQuantity = decimal.NewFromFloat(0)
+	_, err = autoTradeService.placeOrder(exchange, currencyPair, common.SELL_ORDER_TYPE, decimal.NewFromFloat(2))
+	assert.Equal(t, "Order 1 was not filled", err.Error())
+
+	_, err = autoTradeService.placeOrder(&MockReadOnlyExchange{}, currencyPair, common.SELL_ORDER_TYPE, decimal.NewFromFloat(2))
+	assert.Equal(t, "readonly does not support placing orders", err.Error())
+
+	CleanupIntegrationTest()
+}
+
+func TestAutoTradeService_Trade(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	profitDAO := dao.NewProfitDAO(ctx)
+	tradeService := NewTradeService(ctx, dao.NewTradeDAO(ctx), mapper.NewTradeMapper(ctx))
+	autoTradeService := NewAutoTradeService(ctx, nil, nil, NewProfitService(ctx, profitDAO),
+		tradeService, nil, nil, nil).(*DefaultAutoTradeService)
+	currencyPair := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	chart := &dto.ChartDTO{Id: 1, Base: "BTC", Quote: "USD", Exchange: "mock", Period: 900}
+	lastTrade := &dto.TradeDTO{Price: decimal.NewFromFloat(10000)}
+
+	exchange := &MockOrderExchange{order: &dto.OrderDTO{
+		Id:             "1",
+		Type:           common.ORDER_TYPE_MARKET,
+		Status:         common.ORDER_STATUS_FILLED,
+		Date:           time.Now(),
+		FilledQuantity: decimal.NewFromFloat(1),
+		FillPrice:      decimal.NewFromFloat(10010),
+		Fee:            decimal.NewFromFloat(10),
+		FeeCurrency:    "USD"}}
+	trade, err := autoTradeService.trade(exchange, chart, currencyPair, common.SELL_ORDER_TYPE,
+		decimal.NewFromFloat(1), lastTrade, nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, uint(0), trade.GetId())
+	assert.Equal(t, chart.GetId(), trade.GetChartId())
+	assert.Equal(t, "10010", trade.GetPrice().String())
+
+	profits, err := profitDAO.Find()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(profits))
+	assert.Equal(t, trade.GetId(), profits[0].GetTradeId())
+	assert.Equal(t, "0", profits[0].GetTotal())
+
+	CleanupIntegrationTest()
+}
+
 /*
 func TestAutoTradeService(t *testing.T) {
 	ctx := NewUnitTestContext()
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BACKTEST_SOURCE_EXCHANGE = "exchange"
+	BACKTEST_SOURCE_DB       = "db"
+	BACKTEST_DEFAULT_WARMUP  = 35
+	SECONDS_PER_YEAR         = 365 * 24 * 60 * 60
+)
+
+type DefaultBacktestService struct {
+	ctx                common.Context
+	chartDAO           dao.ChartDAO
+	priceHistoryDAO    dao.PriceHistoryDAO
+	exchangeService    ExchangeService
+	pluginService      PluginService
+	chartMapper        mapper.ChartMapper
+	priceHistoryMapper mapper.PriceHistoryMapper
+	BacktestService
+}
+
+// backtestAccount holds the simulated balances of a backtest. costBasis is the
+// quote currency spent, including fees, on the base currency currently held.
+type backtestAccount struct {
+	fee       decimal.Decimal
+	slippage  decimal.Decimal
+	base      decimal.Decimal
+	quote     decimal.Decimal
+	costBasis decimal.Decimal
+}
+
+func NewBacktestService(ctx common.Context, chartDAO dao.ChartDAO, priceHistoryDAO dao.PriceHistoryDAO,
+	exchangeService ExchangeService, pluginService PluginService, chartMapper mapper.ChartMapper,
+	priceHistoryMapper mapper.PriceHistoryMapper) BacktestService {
+	return &DefaultBacktestService{
+		ctx:                ctx,
+		chartDAO:           chartDAO,
+		priceHistoryDAO:    priceHistoryDAO,
+		exchangeService:    exchangeService,
+		pluginService:      pluginService,
+		chartMapper:        chartMapper,
+		priceHistoryMapper: priceHistoryMapper}
+}
+
+// GetChart returns one of the current user's charts with its indicators and
+// strategies loaded.
+func (service *DefaultBacktestService) GetChart(chartId uint) (common.Chart, error) {
+	chart, err := service.chartDAO.Get(chartId)
+	if err != nil || chart.GetUserId() != service.ctx.GetUser().GetId() {
+		return nil, errors.New(fmt.Sprintf("Unable to locate chart: %d", chartId))
+	}
+	indicators, err := service.chartDAO.GetIndicators(chart)
+	if err != nil {
+		return nil, err
+	}
+	strategies, err := service.chartDAO.GetStrategies(chart)
+	if err != nil {
+		return nil, err
+	}
+	chart.SetIndicators(indicators)
+	chart.SetStrategies(strategies)
+	return service.chartMapper.MapChartEntityToDto(chart), nil
+}
+
+// LoadCandlesticks returns the chart's candlesticks between the option's start
+// and end dates from the exchange or the price database. Candlesticks fetched
+// from the exchange are stored in the price database, so a range backtested
+// once can be backtested again from the db source.
+func (service *DefaultBacktestService) LoadCandlesticks(chart common.Chart, options *dto.BacktestOptionsDTO) ([]common.Candlestick, error) {
+	var candles []common.Candlestick
+	if !options.Start.Before(options.End) {
+		return nil, errors.New(fmt.Sprintf("Invalid backtest date range: %s - %s", options.Start, options.End))
+	}
+	currencyPair := &common.CurrencyPair{
+		Base:          chart.GetBase(),
+		Quote:         chart.GetQuote(),
+		LocalCurrency: service.ctx.GetUser().GetLocalCurrency()}
+	service.ctx.GetLogger().Debugf("[BacktestService.LoadCandlesticks] Loading %s %s-%s candlesticks from %s: %s - %s",
+		chart.GetExchange(), chart.GetBase(), chart.GetQuote(), options.Source, options.Start, options.End)
+	switch options.Source {
+	case BACKTEST_SOURCE_DB:
+		history, err := service.priceHistoryDAO.Find(chart.GetExchange(), currencyPair,
+			chart.GetPeriod(), options.Start, options.End)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range history {
+			candles = append(candles, service.priceHistoryMapper.MapPriceHistoryEntityToCandlestick(&entity))
+		}
+	case "", BACKTEST_SOURCE_EXCHANGE:
+		exchange, err := service.exchangeService.CreateExchange(chart.GetExchange())
+		if err != nil {
+			return nil, err
+		}
+		candles, err = exchange.GetPriceHistory(currencyPair, options.Start, options.End, chart.GetPeriod())
+		if err != nil {
+			return nil, err
+		}
+		service.storeCandlesticks(chart, currencyPair, candles, options)
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported backtest source: %s", options.Source))
+	}
+	return candles, nil
+}
+
+// storeCandlesticks saves the candlesticks not already in the price database.
+// Failing to store them is logged rather than failing the backtest.
+func (service *DefaultBacktestService) storeCandlesticks(chart common.Chart, currencyPair *common.CurrencyPair,
+	candles []common.Candlestick, options *dto.BacktestOptionsDTO) {
+	history, err := service.priceHistoryDAO.Find(chart.GetExchange(), currencyPair,
+		chart.GetPeriod(), options.Start, options.End)
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[BacktestService.storeCandlesticks] Error loading price history: %s", err.Error())
+		return
+	}
+	stored := make(map[int64]bool, len(history))
+	for _, entity := range history {
+		stored[entity.GetTime()] = true
+	}
+	for _, candle := range candles {
+		if stored[candle.Date.Unix()] {
+			continue
+		}
+		candle.Exchange = chart.GetExchange()
+		candle.CurrencyPair = currencyPair
+		candle.Period = chart.GetPeriod()
+		if err := service.priceHistoryDAO.Create(service.priceHistoryMapper.MapCandlestickToPriceHistoryEntity(&candle)); err != nil {
+			service.ctx.GetLogger().Errorf("[BacktestService.storeCandlesticks] Error saving price history: %s", err.Error())
+			return
+		}
+		stored[candle.Date.Unix()] = true
+	}
+}
+
+func (service *DefaultBacktestService) Backtest(chartId uint, options *dto.BacktestOptionsDTO) (*dto.BacktestResultDTO, error) {
+	chart, err := service.GetChart(chartId)
+	if err != nil {
+		return nil, err
+	}
+	candles, err := service.LoadCandlesticks(chart, options)
+	if err != nil {
+		return nil, err
+	}
+	return service.Run(chart, candles, options)
+}
+
+// Run replays candles through the chart's indicators and strategies. The first
+// Warmup candles seed the indicators. Each remaining candle is analyzed at its
+// close as the new price before it is passed to the indicators' OnPeriodChange,
+// the same order a live price stream delivers them. Signals are filled as market
+// orders at the close, moved against the order by the slippage rate, and charged
+// the fee rate in the quote currency.
+func (service *DefaultBacktestService) Run(chart common.Chart, candles []common.Candlestick,
+	options *dto.BacktestOptionsDTO) (*dto.BacktestResultDTO, error) {
+
+	balance, fee, slippage, warmup, err := service.defaults(options)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) <= warmup {
+		return nil, errors.New(fmt.Sprintf("Backtest requires more than %d candlesticks, received %d",
+			warmup, len(candles)))
+	}
+	if len(chart.GetStrategies()) == 0 {
+		return nil, errors.New(fmt.Sprintf("Chart %s-%s has no strategies to backtest", chart.GetBase(), chart.GetQuote()))
+	}
+
+	indicators, err := service.createIndicators(chart, candles[:warmup])
+	if err != nil {
+		return nil, err
+	}
+	strategies := make([]func(params *common.TradingStrategyParams) (common.TradingStrategy, error), len(chart.GetStrategies()))
+	for i, chartStrategy := range chart.GetStrategies() {
+		if strategies[i], err = service.pluginService.CreateStrategy(chartStrategy.GetName()); err != nil {
+			return nil, err
+		}
+	}
+
+	currencyPair := &common.CurrencyPair{
+		Base:          chart.GetBase(),
+		Quote:         chart.GetQuote(),
+		LocalCurrency: service.ctx.GetUser().GetLocalCurrency()}
+	account := &backtestAccount{
+		fee:      fee,
+		slippage: slippage,
+		quote:    balance}
+	var lastTrade common.Trade = &dto.TradeDTO{}
+	result := &dto.BacktestResultDTO{
+		ChartId:         chart.GetId(),
+		Exchange:        chart.GetExchange(),
+		Base:            chart.GetBase(),
+		Quote:           chart.GetQuote(),
+		Period:          chart.GetPeriod(),
+		Start:           candles[warmup].Date,
+		End:             candles[len(candles)-1].Date,
+		Candles:         len(candles) - warmup,
+		StartingBalance: balance,
+		Fees:            decimal.NewFromFloat(0),
+		Trades:          make([]dto.BacktestTradeDTO, 0),
+		Equity:          make([]dto.BacktestEquityDTO, 0, len(candles)-warmup)}
+
+	for i := warmup; i < len(candles); i++ {
+		candle := candles[i]
+		for j, chartStrategy := range chart.GetStrategies() {
+			params := &common.TradingStrategyParams{
+				CurrencyPair: currencyPair,
+				Balances:     account.balances(currencyPair),
+				Indicators:   indicators,
+				NewPrice:     candle.Close,
+				LastTrade:    lastTrade,
+				TradeFee:     fee,
+				Config:       parsePluginParameters(chartStrategy.GetParameters())}
+			strategy, err := strategies[j](params)
+			if err != nil {
+				return nil, err
+			}
+			buy, sell, data, err := strategy.Analyze()
+			if err != nil {
+				service.ctx.GetLogger().Debugf("[BacktestService.Run] %s %s: %s", candle.Date, chartStrategy.GetName(), err.Error())
+				continue
+			}
+			if !buy && !sell {
+				continue
+			}
+			service.ctx.GetLogger().Debugf("[BacktestService.Run] %s %s buy=%t, sell=%t, data=%+v",
+				candle.Date, chartStrategy.GetName(), buy, sell, data)
+			baseAmount, quoteAmount := strategy.GetTradeAmounts()
+			var trade *dto.BacktestTradeDTO
+			if buy {
+				trade = account.buy(candle.Close, quoteAmount)
+			} else {
+				trade = account.sell(candle.Close, baseAmount)
+			}
+			if trade == nil {
+				continue
+			}
+			trade.Date = candle.Date
+			result.Trades = append(result.Trades, *trade)
+			result.Fees = result.Fees.Add(trade.Fee)
+			lastTrade = &dto.TradeDTO{
+				ChartId:  chart.GetId(),
+				UserId:   service.ctx.GetUser().GetId(),
+				Exchange: chart.GetExchange(),
+				Base:     chart.GetBase(),
+				Quote:    chart.GetQuote(),
+				Date:     trade.Date,
+				Type:     trade.Type,
+				Price:    trade.Price,
+				Amount:   trade.Quantity}
+		}
+		for _, indicator := range indicators {
+			indicator.OnPeriodChange(&candle)
+		}
+		result.Equity = append(result.Equity, dto.BacktestEquityDTO{
+			Date:   candle.Date,
+			Price:  candle.Close,
+			Equity: account.equity(candle.Close)})
+	}
+
+	service.summarize(result)
+	service.ctx.GetLogger().Debugf("[BacktestService.Run] %s %s-%s: %d trades, return=%s, max drawdown=%s, win rate=%s, sharpe=%s",
+		chart.GetExchange(), chart.GetBase(), chart.GetQuote(), len(result.Trades), result.Return,
+		result.MaxDrawdown, result.WinRate, result.Sharpe)
+	return result, nil
+}
+
+func (service *DefaultBacktestService) defaults(options *dto.BacktestOptionsDTO) (decimal.Decimal, decimal.Decimal, decimal.Decimal, int, error) {
+	balance := options.Balance
+	if !balance.IsPositive() {
+		balance = decimal.NewFromFloat(PAPER_DEFAULT_BALANCE)
+	}
+	fee := decimal.NewFromFloat(PAPER_DEFAULT_FEE)
+	if options.Fee != nil {
+		if options.Fee.IsNegative() {
+			return balance, fee, decimal.Zero, 0, errors.New(fmt.Sprintf("Invalid fee: %s", options.Fee))
+		}
+		fee = *options.Fee
+	}
+	slippage := decimal.NewFromFloat(PAPER_DEFAULT_SLIPPAGE)
+	if options.Slippage != nil {
+		if options.Slippage.IsNegative() {
+			return balance, fee, slippage, 0, errors.New(fmt.Sprintf("Invalid slippage: %s", options.Slippage))
+		}
+		slippage = *options.Slippage
+	}
+	warmup := options.Warmup
+	if warmup <= 0 {
+		warmup = BACKTEST_DEFAULT_WARMUP
+	}
+	return balance, fee, slippage, warmup, nil
+}
+
+func (service *DefaultBacktestService) createIndicators(chart common.Chart,
+	candles []common.Candlestick) (map[string]common.FinancialIndicator, error) {
+	indicators := make(map[string]common.FinancialIndicator, len(chart.GetIndicators()))
+	for _, chartIndicator := range chart.GetIndicators() {
+		constructor, err := service.pluginService.CreateIndicator(chartIndicator.GetName())
+		if err != nil {
+			return nil, err
+		}
+		indicator, err := constructor(candles, parsePluginParameters(chartIndicator.GetParameters()))
+		if err != nil {
+			return nil, err
+		}
+		indicators[indicator.GetName()] = indicator
+	}
+	return indicators, nil
+}
+
+// parsePluginParameters splits a chart indicator or strategy's comma separated
+// parameters, returning nil when none are configured so the plugin uses its
+// defaults.
+func parsePluginParameters(parameters string) []string {
+	if parameters == "" {
+		return nil
+	}
+	return strings.Split(parameters, ",")
+}
+
+// summarize calculates the return, max drawdown, win rate and annualized Sharpe
+// ratio from the result's equity curve and trades.
+func (service *DefaultBacktestService) summarize(result *dto.BacktestResultDTO) {
+	zero := decimal.NewFromFloat(0)
+	result.EndingBalance = result.StartingBalance
+	result.MaxDrawdown = zero
+	result.WinRate = zero
+	result.Sharpe = zero
+	if len(result.Equity) > 0 {
+		result.EndingBalance = result.Equity[len(result.Equity)-1].Equity
+	}
+	result.Return = result.EndingBalance.Sub(result.StartingBalance).Div(result.StartingBalance)
+
+	peak := result.StartingBalance
+	previous := result.StartingBalance
+	returns := make([]float64, 0, len(result.Equity))
+	for _, point := range result.Equity {
+		if point.Equity.GreaterThan(peak) {
+			peak = point.Equity
+		}
+		if drawdown := peak.Sub(point.Equity).Div(peak); drawdown.GreaterThan(result.MaxDrawdown) {
+			result.MaxDrawdown = drawdown
+		}
+		r, _ := point.Equity.Sub(previous).Div(previous).Float64()
+		returns = append(returns, r)
+		previous = point.Equity
+	}
+
+	var sells, wins int64
+	for _, trade := range result.Trades {
+		if trade.Type != common.SELL_ORDER_TYPE {
+			continue
+		}
+		sells++
+		if trade.Profit.IsPositive() {
+			wins++
+		}
+	}
+	if sells > 0 {
+		result.WinRate = decimal.New(wins, 0).Div(decimal.New(sells, 0))
+	}
+
+	if len(returns) < 2 || result.Period <= 0 {
+		return
+	}
+	var mean, variance float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	if stddev == 0 {
+		return
+	}
+	sharpe := mean / stddev * math.Sqrt(float64(SECONDS_PER_YEAR)/float64(result.Period))
+	result.Sharpe = decimal.NewFromFloat(sharpe).Round(4)
+}
+
+func (account *backtestAccount) balances(currencyPair *common.CurrencyPair) []common.Coin {
+	return []common.Coin{
+		&dto.CoinDTO{
+			Currency:  currencyPair.Base,
+			Balance:   account.base,
+			Available: account.base},
+		&dto.CoinDTO{
+			Currency:  currencyPair.Quote,
+			Balance:   account.quote,
+			Available: account.quote}}
+}
+
+func (account *backtestAccount) equity(price decimal.Decimal) decimal.Decimal {
+	return account.quote.Add(account.base.Mul(price))
+}
+
+// buy spends up to quoteAmount, including the fee, on the base currency. Returns
+// nil when the amount is too small to fill.
+func (account *backtestAccount) buy(price, quoteAmount decimal.Decimal) *dto.BacktestTradeDTO {
+	one := decimal.NewFromFloat(1)
+	if quoteAmount.GreaterThan(account.quote) {
+		quoteAmount = account.quote
+	}
+	fillPrice := price.Mul(one.Add(account.slippage))
+	quantity := quoteAmount.Div(fillPrice.Mul(one.Add(account.fee))).Truncate(8)
+	if !quantity.IsPositive() {
+		return nil
+	}
+	cost := fillPrice.Mul(quantity)
+	fee := cost.Mul(account.fee)
+	account.quote = account.quote.Sub(cost).Sub(fee)
+	account.base = account.base.Add(quantity)
+	account.costBasis = account.costBasis.Add(cost).Add(fee)
+	return &dto.BacktestTradeDTO{
+		Type:     common.BUY_ORDER_TYPE,
+		Price:    fillPrice,
+		Quantity: quantity,
+		Fee:      fee,
+		Profit:   decimal.NewFromFloat(0)}
+}
+
+// sell sells up to baseAmount of the base currency, realizing the profit over its
+// share of the cost basis. Returns nil when the amount is too small to fill.
+func (account *backtestAccount) sell(price, baseAmount decimal.Decimal) *dto.BacktestTradeDTO {
+	one := decimal.NewFromFloat(1)
+	quantity := baseAmount.Truncate(8)
+	if quantity.GreaterThan(account.base) {
+		quantity = account.base
+	}
+	if !quantity.IsPositive() {
+		return nil
+	}
+	fillPrice := price.Mul(one.Sub(account.slippage))
+	proceeds := fillPrice.Mul(quantity)
+	fee := proceeds.Mul(account.fee)
+	basis := account.costBasis.Mul(quantity).Div(account.base)
+	account.quote = account.quote.Add(proceeds).Sub(fee)
+	account.base = account.base.Sub(quantity)
+	account.costBasis = account.costBasis.Sub(basis)
+	return &dto.BacktestTradeDTO{
+		Type:     common.SELL_ORDER_TYPE,
+		Price:    fillPrice,
+		Quantity: quantity,
+		Fee:      fee,
+		Profit:   proceeds.Sub(fee).Sub(basis)}
+}
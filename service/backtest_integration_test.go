@@ -0,0 +1,329 @@
+// +build integration
+
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockPluginService_Backtest struct {
+	PluginService
+	indicator *MockFinancialIndicator_Backtest
+}
+
+type MockFinancialIndicator_Backtest struct {
+	common.FinancialIndicator
+	warmup  int
+	periods []decimal.Decimal
+}
+
+type MockExchangeService_Backtest struct {
+	ExchangeService
+	exchange *MockExchange_Backtest
+}
+
+type MockExchange_Backtest struct {
+	common.Exchange
+	candles []common.Candlestick
+}
+
+// MockTradingStrategy_Backtest buys its whole quote balance below Config[0] and
+// sells its whole base balance above Config[1].
+type MockTradingStrategy_Backtest struct {
+	common.TradingStrategy
+	params *common.TradingStrategyParams
+}
+
+func (mps *MockPluginService_Backtest) CreateIndicator(indicatorName string) (func(candles []common.Candlestick, params []string) (common.FinancialIndicator, error), error) {
+	return func(candles []common.Candlestick, params []string) (common.FinancialIndicator, error) {
+		mps.indicator.warmup = len(candles)
+		return mps.indicator, nil
+	}, nil
+}
+
+func (mps *MockPluginService_Backtest) CreateStrategy(strategyName string) (func(params *common.TradingStrategyParams) (common.TradingStrategy, error), error) {
+	return func(params *common.TradingStrategyParams) (common.TradingStrategy, error) {
+		if params.Config == nil {
+			params.Config = []string{"95", "115"}
+		}
+		if len(params.Config) != 2 {
+			return nil, errors.New("Invalid configuration")
+		}
+		return &MockTradingStrategy_Backtest{params: params}, nil
+	}, nil
+}
+
+func (mes *MockExchangeService_Backtest) CreateExchange(exchangeName string) (common.Exchange, error) {
+	return mes.exchange, nil
+}
+
+func (me *MockExchange_Backtest) GetPriceHistory(currencyPair *common.CurrencyPair, start, end time.Time,
+	granularity int) ([]common.Candlestick, error) {
+	return me.candles, nil
+}
+
+func (mfi *MockFinancialIndicator_Backtest) GetName() string {
+	return "MockIndicator"
+}
+
+func (mfi *MockFinancialIndicator_Backtest) GetDefaultParameters() []string {
+	return []string{"14"}
+}
+
+func (mfi *MockFinancialIndicator_Backtest) OnPeriodChange(candle *common.Candlestick) {
+	mfi.periods = append(mfi.periods, candle.Close)
+}
+
+func (mts *MockTradingStrategy_Backtest) GetDefaultParameters() []string {
+	return []string{"95", "115"}
+}
+
+func (mts *MockTradingStrategy_Backtest) GetRequiredIndicators() []string {
+	return []string{"MockIndicator"}
+}
+
+func (mts *MockTradingStrategy_Backtest) Analyze() (bool, bool, map[string]string, error) {
+	buyBelow, _ := strconv.ParseFloat(mts.params.Config[0], 64)
+	sellAbove, _ := strconv.ParseFloat(mts.params.Config[1], 64)
+	if _, ok := mts.params.Indicators["MockIndicator"]; !ok {
+		return false, false, nil, errors.New("Strategy requires missing indicator: MockIndicator")
+	}
+	holding := mts.params.LastTrade.GetType() == common.BUY_ORDER_TYPE
+	if !holding && mts.params.NewPrice.LessThan(decimal.NewFromFloat(buyBelow)) {
+		return true, false, nil, nil
+	}
+	if holding && mts.params.NewPrice.GreaterThan(decimal.NewFromFloat(sellAbove)) {
+		return false, true, nil, nil
+	}
+	return false, false, nil, errors.New("No signal")
+}
+
+func (mts *MockTradingStrategy_Backtest) GetTradeAmounts() (decimal.Decimal, decimal.Decimal) {
+	var baseAmount, quoteAmount decimal.Decimal
+	for _, coin := range mts.params.Balances {
+		switch coin.GetCurrency() {
+		case mts.params.CurrencyPair.Base:
+			baseAmount = coin.GetAvailable()
+		case mts.params.CurrencyPair.Quote:
+			quoteAmount = coin.GetAvailable()
+		}
+	}
+	return baseAmount, quoteAmount
+}
+
+func createBacktestCandles(start time.Time, prices ...float64) []common.Candlestick {
+	candles := make([]common.Candlestick, len(prices))
+	for i, price := range prices {
+		candles[i] = common.Candlestick{
+			Exchange:     "GDAX",
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD"},
+			Period:       900,
+			Date:         start.Add(time.Duration(i*900) * time.Second),
+			Close:        decimal.NewFromFloat(price)}
+	}
+	return candles
+}
+
+func TestBacktestService_Run(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	pluginService := &MockPluginService_Backtest{indicator: &MockFinancialIndicator_Backtest{}}
+	backtestService := NewBacktestService(ctx, nil, nil, nil, pluginService,
+		mapper.NewChartMapper(ctx), mapper.NewPriceHistoryMapper())
+
+	chart := &dto.ChartDTO{
+		Id:       1,
+		Base:     "BTC",
+		Quote:    "USD",
+		Exchange: "GDAX",
+		Period:   900,
+		Indicators: []common.ChartIndicator{
+			&dto.ChartIndicatorDTO{Name: "MockIndicator"}},
+		Strategies: []common.ChartStrategy{
+			&dto.ChartStrategyDTO{Name: "MockStrategy", Parameters: "95,115"}}}
+	rate := decimal.NewFromFloat(0.01)
+	options := &dto.BacktestOptionsDTO{
+		Balance:  decimal.NewFromFloat(1000),
+		Fee:      &rate,
+		Slippage: &rate,
+		Warmup:   2}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := createBacktestCandles(start, 100, 100, 100, 90, 95, 120, 110, 80, 70, 75)
+
+	result, err := backtestService.Run(chart, candles, options)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pluginService.indicator.warmup)
+	assert.Equal(t, 8, len(pluginService.indicator.periods))
+	assert.Equal(t, "100", pluginService.indicator.periods[0].String())
+	assert.Equal(t, 8, result.Candles)
+	assert.Equal(t, 8, len(result.Equity))
+	assert.True(t, candles[2].Date.Equal(result.Start))
+	assert.True(t, candles[9].Date.Equal(result.End))
+
+	assert.Equal(t, 3, len(result.Trades))
+	assert.Equal(t, common.BUY_ORDER_TYPE, result.Trades[0].Type)
+	assert.Equal(t, "90.9", result.Trades[0].Price.String())
+	assert.True(t, candles[3].Date.Equal(result.Trades[0].Date))
+	assert.Equal(t, common.SELL_ORDER_TYPE, result.Trades[1].Type)
+	assert.Equal(t, "118.8", result.Trades[1].Price.String())
+	assert.Equal(t, result.Trades[0].Quantity.String(), result.Trades[1].Quantity.String())
+	assert.True(t, result.Trades[1].Profit.IsPositive())
+	assert.Equal(t, common.BUY_ORDER_TYPE, result.Trades[2].Type)
+	assert.Equal(t, "80.8", result.Trades[2].Price.String())
+
+	// Holding base at the final close of 75 after buying at 80.8
+	assert.Equal(t, "1", result.WinRate.String())
+	assert.True(t, result.EndingBalance.Equal(result.Equity[7].Equity))
+	assert.True(t, result.EndingBalance.GreaterThan(result.StartingBalance))
+	assert.True(t, result.Return.Equal(result.EndingBalance.Sub(decimal.NewFromFloat(1000)).Div(decimal.NewFromFloat(1000))))
+	assert.True(t, result.MaxDrawdown.IsPositive())
+	assert.True(t, result.Sharpe.IsPositive())
+	assert.True(t, result.Fees.Equal(result.Trades[0].Fee.Add(result.Trades[1].Fee).Add(result.Trades[2].Fee)))
+
+	_, err = backtestService.Run(chart, candles[:2], options)
+	assert.Equal(t, "Backtest requires more than 2 candlesticks, received 2", err.Error())
+
+	// Zero fee and slippage rates are used as given, negative rates rejected
+	zero := decimal.Zero
+	result, err = backtestService.Run(chart, candles, &dto.BacktestOptionsDTO{
+		Balance:  decimal.NewFromFloat(1000),
+		Fee:      &zero,
+		Slippage: &zero,
+		Warmup:   2})
+	assert.Nil(t, err)
+	assert.Equal(t, "90", result.Trades[0].Price.String())
+	assert.True(t, result.Fees.IsZero())
+	negative := decimal.NewFromFloat(-0.01)
+	_, err = backtestService.Run(chart, candles, &dto.BacktestOptionsDTO{Fee: &negative, Warmup: 2})
+	assert.Equal(t, "Invalid fee: -0.01", err.Error())
+	_, err = backtestService.Run(chart, candles, &dto.BacktestOptionsDTO{Slippage: &negative, Warmup: 2})
+	assert.Equal(t, "Invalid slippage: -0.01", err.Error())
+
+	chart.Strategies = []common.ChartStrategy{&dto.ChartStrategyDTO{Name: "MockStrategy", Parameters: "95"}}
+	_, err = backtestService.Run(chart, candles, options)
+	assert.Equal(t, "Invalid configuration", err.Error())
+
+	CleanupIntegrationTest()
+}
+
+func TestBacktestService_Summarize(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	backtestService := NewBacktestService(ctx, nil, nil, nil, nil, nil, nil).(*DefaultBacktestService)
+
+	result := &dto.BacktestResultDTO{
+		Period:          86400,
+		StartingBalance: decimal.NewFromFloat(1000),
+		Equity: []dto.BacktestEquityDTO{
+			{Equity: decimal.NewFromFloat(1100)},
+			{Equity: decimal.NewFromFloat(880)},
+			{Equity: decimal.NewFromFloat(990)},
+			{Equity: decimal.NewFromFloat(1200)}},
+		Trades: []dto.BacktestTradeDTO{
+			{Type: common.BUY_ORDER_TYPE},
+			{Type: common.SELL_ORDER_TYPE, Profit: decimal.NewFromFloat(50)},
+			{Type: common.BUY_ORDER_TYPE},
+			{Type: common.SELL_ORDER_TYPE, Profit: decimal.NewFromFloat(-20)}}}
+	backtestService.summarize(result)
+	assert.Equal(t, "1200", result.EndingBalance.String())
+	assert.Equal(t, "0.2", result.Return.String())
+	assert.Equal(t, "0.2", result.MaxDrawdown.String())
+	assert.Equal(t, "0.5", result.WinRate.String())
+	assert.True(t, result.Sharpe.IsPositive())
+
+	result.Equity = nil
+	result.Trades = nil
+	backtestService.summarize(result)
+	assert.Equal(t, "1000", result.EndingBalance.String())
+	assert.Equal(t, "0", result.Return.String())
+	assert.Equal(t, "0", result.WinRate.String())
+	assert.Equal(t, "0", result.Sharpe.String())
+
+	CleanupIntegrationTest()
+}
+
+func TestBacktestService_LoadCandlesticks(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	priceHistoryDAO := dao.NewPriceHistoryDAO(ctx)
+	backtestService := NewBacktestService(ctx, nil, priceHistoryDAO, nil, nil, nil, mapper.NewPriceHistoryMapper())
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, price := range []float64{13000, 13100, 13050} {
+		assert.Nil(t, priceHistoryDAO.Create(&entity.PriceHistory{
+			Exchange: "GDAX",
+			Base:     "BTC",
+			Quote:    "USD",
+			Period:   900,
+			Time:     start.Add(time.Duration(i*900) * time.Second).Unix(),
+			Close:    price}))
+	}
+
+	chart := &dto.ChartDTO{Base: "BTC", Quote: "USD", Exchange: "GDAX", Period: 900}
+	candles, err := backtestService.LoadCandlesticks(chart, &dto.BacktestOptionsDTO{
+		Start:  start,
+		End:    start.Add(time.Hour),
+		Source: BACKTEST_SOURCE_DB})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(candles))
+	assert.Equal(t, "13100", candles[1].Close.String())
+	assert.Equal(t, 900, candles[1].Period)
+	assert.True(t, start.Add(15*time.Minute).Equal(candles[1].Date))
+
+	_, err = backtestService.LoadCandlesticks(chart, &dto.BacktestOptionsDTO{
+		Start:  start,
+		End:    start.Add(time.Hour),
+		Source: "csv"})
+	assert.Equal(t, "Unsupported backtest source: csv", err.Error())
+
+	_, err = backtestService.LoadCandlesticks(chart, &dto.BacktestOptionsDTO{
+		Start: start,
+		End:   start})
+	assert.NotNil(t, err)
+
+	CleanupIntegrationTest()
+}
+
+func TestBacktestService_StoreCandlesticks(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	priceHistoryDAO := dao.NewPriceHistoryDAO(ctx)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	exchangeService := &MockExchangeService_Backtest{
+		exchange: &MockExchange_Backtest{candles: createBacktestCandles(start, 13000, 13100, 13050)}}
+	backtestService := NewBacktestService(ctx, nil, priceHistoryDAO, exchangeService, nil, nil,
+		mapper.NewPriceHistoryMapper())
+
+	assert.Nil(t, priceHistoryDAO.Create(&entity.PriceHistory{
+		Exchange: "GDAX",
+		Base:     "BTC",
+		Quote:    "USD",
+		Period:   900,
+		Time:     start.Unix(),
+		Close:    13000}))
+
+	// Candlesticks fetched from the exchange are stored once for the db source
+	chart := &dto.ChartDTO{Base: "BTC", Quote: "USD", Exchange: "GDAX", Period: 900}
+	options := &dto.BacktestOptionsDTO{Start: start, End: start.Add(time.Hour)}
+	for i := 0; i < 2; i++ {
+		candles, err := backtestService.LoadCandlesticks(chart, options)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(candles))
+	}
+	options.Source = BACKTEST_SOURCE_DB
+	candles, err := backtestService.LoadCandlesticks(chart, options)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(candles))
+	assert.Equal(t, "13050", candles[2].Close.String())
+	assert.Equal(t, "GDAX", candles[2].Exchange)
+	assert.Equal(t, 900, candles[2].Period)
+
+	CleanupIntegrationTest()
+}
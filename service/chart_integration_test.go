@@ -295,6 +295,10 @@ func (mes *MockExchangeService_Chart) CreateExchange(exchangeName string) (commo
 	return new(MockExchange_Chart), nil
 }
 
+func (mes *MockExchangeService_Chart) CreatePaperExchange(exchangeName string) (common.Exchange, error) {
+	return new(MockExchange_Chart), nil
+}
+
 func (mes *MockExchangeService_Chart) GetCurrencyPairs(exchangeName string) ([]common.CurrencyPair, error) {
 	return []common.CurrencyPair{
 		common.CurrencyPair{
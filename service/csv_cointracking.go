@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	COINTRACKING_DATE_FORMAT = "2006-01-02 15:04:05"
+	COINTRACKING_TRADE       = "Trade"
+	COINTRACKING_DEPOSIT     = "Deposit"
+	COINTRACKING_WITHDRAWAL  = "Withdrawal"
+)
+
+// The CoinTracking trade table. The currency columns all share the same name so
+// columns are matched by position.
+var coinTrackingColumns = []string{
+	"Type",
+	"Buy",
+	"Cur.",
+	"Sell",
+	"Cur.",
+	"Fee",
+	"Cur.",
+	"Exchange",
+	"Group",
+	"Comment",
+	"Date"}
+
+var coinTrackingDateFormats = []string{
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	COINTRACKING_DATE_FORMAT,
+	"2006-01-02T15:04:05Z07:00"}
+
+// CoinTracking incoming transaction types by transaction category
+var coinTrackingIncomeTypes = map[string]string{
+	common.TX_CATEGORY_DEPOSIT:  COINTRACKING_DEPOSIT,
+	common.TX_CATEGORY_INCOME:   "Income",
+	common.TX_CATEGORY_MINING:   "Mining",
+	common.TX_CATEGORY_STAKING:  "Staking",
+	common.TX_CATEGORY_AIRDROP:  "Airdrop",
+	common.TX_CATEGORY_FORK:     "Airdrop",
+	common.TX_CATEGORY_TRANSFER: COINTRACKING_DEPOSIT}
+
+// CoinTracking outgoing transaction types by transaction category
+var coinTrackingExpenseTypes = map[string]string{
+	common.TX_CATEGORY_WITHDRAWAL: COINTRACKING_WITHDRAWAL,
+	common.TX_CATEGORY_GIFT:       "Gift",
+	common.TX_CATEGORY_DONATION:   "Donation",
+	common.TX_CATEGORY_SPEND:      "Spend",
+	common.TX_CATEGORY_LOST:       "Lost",
+	common.TX_CATEGORY_TRANSFER:   COINTRACKING_WITHDRAWAL}
+
+// The transaction category of each CoinTracking type along with whether it is
+// received or sent.
+var coinTrackingTypes = map[string]struct {
+	category string
+	incoming bool
+}{
+	"deposit":         {common.TX_CATEGORY_DEPOSIT, true},
+	"income":          {common.TX_CATEGORY_INCOME, true},
+	"reward / bonus":  {common.TX_CATEGORY_INCOME, true},
+	"interest income": {common.TX_CATEGORY_INCOME, true},
+	"lending income":  {common.TX_CATEGORY_INCOME, true},
+	"gift / tip":      {common.TX_CATEGORY_INCOME, true},
+	"mining":          {common.TX_CATEGORY_MINING, true},
+	"staking":         {common.TX_CATEGORY_STAKING, true},
+	"airdrop":         {common.TX_CATEGORY_AIRDROP, true},
+	"withdrawal":      {common.TX_CATEGORY_WITHDRAWAL, false},
+	"gift":            {common.TX_CATEGORY_GIFT, false},
+	"donation":        {common.TX_CATEGORY_DONATION, false},
+	"spend":           {common.TX_CATEGORY_SPEND, false},
+	"lost":            {common.TX_CATEGORY_LOST, false},
+	"stolen":          {common.TX_CATEGORY_LOST, false}}
+
+// coinTrackingFormat is CoinTracking's trade table CSV, where a row is a
+// trade, a deposit or a withdrawal depending on its type. Buy is the amount
+// received and Sell the amount sent.
+type coinTrackingFormat struct{}
+
+func (format *coinTrackingFormat) Header() []string {
+	return coinTrackingColumns
+}
+
+func (format *coinTrackingFormat) Validate(header []string) error {
+	if len(header) < len(coinTrackingColumns) {
+		return errors.New(fmt.Sprintf("Expected %d CoinTracking columns, found %d", len(coinTrackingColumns), len(header)))
+	}
+	for i, column := range coinTrackingColumns {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), column) {
+			return errors.New(fmt.Sprintf("Expected CoinTracking column %d to be %s, found %s", i+1, column, header[i]))
+		}
+	}
+	return nil
+}
+
+func (format *coinTrackingFormat) Parse(row int, values []string) (*csvRecord, *CSVRowError) {
+	if len(values) < len(coinTrackingColumns) {
+		return nil, &CSVRowError{Row: row, Message: fmt.Sprintf("Expected %d columns, found %d", len(coinTrackingColumns), len(values))}
+	}
+	var rowErr *CSVRowError
+	record := &csvRecord{
+		ReceivedCurrency: strings.ToUpper(strings.TrimSpace(values[2])),
+		SentCurrency:     strings.ToUpper(strings.TrimSpace(values[4])),
+		FeeCurrency:      strings.ToUpper(strings.TrimSpace(values[6])),
+		Network:          strings.TrimSpace(values[7]),
+		Reference:        strings.TrimSpace(values[9])}
+	if record.Date, rowErr = parseCSVDate(row, "Date", values[10], coinTrackingDateFormats); rowErr != nil {
+		return nil, rowErr
+	}
+	amounts := []struct {
+		column   string
+		value    string
+		currency string
+		amount   *decimal.Decimal
+	}{
+		{"Buy", values[1], record.ReceivedCurrency, &record.Received},
+		{"Sell", values[3], record.SentCurrency, &record.Sent},
+		{"Fee", values[5], record.FeeCurrency, &record.Fee}}
+	for _, amount := range amounts {
+		if *amount.amount, rowErr = parseCSVAmount(row, amount.column, amount.value); rowErr != nil {
+			return nil, rowErr
+		}
+		if !amount.amount.IsZero() && amount.currency == "" {
+			return nil, &CSVRowError{Row: row, Column: amount.column, Message: "Missing currency"}
+		}
+	}
+	txType := strings.TrimSpace(values[0])
+	if strings.EqualFold(txType, COINTRACKING_TRADE) {
+		if record.Received.IsZero() || record.Sent.IsZero() {
+			return nil, &CSVRowError{Row: row, Column: "Type", Message: "Trades require a buy and a sell amount"}
+		}
+		return record, nil
+	}
+	t, ok := coinTrackingTypes[strings.ToLower(txType)]
+	if !ok {
+		return nil, &CSVRowError{Row: row, Column: "Type", Message: fmt.Sprintf("Unsupported type: %s", txType)}
+	}
+	if t.incoming && (record.Received.IsZero() || !record.Sent.IsZero()) {
+		return nil, &CSVRowError{Row: row, Column: "Type", Message: fmt.Sprintf("%s requires only a buy amount", txType)}
+	}
+	if !t.incoming && (record.Sent.IsZero() || !record.Received.IsZero()) {
+		return nil, &CSVRowError{Row: row, Column: "Type", Message: fmt.Sprintf("%s requires only a sell amount", txType)}
+	}
+	record.Category = t.category
+	return record, nil
+}
+
+func (format *coinTrackingFormat) Format(record *csvRecord) []string {
+	txType := COINTRACKING_TRADE
+	switch {
+	case record.Sent.IsZero():
+		txType = COINTRACKING_DEPOSIT
+		if t, ok := coinTrackingIncomeTypes[record.Category]; ok {
+			txType = t
+		}
+	case record.Received.IsZero():
+		txType = COINTRACKING_WITHDRAWAL
+		if t, ok := coinTrackingExpenseTypes[record.Category]; ok {
+			txType = t
+		}
+	}
+	return []string{
+		txType,
+		formatCSVAmount(record.Received),
+		formatCSVCurrency(record.Received, record.ReceivedCurrency),
+		formatCSVAmount(record.Sent),
+		formatCSVCurrency(record.Sent, record.SentCurrency),
+		formatCSVAmount(record.Fee),
+		formatCSVCurrency(record.Fee, record.FeeCurrency),
+		record.Network,
+		"",
+		record.Reference,
+		record.Date.UTC().Format(COINTRACKING_DATE_FORMAT)}
+}
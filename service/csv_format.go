@@ -0,0 +1,454 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/shopspring/decimal"
+)
+
+// The universal CSV layouts of other crypto tax tools accepted by
+// ImportUniversalCSV and written by ExportUniversalCSV.
+const (
+	CSV_FORMAT_KOINLY       = "koinly"
+	CSV_FORMAT_COINTRACKING = "cointracking"
+)
+
+// CSVRowError reports a row of a universal CSV file that could not be imported.
+// Rows are numbered from 1, the header being row 1.
+type CSVRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e CSVRowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("Row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("Row %d, %s: %s", e.Row, e.Column, e.Message)
+}
+
+// csvRecord is a transaction the way tax tools lay it out: the amount received,
+// the amount sent and the fee, each in its own currency. The fee is deducted
+// from the balance separately from the amounts sent and received. Files that
+// state whether a trade is a buy or a sell set its type, and files that number
+// their transactions set their id.
+type csvRecord struct {
+	Id               string
+	Type             string
+	Date             time.Time
+	Network          string
+	Category         string
+	Received         decimal.Decimal
+	ReceivedCurrency string
+	Sent             decimal.Decimal
+	SentCurrency     string
+	Fee              decimal.Decimal
+	FeeCurrency      string
+	Value            decimal.Decimal
+	ValueCurrency    string
+	Reference        string
+}
+
+// csvParser reads the rows of a CSV layout.
+type csvParser interface {
+	Validate(header []string) error
+	Parse(row int, values []string) (*csvRecord, *CSVRowError)
+}
+
+// csvFormat reads and writes the rows of a universal CSV layout.
+type csvFormat interface {
+	csvParser
+	Header() []string
+	Format(record *csvRecord) []string
+}
+
+func newCSVFormat(name string) (csvFormat, error) {
+	switch strings.ToLower(name) {
+	case CSV_FORMAT_KOINLY:
+		return &koinlyFormat{}, nil
+	case CSV_FORMAT_COINTRACKING:
+		return &coinTrackingFormat{}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unsupported CSV format: %s", name))
+}
+
+// ImportUniversalCSV imports a Koinly or CoinTracking CSV file. Rows that cannot
+// be parsed are reported and skipped while the rest of the file is imported.
+// Transactions are recorded on the network named in the file, falling back to
+// the given network and then to the name of the format. Rows imported before
+// are skipped, making it safe to import the same file again.
+func (service *TransactionServiceImpl) ImportUniversalCSV(file, format, network string) ([]common.Transaction, []CSVRowError, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.ImportUniversalCSV] Importing %s file %s", format, file)
+	csvFormat, err := newCSVFormat(format)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	if network == "" {
+		network = strings.ToLower(format)
+	}
+	transactions, rowErrors, err := parseUniversalCSV(f, csvFormat, network,
+		service.ctx.GetUser().GetLocalCurrency(), service.getFiatPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+	imported, err := service.saveImport(transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return imported, rowErrors, nil
+}
+
+// saveImport saves the imported transactions and matches any transfers among
+// them. Transactions already saved by an earlier import of the same file are
+// skipped, so only the new transactions are returned.
+func (service *TransactionServiceImpl) saveImport(transactions []common.Transaction) ([]common.Transaction, error) {
+	var imported []common.Transaction
+	for _, tx := range transactions {
+		if _, err := service.dao.Get(tx.GetId()); err == nil {
+			service.ctx.GetLogger().Debugf("[TransactionService.saveImport] Skipping previously imported transaction %s", tx.GetId())
+			continue
+		}
+		if err := service.dao.Create(service.mapper.MapTransactionDtoToEntity(tx)); err != nil {
+			service.ctx.GetLogger().Errorf("[TransactionService.saveImport] Error saving transaction %s: %s",
+				tx.GetId(), err.Error())
+			return nil, err
+		}
+		imported = append(imported, tx)
+	}
+	if _, err := service.MatchTransfers(); err != nil {
+		service.ctx.GetLogger().Errorf("[TransactionService.saveImport] Error matching transfers: %s", err.Error())
+	}
+	return imported, nil
+}
+
+// ExportUniversalCSV writes the transaction history to filename in the Koinly or
+// CoinTracking layout.
+func (service *TransactionServiceImpl) ExportUniversalCSV(filename, format string) error {
+	service.ctx.GetLogger().Debugf("[TransactionService.ExportUniversalCSV] Exporting %s file %s", format, filename)
+	csvFormat, err := newCSVFormat(format)
+	if err != nil {
+		return err
+	}
+	transactions, err := service.GetHistory("asc")
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeUniversalCSV(file, csvFormat, transactions)
+}
+
+// getFiatPrice returns the local currency price of currency at date, or zero
+// when it is not available.
+func (service *TransactionServiceImpl) getFiatPrice(currency string, date time.Time) decimal.Decimal {
+	if service.fiatPriceService == nil {
+		return decimal.Zero
+	}
+	candle, err := service.fiatPriceService.GetPriceAt(currency, date)
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[TransactionService.getFiatPrice] Error pricing %s at %s: %s",
+			currency, date, err.Error())
+		return decimal.Zero
+	}
+	return candle.Close
+}
+
+// parseUniversalCSV validates the header and converts each row of the file to a
+// transaction, collecting the rows that fail instead of aborting the import.
+func parseUniversalCSV(reader io.Reader, format csvParser, network, localCurrency string,
+	priceAt func(currency string, date time.Time) decimal.Decimal) ([]common.Transaction, []CSVRowError, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	lines, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil, errors.New("Empty CSV file")
+	}
+	if err := format.Validate(lines[0]); err != nil {
+		return nil, nil, err
+	}
+	var transactions []common.Transaction
+	var rowErrors []CSVRowError
+	for i, values := range lines[1:] {
+		row := i + 2
+		if isBlankCSVRow(values) {
+			continue
+		}
+		record, rowErr := format.Parse(row, values)
+		if rowErr != nil {
+			rowErrors = append(rowErrors, *rowErr)
+			continue
+		}
+		if record.Network == "" {
+			record.Network = network
+		}
+		tx, err := newUniversalCSVTransaction(record, localCurrency, priceAt)
+		if err != nil {
+			rowErrors = append(rowErrors, CSVRowError{Row: row, Message: err.Error()})
+			continue
+		}
+		if record.Id != "" {
+			tx.Id = fmt.Sprintf("%s-%s", network, record.Id)
+		} else {
+			tx.Id = fmt.Sprintf("%s-%x", network, sha1.Sum([]byte(strings.Join(values, ","))))
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, rowErrors, nil
+}
+
+func writeUniversalCSV(writer io.Writer, format csvFormat, transactions []common.Transaction) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(format.Header()); err != nil {
+		return err
+	}
+	for _, tx := range transactions {
+		if tx.IsDeleted() {
+			continue
+		}
+		if err := csvWriter.Write(format.Format(newUniversalCSVRecord(tx))); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// newUniversalCSVTransaction converts a record to a transaction. Receiving and
+// sending is a trade, buying the crypto currency received unless the record is
+// a sell or, lacking a type, fiat was received for it. Only receiving is a deposit and only sending a withdrawal.
+// Values not given in the local currency are priced at the transaction date.
+func newUniversalCSVTransaction(record *csvRecord, localCurrency string,
+	priceAt func(currency string, date time.Time) decimal.Decimal) (*dto.TransactionDTO, error) {
+	var txType, category, base, quote string
+	var quantity, total decimal.Decimal
+	received := record.ReceivedCurrency != "" && record.Received.IsPositive()
+	sent := record.SentCurrency != "" && record.Sent.IsPositive()
+	switch {
+	case received && sent:
+		txType, category = common.BUY_ORDER_TYPE, common.TX_CATEGORY_TRADE
+		base, quote = record.ReceivedCurrency, record.SentCurrency
+		quantity, total = record.Received, record.Sent
+		if record.Type == common.SELL_ORDER_TYPE || (record.Type == "" &&
+			isFiatCurrency(record.ReceivedCurrency) && !isFiatCurrency(record.SentCurrency)) {
+			txType = common.SELL_ORDER_TYPE
+			base, quote = record.SentCurrency, record.ReceivedCurrency
+			quantity, total = record.Sent, record.Received
+		}
+	case received:
+		txType, category = common.DEPOSIT_ORDER_TYPE, common.TX_CATEGORY_DEPOSIT
+		base, quote = record.ReceivedCurrency, localCurrency
+		quantity = record.Received
+	case sent:
+		txType, category = common.WITHDRAWAL_ORDER_TYPE, common.TX_CATEGORY_WITHDRAWAL
+		base, quote = record.SentCurrency, localCurrency
+		quantity = record.Sent
+		// Withdrawals include a fee in the withdrawn currency in their quantity
+		if record.FeeCurrency == base {
+			quantity = quantity.Add(record.Fee)
+		}
+	default:
+		return nil, errors.New("Nothing sent or received")
+	}
+	// Labels classify deposits and withdrawals, trades are always trades
+	if record.Category != "" && txType != common.BUY_ORDER_TYPE && txType != common.SELL_ORDER_TYPE {
+		category = record.Category
+	}
+	fiatTotal := decimal.Zero
+	switch {
+	case record.ValueCurrency == localCurrency && record.Value.IsPositive():
+		fiatTotal = record.Value
+	case quote == localCurrency && !total.IsZero():
+		fiatTotal = total
+	case base == localCurrency:
+		fiatTotal = quantity
+	default:
+		fiatTotal = quantity.Mul(priceAt(base, record.Date))
+		if fiatTotal.IsZero() && !total.IsZero() {
+			fiatTotal = total.Mul(priceAt(quote, record.Date))
+		}
+	}
+	if total.IsZero() {
+		total = fiatTotal
+	}
+	fiatPrice := fiatTotal.Div(quantity)
+	quoteFiatPrice := decimal.NewFromFloat(1)
+	if quote != localCurrency {
+		quoteFiatPrice = fiatTotal.Div(total)
+	}
+	fiatFee := decimal.Zero
+	switch {
+	case record.Fee.IsZero():
+	case record.FeeCurrency == localCurrency:
+		fiatFee = record.Fee
+	case record.FeeCurrency == base:
+		fiatFee = record.Fee.Mul(fiatPrice)
+	case record.FeeCurrency == quote:
+		fiatFee = record.Fee.Mul(quoteFiatPrice)
+	default:
+		fiatFee = record.Fee.Mul(priceAt(record.FeeCurrency, record.Date))
+	}
+	feeCurrency := record.FeeCurrency
+	if feeCurrency == "" {
+		feeCurrency = quote
+	}
+	currencyPair := &common.CurrencyPair{
+		Base:          base,
+		Quote:         quote,
+		LocalCurrency: localCurrency}
+	return &dto.TransactionDTO{
+		Date:                   record.Date,
+		MarketPair:             currencyPair,
+		CurrencyPair:           currencyPair,
+		Type:                   txType,
+		Category:               category,
+		Network:                record.Network,
+		NetworkDisplayName:     record.Network,
+		Quantity:               quantity.String(),
+		QuantityCurrency:       base,
+		FiatQuantity:           fiatTotal.StringFixed(2),
+		FiatQuantityCurrency:   localCurrency,
+		Price:                  total.Div(quantity).String(),
+		PriceCurrency:          quote,
+		FiatPrice:              fiatPrice.StringFixed(2),
+		FiatPriceCurrency:      localCurrency,
+		QuoteFiatPrice:         quoteFiatPrice.StringFixed(2),
+		QuoteFiatPriceCurrency: localCurrency,
+		Fee:                    record.Fee.String(),
+		FeeCurrency:            feeCurrency,
+		FiatFee:                fiatFee.StringFixed(2),
+		FiatFeeCurrency:        localCurrency,
+		Total:                  total.String(),
+		TotalCurrency:          quote,
+		FiatTotal:              fiatTotal.StringFixed(2),
+		FiatTotalCurrency:      localCurrency}, nil
+}
+
+// newUniversalCSVRecord lays a transaction out as the amounts received and sent.
+func newUniversalCSVRecord(tx common.Transaction) *csvRecord {
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	total, _ := decimal.NewFromString(tx.GetTotal())
+	fee, _ := decimal.NewFromString(tx.GetFee())
+	fiatTotal, _ := decimal.NewFromString(tx.GetFiatTotal())
+	base := tx.GetCurrencyPair().Base
+	quote := tx.GetCurrencyPair().Quote
+	record := &csvRecord{
+		Date:          tx.GetDate(),
+		Network:       tx.GetNetwork(),
+		Category:      tx.GetCategory(),
+		Value:         fiatTotal,
+		ValueCurrency: tx.GetFiatTotalCurrency(),
+		Reference:     tx.GetId()}
+	if record.ValueCurrency == "" {
+		record.ValueCurrency = tx.GetCurrencyPair().LocalCurrency
+	}
+	if !fee.IsZero() {
+		record.Fee, record.FeeCurrency = fee, tx.GetFeeCurrency()
+	}
+	switch tx.GetType() {
+	case common.BUY_ORDER_TYPE:
+		record.Type = common.BUY_ORDER_TYPE
+		record.Received, record.ReceivedCurrency = quantity, base
+		record.Sent, record.SentCurrency = total, quote
+	case common.SELL_ORDER_TYPE:
+		record.Type = common.SELL_ORDER_TYPE
+		record.Sent, record.SentCurrency = quantity, base
+		record.Received, record.ReceivedCurrency = total, quote
+	case common.DEPOSIT_ORDER_TYPE:
+		record.Received, record.ReceivedCurrency = quantity, base
+	case common.WITHDRAWAL_ORDER_TYPE:
+		record.Sent, record.SentCurrency = quantity, base
+		if record.FeeCurrency == base {
+			record.Sent = quantity.Sub(fee)
+		}
+	}
+	return record
+}
+
+// csvThousandsPattern matches amounts whose commas group the integer digits in
+// threes, the only commas read as thousands separators.
+var csvThousandsPattern = regexp.MustCompile(`^-?\d{1,3}(,\d{3})+(\.\d*)?$`)
+
+// parseCSVAmount parses an optional amount column, which may use thousands
+// separators. Any other comma may be a decimal comma, as in 0,5, and is
+// rejected rather than guessed at.
+func parseCSVAmount(row int, column, value string) (decimal.Decimal, *CSVRowError) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ",") {
+		if !csvThousandsPattern.MatchString(value) {
+			return decimal.Zero, &CSVRowError{Row: row, Column: column,
+				Message: fmt.Sprintf("Ambiguous amount: %s, use a period as the decimal separator", value)}
+		}
+		value = strings.Replace(value, ",", "", -1)
+	}
+	if value == "" || value == "-" {
+		return decimal.Zero, nil
+	}
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, &CSVRowError{Row: row, Column: column, Message: fmt.Sprintf("Invalid amount: %s", value)}
+	}
+	return amount.Abs(), nil
+}
+
+// parseCSVDate parses a date in one of layouts. Dates without a time zone are
+// taken as UTC.
+func parseCSVDate(row int, column, value string, layouts []string) (time.Time, *CSVRowError) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, &CSVRowError{Row: row, Column: column, Message: fmt.Sprintf("Invalid date: %s", value)}
+}
+
+func formatCSVAmount(amount decimal.Decimal) string {
+	if amount.IsZero() {
+		return ""
+	}
+	return amount.String()
+}
+
+func formatCSVCurrency(amount decimal.Decimal, currency string) string {
+	if amount.IsZero() {
+		return ""
+	}
+	return currency
+}
+
+func isFiatCurrency(currency string) bool {
+	_, found := common.FiatCurrencies[currency]
+	return found
+}
+
+func isBlankCSVRow(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
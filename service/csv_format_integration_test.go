@@ -0,0 +1,180 @@
+// +build integration
+
+package service
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func csvTestPriceAt(currency string, date time.Time) decimal.Decimal {
+	prices := map[string]float64{"BTC": 1000, "ETH": 50}
+	return decimal.NewFromFloat(prices[currency])
+}
+
+func TestParseUniversalCSV_Koinly(t *testing.T) {
+	f, err := os.Open("../test/data/koinly.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	transactions, rowErrors, err := parseUniversalCSV(f, &koinlyFormat{}, "koinly", "USD", csvTestPriceAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(transactions))
+	assert.Equal(t, []CSVRowError{
+		{Row: 7, Column: "Date", Message: "Invalid date: yesterday"},
+		{Row: 8, Column: "Sent Amount", Message: "Invalid amount: abc"}}, rowErrors)
+
+	buy := transactions[0]
+	assert.Equal(t, common.BUY_ORDER_TYPE, buy.GetType())
+	assert.Equal(t, common.TX_CATEGORY_TRADE, buy.GetCategory())
+	assert.Equal(t, "koinly", buy.GetNetwork())
+	assert.Equal(t, "BTC", buy.GetCurrencyPair().Base)
+	assert.Equal(t, "USD", buy.GetCurrencyPair().Quote)
+	assert.Equal(t, "2", buy.GetQuantity())
+	assert.Equal(t, "2000.00", buy.GetFiatTotal())
+	assert.Equal(t, "10.00", buy.GetFiatFee())
+
+	mining := transactions[1]
+	assert.Equal(t, common.DEPOSIT_ORDER_TYPE, mining.GetType())
+	assert.Equal(t, common.TX_CATEGORY_MINING, mining.GetCategory())
+	assert.Equal(t, "120.00", mining.GetFiatTotal())
+
+	// Crypto to crypto trades are valued at the price of the currency bought
+	swap := transactions[2]
+	assert.Equal(t, common.BUY_ORDER_TYPE, swap.GetType())
+	assert.Equal(t, "ETH", swap.GetCurrencyPair().Base)
+	assert.Equal(t, "BTC", swap.GetCurrencyPair().Quote)
+	assert.Equal(t, "500.00", swap.GetFiatTotal())
+	assert.Equal(t, "1000.00", swap.GetQuoteFiatPrice())
+	assert.Equal(t, "1.00", swap.GetFiatFee())
+
+	sell := transactions[3]
+	assert.Equal(t, common.SELL_ORDER_TYPE, sell.GetType())
+	assert.Equal(t, "0.9", sell.GetQuantity())
+	assert.Equal(t, "1350", sell.GetTotal())
+
+	// Withdrawals include the fee in their quantity
+	gift := transactions[4]
+	assert.Equal(t, common.WITHDRAWAL_ORDER_TYPE, gift.GetType())
+	assert.Equal(t, common.TX_CATEGORY_GIFT, gift.GetCategory())
+	assert.Equal(t, "0.05", gift.GetQuantity())
+	assert.Equal(t, "65.00", gift.GetFiatTotal())
+	assert.Equal(t, "1.30", gift.GetFiatFee())
+}
+
+func TestParseCSVAmount(t *testing.T) {
+	amount, rowErr := parseCSVAmount(2, "Amount", "1,234,567.5")
+	assert.Nil(t, rowErr)
+	assert.Equal(t, "1234567.5", amount.String())
+
+	amount, rowErr = parseCSVAmount(2, "Amount", "-0.5")
+	assert.Nil(t, rowErr)
+	assert.Equal(t, "0.5", amount.String())
+
+	for _, value := range []string{"0,5", "1,5", "12,34", "1.234,5"} {
+		_, rowErr = parseCSVAmount(2, "Amount", value)
+		assert.Equal(t, &CSVRowError{Row: 2, Column: "Amount",
+			Message: "Ambiguous amount: " + value + ", use a period as the decimal separator"}, rowErr)
+	}
+}
+
+func TestParseUniversalCSV_CoinTracking(t *testing.T) {
+	f, err := os.Open("../test/data/cointracking.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	transactions, rowErrors, err := parseUniversalCSV(f, &coinTrackingFormat{}, "cointracking", "USD", csvTestPriceAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(transactions))
+	assert.Equal(t, 2, len(rowErrors))
+	assert.Equal(t, 5, rowErrors[0].Row)
+	assert.Equal(t, "Unsupported type: Margin Profit", rowErrors[0].Message)
+	assert.Equal(t, 6, rowErrors[1].Row)
+	assert.Equal(t, "Type", rowErrors[1].Column)
+
+	assert.Equal(t, "gdax", transactions[0].GetNetwork())
+	assert.Equal(t, time.Date(2017, 01, 01, 12, 0, 0, 0, time.UTC), transactions[0].GetDate())
+	assert.Equal(t, common.TX_CATEGORY_MINING, transactions[1].GetCategory())
+	assert.Equal(t, "100.00", transactions[1].GetFiatTotal())
+	assert.Equal(t, common.WITHDRAWAL_ORDER_TYPE, transactions[2].GetType())
+	assert.Equal(t, common.TX_CATEGORY_SPEND, transactions[2].GetCategory())
+	assert.Equal(t, "BTC wallet", transactions[2].GetNetwork())
+}
+
+func TestParseUniversalCSV_InvalidHeader(t *testing.T) {
+	_, _, err := parseUniversalCSV(strings.NewReader("Date,Sent Amount,Received Amount\n"),
+		&koinlyFormat{}, "koinly", "USD", csvTestPriceAt)
+	assert.Equal(t, "Missing Koinly columns: Sent Currency, Received Currency", err.Error())
+
+	_, _, err = parseUniversalCSV(strings.NewReader("Type,Buy,Cur.,Sell,Cur.,Fee,Cur.,Exchange,Group,Date,Comment\n"),
+		&coinTrackingFormat{}, "cointracking", "USD", csvTestPriceAt)
+	assert.NotNil(t, err)
+}
+
+func TestWriteUniversalCSV(t *testing.T) {
+	f, err := os.Open("../test/data/koinly.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+	transactions, _, err := parseUniversalCSV(f, &koinlyFormat{}, "koinly", "USD", csvTestPriceAt)
+	assert.Nil(t, err)
+
+	for _, format := range []csvFormat{&koinlyFormat{}, &coinTrackingFormat{}} {
+		var buf bytes.Buffer
+		assert.Nil(t, writeUniversalCSV(&buf, format, transactions))
+		exported, rowErrors, err := parseUniversalCSV(&buf, format, "koinly", "USD", csvTestPriceAt)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(rowErrors))
+		assert.Equal(t, len(transactions), len(exported))
+		for i, tx := range exported {
+			assert.Equal(t, transactions[i].GetType(), tx.GetType())
+			assert.Equal(t, transactions[i].GetCategory(), tx.GetCategory())
+			assert.Equal(t, transactions[i].GetDate(), tx.GetDate())
+			assert.Equal(t, transactions[i].GetQuantity(), tx.GetQuantity())
+			assert.Equal(t, transactions[i].GetCurrencyPair(), tx.GetCurrencyPair())
+			assert.Equal(t, transactions[i].GetFee(), tx.GetFee())
+		}
+	}
+}
+
+func TestTransactionService_ImportUniversalCSV(t *testing.T) {
+	transactionDAO, transactionService := createTransactionService()
+
+	file, err := ioutil.TempFile("", "tradebot-koinly-")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("Date,Sent Amount,Sent Currency,Received Amount,Received Currency,Fee Amount,Fee Currency\n" +
+		"2017-01-01 12:00:00 UTC,2000,USD,2,BTC,10,USD\n" +
+		"2017-04-01 12:00:00 UTC,0.9,BTC,1350,,5,USD\n")
+	file.Close()
+
+	transactions, rowErrors, err := transactionService.ImportUniversalCSV(file.Name(), CSV_FORMAT_KOINLY, "gdax")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transactions))
+	assert.Equal(t, []CSVRowError{{Row: 3, Column: "Received Amount", Message: "Missing currency"}}, rowErrors)
+
+	entities, err := transactionDAO.Find("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entities))
+	assert.Equal(t, "gdax", entities[0].GetNetwork())
+
+	// Importing the same file again skips the rows already imported
+	transactions, _, err = transactionService.ImportUniversalCSV(file.Name(), CSV_FORMAT_KOINLY, "gdax")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(transactions))
+	entities, err = transactionDAO.Find("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entities))
+
+	_, _, err = transactionService.ImportUniversalCSV(file.Name(), "quickbooks", "")
+	assert.Equal(t, "Unsupported CSV format: quickbooks", err.Error())
+
+	CleanupIntegrationTest()
+}
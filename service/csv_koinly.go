@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/shopspring/decimal"
+)
+
+const KOINLY_DATE_FORMAT = "2006-01-02 15:04:05 MST"
+
+var koinlyColumns = []string{
+	"Date",
+	"Sent Amount",
+	"Sent Currency",
+	"Received Amount",
+	"Received Currency",
+	"Fee Amount",
+	"Fee Currency",
+	"Net Worth Amount",
+	"Net Worth Currency",
+	"Label",
+	"Description",
+	"TxHash"}
+
+var koinlyRequiredColumns = []string{
+	"Date",
+	"Sent Amount",
+	"Sent Currency",
+	"Received Amount",
+	"Received Currency"}
+
+var koinlyDateFormats = []string{
+	KOINLY_DATE_FORMAT,
+	"2006-01-02 15:04 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.000Z"}
+
+// Koinly labels by transaction category. Categories without a label, such as
+// spends, are left for Koinly to classify.
+var koinlyLabels = map[string]string{
+	common.TX_CATEGORY_INCOME:   "income",
+	common.TX_CATEGORY_MINING:   "mining",
+	common.TX_CATEGORY_STAKING:  "staking",
+	common.TX_CATEGORY_AIRDROP:  "airdrop",
+	common.TX_CATEGORY_FORK:     "fork",
+	common.TX_CATEGORY_GIFT:     "gift",
+	common.TX_CATEGORY_DONATION: "donation",
+	common.TX_CATEGORY_LOST:     "lost"}
+
+// Koinly labels that import as a transaction category but are not exported.
+var koinlyLabelAliases = map[string]string{
+	"reward":           common.TX_CATEGORY_INCOME,
+	"other income":     common.TX_CATEGORY_INCOME,
+	"lending interest": common.TX_CATEGORY_INCOME,
+	"loan interest":    common.TX_CATEGORY_INCOME,
+	"cashback":         common.TX_CATEGORY_INCOME,
+	"stolen":           common.TX_CATEGORY_LOST,
+	"cost":             common.TX_CATEGORY_SPEND}
+
+// koinlyFormat is Koinly's universal CSV template. Columns are matched by name
+// and may appear in any order.
+type koinlyFormat struct {
+	columns map[string]int
+}
+
+func (format *koinlyFormat) Header() []string {
+	return koinlyColumns
+}
+
+func (format *koinlyFormat) Validate(header []string) error {
+	format.columns = make(map[string]int)
+	for i, column := range header {
+		format.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	var missing []string
+	for _, column := range koinlyRequiredColumns {
+		if _, ok := format.columns[strings.ToLower(column)]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New(fmt.Sprintf("Missing Koinly columns: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+func (format *koinlyFormat) value(values []string, column string) string {
+	i, ok := format.columns[strings.ToLower(column)]
+	if !ok || i >= len(values) {
+		return ""
+	}
+	return strings.TrimSpace(values[i])
+}
+
+func (format *koinlyFormat) Parse(row int, values []string) (*csvRecord, *CSVRowError) {
+	var rowErr *CSVRowError
+	record := &csvRecord{
+		SentCurrency:     strings.ToUpper(format.value(values, "Sent Currency")),
+		ReceivedCurrency: strings.ToUpper(format.value(values, "Received Currency")),
+		FeeCurrency:      strings.ToUpper(format.value(values, "Fee Currency")),
+		ValueCurrency:    strings.ToUpper(format.value(values, "Net Worth Currency")),
+		Reference:        format.value(values, "TxHash")}
+	if record.Date, rowErr = parseCSVDate(row, "Date", format.value(values, "Date"), koinlyDateFormats); rowErr != nil {
+		return nil, rowErr
+	}
+	amounts := []struct {
+		column   string
+		currency string
+		amount   *decimal.Decimal
+	}{
+		{"Sent Amount", record.SentCurrency, &record.Sent},
+		{"Received Amount", record.ReceivedCurrency, &record.Received},
+		{"Fee Amount", record.FeeCurrency, &record.Fee},
+		{"Net Worth Amount", record.ValueCurrency, &record.Value}}
+	for _, amount := range amounts {
+		if *amount.amount, rowErr = parseCSVAmount(row, amount.column, format.value(values, amount.column)); rowErr != nil {
+			return nil, rowErr
+		}
+		if !amount.amount.IsZero() && amount.currency == "" {
+			return nil, &CSVRowError{Row: row, Column: amount.column, Message: "Missing currency"}
+		}
+	}
+	label := strings.ToLower(format.value(values, "Label"))
+	if label != "" {
+		category := ""
+		for c, l := range koinlyLabels {
+			if l == label {
+				category = c
+			}
+		}
+		if category == "" {
+			category = koinlyLabelAliases[label]
+		}
+		// Trade labels such as swap do not change how a trade is reported
+		if category == "" && (record.Sent.IsZero() || record.Received.IsZero()) {
+			return nil, &CSVRowError{Row: row, Column: "Label", Message: fmt.Sprintf("Unsupported label: %s", label)}
+		}
+		record.Category = category
+	}
+	return record, nil
+}
+
+func (format *koinlyFormat) Format(record *csvRecord) []string {
+	return []string{
+		record.Date.UTC().Format(KOINLY_DATE_FORMAT),
+		formatCSVAmount(record.Sent),
+		formatCSVCurrency(record.Sent, record.SentCurrency),
+		formatCSVAmount(record.Received),
+		formatCSVCurrency(record.Received, record.ReceivedCurrency),
+		formatCSVAmount(record.Fee),
+		formatCSVCurrency(record.Fee, record.FeeCurrency),
+		formatCSVAmount(record.Value),
+		formatCSVCurrency(record.Value, record.ValueCurrency),
+		koinlyLabels[record.Category],
+		record.Network,
+		record.Reference}
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+// Date format for templates whose date column holds seconds since the epoch
+const CSV_TEMPLATE_DATE_UNIX = "unix"
+
+// The transaction types and categories a template's types may map to, along
+// with the type of transaction each implies.
+var csvTemplateTypes = map[string]string{
+	common.BUY_ORDER_TYPE:        common.BUY_ORDER_TYPE,
+	common.SELL_ORDER_TYPE:       common.SELL_ORDER_TYPE,
+	common.DEPOSIT_ORDER_TYPE:    common.DEPOSIT_ORDER_TYPE,
+	common.WITHDRAWAL_ORDER_TYPE: common.WITHDRAWAL_ORDER_TYPE,
+	common.TX_CATEGORY_INCOME:    common.DEPOSIT_ORDER_TYPE,
+	common.TX_CATEGORY_MINING:    common.DEPOSIT_ORDER_TYPE,
+	common.TX_CATEGORY_STAKING:   common.DEPOSIT_ORDER_TYPE,
+	common.TX_CATEGORY_AIRDROP:   common.DEPOSIT_ORDER_TYPE,
+	common.TX_CATEGORY_FORK:      common.DEPOSIT_ORDER_TYPE,
+	common.TX_CATEGORY_SPEND:     common.WITHDRAWAL_ORDER_TYPE,
+	common.TX_CATEGORY_GIFT:      common.WITHDRAWAL_ORDER_TYPE,
+	common.TX_CATEGORY_DONATION:  common.WITHDRAWAL_ORDER_TYPE,
+	common.TX_CATEGORY_LOST:      common.WITHDRAWAL_ORDER_TYPE}
+
+type DefaultCSVTemplateService struct {
+	ctx    common.Context
+	dao    dao.CSVTemplateDAO
+	mapper mapper.CSVTemplateMapper
+	CSVTemplateService
+}
+
+func NewCSVTemplateService(ctx common.Context, csvTemplateDAO dao.CSVTemplateDAO,
+	csvTemplateMapper mapper.CSVTemplateMapper) CSVTemplateService {
+	return &DefaultCSVTemplateService{
+		ctx:    ctx,
+		dao:    csvTemplateDAO,
+		mapper: csvTemplateMapper}
+}
+
+func (service *DefaultCSVTemplateService) GetMapper() mapper.CSVTemplateMapper {
+	return service.mapper
+}
+
+func (service *DefaultCSVTemplateService) GetTemplates() ([]common.CSVTemplate, error) {
+	entities, err := service.dao.Find()
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]common.CSVTemplate, len(entities))
+	for i, entity := range entities {
+		templates[i] = service.mapper.MapCSVTemplateEntityToDto(&entity)
+	}
+	return templates, nil
+}
+
+func (service *DefaultCSVTemplateService) GetTemplate(name string) (common.CSVTemplate, error) {
+	entity, err := service.dao.Get(name)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("CSV template not found: %s", name))
+	}
+	return service.mapper.MapCSVTemplateEntityToDto(entity), nil
+}
+
+// SaveTemplate validates and saves the template for the current user, replacing
+// the user's template of the same name. Templates shared by other users are
+// saved the same way.
+func (service *DefaultCSVTemplateService) SaveTemplate(template common.CSVTemplate) (common.CSVTemplate, error) {
+	service.ctx.GetLogger().Debugf("[CSVTemplateService.SaveTemplate] Saving %s's template %s",
+		service.ctx.GetUser().GetUsername(), template.GetName())
+	if _, err := newCSVTemplateParser(template); err != nil {
+		return nil, err
+	}
+	types := make(map[string]string)
+	for value, txType := range template.GetTypes() {
+		types[value] = strings.ToLower(txType)
+	}
+	templateDTO := &dto.CSVTemplateDTO{
+		UserId:                service.ctx.GetUser().GetId(),
+		Name:                  template.GetName(),
+		Network:               template.GetNetwork(),
+		IdColumn:              template.GetIdColumn(),
+		DateColumn:            template.GetDateColumn(),
+		DateFormat:            template.GetDateFormat(),
+		TimeZone:              template.GetTimeZone(),
+		TypeColumn:            template.GetTypeColumn(),
+		Types:                 types,
+		CurrencyPairColumn:    template.GetCurrencyPairColumn(),
+		CurrencyPairSeparator: template.GetCurrencyPairSeparator(),
+		QuoteFirst:            template.IsQuoteFirst(),
+		BaseColumn:            template.GetBaseColumn(),
+		QuoteColumn:           template.GetQuoteColumn(),
+		QuantityColumn:        template.GetQuantityColumn(),
+		PriceColumn:           template.GetPriceColumn(),
+		TotalColumn:           template.GetTotalColumn(),
+		FeeColumn:             template.GetFeeColumn(),
+		FeeCurrencyColumn:     template.GetFeeCurrencyColumn(),
+		FeeCurrency:           strings.ToUpper(template.GetFeeCurrency())}
+	if existing, err := service.dao.Get(template.GetName()); err == nil {
+		templateDTO.Id = existing.GetId()
+	}
+	entity := service.mapper.MapCSVTemplateDtoToEntity(templateDTO)
+	var err error
+	if templateDTO.Id == 0 {
+		err = service.dao.Create(entity)
+	} else {
+		err = service.dao.Save(entity)
+	}
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[CSVTemplateService.SaveTemplate] Error saving template: %s", err.Error())
+		return nil, err
+	}
+	return service.mapper.MapCSVTemplateEntityToDto(entity), nil
+}
+
+func (service *DefaultCSVTemplateService) DeleteTemplate(name string) error {
+	service.ctx.GetLogger().Debugf("[CSVTemplateService.DeleteTemplate] Deleting %s's template %s",
+		service.ctx.GetUser().GetUsername(), name)
+	if _, err := service.dao.Get(name); err != nil {
+		return errors.New(fmt.Sprintf("CSV template not found: %s", name))
+	}
+	return service.dao.Delete(name)
+}
+
+// ImportTemplateCSV imports a CSV file using one of the user's templates. Rows
+// that cannot be parsed are reported and skipped while the rest of the file is
+// imported.
+func (service *TransactionServiceImpl) ImportTemplateCSV(file, templateName string) ([]common.Transaction, []CSVRowError, error) {
+	transactions, rowErrors, err := service.parseTemplateCSV(file, templateName)
+	if err != nil {
+		return nil, nil, err
+	}
+	imported, err := service.saveImport(transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return imported, rowErrors, nil
+}
+
+// importTemplateCSV imports a CSV file using one of the user's templates,
+// importing nothing if any row cannot be parsed.
+func (service *TransactionServiceImpl) importTemplateCSV(file, templateName string) ([]common.Transaction, error) {
+	transactions, rowErrors, err := service.parseTemplateCSV(file, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowErrors) > 0 {
+		return nil, errors.New(fmt.Sprintf("%d invalid rows, the first being %s", len(rowErrors), rowErrors[0].Error()))
+	}
+	return service.saveImport(transactions)
+}
+
+// parseTemplateCSV parses a CSV file using one of the user's templates.
+// Transactions are recorded on the template's network, defaulting to the
+// template's name.
+func (service *TransactionServiceImpl) parseTemplateCSV(file, templateName string) ([]common.Transaction, []CSVRowError, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.parseTemplateCSV] Parsing %s using template %s", file, templateName)
+	if service.csvTemplateService == nil {
+		return nil, nil, errors.New("CSV templates not available")
+	}
+	template, err := service.csvTemplateService.GetTemplate(templateName)
+	if err != nil {
+		return nil, nil, err
+	}
+	parser, err := newCSVTemplateParser(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	network := template.GetNetwork()
+	if network == "" {
+		network = template.GetName()
+	}
+	return parseUniversalCSV(f, parser, network, service.ctx.GetUser().GetLocalCurrency(), service.getFiatPrice)
+}
+
+// csvTemplateParser parses the rows of an exchange's CSV export as mapped by a
+// user's template.
+type csvTemplateParser struct {
+	template common.CSVTemplate
+	location *time.Location
+	types    map[string]string
+	columns  map[string]int
+}
+
+// newCSVTemplateParser validates the template, which must map the date, type
+// and quantity columns, the currencies and, for templates that import trades,
+// the price or the total.
+func newCSVTemplateParser(template common.CSVTemplate) (*csvTemplateParser, error) {
+	if strings.TrimSpace(template.GetName()) == "" {
+		return nil, errors.New("CSV template name required")
+	}
+	if template.GetDateColumn() == "" || template.GetTypeColumn() == "" || template.GetQuantityColumn() == "" {
+		return nil, errors.New("CSV template date, type and quantity columns required")
+	}
+	if template.GetCurrencyPairColumn() == "" && template.GetBaseColumn() == "" {
+		return nil, errors.New("CSV template currency pair or base currency column required")
+	}
+	location, err := time.LoadLocation(template.GetTimeZone())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid CSV template time zone: %s", template.GetTimeZone()))
+	}
+	if len(template.GetTypes()) == 0 {
+		return nil, errors.New("CSV template types required")
+	}
+	types := make(map[string]string)
+	trades := false
+	for value, txType := range template.GetTypes() {
+		txType = strings.ToLower(txType)
+		if _, ok := csvTemplateTypes[txType]; !ok {
+			return nil, errors.New(fmt.Sprintf("Invalid CSV template type for %s: %s", value, txType))
+		}
+		types[strings.ToLower(strings.TrimSpace(value))] = txType
+		trades = trades || txType == common.BUY_ORDER_TYPE || txType == common.SELL_ORDER_TYPE
+	}
+	if trades && template.GetPriceColumn() == "" && template.GetTotalColumn() == "" {
+		return nil, errors.New("CSV template price or total column required to import trades")
+	}
+	return &csvTemplateParser{
+		template: template,
+		location: location,
+		types:    types}, nil
+}
+
+// Validate checks the header contains each column the template maps.
+func (parser *csvTemplateParser) Validate(header []string) error {
+	parser.columns = make(map[string]int)
+	for i, column := range header {
+		parser.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	var missing []string
+	for _, column := range []string{
+		parser.template.GetIdColumn(),
+		parser.template.GetDateColumn(),
+		parser.template.GetTypeColumn(),
+		parser.template.GetCurrencyPairColumn(),
+		parser.template.GetBaseColumn(),
+		parser.template.GetQuoteColumn(),
+		parser.template.GetQuantityColumn(),
+		parser.template.GetPriceColumn(),
+		parser.template.GetTotalColumn(),
+		parser.template.GetFeeColumn(),
+		parser.template.GetFeeCurrencyColumn()} {
+		if column == "" {
+			continue
+		}
+		if _, ok := parser.columns[strings.ToLower(column)]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New(fmt.Sprintf("Missing %s template columns: %s", parser.template.GetName(), strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+func (parser *csvTemplateParser) value(values []string, column string) string {
+	i, ok := parser.columns[strings.ToLower(column)]
+	if column == "" || !ok || i >= len(values) {
+		return ""
+	}
+	return strings.TrimSpace(values[i])
+}
+
+func (parser *csvTemplateParser) Parse(row int, values []string) (*csvRecord, *CSVRowError) {
+	template := parser.template
+	typeValue := parser.value(values, template.GetTypeColumn())
+	category, ok := parser.types[strings.ToLower(typeValue)]
+	if !ok {
+		return nil, &CSVRowError{Row: row, Column: template.GetTypeColumn(), Message: fmt.Sprintf("Unmapped type: %s", typeValue)}
+	}
+	txType := csvTemplateTypes[category]
+	date, rowErr := parser.parseDate(row, parser.value(values, template.GetDateColumn()))
+	if rowErr != nil {
+		return nil, rowErr
+	}
+	base, quote := parser.parseCurrencyPair(values)
+	if base == "" {
+		return nil, &CSVRowError{Row: row, Message: "Missing currency"}
+	}
+	quantity, rowErr := parseCSVAmount(row, template.GetQuantityColumn(), parser.value(values, template.GetQuantityColumn()))
+	if rowErr != nil {
+		return nil, rowErr
+	}
+	if quantity.IsZero() {
+		return nil, &CSVRowError{Row: row, Column: template.GetQuantityColumn(), Message: "Missing quantity"}
+	}
+	fee, rowErr := parseCSVAmount(row, template.GetFeeColumn(), parser.value(values, template.GetFeeColumn()))
+	if rowErr != nil {
+		return nil, rowErr
+	}
+	record := &csvRecord{
+		Id:          parser.value(values, template.GetIdColumn()),
+		Date:        date,
+		Network:     template.GetNetwork(),
+		Fee:         fee,
+		FeeCurrency: strings.ToUpper(parser.value(values, template.GetFeeCurrencyColumn()))}
+	if record.FeeCurrency == "" {
+		record.FeeCurrency = template.GetFeeCurrency()
+	}
+	switch txType {
+	case common.BUY_ORDER_TYPE, common.SELL_ORDER_TYPE:
+		if quote == "" {
+			return nil, &CSVRowError{Row: row, Message: "Missing quote currency"}
+		}
+		total, rowErr := parseCSVAmount(row, template.GetTotalColumn(), parser.value(values, template.GetTotalColumn()))
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		if template.GetTotalColumn() == "" {
+			price, rowErr := parseCSVAmount(row, template.GetPriceColumn(), parser.value(values, template.GetPriceColumn()))
+			if rowErr != nil {
+				return nil, rowErr
+			}
+			total = quantity.Mul(price)
+		}
+		if total.IsZero() {
+			return nil, &CSVRowError{Row: row, Message: "Missing price or total"}
+		}
+		if record.FeeCurrency == "" {
+			record.FeeCurrency = quote
+		}
+		record.Type = txType
+		if txType == common.BUY_ORDER_TYPE {
+			record.Received, record.ReceivedCurrency = quantity, base
+			record.Sent, record.SentCurrency = total, quote
+		} else {
+			record.Sent, record.SentCurrency = quantity, base
+			record.Received, record.ReceivedCurrency = total, quote
+		}
+	case common.DEPOSIT_ORDER_TYPE:
+		record.Category = category
+		record.Received, record.ReceivedCurrency = quantity, base
+	case common.WITHDRAWAL_ORDER_TYPE:
+		record.Category = category
+		record.Sent, record.SentCurrency = quantity, base
+	}
+	if record.FeeCurrency == "" {
+		record.FeeCurrency = base
+	}
+	if record.Fee.IsZero() {
+		record.Fee, record.FeeCurrency = decimal.Zero, ""
+	}
+	return record, nil
+}
+
+func (parser *csvTemplateParser) parseDate(row int, value string) (time.Time, *CSVRowError) {
+	column := parser.template.GetDateColumn()
+	switch parser.template.GetDateFormat() {
+	case CSV_TEMPLATE_DATE_UNIX:
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, &CSVRowError{Row: row, Column: column, Message: fmt.Sprintf("Invalid date: %s", value)}
+		}
+		return time.Unix(seconds, 0).In(parser.location), nil
+	case "":
+		return parseCSVDate(row, column, value, []string{time.RFC3339})
+	}
+	date, err := time.ParseInLocation(parser.template.GetDateFormat(), value, parser.location)
+	if err != nil {
+		return time.Time{}, &CSVRowError{Row: row, Column: column, Message: fmt.Sprintf("Invalid date: %s", value)}
+	}
+	return date, nil
+}
+
+// parseCurrencyPair splits the currency pair column on the template's
+// separator, or reads the base and quote columns.
+func (parser *csvTemplateParser) parseCurrencyPair(values []string) (string, string) {
+	template := parser.template
+	var base, quote string
+	if pair := parser.value(values, template.GetCurrencyPairColumn()); pair != "" {
+		currencies := []string{pair}
+		if template.GetCurrencyPairSeparator() != "" {
+			currencies = strings.SplitN(pair, template.GetCurrencyPairSeparator(), 2)
+		}
+		base = currencies[0]
+		if len(currencies) == 2 {
+			quote = currencies[1]
+			if template.IsQuoteFirst() {
+				base, quote = quote, base
+			}
+		}
+	}
+	if value := parser.value(values, template.GetBaseColumn()); value != "" {
+		base = value
+	}
+	if value := parser.value(values, template.GetQuoteColumn()); value != "" {
+		quote = value
+	}
+	return strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+}
@@ -0,0 +1,135 @@
+// +build integration
+
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCSVTemplateTestTemplate() *dto.CSVTemplateDTO {
+	return &dto.CSVTemplateDTO{
+		Name:                  "otc",
+		IdColumn:              "Trade #",
+		DateColumn:            "Executed",
+		DateFormat:            "2006-01-02 15:04",
+		TimeZone:              "America/New_York",
+		TypeColumn:            "Side",
+		Types:                 map[string]string{"BOUGHT": "buy", "SOLD": "sell", "SENT": "spend"},
+		CurrencyPairColumn:    "Market",
+		CurrencyPairSeparator: "-",
+		QuoteFirst:            true,
+		QuantityColumn:        "Amount",
+		PriceColumn:           "Rate",
+		FeeColumn:             "Commission",
+		FeeCurrency:           "USD"}
+}
+
+func TestCSVTemplateParser(t *testing.T) {
+	parser, err := newCSVTemplateParser(createCSVTemplateTestTemplate())
+	assert.Nil(t, err)
+	f, err := os.Open("../test/data/otc.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+
+	transactions, rowErrors, err := parseUniversalCSV(f, parser, "otc", "USD", csvTestPriceAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(transactions))
+	assert.Equal(t, []CSVRowError{
+		{Row: 5, Column: "Side", Message: "Unmapped type: SWAPPED"},
+		{Row: 6, Column: "Executed", Message: "Invalid date: 2017-13-01 08:00"},
+		{Row: 7, Message: "Missing quote currency"}}, rowErrors)
+
+	buy := transactions[0]
+	assert.Equal(t, "otc-1001", buy.GetId())
+	assert.Equal(t, common.BUY_ORDER_TYPE, buy.GetType())
+	assert.Equal(t, "BTC", buy.GetCurrencyPair().Base)
+	assert.Equal(t, "USD", buy.GetCurrencyPair().Quote)
+	assert.Equal(t, time.Date(2017, 01, 01, 12, 0, 0, 0, time.UTC), buy.GetDate().UTC())
+	assert.Equal(t, "2000", buy.GetTotal())
+	assert.Equal(t, "10.00", buy.GetFiatFee())
+
+	sell := transactions[1]
+	assert.Equal(t, common.SELL_ORDER_TYPE, sell.GetType())
+	assert.Equal(t, "0.9", sell.GetQuantity())
+	assert.Equal(t, "1350", sell.GetTotal())
+	assert.Equal(t, "5", sell.GetFee())
+
+	spend := transactions[2]
+	assert.Equal(t, common.WITHDRAWAL_ORDER_TYPE, spend.GetType())
+	assert.Equal(t, common.TX_CATEGORY_SPEND, spend.GetCategory())
+	assert.Equal(t, "BTC", spend.GetCurrencyPair().Base)
+	assert.Equal(t, "50.00", spend.GetFiatTotal())
+}
+
+func TestCSVTemplateParser_InvalidTemplate(t *testing.T) {
+	template := createCSVTemplateTestTemplate()
+	template.Types["SWAPPED"] = "swap"
+	_, err := newCSVTemplateParser(template)
+	assert.Equal(t, "Invalid CSV template type for SWAPPED: swap", err.Error())
+
+	template = createCSVTemplateTestTemplate()
+	template.PriceColumn = ""
+	_, err = newCSVTemplateParser(template)
+	assert.Equal(t, "CSV template price or total column required to import trades", err.Error())
+
+	template.Types = map[string]string{"SENT": "spend"}
+	parser, err := newCSVTemplateParser(template)
+	assert.Nil(t, err)
+	assert.Equal(t, "Missing otc template columns: Commission",
+		parser.Validate([]string{"Trade #", "Executed", "Side", "Market", "Amount"}).Error())
+}
+
+func TestCSVTemplateService(t *testing.T) {
+	transactionDAO, transactionService := createTransactionService()
+	csvTemplateService := NewCSVTemplateService(TEST_CONTEXT, dao.NewCSVTemplateDAO(TEST_CONTEXT),
+		mapper.NewCSVTemplateMapper(TEST_CONTEXT))
+
+	saved, err := csvTemplateService.SaveTemplate(createCSVTemplateTestTemplate())
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), saved.GetUserId())
+
+	// Saving a template of the same name replaces it
+	template := createCSVTemplateTestTemplate()
+	template.Network = "otc desk"
+	replaced, err := csvTemplateService.SaveTemplate(template)
+	assert.Nil(t, err)
+	assert.Equal(t, saved.GetId(), replaced.GetId())
+	templates, err := csvTemplateService.GetTemplates()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(templates))
+	assert.Equal(t, "otc desk", templates[0].GetNetwork())
+	assert.Equal(t, "sell", templates[0].GetTypes()["SOLD"])
+
+	file, err := ioutil.TempFile("", "tradebot-otc-")
+	assert.Nil(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("Trade #,Executed,Side,Market,Amount,Rate,Commission\n" +
+		"1001,2017-01-01 07:00,BOUGHT,USD-BTC,2,1000,10\n" +
+		"1002,2017-04-01 08:00,SOLD,USD-BTC,0.9,1500,5\n")
+	file.Close()
+
+	// ImportCSV uses the template named after the exchange
+	transactions, err := transactionService.ImportCSV(file.Name(), "otc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(transactions))
+	entities, err := transactionDAO.Find("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entities))
+	assert.Equal(t, "otc desk", entities[0].GetNetwork())
+	assert.Equal(t, "otc desk-1001", entities[0].GetId())
+
+	assert.Nil(t, csvTemplateService.DeleteTemplate("otc"))
+	_, err = csvTemplateService.GetTemplate("otc")
+	assert.Equal(t, "CSV template not found: otc", err.Error())
+
+	CleanupIntegrationTest()
+}
@@ -38,9 +38,36 @@ func (service *DefaultExchangeService) CreateExchange(exchangeName string) (comm
 		return nil, err
 	}
 	exchange, err := service.pluginService.CreateExchange(exchangeName)
+	if err != nil {
+		return nil, err
+	}
 	return exchange(service.ctx, userCryptoExchange), nil
 }
 
+// CreatePaperExchange creates the paper trading exchange plugin, which trades
+// against simulated balances using the named exchange's market data.
+func (service *DefaultExchangeService) CreatePaperExchange(exchangeName string) (common.Exchange, error) {
+	userEntity := &entity.User{Id: service.ctx.GetUser().GetId()}
+	userCryptoExchange, err := service.userDAO.GetExchange(userEntity, exchangeName)
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[ExchangeService.CreatePaperExchange] Error: %s", err.Error())
+		return nil, err
+	}
+	paper, err := service.pluginService.CreateExchange(common.PAPER_EXCHANGE)
+	if err != nil {
+		return nil, err
+	}
+	exchange := paper(service.ctx, &entity.UserCryptoExchange{
+		UserID: userEntity.Id,
+		Name:   common.PAPER_EXCHANGE,
+		Key:    userCryptoExchange.GetName(),
+		Extra:  userCryptoExchange.GetExtra()})
+	if exchange == nil {
+		return nil, errors.New(fmt.Sprintf("Unable to create %s paper exchange", exchangeName))
+	}
+	return exchange, nil
+}
+
 func (service *DefaultExchangeService) GetDisplayNames() ([]string, error) {
 	names, err := service.pluginService.GetPlugins(common.EXCHANGE_PLUGIN_TYPE)
 	if err != nil {
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+// Imported deposits of the new currency received up to this many days after a
+// fork or airdrop are taken to be its distribution. Exchanges credited some
+// forks months after the event.
+const FORK_EVENT_MATCH_DAYS = 180
+
+type DefaultForkEventService struct {
+	ctx               common.Context
+	forkEventDAO      dao.ForkEventDAO
+	transactionDAO    dao.TransactionDAO
+	mapper            mapper.ForkEventMapper
+	transactionMapper mapper.TransactionMapper
+	ForkEventService
+}
+
+func NewForkEventService(ctx common.Context, forkEventDAO dao.ForkEventDAO, transactionDAO dao.TransactionDAO,
+	forkEventMapper mapper.ForkEventMapper, transactionMapper mapper.TransactionMapper) ForkEventService {
+	return &DefaultForkEventService{
+		ctx:               ctx,
+		forkEventDAO:      forkEventDAO,
+		transactionDAO:    transactionDAO,
+		mapper:            forkEventMapper,
+		transactionMapper: transactionMapper}
+}
+
+func (service *DefaultForkEventService) GetMapper() mapper.ForkEventMapper {
+	return service.mapper
+}
+
+func (service *DefaultForkEventService) GetForkEvents() ([]common.ForkEvent, error) {
+	entities, err := service.forkEventDAO.Find()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]common.ForkEvent, len(entities))
+	for i, entity := range entities {
+		events[i] = service.mapper.MapForkEventEntityToDto(&entity)
+	}
+	return events, nil
+}
+
+// CreateForkEvent records the event and credits the new currency to each
+// exchange and wallet holding the forked currency on the event date. Deposits
+// of the new currency already imported from a network are recategorized as the
+// event so they are no longer valued at the market price of the day; a deposit
+// is created for the networks that have none. The ratio defaults to one new
+// coin per coin held and the cost basis per new coin to zero. Recording the
+// same event again returns its deposits without changing anything.
+func (service *DefaultForkEventService) CreateForkEvent(event common.ForkEvent) ([]common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[ForkEventService.CreateForkEvent] %s's %s of %s from %s on %s",
+		service.ctx.GetUser().GetUsername(), event.GetCategory(), event.GetNewCurrency(), event.GetCurrency(), event.GetDate())
+	category := event.GetCategory()
+	if category == "" {
+		category = common.TX_CATEGORY_FORK
+	}
+	if category != common.TX_CATEGORY_FORK && category != common.TX_CATEGORY_AIRDROP {
+		return nil, errors.New(fmt.Sprintf("Invalid fork event category: %s", category))
+	}
+	if event.GetCurrency() == "" || event.GetNewCurrency() == "" || event.GetCurrency() == event.GetNewCurrency() {
+		return nil, errors.New("Fork event currency and new currency required")
+	}
+	if event.GetDate().IsZero() {
+		return nil, errors.New("Fork event date required")
+	}
+	ratio := event.GetRatio()
+	if ratio.IsZero() {
+		ratio = decimal.NewFromFloat(1)
+	}
+	if ratio.IsNegative() || event.GetCostBasis().IsNegative() {
+		return nil, errors.New(fmt.Sprintf("Invalid fork event ratio %s or cost basis %s", ratio, event.GetCostBasis()))
+	}
+	forkEvent := &dto.ForkEventDTO{
+		UserId:      service.ctx.GetUser().GetId(),
+		Category:    category,
+		Currency:    event.GetCurrency(),
+		NewCurrency: event.GetNewCurrency(),
+		Date:        event.GetDate(),
+		Ratio:       ratio,
+		CostBasis:   event.GetCostBasis()}
+	events, err := service.GetForkEvents()
+	if err != nil {
+		return nil, err
+	}
+	var recorded common.ForkEvent
+	for _, e := range events {
+		if e.GetCategory() == category && e.GetCurrency() == forkEvent.Currency &&
+			e.GetNewCurrency() == forkEvent.NewCurrency && e.GetDate().Equal(forkEvent.Date) {
+			recorded = e
+		}
+	}
+	if recorded != nil {
+		if !recorded.GetRatio().Equal(ratio) || !recorded.GetCostBasis().Equal(forkEvent.CostBasis) {
+			return nil, errors.New(fmt.Sprintf("%s of %s on %s already recorded with ratio %s and cost basis %s, delete it to change them",
+				category, forkEvent.NewCurrency, forkEvent.Date, recorded.GetRatio(), recorded.GetCostBasis()))
+		}
+	} else {
+		entity := service.mapper.MapForkEventDtoToEntity(forkEvent)
+		if err := service.forkEventDAO.Create(entity); err != nil {
+			service.ctx.GetLogger().Errorf("[ForkEventService.CreateForkEvent] Error saving fork event: %s", err.Error())
+			return nil, err
+		}
+		recorded = service.mapper.MapForkEventEntityToDto(entity)
+	}
+	history, err := service.getHistory()
+	if err != nil {
+		return nil, err
+	}
+	var deposits []common.Transaction
+	for _, deposit := range forkDeposits(recorded, history) {
+		if deposit.GetCategory() != category {
+			service.ctx.GetLogger().Debugf("[ForkEventService.CreateForkEvent] Recategorizing deposit %s as %s", deposit.GetId(), category)
+			if err := service.updateCategory(deposit, category); err != nil {
+				return nil, err
+			}
+			recategorized := cloneTransaction(deposit)
+			recategorized.Category = category
+			deposit = recategorized
+		}
+		deposits = append(deposits, deposit)
+	}
+	for _, deposit := range newForkTransactions(recorded, service.ctx.GetUser().GetLocalCurrency(), history) {
+		if err := service.transactionDAO.Create(service.transactionMapper.MapTransactionDtoToEntity(deposit)); err != nil {
+			service.ctx.GetLogger().Errorf("[ForkEventService.CreateForkEvent] Error saving %s deposit: %s", category, err.Error())
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+	sort.SliceStable(deposits, func(i, j int) bool {
+		return deposits[i].GetDate().Before(deposits[j].GetDate())
+	})
+	return deposits, nil
+}
+
+// DeleteForkEvent removes the event along with the deposits it created. Imported
+// deposits it recategorized are returned to plain deposits.
+func (service *DefaultForkEventService) DeleteForkEvent(id uint) error {
+	service.ctx.GetLogger().Debugf("[ForkEventService.DeleteForkEvent] id: %d", id)
+	events, err := service.GetForkEvents()
+	if err != nil {
+		return err
+	}
+	var event common.ForkEvent
+	for _, e := range events {
+		if e.GetId() == id {
+			event = e
+		}
+	}
+	if event == nil {
+		return errors.New(fmt.Sprintf("Fork event not found: %d", id))
+	}
+	history, err := service.getHistory()
+	if err != nil {
+		return err
+	}
+	for _, deposit := range forkDeposits(event, history) {
+		if deposit.GetCategory() != event.GetCategory() {
+			continue
+		}
+		if strings.HasPrefix(deposit.GetId(), forkTransactionPrefix(event)) {
+			deleted := cloneTransaction(deposit)
+			deleted.Deleted = true
+			err = service.transactionDAO.Save(service.transactionMapper.MapTransactionDtoToEntity(deleted))
+		} else {
+			err = service.updateCategory(deposit, common.TX_CATEGORY_DEPOSIT)
+		}
+		if err != nil {
+			service.ctx.GetLogger().Errorf("[ForkEventService.DeleteForkEvent] Error reverting deposit %s: %s", deposit.GetId(), err.Error())
+			return err
+		}
+	}
+	return service.forkEventDAO.Delete(service.mapper.MapForkEventDtoToEntity(event))
+}
+
+func (service *DefaultForkEventService) getHistory() ([]common.Transaction, error) {
+	entities, err := service.transactionDAO.Find("asc")
+	if err != nil {
+		return nil, err
+	}
+	var history []common.Transaction
+	for _, entity := range entities {
+		history = append(history, service.transactionMapper.MapTransactionEntityToDto(&entity))
+	}
+	return history, nil
+}
+
+func (service *DefaultForkEventService) updateCategory(tx common.Transaction, category string) error {
+	return service.transactionDAO.Update(service.transactionMapper.MapTransactionDtoToEntity(tx), "category", category)
+}
+
+// forkDeposits returns the deposits crediting the event's new currency: those
+// already categorized as the event and the imported deposits of the new
+// currency, received within FORK_EVENT_MATCH_DAYS of the event, that are not
+// categorized as anything but a deposit or matched to a withdrawal.
+func forkDeposits(event common.ForkEvent, history []common.Transaction) []common.Transaction {
+	end := event.GetDate().AddDate(0, 0, FORK_EVENT_MATCH_DAYS)
+	var deposits []common.Transaction
+	for _, tx := range history {
+		if tx.IsDeleted() || tx.GetType() != common.DEPOSIT_ORDER_TYPE ||
+			tx.GetCurrencyPair().Base != event.GetNewCurrency() || tx.GetTransferId() != "" {
+			continue
+		}
+		if tx.GetDate().Before(event.GetDate()) || !tx.GetDate().Before(end) {
+			continue
+		}
+		if tx.GetCategory() == event.GetCategory() || IsTransferCategory(tx.GetCategory(), common.TX_CATEGORY_DEPOSIT) {
+			deposits = append(deposits, tx)
+		}
+	}
+	return deposits
+}
+
+func forkTransactionPrefix(event common.ForkEvent) string {
+	return fmt.Sprintf("%s-%d-", event.GetCategory(), event.GetId())
+}
+
+// newForkTransactions creates the deposits of the new currency to the networks
+// holding the forked currency at the time of the event, valued at the event's
+// cost basis. Networks already credited by one of the event's deposits are
+// skipped.
+func newForkTransactions(event common.ForkEvent, localCurrency string, history []common.Transaction) []common.Transaction {
+	holdings, displayNames := holdingsAt(history, event.GetCurrency(), event.GetDate())
+	for _, deposit := range forkDeposits(event, history) {
+		delete(holdings, deposit.GetNetwork())
+	}
+	var networks []string
+	for network := range holdings {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	currencyPair := &common.CurrencyPair{
+		Base:          event.GetNewCurrency(),
+		Quote:         localCurrency,
+		LocalCurrency: localCurrency}
+	var deposits []common.Transaction
+	for _, network := range networks {
+		if !holdings[network].IsPositive() {
+			continue
+		}
+		quantity := holdings[network].Mul(event.GetRatio())
+		fiatTotal := quantity.Mul(event.GetCostBasis())
+		deposits = append(deposits, &dto.TransactionDTO{
+			Id:                     forkTransactionPrefix(event) + network,
+			Date:                   event.GetDate(),
+			MarketPair:             currencyPair,
+			CurrencyPair:           currencyPair,
+			Type:                   common.DEPOSIT_ORDER_TYPE,
+			Category:               event.GetCategory(),
+			Network:                network,
+			NetworkDisplayName:     displayNames[network],
+			Quantity:               quantity.String(),
+			QuantityCurrency:       event.GetNewCurrency(),
+			FiatQuantity:           fiatTotal.StringFixed(2),
+			FiatQuantityCurrency:   localCurrency,
+			Price:                  event.GetCostBasis().String(),
+			PriceCurrency:          localCurrency,
+			FiatPrice:              event.GetCostBasis().String(),
+			FiatPriceCurrency:      localCurrency,
+			QuoteFiatPrice:         "1",
+			QuoteFiatPriceCurrency: localCurrency,
+			Fee:                    "0",
+			FeeCurrency:            localCurrency,
+			FiatFee:                "0",
+			FiatFeeCurrency:        localCurrency,
+			Total:                  fiatTotal.StringFixed(2),
+			TotalCurrency:          localCurrency,
+			FiatTotal:              fiatTotal.StringFixed(2),
+			FiatTotalCurrency:      localCurrency})
+	}
+	return deposits
+}
+
+// holdingsAt replays the history up to and including date and returns the
+// quantity of currency held on each network, along with the networks'
+// display names.
+func holdingsAt(history []common.Transaction, currency string, date time.Time) (map[string]decimal.Decimal, map[string]string) {
+	holdings := make(map[string]decimal.Decimal)
+	displayNames := make(map[string]string)
+	adjust := func(tx common.Transaction, txCurrency string, amount decimal.Decimal) {
+		if txCurrency != currency {
+			return
+		}
+		holdings[tx.GetNetwork()] = holdings[tx.GetNetwork()].Add(amount)
+		displayNames[tx.GetNetwork()] = tx.GetNetworkDisplayName()
+	}
+	for _, tx := range history {
+		if tx.IsDeleted() || tx.GetDate().After(date) {
+			continue
+		}
+		base := tx.GetCurrencyPair().Base
+		quote := tx.GetCurrencyPair().Quote
+		quantity, _ := decimal.NewFromString(tx.GetQuantity())
+		total, _ := decimal.NewFromString(tx.GetTotal())
+		fee, _ := decimal.NewFromString(tx.GetFee())
+		switch tx.GetType() {
+		case common.BUY_ORDER_TYPE:
+			adjust(tx, base, quantity)
+			adjust(tx, quote, total.Neg())
+		case common.SELL_ORDER_TYPE:
+			adjust(tx, base, quantity.Neg())
+			adjust(tx, quote, total)
+		case common.DEPOSIT_ORDER_TYPE:
+			adjust(tx, base, quantity)
+			continue
+		case common.WITHDRAWAL_ORDER_TYPE:
+			// The quantity withdrawn includes a fee in the same currency
+			adjust(tx, base, quantity.Neg())
+			if tx.GetFeeCurrency() != base {
+				adjust(tx, tx.GetFeeCurrency(), fee.Neg())
+			}
+			continue
+		default:
+			continue
+		}
+		adjust(tx, tx.GetFeeCurrency(), fee.Neg())
+	}
+	return holdings, displayNames
+}
@@ -0,0 +1,150 @@
+// +build integration
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkEventService_CreateForkEvent(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	transactionDAO := dao.NewTransactionDAO(ctx)
+	transactionMapper := mapper.NewTransactionMapper(ctx)
+	forkEventService := NewForkEventService(ctx, dao.NewForkEventDAO(ctx), transactionDAO,
+		mapper.NewForkEventMapper(ctx), transactionMapper)
+
+	forked := time.Date(2017, 8, 01, 13, 16, 14, 0, time.UTC)
+	btcusd := &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"}
+	bchusd := &common.CurrencyPair{Base: "BCH", Quote: "USD", LocalCurrency: "USD"}
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "buy-1",
+			Date:         forked.AddDate(0, -6, 0),
+			MarketPair:   btcusd,
+			CurrencyPair: btcusd,
+			Type:         common.BUY_ORDER_TYPE,
+			Network:      "gdax",
+			Quantity:     "2",
+			Total:        "2000",
+			Fee:          "10",
+			FeeCurrency:  "USD"},
+		&dto.TransactionDTO{
+			Id:           "withdrawal-1",
+			Date:         forked.AddDate(0, -1, 0),
+			MarketPair:   btcusd,
+			CurrencyPair: btcusd,
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Network:      "gdax",
+			Quantity:     "1",
+			Fee:          "0.001",
+			FeeCurrency:  "BTC"},
+		&dto.TransactionDTO{
+			Id:           "deposit-1",
+			Date:         forked.AddDate(0, -1, 0).Add(time.Hour),
+			MarketPair:   btcusd,
+			CurrencyPair: btcusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_TRANSFER,
+			Network:      "BTC wallet",
+			Quantity:     "0.999"},
+		&dto.TransactionDTO{
+			Id:           "buy-2",
+			Date:         forked.Add(time.Hour),
+			MarketPair:   btcusd,
+			CurrencyPair: btcusd,
+			Type:         common.BUY_ORDER_TYPE,
+			Network:      "gdax",
+			Quantity:     "5",
+			Total:        "15000"},
+		&dto.TransactionDTO{
+			Id:           "deposit-2",
+			Date:         forked.AddDate(0, 0, 30),
+			MarketPair:   bchusd,
+			CurrencyPair: bchusd,
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_DEPOSIT,
+			Network:      "gdax",
+			Quantity:     "1",
+			FiatPrice:    "400",
+			FiatTotal:    "400"}}
+	for _, tx := range transactions {
+		assert.Nil(t, transactionDAO.Create(transactionMapper.MapTransactionDtoToEntity(tx)))
+	}
+
+	_, err := forkEventService.CreateForkEvent(&dto.ForkEventDTO{
+		Category:    common.TX_CATEGORY_GIFT,
+		Currency:    "BTC",
+		NewCurrency: "BCH",
+		Date:        forked})
+	assert.NotNil(t, err)
+
+	forkEvent := &dto.ForkEventDTO{
+		Currency:    "BTC",
+		NewCurrency: "BCH",
+		Date:        forked,
+		CostBasis:   decimal.NewFromFloat(10)}
+	deposits, err := forkEventService.CreateForkEvent(forkEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(deposits))
+	assert.Equal(t, "fork-1-BTC wallet", deposits[0].GetId())
+	assert.Equal(t, "0.999", deposits[0].GetQuantity())
+	assert.Equal(t, "9.99", deposits[0].GetFiatTotal())
+	// The BCH gdax credited after the fork is recategorized rather than credited twice
+	assert.Equal(t, "deposit-2", deposits[1].GetId())
+	assert.Equal(t, common.TX_CATEGORY_FORK, deposits[1].GetCategory())
+
+	deposit, err := transactionDAO.Get("deposit-2")
+	assert.Nil(t, err)
+	assert.Equal(t, common.TX_CATEGORY_FORK, deposit.GetCategory())
+	deposit, err = transactionDAO.Get("fork-1-BTC wallet")
+	assert.Nil(t, err)
+	assert.Equal(t, common.DEPOSIT_ORDER_TYPE, deposit.GetType())
+	assert.Equal(t, common.TX_CATEGORY_FORK, deposit.GetCategory())
+	assert.Equal(t, forked, deposit.GetDate().UTC())
+	_, err = transactionDAO.Get("fork-1-gdax")
+	assert.NotNil(t, err)
+
+	events, err := forkEventService.GetForkEvents()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, common.TX_CATEGORY_FORK, events[0].GetCategory())
+	assert.Equal(t, "1", events[0].GetRatio().String())
+	assert.Equal(t, "10", events[0].GetCostBasis().String())
+
+	// Recording the event again changes nothing
+	deposits, err = forkEventService.CreateForkEvent(forkEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(deposits))
+	assert.Equal(t, "fork-1-BTC wallet", deposits[0].GetId())
+	assert.Equal(t, "deposit-2", deposits[1].GetId())
+	events, err = forkEventService.GetForkEvents()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+
+	forkEvent.CostBasis = decimal.NewFromFloat(20)
+	_, err = forkEventService.CreateForkEvent(forkEvent)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, forkEventService.DeleteForkEvent(events[0].GetId()))
+	deposit, err = transactionDAO.Get("deposit-2")
+	assert.Nil(t, err)
+	assert.Equal(t, common.TX_CATEGORY_DEPOSIT, deposit.GetCategory())
+	deposit, err = transactionDAO.Get("fork-1-BTC wallet")
+	assert.Nil(t, err)
+	assert.True(t, deposit.IsDeleted())
+	events, err = forkEventService.GetForkEvents()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+	assert.Equal(t, "Fork event not found: 1", forkEventService.DeleteForkEvent(1).Error())
+
+	CleanupIntegrationTest()
+}
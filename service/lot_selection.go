@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+)
+
+type DefaultLotSelectionService struct {
+	ctx             common.Context
+	lotSelectionDAO dao.LotSelectionDAO
+	transactionDAO  dao.TransactionDAO
+	mapper          mapper.LotSelectionMapper
+	LotSelectionService
+}
+
+func NewLotSelectionService(ctx common.Context, lotSelectionDAO dao.LotSelectionDAO, transactionDAO dao.TransactionDAO,
+	lotSelectionMapper mapper.LotSelectionMapper) LotSelectionService {
+	return &DefaultLotSelectionService{
+		ctx:             ctx,
+		lotSelectionDAO: lotSelectionDAO,
+		transactionDAO:  transactionDAO,
+		mapper:          lotSelectionMapper}
+}
+
+func (service *DefaultLotSelectionService) GetMapper() mapper.LotSelectionMapper {
+	return service.mapper
+}
+
+func (service *DefaultLotSelectionService) GetSelections() ([]common.LotSelection, error) {
+	entities, err := service.lotSelectionDAO.Find()
+	if err != nil {
+		return nil, err
+	}
+	selections := make([]common.LotSelection, len(entities))
+	for i, entity := range entities {
+		selections[i] = service.mapper.MapLotSelectionEntityToDto(&entity)
+	}
+	return selections, nil
+}
+
+func (service *DefaultLotSelectionService) GetSelectionsFor(saleTransactionId string) ([]common.LotSelection, error) {
+	entities, err := service.lotSelectionDAO.FindBySale(saleTransactionId)
+	if err != nil {
+		return nil, err
+	}
+	selections := make([]common.LotSelection, len(entities))
+	for i, entity := range entities {
+		selections[i] = service.mapper.MapLotSelectionEntityToDto(&entity)
+	}
+	return selections, nil
+}
+
+func (service *DefaultLotSelectionService) SetSelections(saleTransactionId string, selections []common.LotSelection) error {
+	service.ctx.GetLogger().Debugf("[LotSelectionService.SetSelections] Assigning %d lots to %s's sale transaction %s",
+		len(selections), service.ctx.GetUser().GetUsername(), saleTransactionId)
+	if _, err := service.transactionDAO.Get(saleTransactionId); err != nil {
+		return errors.New(fmt.Sprintf("Sale transaction not found: %s", saleTransactionId))
+	}
+	for _, selection := range selections {
+		if selection.GetCurrency() == "" {
+			return errors.New("Lot selection currency required")
+		}
+		if !selection.GetQuantity().IsPositive() {
+			return errors.New(fmt.Sprintf("Invalid lot selection quantity: %s", selection.GetQuantity()))
+		}
+		if _, err := service.transactionDAO.Get(selection.GetBuyTransactionId()); err != nil {
+			return errors.New(fmt.Sprintf("Buy transaction not found: %s", selection.GetBuyTransactionId()))
+		}
+	}
+	if err := service.lotSelectionDAO.DeleteBySale(saleTransactionId); err != nil {
+		return err
+	}
+	for _, selection := range selections {
+		entity := service.mapper.MapLotSelectionDtoToEntity(&dto.LotSelectionDTO{
+			UserId:            service.ctx.GetUser().GetId(),
+			SaleTransactionId: saleTransactionId,
+			BuyTransactionId:  selection.GetBuyTransactionId(),
+			Currency:          selection.GetCurrency(),
+			Quantity:          selection.GetQuantity()})
+		if err := service.lotSelectionDAO.Create(entity); err != nil {
+			service.ctx.GetLogger().Errorf("[LotSelectionService.SetSelections] Error saving lot selection: %s", err.Error())
+			return err
+		}
+	}
+	return nil
+}
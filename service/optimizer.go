@@ -0,0 +1,556 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OPTIMIZE_GRID                = "grid"
+	OPTIMIZE_RANDOM              = "random"
+	OPTIMIZE_RETURN              = "return"
+	OPTIMIZE_SHARPE              = "sharpe"
+	OPTIMIZE_WIN_RATE            = "win_rate"
+	OPTIMIZE_MAX_DRAWDOWN        = "max_drawdown"
+	OPTIMIZER_DEFAULT_FOLDS      = 3
+	OPTIMIZER_DEFAULT_ITERATIONS = 100
+	OPTIMIZER_DEFAULT_TOP        = 10
+	OPTIMIZER_MAX_CANDIDATES     = 1000
+)
+
+type DefaultOptimizerService struct {
+	ctx               common.Context
+	backtestService   BacktestService
+	pluginService     PluginService
+	chartIndicatorDAO dao.ChartIndicatorDAO
+	chartStrategyDAO  dao.ChartStrategyDAO
+	chartMapper       mapper.ChartMapper
+	OptimizerService
+}
+
+// optimizerWindow is a range of candles backtested as one walk-forward window.
+// Each window is preceded by the warmup candles that seed the indicators.
+type optimizerWindow struct {
+	start int
+	end   int
+}
+
+func NewOptimizerService(ctx common.Context, backtestService BacktestService, pluginService PluginService,
+	chartIndicatorDAO dao.ChartIndicatorDAO, chartStrategyDAO dao.ChartStrategyDAO,
+	chartMapper mapper.ChartMapper) OptimizerService {
+	return &DefaultOptimizerService{
+		ctx:               ctx,
+		backtestService:   backtestService,
+		pluginService:     pluginService,
+		chartIndicatorDAO: chartIndicatorDAO,
+		chartStrategyDAO:  chartStrategyDAO,
+		chartMapper:       chartMapper}
+}
+
+// Optimize searches the declared parameter ranges of one of the chart's
+// strategies and its required indicators. The candles after the warmup are split
+// into Folds + 1 consecutive windows; fold n trains on window n and tests on
+// window n + 1, so every parameter set is backtested once per window. Each
+// fold's parameters are chosen on its in-sample window alone and the result
+// reports how they performed on the window after it. Parameter sets are ranked
+// on the most recent in-sample window, so the best set is the last fold's
+// winner and no out-of-sample window takes part in choosing it.
+func (service *DefaultOptimizerService) Optimize(chartId uint, options *dto.OptimizationOptionsDTO) (*dto.OptimizationResultDTO, error) {
+	method, objective, folds, iterations, top, err := service.defaults(options)
+	if err != nil {
+		return nil, err
+	}
+	chart, err := service.backtestService.GetChart(chartId)
+	if err != nil {
+		return nil, err
+	}
+	var chartStrategy common.ChartStrategy
+	for _, strategy := range chart.GetStrategies() {
+		if strategy.GetName() == options.Strategy {
+			chartStrategy = strategy
+		}
+	}
+	if chartStrategy == nil {
+		return nil, errors.New(fmt.Sprintf("Chart %d has no strategy: %s", chartId, options.Strategy))
+	}
+	backtestOptions := options.BacktestOptionsDTO
+	if backtestOptions.Warmup <= 0 {
+		backtestOptions.Warmup = BACKTEST_DEFAULT_WARMUP
+	}
+	warmup := backtestOptions.Warmup
+	candles, err := service.backtestService.LoadCandlesticks(chart, &backtestOptions)
+	if err != nil {
+		return nil, err
+	}
+	windowSize := (len(candles) - warmup) / (folds + 1)
+	if windowSize < 2 {
+		return nil, errors.New(fmt.Sprintf("Optimizing %d walk-forward folds requires at least %d candlesticks, received %d",
+			folds, warmup+(folds+1)*2, len(candles)))
+	}
+	windows := make([]optimizerWindow, folds+1)
+	for i := range windows {
+		windows[i] = optimizerWindow{
+			start: warmup + i*windowSize,
+			end:   warmup + (i+1)*windowSize}
+	}
+	windows[folds].end = len(candles)
+
+	base, err := service.baseParameters(chart, chartStrategy, candles[:warmup])
+	if err != nil {
+		return nil, err
+	}
+	values, err := service.parameterValues(base, options.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	var sets []dto.OptimizationParameterSetDTO
+	if method == OPTIMIZE_GRID {
+		sets, err = service.gridSearch(base, options.Parameters, values)
+	} else {
+		seed := options.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		sets, err = service.randomSearch(base, options.Parameters, values, iterations, seed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	service.ctx.GetLogger().Debugf("[OptimizerService.Optimize] Evaluating %d %s parameter sets across %d folds of %d candlesticks",
+		len(sets), chartStrategy.GetName(), folds, windowSize)
+
+	result := &dto.OptimizationResultDTO{
+		ChartId:     chart.GetId(),
+		Strategy:    chartStrategy.GetName(),
+		Method:      method,
+		Objective:   objective,
+		Folds:       folds,
+		Candidates:  len(sets),
+		WalkForward: make([]dto.OptimizationFoldDTO, 0, folds)}
+	candidates := make([]dto.OptimizationCandidateDTO, 0, len(sets))
+	scores := make([][]decimal.Decimal, 0, len(sets))
+	for _, set := range sets {
+		candidateChart := service.applyParameters(chart, set)
+		windowScores := make([]decimal.Decimal, len(windows))
+		trades := 0
+		for i, window := range windows {
+			backtest, err := service.backtestService.Run(candidateChart, candles[window.start-warmup:window.end], &backtestOptions)
+			if err != nil {
+				service.ctx.GetLogger().Debugf("[OptimizerService.Optimize] Parameter set %+v failed: %s", set, err.Error())
+				windowScores = nil
+				break
+			}
+			windowScores[i] = service.score(backtest, objective)
+			if i == folds {
+				trades = len(backtest.Trades)
+			}
+		}
+		if windowScores == nil {
+			result.Failed++
+			continue
+		}
+		candidates = append(candidates, dto.OptimizationCandidateDTO{
+			Parameters: set,
+			TrainScore: windowScores[folds-1],
+			TestScore:  windowScores[folds],
+			Trades:     trades})
+		scores = append(scores, windowScores)
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New(fmt.Sprintf("All %d parameter sets failed to backtest", len(sets)))
+	}
+
+	best := 0
+	outOfSample := make([]decimal.Decimal, 0, folds)
+	for fold := 0; fold < folds; fold++ {
+		best = 0
+		for i := range candidates {
+			if scores[i][fold].GreaterThan(scores[best][fold]) {
+				best = i
+			}
+		}
+		outOfSample = append(outOfSample, scores[best][fold+1])
+		result.WalkForward = append(result.WalkForward, dto.OptimizationFoldDTO{
+			Fold:       fold + 1,
+			TrainStart: candles[windows[fold].start].Date,
+			TrainEnd:   candles[windows[fold].end-1].Date,
+			TestStart:  candles[windows[fold+1].start].Date,
+			TestEnd:    candles[windows[fold+1].end-1].Date,
+			Parameters: candidates[best].Parameters,
+			TrainScore: scores[best][fold],
+			TestScore:  scores[best][fold+1]})
+	}
+
+	result.OutOfSampleScore = service.mean(outOfSample)
+	result.Best = candidates[best]
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].TrainScore.GreaterThan(candidates[j].TrainScore)
+	})
+	if len(candidates) > top {
+		candidates = candidates[:top]
+	}
+	result.Ranking = candidates
+
+	if options.Save {
+		if _, err := service.SaveParameters(chartId, &result.Best.Parameters); err != nil {
+			return nil, err
+		}
+		result.Saved = true
+	}
+	return result, nil
+}
+
+// SaveParameters writes a parameter set onto the chart's strategy and indicators
+// so the trading bot and backtests use it.
+func (service *DefaultOptimizerService) SaveParameters(chartId uint, parameters *dto.OptimizationParameterSetDTO) (common.Chart, error) {
+	chart, err := service.backtestService.GetChart(chartId)
+	if err != nil {
+		return nil, err
+	}
+	var chartStrategy common.ChartStrategy
+	for _, strategy := range chart.GetStrategies() {
+		if strategy.GetName() == parameters.Strategy {
+			chartStrategy = strategy
+		}
+	}
+	if chartStrategy == nil {
+		return nil, errors.New(fmt.Sprintf("Chart %d has no strategy: %s", chartId, parameters.Strategy))
+	}
+	chartIndicators := make(map[string]common.ChartIndicator, len(chart.GetIndicators()))
+	for _, indicator := range chart.GetIndicators() {
+		chartIndicators[indicator.GetName()] = indicator
+	}
+	for name := range parameters.Indicators {
+		if _, ok := chartIndicators[name]; !ok {
+			return nil, errors.New(fmt.Sprintf("Chart %d has no indicator: %s", chartId, name))
+		}
+	}
+	if err := service.validateParameters(chart, parameters); err != nil {
+		return nil, err
+	}
+	service.ctx.GetLogger().Debugf("[OptimizerService.SaveParameters] Saving chart %d parameters: %+v", chartId, parameters)
+	strategy := service.chartMapper.MapStrategyDtoToEntity(&dto.ChartStrategyDTO{
+		Id:         chartStrategy.GetId(),
+		ChartId:    chartStrategy.GetChartId(),
+		Name:       chartStrategy.GetName(),
+		Parameters: strings.Join(parameters.Parameters, ",")})
+	if err := service.chartStrategyDAO.Save(&strategy); err != nil {
+		return nil, err
+	}
+	for name, values := range parameters.Indicators {
+		chartIndicator := chartIndicators[name]
+		indicator := service.chartMapper.MapIndicatorDtoToEntity(&dto.ChartIndicatorDTO{
+			Id:         chartIndicator.GetId(),
+			ChartId:    chartIndicator.GetChartId(),
+			Name:       chartIndicator.GetName(),
+			Parameters: strings.Join(values, ",")})
+		if err := service.chartIndicatorDAO.Save(&indicator); err != nil {
+			return nil, err
+		}
+	}
+	return service.backtestService.GetChart(chartId)
+}
+
+// validateParameters constructs the strategy with the parameters to be saved so
+// a set the plugin rejects is never written to the chart the trading bot runs.
+// Only the names of the chart's indicators are needed to construct it.
+func (service *DefaultOptimizerService) validateParameters(chart common.Chart, parameters *dto.OptimizationParameterSetDTO) error {
+	constructor, err := service.pluginService.CreateStrategy(parameters.Strategy)
+	if err != nil {
+		return err
+	}
+	indicators := make(map[string]common.FinancialIndicator, len(chart.GetIndicators()))
+	for _, indicator := range chart.GetIndicators() {
+		indicators[indicator.GetName()] = nil
+	}
+	_, err = constructor(&common.TradingStrategyParams{
+		CurrencyPair: &common.CurrencyPair{
+			Base:          chart.GetBase(),
+			Quote:         chart.GetQuote(),
+			LocalCurrency: service.ctx.GetUser().GetLocalCurrency()},
+		Indicators: indicators,
+		LastTrade:  &dto.TradeDTO{},
+		Config:     parameters.Parameters})
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid %s parameters %s: %s", parameters.Strategy,
+			strings.Join(parameters.Parameters, ","), err.Error()))
+	}
+	return nil
+}
+
+func (service *DefaultOptimizerService) defaults(options *dto.OptimizationOptionsDTO) (string, string, int, int, int, error) {
+	method := options.Method
+	if method == "" {
+		method = OPTIMIZE_GRID
+	}
+	if method != OPTIMIZE_GRID && method != OPTIMIZE_RANDOM {
+		return "", "", 0, 0, 0, errors.New(fmt.Sprintf("Unsupported optimization method: %s", method))
+	}
+	objective := options.Objective
+	if objective == "" {
+		objective = OPTIMIZE_SHARPE
+	}
+	switch objective {
+	case OPTIMIZE_RETURN, OPTIMIZE_SHARPE, OPTIMIZE_WIN_RATE, OPTIMIZE_MAX_DRAWDOWN:
+	default:
+		return "", "", 0, 0, 0, errors.New(fmt.Sprintf("Unsupported optimization objective: %s", objective))
+	}
+	folds := options.Folds
+	if folds <= 0 {
+		folds = OPTIMIZER_DEFAULT_FOLDS
+	}
+	iterations := options.Iterations
+	if iterations <= 0 {
+		iterations = OPTIMIZER_DEFAULT_ITERATIONS
+	}
+	if iterations > OPTIMIZER_MAX_CANDIDATES {
+		iterations = OPTIMIZER_MAX_CANDIDATES
+	}
+	top := options.Top
+	if top <= 0 {
+		top = OPTIMIZER_DEFAULT_TOP
+	}
+	return method, objective, folds, iterations, top, nil
+}
+
+// baseParameters returns the chart's current parameters for the strategy and its
+// required indicators, substituting the plugins' defaults where none are saved.
+func (service *DefaultOptimizerService) baseParameters(chart common.Chart, chartStrategy common.ChartStrategy,
+	candles []common.Candlestick) (dto.OptimizationParameterSetDTO, error) {
+	set := dto.OptimizationParameterSetDTO{
+		Strategy:   chartStrategy.GetName(),
+		Indicators: make(map[string][]string)}
+	indicators := make(map[string]common.FinancialIndicator, len(chart.GetIndicators()))
+	indicatorParameters := make(map[string][]string, len(chart.GetIndicators()))
+	for _, chartIndicator := range chart.GetIndicators() {
+		constructor, err := service.pluginService.CreateIndicator(chartIndicator.GetName())
+		if err != nil {
+			return set, err
+		}
+		parameters := parsePluginParameters(chartIndicator.GetParameters())
+		indicator, err := constructor(candles, parameters)
+		if err != nil {
+			return set, err
+		}
+		if parameters == nil {
+			parameters = indicator.GetDefaultParameters()
+		}
+		indicators[indicator.GetName()] = indicator
+		indicatorParameters[chartIndicator.GetName()] = parameters
+	}
+	constructor, err := service.pluginService.CreateStrategy(chartStrategy.GetName())
+	if err != nil {
+		return set, err
+	}
+	set.Parameters = parsePluginParameters(chartStrategy.GetParameters())
+	params := &common.TradingStrategyParams{
+		CurrencyPair: &common.CurrencyPair{
+			Base:          chart.GetBase(),
+			Quote:         chart.GetQuote(),
+			LocalCurrency: service.ctx.GetUser().GetLocalCurrency()},
+		Indicators: indicators,
+		LastTrade:  &dto.TradeDTO{},
+		Config:     set.Parameters}
+	strategy, err := constructor(params)
+	if err != nil && set.Parameters != nil {
+		// Searched from the defaults, as the trading bot runs it
+		service.ctx.GetLogger().Warningf("[OptimizerService.baseParameters] Invalid %s parameters saved on chart %d, using defaults: %s",
+			chartStrategy.GetName(), chart.GetId(), err.Error())
+		set.Parameters, params.Config = nil, nil
+		strategy, err = constructor(params)
+	}
+	if err != nil {
+		return set, err
+	}
+	if set.Parameters == nil {
+		set.Parameters = strategy.GetDefaultParameters()
+	}
+	for _, name := range strategy.GetRequiredIndicators() {
+		parameters, ok := indicatorParameters[name]
+		if !ok {
+			return set, errors.New(fmt.Sprintf("Chart %d is missing indicator %s required by %s",
+				chart.GetId(), name, chartStrategy.GetName()))
+		}
+		set.Indicators[name] = parameters
+	}
+	return set, nil
+}
+
+// parameterValues returns the candidate values of each declared parameter after
+// checking it targets the strategy or one of its required indicators.
+func (service *DefaultOptimizerService) parameterValues(base dto.OptimizationParameterSetDTO,
+	parameters []dto.OptimizationParameterDTO) ([][]string, error) {
+	values := make([][]string, len(parameters))
+	for i, parameter := range parameters {
+		target := service.target(&base, parameter)
+		if target == nil {
+			return nil, errors.New(fmt.Sprintf("%s is not %s or one of its required indicators", parameter.Plugin, base.Strategy))
+		}
+		if parameter.Index < 0 || parameter.Index >= len(target) {
+			return nil, errors.New(fmt.Sprintf("Invalid %s parameter index %d, expected 0 - %d",
+				parameter.Plugin, parameter.Index, len(target)-1))
+		}
+		if len(parameter.Values) > 0 {
+			values[i] = parameter.Values
+			continue
+		}
+		if !parameter.Step.IsPositive() || parameter.Min.GreaterThan(parameter.Max) {
+			return nil, errors.New(fmt.Sprintf("Invalid %s parameter %d range: %s - %s by %s",
+				parameter.Plugin, parameter.Index, parameter.Min, parameter.Max, parameter.Step))
+		}
+		for value := parameter.Min; value.LessThanOrEqual(parameter.Max); value = value.Add(parameter.Step) {
+			if len(values[i]) == OPTIMIZER_MAX_CANDIDATES {
+				return nil, errors.New(fmt.Sprintf("%s parameter %d range exceeds %d values",
+					parameter.Plugin, parameter.Index, OPTIMIZER_MAX_CANDIDATES))
+			}
+			values[i] = append(values[i], value.String())
+		}
+	}
+	return values, nil
+}
+
+// gridSearch returns every combination of the declared parameters' values.
+func (service *DefaultOptimizerService) gridSearch(base dto.OptimizationParameterSetDTO,
+	parameters []dto.OptimizationParameterDTO, values [][]string) ([]dto.OptimizationParameterSetDTO, error) {
+	total := 1
+	for _, parameterValues := range values {
+		total *= len(parameterValues)
+		if total > OPTIMIZER_MAX_CANDIDATES {
+			return nil, errors.New(fmt.Sprintf("Grid search exceeds the maximum of %d parameter sets, narrow the ranges or use random search",
+				OPTIMIZER_MAX_CANDIDATES))
+		}
+	}
+	sets := make([]dto.OptimizationParameterSetDTO, 0, total)
+	indexes := make([]int, len(values))
+	for {
+		sets = append(sets, service.createParameterSet(base, parameters, values, indexes))
+		i := len(indexes) - 1
+		for ; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(values[i]) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i < 0 {
+			return sets, nil
+		}
+	}
+}
+
+// randomSearch samples up to iterations distinct combinations of the declared
+// parameters' values.
+func (service *DefaultOptimizerService) randomSearch(base dto.OptimizationParameterSetDTO,
+	parameters []dto.OptimizationParameterDTO, values [][]string, iterations int, seed int64) ([]dto.OptimizationParameterSetDTO, error) {
+	random := rand.New(rand.NewSource(seed))
+	total := 1
+	for _, parameterValues := range values {
+		total *= len(parameterValues)
+		if total >= iterations {
+			break
+		}
+	}
+	if total < iterations {
+		iterations = total
+	}
+	sets := make([]dto.OptimizationParameterSetDTO, 0, iterations)
+	sampled := make(map[string]bool, iterations)
+	for attempts := 0; len(sets) < iterations && attempts < iterations*10; attempts++ {
+		indexes := make([]int, len(values))
+		for i := range values {
+			indexes[i] = random.Intn(len(values[i]))
+		}
+		key := fmt.Sprint(indexes)
+		if sampled[key] {
+			continue
+		}
+		sampled[key] = true
+		sets = append(sets, service.createParameterSet(base, parameters, values, indexes))
+	}
+	return sets, nil
+}
+
+func (service *DefaultOptimizerService) createParameterSet(base dto.OptimizationParameterSetDTO,
+	parameters []dto.OptimizationParameterDTO, values [][]string, indexes []int) dto.OptimizationParameterSetDTO {
+	set := dto.OptimizationParameterSetDTO{
+		Strategy:   base.Strategy,
+		Parameters: append([]string(nil), base.Parameters...),
+		Indicators: make(map[string][]string, len(base.Indicators))}
+	for name, indicatorParameters := range base.Indicators {
+		set.Indicators[name] = append([]string(nil), indicatorParameters...)
+	}
+	for i, parameter := range parameters {
+		service.target(&set, parameter)[parameter.Index] = values[i][indexes[i]]
+	}
+	return set
+}
+
+// target returns the parameter slice of the set the declared parameter applies to.
+func (service *DefaultOptimizerService) target(set *dto.OptimizationParameterSetDTO, parameter dto.OptimizationParameterDTO) []string {
+	if parameter.Plugin == set.Strategy {
+		return set.Parameters
+	}
+	return set.Indicators[parameter.Plugin]
+}
+
+// applyParameters returns a copy of the chart configured with the parameter set,
+// keeping only the strategy being optimized.
+func (service *DefaultOptimizerService) applyParameters(chart common.Chart, set dto.OptimizationParameterSetDTO) common.Chart {
+	var indicators []common.ChartIndicator
+	for _, indicator := range chart.GetIndicators() {
+		parameters := indicator.GetParameters()
+		if values, ok := set.Indicators[indicator.GetName()]; ok {
+			parameters = strings.Join(values, ",")
+		}
+		indicators = append(indicators, &dto.ChartIndicatorDTO{
+			Id:         indicator.GetId(),
+			ChartId:    indicator.GetChartId(),
+			Name:       indicator.GetName(),
+			Parameters: parameters})
+	}
+	return &dto.ChartDTO{
+		Id:         chart.GetId(),
+		Base:       chart.GetBase(),
+		Quote:      chart.GetQuote(),
+		Exchange:   chart.GetExchange(),
+		Period:     chart.GetPeriod(),
+		AutoTrade:  chart.GetAutoTrade(),
+		Indicators: indicators,
+		Strategies: []common.ChartStrategy{
+			&dto.ChartStrategyDTO{
+				ChartId:    chart.GetId(),
+				Name:       set.Strategy,
+				Parameters: strings.Join(set.Parameters, ",")}}}
+}
+
+func (service *DefaultOptimizerService) score(result *dto.BacktestResultDTO, objective string) decimal.Decimal {
+	switch objective {
+	case OPTIMIZE_RETURN:
+		return result.Return
+	case OPTIMIZE_WIN_RATE:
+		return result.WinRate
+	case OPTIMIZE_MAX_DRAWDOWN:
+		return result.MaxDrawdown.Neg()
+	}
+	return result.Sharpe
+}
+
+func (service *DefaultOptimizerService) mean(values []decimal.Decimal) decimal.Decimal {
+	sum := decimal.NewFromFloat(0)
+	for _, value := range values {
+		sum = sum.Add(value)
+	}
+	return sum.Div(decimal.New(int64(len(values)), 0))
+}
@@ -0,0 +1,263 @@
+// +build integration
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockBacktestService_Optimizer struct {
+	BacktestService
+	chart   common.Chart
+	candles []common.Candlestick
+}
+
+func (mbs *MockBacktestService_Optimizer) GetChart(chartId uint) (common.Chart, error) {
+	return mbs.chart, nil
+}
+
+func (mbs *MockBacktestService_Optimizer) LoadCandlesticks(chart common.Chart, options *dto.BacktestOptionsDTO) ([]common.Candlestick, error) {
+	return mbs.candles, nil
+}
+
+func createOptimizerService(ctx common.Context) OptimizerService {
+	pluginService := &MockPluginService_Backtest{indicator: &MockFinancialIndicator_Backtest{}}
+	backtestService := NewBacktestService(ctx, nil, nil, nil, pluginService,
+		mapper.NewChartMapper(ctx), mapper.NewPriceHistoryMapper())
+	cycle := []float64{100, 90, 95, 120, 110, 85}
+	prices := []float64{100, 100}
+	for i := 0; i < 3; i++ {
+		prices = append(prices, cycle...)
+	}
+	mockBacktestService := &MockBacktestService_Optimizer{
+		BacktestService: backtestService,
+		candles:         createBacktestCandles(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), prices...),
+		chart: &dto.ChartDTO{
+			Id:       1,
+			Base:     "BTC",
+			Quote:    "USD",
+			Exchange: "GDAX",
+			Period:   900,
+			Indicators: []common.ChartIndicator{
+				&dto.ChartIndicatorDTO{Name: "MockIndicator"}},
+			Strategies: []common.ChartStrategy{
+				&dto.ChartStrategyDTO{Name: "MockStrategy"}}}}
+	return NewOptimizerService(ctx, mockBacktestService, pluginService, nil, nil, mapper.NewChartMapper(ctx))
+}
+
+// createOptimizerTestChart returns a chart running the mock strategy with a
+// saved parameter set, kept apart from the shared integration test chart.
+func createOptimizerTestChart(ctx common.Context) *entity.Chart {
+	return &entity.Chart{
+		UserId:   ctx.GetUser().GetId(),
+		Base:     "BTC",
+		Quote:    "USD",
+		Exchange: "GDAX",
+		Period:   900,
+		Indicators: []entity.ChartIndicator{
+			{Name: "MockIndicator", Parameters: "14"}},
+		Strategies: []entity.ChartStrategy{
+			{Name: "MockStrategy", Parameters: "95,115"}}}
+}
+
+func createOptimizationOptions() *dto.OptimizationOptionsDTO {
+	rate := decimal.NewFromFloat(0.01)
+	return &dto.OptimizationOptionsDTO{
+		BacktestOptionsDTO: dto.BacktestOptionsDTO{
+			Balance:  decimal.NewFromFloat(1000),
+			Fee:      &rate,
+			Slippage: &rate,
+			Warmup:   2},
+		Strategy:  "MockStrategy",
+		Objective: OPTIMIZE_RETURN,
+		Folds:     2,
+		Parameters: []dto.OptimizationParameterDTO{
+			{Plugin: "MockStrategy", Index: 0, Values: []string{"80", "95"}},
+			{Plugin: "MockStrategy", Index: 1, Values: []string{"115", "125"}},
+			{Plugin: "MockIndicator", Index: 0, Min: decimal.NewFromFloat(10),
+				Max: decimal.NewFromFloat(20), Step: decimal.NewFromFloat(5)}}}
+}
+
+func TestOptimizerService_GridSearch(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	optimizerService := createOptimizerService(ctx)
+
+	result, err := optimizerService.Optimize(1, createOptimizationOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, OPTIMIZE_GRID, result.Method)
+	assert.Equal(t, 12, result.Candidates)
+	assert.Equal(t, 0, result.Failed)
+	assert.Equal(t, OPTIMIZER_DEFAULT_TOP, len(result.Ranking))
+	assert.False(t, result.Saved)
+
+	// Buying the dip at 90 and selling at 120 is the only profitable set in every window.
+	// It is chosen on the last in-sample window and scored on the window after it.
+	assert.Equal(t, []string{"95", "115"}, result.Best.Parameters.Parameters)
+	assert.Equal(t, "10", result.Best.Parameters.Indicators["MockIndicator"][0])
+	assert.True(t, result.Best.TestScore.IsPositive())
+	assert.True(t, result.Best.TrainScore.IsPositive())
+	assert.Equal(t, 3, result.Best.Trades)
+	assert.Equal(t, result.Best, result.Ranking[0])
+	for i := 1; i < len(result.Ranking); i++ {
+		assert.False(t, result.Ranking[i].TrainScore.GreaterThan(result.Ranking[i-1].TrainScore))
+	}
+
+	assert.Equal(t, 2, len(result.WalkForward))
+	for i, fold := range result.WalkForward {
+		assert.Equal(t, i+1, fold.Fold)
+		assert.Equal(t, []string{"95", "115"}, fold.Parameters.Parameters)
+		assert.True(t, fold.TrainEnd.Before(fold.TestStart))
+		assert.True(t, fold.TestScore.IsPositive())
+	}
+	assert.True(t, result.WalkForward[0].TestStart.Equal(result.WalkForward[1].TrainStart))
+	assert.Equal(t, result.Best.TestScore, result.WalkForward[1].TestScore)
+	assert.Equal(t, result.WalkForward[0].TestScore.Add(result.WalkForward[1].TestScore).Div(decimal.NewFromFloat(2)).String(),
+		result.OutOfSampleScore.String())
+
+	CleanupIntegrationTest()
+}
+
+func TestOptimizerService_RandomSearch(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	optimizerService := createOptimizerService(ctx)
+
+	options := createOptimizationOptions()
+	options.Method = OPTIMIZE_RANDOM
+	options.Iterations = 5
+	options.Seed = 1
+	options.Top = 3
+	result, err := optimizerService.Optimize(1, options)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, result.Candidates)
+	assert.Equal(t, 3, len(result.Ranking))
+	sampled := make(map[string]bool)
+	for _, candidate := range result.Ranking {
+		key := candidate.Parameters.Parameters[0] + candidate.Parameters.Parameters[1] +
+			candidate.Parameters.Indicators["MockIndicator"][0]
+		assert.False(t, sampled[key])
+		sampled[key] = true
+	}
+
+	// Sampling more sets than the ranges allow evaluates each combination once
+	options.Iterations = 50
+	result, err = optimizerService.Optimize(1, options)
+	assert.Nil(t, err)
+	assert.Equal(t, 12, result.Candidates)
+
+	CleanupIntegrationTest()
+}
+
+func TestOptimizerService_InvalidSavedParameters(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	optimizerService := createOptimizerService(ctx).(*DefaultOptimizerService)
+
+	// Saved before parameters were validated, searched from the defaults instead
+	chart := optimizerService.backtestService.(*MockBacktestService_Optimizer).chart
+	chart.GetStrategies()[0].(*dto.ChartStrategyDTO).Parameters = "95"
+	result, err := optimizerService.Optimize(1, createOptimizationOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"95", "115"}, result.Best.Parameters.Parameters)
+
+	CleanupIntegrationTest()
+}
+
+func TestOptimizerService_InvalidOptions(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	optimizerService := createOptimizerService(ctx)
+
+	options := createOptimizationOptions()
+	options.Strategy = "Unknown"
+	_, err := optimizerService.Optimize(1, options)
+	assert.Equal(t, "Chart 1 has no strategy: Unknown", err.Error())
+
+	options = createOptimizationOptions()
+	options.Objective = "profit"
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "Unsupported optimization objective: profit", err.Error())
+
+	options = createOptimizationOptions()
+	options.Parameters[0].Plugin = "RelativeStrengthIndex"
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "RelativeStrengthIndex is not MockStrategy or one of its required indicators", err.Error())
+
+	options = createOptimizationOptions()
+	options.Parameters[1].Index = 2
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "Invalid MockStrategy parameter index 2, expected 0 - 1", err.Error())
+
+	options = createOptimizationOptions()
+	options.Parameters[2].Step = decimal.NewFromFloat(0)
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "Invalid MockIndicator parameter 0 range: 10 - 20 by 0", err.Error())
+
+	options = createOptimizationOptions()
+	options.Parameters[2].Max = decimal.NewFromFloat(600)
+	options.Parameters[2].Step = decimal.NewFromFloat(1)
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "Grid search exceeds the maximum of 1000 parameter sets, narrow the ranges or use random search", err.Error())
+
+	options = createOptimizationOptions()
+	options.Folds = 10
+	_, err = optimizerService.Optimize(1, options)
+	assert.Equal(t, "Optimizing 10 walk-forward folds requires at least 24 candlesticks, received 20", err.Error())
+
+	CleanupIntegrationTest()
+}
+
+func TestOptimizerService_SaveParameters(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	chartDAO := dao.NewChartDAO(ctx)
+	chartMapper := mapper.NewChartMapper(ctx)
+	pluginService := &MockPluginService_Backtest{indicator: &MockFinancialIndicator_Backtest{}}
+	backtestService := NewBacktestService(ctx, chartDAO, nil, nil, pluginService, chartMapper, nil)
+	optimizerService := NewOptimizerService(ctx, backtestService, pluginService,
+		dao.NewChartIndicatorDAO(ctx), dao.NewChartStrategyDAO(ctx), chartMapper)
+
+	chartEntity := createOptimizerTestChart(ctx)
+	assert.Nil(t, chartDAO.Create(chartEntity))
+
+	chart, err := optimizerService.SaveParameters(chartEntity.GetId(), &dto.OptimizationParameterSetDTO{
+		Strategy:   "MockStrategy",
+		Parameters: []string{"90", "120"},
+		Indicators: map[string][]string{
+			"MockIndicator": []string{"20"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(chart.GetStrategies()))
+	assert.Equal(t, "90,120", chart.GetStrategies()[0].GetParameters())
+	assert.Equal(t, 1, len(chart.GetIndicators()))
+	assert.Equal(t, "20", chart.GetIndicators()[0].GetParameters())
+
+	_, err = optimizerService.SaveParameters(chartEntity.GetId(), &dto.OptimizationParameterSetDTO{
+		Strategy:   "MockStrategy",
+		Parameters: []string{"90", "120"},
+		Indicators: map[string][]string{
+			"BollingerBands": []string{"20", "2"}}})
+	assert.Equal(t, "Chart 1 has no indicator: BollingerBands", err.Error())
+
+	_, err = optimizerService.SaveParameters(chartEntity.GetId(), &dto.OptimizationParameterSetDTO{
+		Strategy:   "MockStrategy",
+		Parameters: []string{"90"}})
+	assert.Equal(t, "Invalid MockStrategy parameters 90: Invalid configuration", err.Error())
+	chart, err = backtestService.GetChart(chartEntity.GetId())
+	assert.Nil(t, err)
+	assert.Equal(t, "90,120", chart.GetStrategies()[0].GetParameters())
+
+	_, err = optimizerService.SaveParameters(chartEntity.GetId(), &dto.OptimizationParameterSetDTO{
+		Strategy:   "DefaultTradingStrategy",
+		Parameters: []string{"90", "120"}})
+	assert.Equal(t, "Chart 1 has no strategy: DefaultTradingStrategy", err.Error())
+
+	_, err = optimizerService.SaveParameters(99, &dto.OptimizationParameterSetDTO{Strategy: "MockStrategy"})
+	assert.Equal(t, "Unable to locate chart: 99", err.Error())
+
+	CleanupIntegrationTest()
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PAPER_DEFAULT_FEE      = 0.0025
+	PAPER_DEFAULT_SLIPPAGE = 0.001
+	PAPER_DEFAULT_BALANCE  = 10000
+)
+
+// PAPER_ACCOUNT_LOCKS serializes the reads and writes of each paper account,
+// keyed by user id and exchange, so concurrent orders and fills can not
+// overwrite each other's balances.
+var PAPER_ACCOUNT_LOCKS = make(map[string]*sync.Mutex)
+var PAPER_ACCOUNT_LOCKS_LOCK sync.Mutex
+
+type DefaultPaperAccountService struct {
+	ctx    common.Context
+	dao    dao.PaperAccountDAO
+	mapper mapper.PaperAccountMapper
+	PaperAccountService
+}
+
+func NewPaperAccountService(ctx common.Context, paperAccountDAO dao.PaperAccountDAO,
+	paperAccountMapper mapper.PaperAccountMapper) PaperAccountService {
+	return &DefaultPaperAccountService{
+		ctx:    ctx,
+		dao:    paperAccountDAO,
+		mapper: paperAccountMapper}
+}
+
+func (service *DefaultPaperAccountService) GetMapper() mapper.PaperAccountMapper {
+	return service.mapper
+}
+
+// GetAccount returns the user's paper account for the exchange, opening one
+// funded with PAPER_DEFAULT_BALANCE in the user's local currency on first use.
+func (service *DefaultPaperAccountService) GetAccount(exchange string) (common.PaperAccount, error) {
+	lock := service.lock(exchange)
+	defer lock.Unlock()
+	return service.getAccount(exchange)
+}
+
+func (service *DefaultPaperAccountService) getAccount(exchange string) (common.PaperAccount, error) {
+	account, err := service.dao.Get(exchange)
+	if err == nil {
+		return service.mapper.MapPaperAccountEntityToDto(account), nil
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		service.ctx.GetLogger().Errorf("[PaperAccountService.getAccount] Error loading %s paper account: %s",
+			exchange, err.Error())
+		return nil, err
+	}
+	localCurrency := service.ctx.GetUser().GetLocalCurrency()
+	service.ctx.GetLogger().Debugf("[PaperAccountService.GetAccount] Opening %s's %s paper account with %d %s",
+		service.ctx.GetUser().GetUsername(), exchange, PAPER_DEFAULT_BALANCE, localCurrency)
+	opened, _, err := service.save(&dto.PaperAccountDTO{
+		UserId:   service.ctx.GetUser().GetId(),
+		Exchange: exchange,
+		Fee:      decimal.NewFromFloat(PAPER_DEFAULT_FEE),
+		Slippage: decimal.NewFromFloat(PAPER_DEFAULT_SLIPPAGE),
+		Balances: map[string]decimal.Decimal{
+			localCurrency: decimal.NewFromFloat(PAPER_DEFAULT_BALANCE)}})
+	return opened, err
+}
+
+// SaveAccount replaces the fee and slippage rates and the balances of the
+// exchange's paper account. Orders already placed are kept.
+func (service *DefaultPaperAccountService) SaveAccount(account common.PaperAccount) (common.PaperAccount, error) {
+	service.ctx.GetLogger().Debugf("[PaperAccountService.SaveAccount] Saving %s's %s paper account",
+		service.ctx.GetUser().GetUsername(), account.GetExchange())
+	one := decimal.NewFromFloat(1)
+	if account.GetFee().IsNegative() || !account.GetFee().LessThan(one) {
+		return nil, errors.New(fmt.Sprintf("Invalid paper trading fee: %s", account.GetFee()))
+	}
+	if account.GetSlippage().IsNegative() || !account.GetSlippage().LessThan(one) {
+		return nil, errors.New(fmt.Sprintf("Invalid paper trading slippage: %s", account.GetSlippage()))
+	}
+	balances := make(map[string]decimal.Decimal)
+	for currency, amount := range account.GetBalances() {
+		if amount.IsNegative() {
+			return nil, errors.New(fmt.Sprintf("Invalid %s paper balance: %s", currency, amount))
+		}
+		if !amount.IsZero() {
+			balances[strings.ToUpper(currency)] = amount
+		}
+	}
+	lock := service.lock(account.GetExchange())
+	defer lock.Unlock()
+	existing, err := service.getAccount(account.GetExchange())
+	if err != nil {
+		return nil, err
+	}
+	saved, _, err := service.save(&dto.PaperAccountDTO{
+		Id:       existing.GetId(),
+		UserId:   existing.GetUserId(),
+		Exchange: existing.GetExchange(),
+		Fee:      account.GetFee(),
+		Slippage: account.GetSlippage(),
+		Balances: balances})
+	return saved, err
+}
+
+// ResetAccount deletes the exchange's paper account along with its order
+// history. The next request for the account opens a new default account.
+func (service *DefaultPaperAccountService) ResetAccount(exchange string) error {
+	service.ctx.GetLogger().Debugf("[PaperAccountService.ResetAccount] Resetting %s's %s paper account",
+		service.ctx.GetUser().GetUsername(), exchange)
+	lock := service.lock(exchange)
+	defer lock.Unlock()
+	account, err := service.dao.Get(exchange)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Paper account not found: %s", exchange))
+	}
+	return service.dao.Delete(account)
+}
+
+func (service *DefaultPaperAccountService) GetOrders(exchange string) ([]common.Order, error) {
+	account, err := service.GetAccount(exchange)
+	if err != nil {
+		return nil, err
+	}
+	return service.getOrders(account)
+}
+
+func (service *DefaultPaperAccountService) GetOrder(exchange, orderId string) (common.Order, error) {
+	account, err := service.GetAccount(exchange)
+	if err != nil {
+		return nil, err
+	}
+	return service.getOrder(account, orderId)
+}
+
+// PlaceOrder fills market orders, and limit orders the market price has already
+// crossed, immediately. Other limit orders stay open until FillOrders is called
+// with a price that crosses them. Orders the account can not pay for are
+// rejected.
+func (service *DefaultPaperAccountService) PlaceOrder(exchange string, order common.Order,
+	marketPrice decimal.Decimal) (common.Order, error) {
+	service.ctx.GetLogger().Debugf("[PaperAccountService.PlaceOrder] Placing %s %s order for %s %s on %s at %s",
+		order.GetType(), order.GetSide(), order.GetQuantity(), order.GetCurrencyPair(), exchange, marketPrice)
+	zero := decimal.NewFromFloat(0)
+	if order.GetSide() != common.BUY_ORDER_TYPE && order.GetSide() != common.SELL_ORDER_TYPE {
+		return nil, errors.New(fmt.Sprintf("Invalid order side: %s", order.GetSide()))
+	}
+	if order.GetType() != common.ORDER_TYPE_MARKET && order.GetType() != common.ORDER_TYPE_LIMIT {
+		return nil, errors.New(fmt.Sprintf("Invalid order type: %s", order.GetType()))
+	}
+	if !order.GetQuantity().GreaterThan(zero) {
+		return nil, errors.New(fmt.Sprintf("Invalid order quantity: %s", order.GetQuantity()))
+	}
+	if order.GetType() == common.ORDER_TYPE_LIMIT && !order.GetPrice().GreaterThan(zero) {
+		return nil, errors.New(fmt.Sprintf("Invalid limit price: %s", order.GetPrice()))
+	}
+	if !marketPrice.GreaterThan(zero) {
+		return nil, errors.New(fmt.Sprintf("No market price for %s", order.GetCurrencyPair()))
+	}
+	lock := service.lock(exchange)
+	defer lock.Unlock()
+	account, err := service.getAccount(exchange)
+	if err != nil {
+		return nil, err
+	}
+	paperAccount := account.(*dto.PaperAccountDTO)
+	placed := &dto.OrderDTO{
+		Exchange:       common.PAPER_EXCHANGE,
+		CurrencyPair:   order.GetCurrencyPair(),
+		Side:           order.GetSide(),
+		Type:           order.GetType(),
+		Status:         common.ORDER_STATUS_OPEN,
+		Date:           time.Now(),
+		Quantity:       order.GetQuantity(),
+		Price:          order.GetPrice(),
+		FilledQuantity: zero,
+		FillPrice:      zero,
+		Fee:            zero,
+		FeeCurrency:    order.GetCurrencyPair().Quote}
+	if placed.Type == common.ORDER_TYPE_MARKET {
+		placed.Price = marketPrice
+	}
+	filled, err := service.fill(paperAccount, placed, marketPrice)
+	if err != nil {
+		return nil, err
+	}
+	if !filled {
+		if err := service.checkFunds(paperAccount, placed); err != nil {
+			return nil, err
+		}
+	}
+	_, orders, err := service.save(paperAccount, placed)
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// FillOrders fills the open limit orders for the currency pair crossed by the
+// market price, returning the orders that changed. Orders the account can no
+// longer pay for are cancelled.
+func (service *DefaultPaperAccountService) FillOrders(exchange string, currencyPair *common.CurrencyPair,
+	marketPrice decimal.Decimal) ([]common.Order, error) {
+	lock := service.lock(exchange)
+	defer lock.Unlock()
+	account, err := service.getAccount(exchange)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := service.getOrders(account)
+	if err != nil {
+		return nil, err
+	}
+	paperAccount := account.(*dto.PaperAccountDTO)
+	var changed []common.Order
+	for _, o := range orders {
+		order := o.(*dto.OrderDTO)
+		if order.Status != common.ORDER_STATUS_OPEN || order.CurrencyPair.Base != currencyPair.Base ||
+			order.CurrencyPair.Quote != currencyPair.Quote {
+			continue
+		}
+		filled, err := service.fill(paperAccount, order, marketPrice)
+		if err != nil {
+			service.ctx.GetLogger().Warningf("[PaperAccountService.FillOrders] Cancelling order %s: %s", order.Id, err.Error())
+			order.Status = common.ORDER_STATUS_CANCELLED
+		} else if !filled {
+			continue
+		}
+		changed = append(changed, order)
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+	_, saved, err := service.save(paperAccount, changed...)
+	return saved, err
+}
+
+func (service *DefaultPaperAccountService) CancelOrder(exchange, orderId string) (common.Order, error) {
+	service.ctx.GetLogger().Debugf("[PaperAccountService.CancelOrder] Cancelling %s order %s", exchange, orderId)
+	lock := service.lock(exchange)
+	defer lock.Unlock()
+	account, err := service.getAccount(exchange)
+	if err != nil {
+		return nil, err
+	}
+	order, err := service.getOrder(account, orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.GetStatus() != common.ORDER_STATUS_OPEN {
+		return nil, errors.New(fmt.Sprintf("Paper order %s is %s", orderId, order.GetStatus()))
+	}
+	order.(*dto.OrderDTO).Status = common.ORDER_STATUS_CANCELLED
+	_, orders, err := service.save(account, order)
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// lock acquires the lock of the user's paper account for the exchange; the
+// caller must release it.
+func (service *DefaultPaperAccountService) lock(exchange string) *sync.Mutex {
+	key := fmt.Sprintf("%d-%s", service.ctx.GetUser().GetId(), exchange)
+	PAPER_ACCOUNT_LOCKS_LOCK.Lock()
+	lock, ok := PAPER_ACCOUNT_LOCKS[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		PAPER_ACCOUNT_LOCKS[key] = lock
+	}
+	PAPER_ACCOUNT_LOCKS_LOCK.Unlock()
+	lock.Lock()
+	return lock
+}
+
+// fill executes the order at the market price moved against the order by the
+// account's slippage rate, capped at the limit price, and charges the account's
+// fee in the quote currency. It returns false when the market price has not
+// crossed the limit price.
+func (service *DefaultPaperAccountService) fill(account *dto.PaperAccountDTO, order *dto.OrderDTO,
+	marketPrice decimal.Decimal) (bool, error) {
+	one := decimal.NewFromFloat(1)
+	isLimit := order.Type == common.ORDER_TYPE_LIMIT
+	var fillPrice decimal.Decimal
+	if order.Side == common.BUY_ORDER_TYPE {
+		if isLimit && marketPrice.GreaterThan(order.Price) {
+			return false, nil
+		}
+		fillPrice = marketPrice.Mul(one.Add(account.Slippage))
+		if isLimit && fillPrice.GreaterThan(order.Price) {
+			fillPrice = order.Price
+		}
+	} else {
+		if isLimit && marketPrice.LessThan(order.Price) {
+			return false, nil
+		}
+		fillPrice = marketPrice.Mul(one.Sub(account.Slippage))
+		if isLimit && fillPrice.LessThan(order.Price) {
+			fillPrice = order.Price
+		}
+	}
+	base, quote := order.CurrencyPair.Base, order.CurrencyPair.Quote
+	total := order.Quantity.Mul(fillPrice)
+	fee := total.Mul(account.Fee)
+	if order.Side == common.BUY_ORDER_TYPE {
+		cost := total.Add(fee)
+		if err := service.debit(account, quote, cost); err != nil {
+			return false, err
+		}
+		service.credit(account, base, order.Quantity)
+	} else {
+		if err := service.debit(account, base, order.Quantity); err != nil {
+			return false, err
+		}
+		service.credit(account, quote, total.Sub(fee))
+	}
+	order.Status = common.ORDER_STATUS_FILLED
+	order.FilledQuantity = order.Quantity
+	order.FillPrice = fillPrice
+	order.Fee = fee
+	order.FeeCurrency = quote
+	return true, nil
+}
+
+// checkFunds verifies the account can pay for an open limit order at its limit
+// price. Funds are not reserved; they are checked again when the order fills.
+func (service *DefaultPaperAccountService) checkFunds(account *dto.PaperAccountDTO, order *dto.OrderDTO) error {
+	currency, required := order.CurrencyPair.Base, order.Quantity
+	if order.Side == common.BUY_ORDER_TYPE {
+		currency = order.CurrencyPair.Quote
+		required = order.Quantity.Mul(order.Price).Mul(decimal.NewFromFloat(1).Add(account.Fee))
+	}
+	return service.insufficientFunds(account, currency, required)
+}
+
+func (service *DefaultPaperAccountService) insufficientFunds(account *dto.PaperAccountDTO, currency string,
+	required decimal.Decimal) error {
+	if available := account.Balances[currency]; available.LessThan(required) {
+		return errors.New(fmt.Sprintf("Insufficient %s balance: %s available, %s required", currency, available, required))
+	}
+	return nil
+}
+
+func (service *DefaultPaperAccountService) debit(account *dto.PaperAccountDTO, currency string, amount decimal.Decimal) error {
+	if err := service.insufficientFunds(account, currency, amount); err != nil {
+		return err
+	}
+	service.credit(account, currency, amount.Neg())
+	return nil
+}
+
+func (service *DefaultPaperAccountService) credit(account *dto.PaperAccountDTO, currency string, amount decimal.Decimal) {
+	if account.Balances == nil {
+		account.Balances = make(map[string]decimal.Decimal)
+	}
+	balance := account.Balances[currency].Add(amount)
+	if balance.IsZero() {
+		delete(account.Balances, currency)
+		return
+	}
+	account.Balances[currency] = balance
+}
+
+func (service *DefaultPaperAccountService) getOrders(account common.PaperAccount) ([]common.Order, error) {
+	entities, err := service.dao.FindOrders(service.mapper.MapPaperAccountDtoToEntity(account))
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]common.Order, len(entities))
+	for i, orderEntity := range entities {
+		orders[i] = service.mapper.MapPaperOrderEntityToDto(&orderEntity, common.PAPER_EXCHANGE)
+	}
+	return orders, nil
+}
+
+func (service *DefaultPaperAccountService) getOrder(account common.PaperAccount, orderId string) (common.Order, error) {
+	orders, err := service.getOrders(account)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.GetId() == orderId {
+			return order, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("Paper order not found: %s", orderId))
+}
+
+// save persists the account and orders, returning them with the ids assigned
+// by the database.
+func (service *DefaultPaperAccountService) save(account common.PaperAccount,
+	orders ...common.Order) (common.PaperAccount, []common.Order, error) {
+	accountEntity := service.mapper.MapPaperAccountDtoToEntity(account)
+	orderEntities := make([]entity.PaperOrderEntity, len(orders))
+	for i, order := range orders {
+		orderEntities[i] = service.mapper.MapPaperOrderDtoToEntity(order, account.GetId())
+	}
+	if err := service.dao.Save(accountEntity, orderEntities...); err != nil {
+		service.ctx.GetLogger().Errorf("[PaperAccountService.save] Error saving %s paper account: %s",
+			account.GetExchange(), err.Error())
+		return nil, nil, err
+	}
+	saved := make([]common.Order, len(orderEntities))
+	for i, order := range orderEntities {
+		saved[i] = service.mapper.MapPaperOrderEntityToDto(order, common.PAPER_EXCHANGE)
+	}
+	return service.mapper.MapPaperAccountEntityToDto(accountEntity), saved, nil
+}
@@ -0,0 +1,158 @@
+// +build integration
+
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type FailingPaperAccountDAO struct {
+	dao.PaperAccountDAO
+}
+
+func (dao *FailingPaperAccountDAO) Get(exchange string) (entity.PaperAccountEntity, error) {
+	return nil, errors.New("database is locked")
+}
+
+func createPaperTestOrder(side, orderType string, quantity, price float64) *dto.OrderDTO {
+	return &dto.OrderDTO{
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		Side:         side,
+		Type:         orderType,
+		Quantity:     decimal.NewFromFloat(quantity),
+		Price:        decimal.NewFromFloat(price)}
+}
+
+func TestPaperAccountService(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	paperAccountService := NewPaperAccountService(ctx, dao.NewPaperAccountDAO(ctx), mapper.NewPaperAccountMapper(ctx))
+
+	account, err := paperAccountService.GetAccount("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0025", account.GetFee().String())
+	assert.Equal(t, "0.001", account.GetSlippage().String())
+	assert.Equal(t, "10000", account.GetBalances()["USD"].String())
+
+	_, err = paperAccountService.SaveAccount(&dto.PaperAccountDTO{
+		Exchange: "GDAX",
+		Fee:      decimal.NewFromFloat(1),
+		Slippage: decimal.NewFromFloat(0.01)})
+	assert.Equal(t, "Invalid paper trading fee: 1", err.Error())
+
+	account, err = paperAccountService.SaveAccount(&dto.PaperAccountDTO{
+		Exchange: "GDAX",
+		Fee:      decimal.NewFromFloat(0.001),
+		Slippage: decimal.NewFromFloat(0.01),
+		Balances: map[string]decimal.Decimal{"usd": decimal.NewFromFloat(10000)}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), account.GetId())
+	assert.Equal(t, "10000", account.GetBalances()["USD"].String())
+
+	// Market orders fill at the market price plus slippage
+	buy, err := paperAccountService.PlaceOrder("GDAX",
+		createPaperTestOrder(common.BUY_ORDER_TYPE, common.ORDER_TYPE_MARKET, 1, 0), decimal.NewFromFloat(5000))
+	assert.Nil(t, err)
+	assert.Equal(t, "1", buy.GetId())
+	assert.Equal(t, common.ORDER_STATUS_FILLED, buy.GetStatus())
+	assert.Equal(t, "5050", buy.GetFillPrice().String())
+	assert.Equal(t, "5.05", buy.GetFee().String())
+	account, err = paperAccountService.GetAccount("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, "4944.95", account.GetBalances()["USD"].String())
+	assert.Equal(t, "1", account.GetBalances()["BTC"].String())
+
+	_, err = paperAccountService.PlaceOrder("GDAX",
+		createPaperTestOrder(common.BUY_ORDER_TYPE, common.ORDER_TYPE_MARKET, 1, 0), decimal.NewFromFloat(5000))
+	assert.Equal(t, "Insufficient USD balance: 4944.95 available, 5055.05 required", err.Error())
+
+	// Limit orders stay open until the market crosses the limit price
+	sell, err := paperAccountService.PlaceOrder("GDAX",
+		createPaperTestOrder(common.SELL_ORDER_TYPE, common.ORDER_TYPE_LIMIT, 0.5, 6000), decimal.NewFromFloat(5000))
+	assert.Nil(t, err)
+	assert.Equal(t, common.ORDER_STATUS_OPEN, sell.GetStatus())
+	currencyPair := sell.GetCurrencyPair()
+	changed, err := paperAccountService.FillOrders("GDAX", currencyPair, decimal.NewFromFloat(5900))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(changed))
+	changed, err = paperAccountService.FillOrders("GDAX", currencyPair, decimal.NewFromFloat(6100))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changed))
+	assert.Equal(t, common.ORDER_STATUS_FILLED, changed[0].GetStatus())
+	assert.Equal(t, "6039", changed[0].GetFillPrice().String())
+	assert.Equal(t, "3.0195", changed[0].GetFee().String())
+	account, err = paperAccountService.GetAccount("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, "7961.4305", account.GetBalances()["USD"].String())
+	assert.Equal(t, "0.5", account.GetBalances()["BTC"].String())
+
+	open, err := paperAccountService.PlaceOrder("GDAX",
+		createPaperTestOrder(common.BUY_ORDER_TYPE, common.ORDER_TYPE_LIMIT, 1, 4000), decimal.NewFromFloat(5000))
+	assert.Nil(t, err)
+	cancelled, err := paperAccountService.CancelOrder("GDAX", open.GetId())
+	assert.Nil(t, err)
+	assert.Equal(t, common.ORDER_STATUS_CANCELLED, cancelled.GetStatus())
+	_, err = paperAccountService.CancelOrder("GDAX", open.GetId())
+	assert.Equal(t, "Paper order 3 is cancelled", err.Error())
+
+	orders, err := paperAccountService.GetOrders("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(orders))
+
+	assert.Nil(t, paperAccountService.ResetAccount("GDAX"))
+	account, err = paperAccountService.GetAccount("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, "10000", account.GetBalances()["USD"].String())
+	orders, err = paperAccountService.GetOrders("GDAX")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(orders))
+
+	CleanupIntegrationTest()
+}
+
+func TestPaperAccountService_ConcurrentOrders(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	paperAccountService := NewPaperAccountService(ctx, dao.NewPaperAccountDAO(ctx), mapper.NewPaperAccountMapper(ctx))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := paperAccountService.PlaceOrder("Binance",
+				createPaperTestOrder(common.BUY_ORDER_TYPE, common.ORDER_TYPE_MARKET, 0.1, 0), decimal.NewFromFloat(1000))
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	account, err := paperAccountService.GetAccount("Binance")
+	assert.Nil(t, err)
+	assert.Equal(t, "8996.4975", account.GetBalances()["USD"].String())
+	assert.Equal(t, "1", account.GetBalances()["BTC"].String())
+	orders, err := paperAccountService.GetOrders("Binance")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(orders))
+
+	CleanupIntegrationTest()
+}
+
+func TestPaperAccountService_GetAccountError(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	paperAccountService := NewPaperAccountService(ctx, &FailingPaperAccountDAO{}, mapper.NewPaperAccountMapper(ctx))
+
+	// Only a missing account opens a new one
+	_, err := paperAccountService.GetAccount("GDAX")
+	assert.Equal(t, "database is locked", err.Error())
+
+	CleanupIntegrationTest()
+}
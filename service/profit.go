@@ -18,8 +18,8 @@ func NewProfitService(ctx common.Context, profitDAO dao.ProfitDAO) ProfitService
 		profitDAO: profitDAO}
 }
 
-func (ps *DefaultProfitService) Save(profit common.Profit) {
-	ps.profitDAO.Create(&entity.Profit{
+func (ps *DefaultProfitService) Save(profit common.Profit) error {
+	return ps.profitDAO.Create(&entity.Profit{
 		UserId:   profit.GetUserId(),
 		TradeId:  profit.GetTradeId(),
 		Quantity: profit.GetQuantity().String(),
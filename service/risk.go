@@ -0,0 +1,433 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RISK_GLOBAL_LIMIT      = 0
+	RISK_MAX_REJECTIONS    = 100
+	RISK_QUANTITY_DECIMALS = 8
+	// A signal rejected again for the same reason within the window is only
+	// logged, so a bot blocked by a limit doesn't record a rejection every tick.
+	RISK_REJECTION_WINDOW = 15 * time.Minute
+)
+
+type DefaultRiskService struct {
+	ctx        common.Context
+	riskDAO    dao.RiskDAO
+	tradeDAO   dao.TradeDAO
+	chartDAO   dao.ChartDAO
+	riskMapper mapper.RiskMapper
+	RiskService
+}
+
+// riskPosition is the trading bot's open position on a chart and its recent
+// activity, replayed from the chart's trade history. Cost is the open quantity
+// valued at its average entry price, in the chart's quote currency.
+type riskPosition struct {
+	base       string
+	quote      string
+	quantity   decimal.Decimal
+	cost       decimal.Decimal
+	dailyPnL   decimal.Decimal
+	hourTrades int
+}
+
+func NewRiskService(ctx common.Context, riskDAO dao.RiskDAO, tradeDAO dao.TradeDAO, chartDAO dao.ChartDAO,
+	riskMapper mapper.RiskMapper) RiskService {
+	return &DefaultRiskService{
+		ctx:        ctx,
+		riskDAO:    riskDAO,
+		tradeDAO:   tradeDAO,
+		chartDAO:   chartDAO,
+		riskMapper: riskMapper}
+}
+
+func (service *DefaultRiskService) GetLimits() ([]common.RiskLimit, error) {
+	entities, err := service.riskDAO.FindLimits()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]common.RiskLimit, len(entities))
+	for i, limit := range entities {
+		limits[i] = service.riskMapper.MapRiskLimitEntityToDto(&limit)
+	}
+	return limits, nil
+}
+
+// SaveLimit creates or replaces the global limits (ChartId 0) or the limits of
+// one of the user's charts. The kill switch is left as it is; use SetKillSwitch
+// to change it.
+func (service *DefaultRiskService) SaveLimit(limit common.RiskLimit) (common.RiskLimit, error) {
+	service.ctx.GetLogger().Debugf("[RiskService.SaveLimit] Saving chart %d risk limits: %+v", limit.GetChartId(), limit)
+	if limit.GetMaxPositionSize().IsNegative() {
+		return nil, errors.New(fmt.Sprintf("Invalid max position size: %s", limit.GetMaxPositionSize()))
+	}
+	if limit.GetMaxDailyLoss().IsNegative() {
+		return nil, errors.New(fmt.Sprintf("Invalid max daily loss: %s", limit.GetMaxDailyLoss()))
+	}
+	if limit.GetMaxTradesPerHour() < 0 {
+		return nil, errors.New(fmt.Sprintf("Invalid max trades per hour: %d", limit.GetMaxTradesPerHour()))
+	}
+	if limit.GetMinBalanceReserve().IsNegative() {
+		return nil, errors.New(fmt.Sprintf("Invalid min balance reserve: %s", limit.GetMinBalanceReserve()))
+	}
+	if limit.GetChartId() != RISK_GLOBAL_LIMIT {
+		chart, err := service.chartDAO.Get(limit.GetChartId())
+		if err != nil || chart.GetUserId() != service.ctx.GetUser().GetId() {
+			return nil, errors.New(fmt.Sprintf("Unable to locate chart: %d", limit.GetChartId()))
+		}
+		if len(limit.GetExposures()) > 0 {
+			return nil, errors.New("Exposure caps can only be set on the global risk limits")
+		}
+	}
+	exposures := make(map[string]decimal.Decimal)
+	for currency, maxQuantity := range limit.GetExposures() {
+		if !maxQuantity.IsPositive() {
+			return nil, errors.New(fmt.Sprintf("Invalid %s exposure cap: %s", currency, maxQuantity))
+		}
+		exposures[strings.ToUpper(currency)] = maxQuantity
+	}
+	existing, err := service.riskDAO.GetLimit(limit.GetChartId())
+	if err != nil {
+		return nil, err
+	}
+	limitDTO := &dto.RiskLimitDTO{
+		UserId:            service.ctx.GetUser().GetId(),
+		ChartId:           limit.GetChartId(),
+		MaxPositionSize:   limit.GetMaxPositionSize(),
+		MaxDailyLoss:      limit.GetMaxDailyLoss(),
+		MaxTradesPerHour:  limit.GetMaxTradesPerHour(),
+		MinBalanceReserve: limit.GetMinBalanceReserve(),
+		KillSwitch:        common.KILL_SWITCH_OFF,
+		Exposures:         exposures}
+	if existing != nil {
+		limitDTO.Id = existing.GetId()
+		limitDTO.KillSwitch = existing.GetKillSwitch()
+	}
+	entity := service.riskMapper.MapRiskLimitDtoToEntity(limitDTO)
+	if err := service.riskDAO.SaveLimit(entity); err != nil {
+		return nil, err
+	}
+	return service.riskMapper.MapRiskLimitEntityToDto(entity), nil
+}
+
+func (service *DefaultRiskService) DeleteLimit(chartId uint) error {
+	limit, err := service.riskDAO.GetLimit(chartId)
+	if err != nil {
+		return err
+	}
+	if limit == nil {
+		return errors.New(fmt.Sprintf("No risk limits saved for chart: %d", chartId))
+	}
+	if service.isEngaged(limit.GetKillSwitch()) {
+		return errors.New("Disengage the kill switch before deleting the global risk limits")
+	}
+	service.ctx.GetLogger().Debugf("[RiskService.DeleteLimit] Deleting chart %d risk limits", chartId)
+	return service.riskDAO.DeleteLimit(limit)
+}
+
+func (service *DefaultRiskService) GetKillSwitch() (string, error) {
+	limit, err := service.riskDAO.GetLimit(RISK_GLOBAL_LIMIT)
+	if err != nil {
+		return "", err
+	}
+	if limit == nil || !service.isEngaged(limit.GetKillSwitch()) {
+		return common.KILL_SWITCH_OFF, nil
+	}
+	return limit.GetKillSwitch(), nil
+}
+
+// SetKillSwitch halts the trading bot on every chart, or halts it and sells its
+// open positions when set to KILL_SWITCH_FLATTEN, until set to KILL_SWITCH_OFF.
+func (service *DefaultRiskService) SetKillSwitch(mode string) error {
+	switch mode {
+	case common.KILL_SWITCH_OFF, common.KILL_SWITCH_HALT, common.KILL_SWITCH_FLATTEN:
+	default:
+		return errors.New(fmt.Sprintf("Unsupported kill switch mode: %s", mode))
+	}
+	limit, err := service.riskDAO.GetLimit(RISK_GLOBAL_LIMIT)
+	if err != nil {
+		return err
+	}
+	limitDTO := &dto.RiskLimitDTO{
+		UserId:  service.ctx.GetUser().GetId(),
+		ChartId: RISK_GLOBAL_LIMIT}
+	if limit != nil {
+		limitDTO = service.riskMapper.MapRiskLimitEntityToDto(limit).(*dto.RiskLimitDTO)
+	}
+	limitDTO.KillSwitch = mode
+	service.ctx.GetLogger().Warningf("[RiskService.SetKillSwitch] %s set the kill switch to %s",
+		service.ctx.GetUser().GetUsername(), mode)
+	return service.riskDAO.SaveLimit(service.riskMapper.MapRiskLimitDtoToEntity(limitDTO))
+}
+
+// GetPosition returns the base currency quantity the trading bot holds on the
+// chart according to its trade history.
+func (service *DefaultRiskService) GetPosition(chart common.Chart) (decimal.Decimal, error) {
+	positions, err := service.positions()
+	if err != nil {
+		return decimal.NewFromFloat(0), err
+	}
+	if position, ok := positions[chart.GetId()]; ok {
+		return position.quantity, nil
+	}
+	return decimal.NewFromFloat(0), nil
+}
+
+func (service *DefaultRiskService) GetRejections(limit int) ([]common.RiskRejection, error) {
+	if limit <= 0 || limit > RISK_MAX_REJECTIONS {
+		limit = RISK_MAX_REJECTIONS
+	}
+	entities, err := service.riskDAO.FindRejections(limit)
+	if err != nil {
+		return nil, err
+	}
+	rejections := make([]common.RiskRejection, len(entities))
+	for i, rejection := range entities {
+		rejections[i] = service.riskMapper.MapRiskRejectionEntityToDto(&rejection)
+	}
+	return rejections, nil
+}
+
+// Evaluate checks a trading signal against the user's global and chart risk
+// limits before its order is placed and returns the quantity that may be traded.
+// Buys are reduced to fit the position size, exposure and balance reserve
+// limits. Rejected signals are logged and recorded with the reason. Global
+// position size and daily loss limits apply to the charts sharing the signal's
+// quote currency.
+func (service *DefaultRiskService) Evaluate(chart common.Chart, tradeType string, price, quantity decimal.Decimal,
+	balances []common.Coin) (decimal.Decimal, error) {
+	zero := decimal.NewFromFloat(0)
+	approved, reason, err := service.evaluate(chart, tradeType, price, quantity, balances)
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[RiskService.Evaluate] Unable to evaluate %s signal on chart %d: %s",
+			tradeType, chart.GetId(), err.Error())
+		return zero, err
+	}
+	if reason != "" {
+		return zero, service.reject(chart, tradeType, price, quantity, reason)
+	}
+	if approved.LessThan(quantity) {
+		service.ctx.GetLogger().Infof("[RiskService.Evaluate] Reduced %s signal on chart %d from %s to %s %s",
+			tradeType, chart.GetId(), quantity, approved, chart.GetBase())
+	}
+	return approved, nil
+}
+
+func (service *DefaultRiskService) evaluate(chart common.Chart, tradeType string, price, quantity decimal.Decimal,
+	balances []common.Coin) (decimal.Decimal, string, error) {
+	zero := decimal.NewFromFloat(0)
+	global, err := service.getLimit(RISK_GLOBAL_LIMIT)
+	if err != nil {
+		return zero, "", err
+	}
+	chartLimit, err := service.getLimit(chart.GetId())
+	if err != nil {
+		return zero, "", err
+	}
+	switch global.GetKillSwitch() {
+	case common.KILL_SWITCH_HALT:
+		return zero, "Kill switch engaged: trading is halted", nil
+	case common.KILL_SWITCH_FLATTEN:
+		if tradeType == common.BUY_ORDER_TYPE {
+			return zero, "Kill switch engaged: positions are being flattened", nil
+		}
+	}
+
+	positions, err := service.positions()
+	if err != nil {
+		return zero, "", err
+	}
+	position, ok := positions[chart.GetId()]
+	if !ok {
+		position = &riskPosition{quantity: zero, cost: zero, dailyPnL: zero}
+	}
+	hourTrades, dailyPnL, openCost, held := 0, zero, zero, zero
+	for _, p := range positions {
+		hourTrades += p.hourTrades
+		if p.quote == chart.GetQuote() {
+			dailyPnL = dailyPnL.Add(p.dailyPnL)
+			openCost = openCost.Add(p.cost)
+		}
+		if p.base == chart.GetBase() {
+			held = held.Add(p.quantity)
+		}
+	}
+	if limit := chartLimit.GetMaxTradesPerHour(); limit > 0 && position.hourTrades >= limit {
+		return zero, fmt.Sprintf("Chart made %d trades in the last hour, limit is %d", position.hourTrades, limit), nil
+	}
+	if limit := global.GetMaxTradesPerHour(); limit > 0 && hourTrades >= limit {
+		return zero, fmt.Sprintf("Made %d trades in the last hour, global limit is %d", hourTrades, limit), nil
+	}
+	if tradeType != common.BUY_ORDER_TYPE {
+		return quantity, "", nil
+	}
+
+	if limit := chartLimit.GetMaxDailyLoss(); limit.IsPositive() && !position.dailyPnL.Neg().LessThan(limit) {
+		return zero, fmt.Sprintf("Chart lost %s %s today, limit is %s", position.dailyPnL.Neg(), chart.GetQuote(), limit), nil
+	}
+	if limit := global.GetMaxDailyLoss(); limit.IsPositive() && !dailyPnL.Neg().LessThan(limit) {
+		return zero, fmt.Sprintf("Lost %s %s today, global limit is %s", dailyPnL.Neg(), chart.GetQuote(), limit), nil
+	}
+
+	approved := quantity
+	reserve := global.GetMinBalanceReserve()
+	if chartLimit.GetMinBalanceReserve().GreaterThan(reserve) {
+		reserve = chartLimit.GetMinBalanceReserve()
+	}
+	if reserve.IsPositive() {
+		available := zero
+		for _, coin := range balances {
+			if coin.GetCurrency() == chart.GetQuote() {
+				available = coin.GetAvailable()
+			}
+		}
+		spendable := available.Sub(reserve)
+		if !spendable.IsPositive() {
+			return zero, fmt.Sprintf("%s balance of %s is within the %s reserve", chart.GetQuote(), available, reserve), nil
+		}
+		approved = service.min(approved, spendable.Div(price))
+	}
+	if limit := chartLimit.GetMaxPositionSize(); limit.IsPositive() {
+		if !position.cost.LessThan(limit) {
+			return zero, fmt.Sprintf("Chart position of %s %s is at the limit of %s", position.cost, chart.GetQuote(), limit), nil
+		}
+		approved = service.min(approved, limit.Sub(position.cost).Div(price))
+	}
+	if limit := global.GetMaxPositionSize(); limit.IsPositive() {
+		if !openCost.LessThan(limit) {
+			return zero, fmt.Sprintf("Open positions of %s %s are at the global limit of %s", openCost, chart.GetQuote(), limit), nil
+		}
+		approved = service.min(approved, limit.Sub(openCost).Div(price))
+	}
+	if limit, ok := global.GetExposures()[chart.GetBase()]; ok && limit.IsPositive() {
+		if !held.LessThan(limit) {
+			return zero, fmt.Sprintf("%s exposure of %s is at the cap of %s", chart.GetBase(), held, limit), nil
+		}
+		approved = service.min(approved, limit.Sub(held))
+	}
+	approved = approved.Truncate(RISK_QUANTITY_DECIMALS)
+	if !approved.IsPositive() {
+		return zero, "Quantity within the risk limits rounds to zero", nil
+	}
+	return approved, "", nil
+}
+
+func (service *DefaultRiskService) reject(chart common.Chart, tradeType string, price, quantity decimal.Decimal, reason string) error {
+	service.ctx.GetLogger().Warningf("[RiskService.Evaluate] Rejected %s signal for %s %s on chart %d: %s",
+		tradeType, quantity, chart.GetBase(), chart.GetId(), reason)
+	recent, err := service.riskDAO.GetRecentRejection(chart.GetId(), tradeType, reason, time.Now().Add(-RISK_REJECTION_WINDOW))
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[RiskService.Evaluate] Error retrieving recent rejections: %s", err.Error())
+	}
+	if recent != nil {
+		return errors.New(reason)
+	}
+	rejection := service.riskMapper.MapRiskRejectionDtoToEntity(&dto.RiskRejectionDTO{
+		UserId:   service.ctx.GetUser().GetId(),
+		ChartId:  chart.GetId(),
+		Date:     time.Now(),
+		Base:     chart.GetBase(),
+		Quote:    chart.GetQuote(),
+		Type:     tradeType,
+		Price:    price,
+		Quantity: quantity,
+		Reason:   reason})
+	if err := service.riskDAO.CreateRejection(rejection); err != nil {
+		service.ctx.GetLogger().Errorf("[RiskService.Evaluate] Error saving rejection: %s", err.Error())
+	}
+	return errors.New(reason)
+}
+
+// getLimit returns the saved limits for the chart, or unlimited limits when
+// none are saved.
+func (service *DefaultRiskService) getLimit(chartId uint) (common.RiskLimit, error) {
+	limit, err := service.riskDAO.GetLimit(chartId)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil {
+		return &dto.RiskLimitDTO{
+			UserId:     service.ctx.GetUser().GetId(),
+			ChartId:    chartId,
+			KillSwitch: common.KILL_SWITCH_OFF}, nil
+	}
+	return service.riskMapper.MapRiskLimitEntityToDto(limit), nil
+}
+
+// positions replays the user's trades in date order, keyed by chart id. A sell
+// realizes the difference between its price and the position's average entry
+// price; profits and losses realized since midnight UTC count toward the daily
+// loss limits.
+func (service *DefaultRiskService) positions() (map[uint]*riskPosition, error) {
+	trades := service.tradeDAO.Find(service.ctx.GetUser())
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].GetDate().Before(trades[j].GetDate())
+	})
+	now := time.Now()
+	midnight := now.UTC().Truncate(24 * time.Hour)
+	hourAgo := now.Add(-time.Hour)
+	positions := make(map[uint]*riskPosition)
+	for _, trade := range trades {
+		price, err := decimal.NewFromString(trade.GetPrice())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid price for trade %d: %s", trade.GetId(), trade.GetPrice()))
+		}
+		amount, err := decimal.NewFromString(trade.GetAmount())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid amount for trade %d: %s", trade.GetId(), trade.GetAmount()))
+		}
+		position, ok := positions[trade.GetChartId()]
+		if !ok {
+			position = &riskPosition{
+				base:     trade.GetBase(),
+				quote:    trade.GetQuote(),
+				quantity: decimal.NewFromFloat(0),
+				cost:     decimal.NewFromFloat(0),
+				dailyPnL: decimal.NewFromFloat(0)}
+			positions[trade.GetChartId()] = position
+		}
+		switch trade.GetType() {
+		case common.BUY_ORDER_TYPE:
+			position.quantity = position.quantity.Add(amount)
+			position.cost = position.cost.Add(amount.Mul(price))
+		case common.SELL_ORDER_TYPE:
+			amount = service.min(amount, position.quantity)
+			if amount.IsPositive() {
+				entryPrice := position.cost.Div(position.quantity)
+				if !trade.GetDate().Before(midnight) {
+					position.dailyPnL = position.dailyPnL.Add(amount.Mul(price.Sub(entryPrice)))
+				}
+				position.cost = position.cost.Sub(amount.Mul(entryPrice))
+				position.quantity = position.quantity.Sub(amount)
+			}
+		}
+		if trade.GetDate().After(hourAgo) {
+			position.hourTrades++
+		}
+	}
+	return positions, nil
+}
+
+func (service *DefaultRiskService) isEngaged(killSwitch string) bool {
+	return killSwitch == common.KILL_SWITCH_HALT || killSwitch == common.KILL_SWITCH_FLATTEN
+}
+
+func (service *DefaultRiskService) min(a, b decimal.Decimal) decimal.Decimal {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
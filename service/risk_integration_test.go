@@ -0,0 +1,206 @@
+// +build integration
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRiskService(ctx common.Context) (RiskService, common.Chart) {
+	chartDAO := dao.NewChartDAO(ctx)
+	chartEntity := &entity.Chart{
+		UserId:    ctx.GetUser().GetId(),
+		Base:      "BTC",
+		Quote:     "USD",
+		Exchange:  "GDAX",
+		Period:    900,
+		AutoTrade: common.AUTOTRADE_LIVE}
+	chartDAO.Create(chartEntity)
+	chart := &dto.ChartDTO{
+		Id:       chartEntity.GetId(),
+		Base:     "BTC",
+		Quote:    "USD",
+		Exchange: "GDAX",
+		Period:   900}
+	return NewRiskService(ctx, dao.NewRiskDAO(ctx), dao.NewTradeDAO(ctx), chartDAO, mapper.NewRiskMapper(ctx)), chart
+}
+
+func createRiskTrade(ctx common.Context, chartId uint, tradeType, price, amount string, date time.Time) {
+	dao.NewTradeDAO(ctx).Create(&entity.Trade{
+		ChartId:  chartId,
+		UserId:   ctx.GetUser().GetId(),
+		Base:     "BTC",
+		Quote:    "USD",
+		Exchange: "GDAX",
+		Date:     date,
+		Type:     tradeType,
+		Price:    price,
+		Amount:   amount})
+}
+
+func TestRiskService_Limits(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	riskService, chart := createRiskService(ctx)
+
+	global, err := riskService.SaveLimit(&dto.RiskLimitDTO{
+		MaxPositionSize:  decimal.NewFromFloat(5000),
+		MaxTradesPerHour: 10,
+		Exposures: map[string]decimal.Decimal{
+			"btc": decimal.NewFromFloat(1.5)}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint(RISK_GLOBAL_LIMIT), global.GetChartId())
+	assert.Equal(t, common.KILL_SWITCH_OFF, global.GetKillSwitch())
+	assert.True(t, decimal.NewFromFloat(1.5).Equal(global.GetExposures()["BTC"]))
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		ChartId:      chart.GetId(),
+		MaxDailyLoss: decimal.NewFromFloat(100)})
+	assert.Nil(t, err)
+	limits, err := riskService.GetLimits()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(limits))
+	assert.Equal(t, chart.GetId(), limits[1].GetChartId())
+	assert.True(t, decimal.NewFromFloat(100).Equal(limits[1].GetMaxDailyLoss()))
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{ChartId: 99})
+	assert.Equal(t, "Unable to locate chart: 99", err.Error())
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{MaxDailyLoss: decimal.NewFromFloat(-1)})
+	assert.Equal(t, "Invalid max daily loss: -1", err.Error())
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		ChartId:   chart.GetId(),
+		Exposures: map[string]decimal.Decimal{"BTC": decimal.NewFromFloat(1)}})
+	assert.Equal(t, "Exposure caps can only be set on the global risk limits", err.Error())
+
+	// Saving the global limits leaves the kill switch engaged
+	assert.Nil(t, riskService.SetKillSwitch(common.KILL_SWITCH_HALT))
+	global, err = riskService.SaveLimit(&dto.RiskLimitDTO{MaxTradesPerHour: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, common.KILL_SWITCH_HALT, global.GetKillSwitch())
+	assert.Equal(t, 0, len(global.GetExposures()))
+	killSwitch, err := riskService.GetKillSwitch()
+	assert.Nil(t, err)
+	assert.Equal(t, common.KILL_SWITCH_HALT, killSwitch)
+
+	assert.Equal(t, "Unsupported kill switch mode: panic", riskService.SetKillSwitch("panic").Error())
+	assert.Equal(t, "Disengage the kill switch before deleting the global risk limits",
+		riskService.DeleteLimit(RISK_GLOBAL_LIMIT).Error())
+	assert.Nil(t, riskService.SetKillSwitch(common.KILL_SWITCH_OFF))
+	assert.Nil(t, riskService.DeleteLimit(RISK_GLOBAL_LIMIT))
+	assert.Nil(t, riskService.DeleteLimit(chart.GetId()))
+	assert.Equal(t, "No risk limits saved for chart: 99", riskService.DeleteLimit(99).Error())
+
+	killSwitch, err = riskService.GetKillSwitch()
+	assert.Nil(t, err)
+	assert.Equal(t, common.KILL_SWITCH_OFF, killSwitch)
+
+	CleanupIntegrationTest()
+}
+
+func TestRiskService_Evaluate(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	riskService, chart := createRiskService(ctx)
+	price := decimal.NewFromFloat(10000)
+	quantity := decimal.NewFromFloat(0.5)
+	balances := []common.Coin{
+		&dto.CoinDTO{Currency: "USD", Available: decimal.NewFromFloat(10000)}}
+
+	approved, err := riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.5", approved.String())
+
+	// A round trip losing 1000 USD followed by an open position of 0.5 BTC
+	now := time.Now()
+	createRiskTrade(ctx, chart.GetId(), common.BUY_ORDER_TYPE, "10000", "1", now.Add(-3*time.Second))
+	createRiskTrade(ctx, chart.GetId(), common.SELL_ORDER_TYPE, "9000", "1", now.Add(-2*time.Second))
+	createRiskTrade(ctx, chart.GetId(), common.BUY_ORDER_TYPE, "10000", "0.5", now.Add(-time.Second))
+	position, err := riskService.GetPosition(chart)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.5", position.String())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{MaxDailyLoss: decimal.NewFromFloat(1000)})
+	assert.Nil(t, err)
+	_, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Lost 1000 USD today, global limit is 1000", err.Error())
+	approved, err = riskService.Evaluate(chart, common.SELL_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.5", approved.String())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{ChartId: chart.GetId(), MaxTradesPerHour: 3})
+	assert.Nil(t, err)
+	_, err = riskService.Evaluate(chart, common.SELL_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Chart made 3 trades in the last hour, limit is 3", err.Error())
+
+	// Buys are reduced to fit the global position size, exposure cap and reserve
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{ChartId: chart.GetId(), MaxTradesPerHour: 10})
+	assert.Nil(t, err)
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{MaxPositionSize: decimal.NewFromFloat(8000)})
+	assert.Nil(t, err)
+	approved, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.3", approved.String())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		MaxPositionSize: decimal.NewFromFloat(8000),
+		Exposures:       map[string]decimal.Decimal{"BTC": decimal.NewFromFloat(0.6)}})
+	assert.Nil(t, err)
+	approved, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1", approved.String())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		ChartId:           chart.GetId(),
+		MinBalanceReserve: decimal.NewFromFloat(9500)})
+	assert.Nil(t, err)
+	approved, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.05", approved.String())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		ChartId:           chart.GetId(),
+		MinBalanceReserve: decimal.NewFromFloat(10000)})
+	assert.Nil(t, err)
+	_, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "USD balance of 10000 is within the 10000 reserve", err.Error())
+
+	_, err = riskService.SaveLimit(&dto.RiskLimitDTO{
+		MaxPositionSize: decimal.NewFromFloat(5000)})
+	assert.Nil(t, err)
+	assert.Nil(t, riskService.DeleteLimit(chart.GetId()))
+	_, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Open positions of 5000 USD are at the global limit of 5000", err.Error())
+
+	// The kill switch halts everything or only lets positions be sold
+	assert.Nil(t, riskService.SetKillSwitch(common.KILL_SWITCH_FLATTEN))
+	_, err = riskService.Evaluate(chart, common.BUY_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Kill switch engaged: positions are being flattened", err.Error())
+	approved, err = riskService.Evaluate(chart, common.SELL_ORDER_TYPE, price, quantity, balances)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.5", approved.String())
+	assert.Nil(t, riskService.SetKillSwitch(common.KILL_SWITCH_HALT))
+	_, err = riskService.Evaluate(chart, common.SELL_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Kill switch engaged: trading is halted", err.Error())
+	// Repeated rejections for the same reason are recorded once
+	_, err = riskService.Evaluate(chart, common.SELL_ORDER_TYPE, price, quantity, balances)
+	assert.Equal(t, "Kill switch engaged: trading is halted", err.Error())
+
+	rejections, err := riskService.GetRejections(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(rejections))
+	assert.Equal(t, "Kill switch engaged: trading is halted", rejections[0].GetReason())
+	assert.Equal(t, common.SELL_ORDER_TYPE, rejections[0].GetType())
+	assert.Equal(t, chart.GetId(), rejections[0].GetChartId())
+	assert.True(t, price.Equal(rejections[0].GetPrice()))
+	assert.True(t, quantity.Equal(rejections[0].GetQuantity()))
+	assert.Equal(t, "Lost 1000 USD today, global limit is 1000", rejections[5].GetReason())
+
+	CleanupIntegrationTest()
+}
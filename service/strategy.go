@@ -1,6 +1,8 @@
 package service
 
 import (
+	"strings"
+
 	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
 	"github.com/jeremyhahn/tradebot/mapper"
@@ -75,7 +77,21 @@ func (service *DefaultStrategyService) GetChartStrategies(chart common.Chart, pa
 		if err != nil {
 			return nil, err
 		}
-		TradingStrategy, err := constructor(params)
+		// Each strategy gets its own copy of the params configured with the
+		// chart strategy's saved parameters. A saved set the plugin rejects, such
+		// as one written before parameters were validated, falls back to the
+		// strategy's defaults so trading on the chart carries on.
+		strategyParams := *params
+		if parameters := strategyEntity.GetParameters(); parameters != "" {
+			strategyParams.Config = strings.Split(parameters, ",")
+		}
+		TradingStrategy, err := constructor(&strategyParams)
+		if err != nil && strategyParams.Config != nil {
+			service.ctx.GetLogger().Warningf("[StrategyService.GetChartStrategies] Invalid %s parameters saved on chart %d, using defaults: %s",
+				strategyEntity.GetName(), chart.GetId(), err.Error())
+			strategyParams.Config = params.Config
+			TradingStrategy, err = constructor(&strategyParams)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -122,6 +122,8 @@ func TestGetChartStrategy_GetChartStrategies(t *testing.T) {
 	pluginDAO.Create(strategyEntity)
 
 	chartDAO := dao.NewChartDAO(ctx)
+	// The fixture's saved parameters are invalid for DefaultTradingStrategy,
+	// which falls back to its defaults
 	chartEntity := createIntegrationTestChart(ctx)
 	chartDAO.Create(chartEntity)
 
@@ -155,3 +157,24 @@ func TestGetChartStrategy_GetChartStrategies(t *testing.T) {
 
 	CleanupIntegrationTest()
 }
+
+func TestGetChartStrategy_InvalidSavedParameters(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+
+	// Saved before parameters were validated
+	chartEntity := createOptimizerTestChart(ctx)
+	chartEntity.Strategies[0].Parameters = "95"
+	assert.Nil(t, dao.NewChartDAO(ctx).Create(chartEntity))
+
+	chartMapper := mapper.NewChartMapper(ctx)
+	pluginService := &MockPluginService_Backtest{indicator: &MockFinancialIndicator_Backtest{}}
+	strategyService := NewStrategyService(ctx, dao.NewChartStrategyDAO(ctx), pluginService, nil, chartMapper)
+
+	tradingStrategies, err := strategyService.GetChartStrategies(chartMapper.MapChartEntityToDto(chartEntity),
+		&common.TradingStrategyParams{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tradingStrategies))
+	assert.Equal(t, []string{"95", "115"}, tradingStrategies[0].(*MockTradingStrategy_Backtest).params.Config)
+
+	CleanupIntegrationTest()
+}
@@ -31,8 +31,12 @@ func (ts *DefaultTradeService) Sell(exchange common.Exchange, trade common.Trade
 	ts.ctx.GetLogger().Debugf("[DefaultTradeService.Sell] %+v\n", trade)
 }
 
-func (ts *DefaultTradeService) Save(dto common.Trade) {
-	ts.tradeDAO.Create(ts.tradeMapper.MapTradeDtoToEntity(dto))
+func (ts *DefaultTradeService) Save(dto common.Trade) (common.Trade, error) {
+	entity := ts.tradeMapper.MapTradeDtoToEntity(dto)
+	if err := ts.tradeDAO.Create(entity); err != nil {
+		return nil, err
+	}
+	return ts.tradeMapper.MapTradeEntityToDto(entity), nil
 }
 
 func (ts *DefaultTradeService) GetLastTrade(chart common.Chart) common.Trade {
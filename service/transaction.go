@@ -2,35 +2,50 @@ package service
 
 import (
 	"sort"
+	"time"
 
 	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
 	"github.com/jeremyhahn/tradebot/entity"
 	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+)
+
+// A deposit is matched to a withdrawal of the same currency that arrived
+// within the window, for the amount withdrawn less the withdrawal fee. The
+// tolerance allows for network fees the exchange did not report.
+const (
+	TRANSFER_MATCH_WINDOW     = 72 * time.Hour
+	TRANSFER_AMOUNT_TOLERANCE = "0.001"
 )
 
 type TransactionServiceImpl struct {
-	ctx              common.Context
-	dao              dao.TransactionDAO
-	mapper           mapper.TransactionMapper
-	exchangeService  ExchangeService
-	userService      UserService
-	ethereumService  EthereumService
-	fiatPriceService common.FiatPriceService
+	ctx                common.Context
+	dao                dao.TransactionDAO
+	revisionDAO        dao.TransactionRevisionDAO
+	mapper             mapper.TransactionMapper
+	exchangeService    ExchangeService
+	userService        UserService
+	ethereumService    EthereumService
+	fiatPriceService   common.FiatPriceService
+	csvTemplateService CSVTemplateService
 	TransactionService
 }
 
-func NewTransactionService(ctx common.Context, transactionDAO dao.TransactionDAO, transactionMapper mapper.TransactionMapper,
-	exchangeService ExchangeService, userService UserService, ethereumService EthereumService,
-	fiatPriceService common.FiatPriceService) TransactionService {
+func NewTransactionService(ctx common.Context, transactionDAO dao.TransactionDAO, revisionDAO dao.TransactionRevisionDAO,
+	transactionMapper mapper.TransactionMapper, exchangeService ExchangeService, userService UserService, ethereumService EthereumService,
+	fiatPriceService common.FiatPriceService, csvTemplateService CSVTemplateService) TransactionService {
 	return &TransactionServiceImpl{
-		ctx:              ctx,
-		dao:              transactionDAO,
-		mapper:           transactionMapper,
-		exchangeService:  exchangeService,
-		userService:      userService,
-		ethereumService:  ethereumService,
-		fiatPriceService: fiatPriceService}
+		ctx:                ctx,
+		dao:                transactionDAO,
+		revisionDAO:        revisionDAO,
+		mapper:             transactionMapper,
+		exchangeService:    exchangeService,
+		userService:        userService,
+		ethereumService:    ethereumService,
+		fiatPriceService:   fiatPriceService,
+		csvTemplateService: csvTemplateService}
 }
 
 func (service *TransactionServiceImpl) GetMapper() mapper.TransactionMapper {
@@ -59,14 +74,16 @@ func (service *TransactionServiceImpl) isUnique(needle common.Transaction, hayst
 }
 
 func (service *TransactionServiceImpl) UpdateCategory(id, category string) error {
-	entity, err := service.dao.Get(id)
+	tx, err := service.GetTransaction(id)
 	if err != nil {
 		service.ctx.GetLogger().Errorf("[TransactionService.UpdateCategory] Error updating %s's transaction id %s to category %s: %s",
 			service.ctx.GetUser().GetUsername(), id, category, err.Error())
 		return err
 	}
-	if entity.GetCategory() != category {
-		return service.dao.Update(entity, "category", category)
+	if tx.GetCategory() != category {
+		updated := cloneTransaction(tx)
+		updated.Category = category
+		return service.saveTransaction(common.TX_REVISION_UPDATE, tx, updated)
 	}
 	return nil
 }
@@ -108,9 +125,124 @@ func (service *TransactionServiceImpl) Synchronize() ([]common.Transaction, erro
 			}
 		}
 	}
+	if _, err := service.MatchTransfers(); err != nil {
+		service.ctx.GetLogger().Errorf("[TransactionService.Synchronize] Error matching transfers: %s", err.Error())
+	}
 	return synchronized, nil
 }
 
+// MatchTransfers pairs unmatched withdrawals with the deposits they arrived as,
+// links the two transactions to each other and categorizes both as transfers
+// so the lots they carry keep their original cost basis and acquisition date.
+func (service *TransactionServiceImpl) MatchTransfers() ([]common.Transfer, error) {
+	entities, err := service.dao.Find("asc")
+	if err != nil {
+		return nil, err
+	}
+	var transactions []common.Transaction
+	for _, entity := range entities {
+		transactions = append(transactions, service.mapper.MapTransactionEntityToDto(&entity))
+	}
+	transfers := matchTransfers(transactions)
+	service.ctx.GetLogger().Debugf("[TransactionService.MatchTransfers] Matched %d transfers for %s",
+		len(transfers), service.ctx.GetUser().GetUsername())
+	for _, transfer := range transfers {
+		withdrawal, deposit := transfer.GetWithdrawal(), transfer.GetDeposit()
+		if err := service.linkTransfer(withdrawal.GetId(), deposit.GetId()); err != nil {
+			return nil, err
+		}
+		if err := service.linkTransfer(deposit.GetId(), withdrawal.GetId()); err != nil {
+			return nil, err
+		}
+	}
+	return transfers, nil
+}
+
+func (service *TransactionServiceImpl) linkTransfer(id, transferId string) error {
+	tx, err := service.GetTransaction(id)
+	if err != nil {
+		service.ctx.GetLogger().Errorf("[TransactionService.linkTransfer] Error linking %s's transaction id %s to %s: %s",
+			service.ctx.GetUser().GetUsername(), id, transferId, err.Error())
+		return err
+	}
+	linked := cloneTransaction(tx)
+	linked.TransferId = transferId
+	linked.Category = common.TX_CATEGORY_TRANSFER
+	return service.saveTransaction(common.TX_REVISION_UPDATE, tx, linked)
+}
+
+// matchTransfers pairs each withdrawal, oldest first, with the closest deposit
+// in time that could have received it.
+func matchTransfers(transactions []common.Transaction) []common.Transfer {
+	var withdrawals, deposits []common.Transaction
+	for _, tx := range transactions {
+		if tx.IsDeleted() || tx.GetTransferId() != "" {
+			continue
+		}
+		switch tx.GetType() {
+		case common.WITHDRAWAL_ORDER_TYPE:
+			if IsTransferCategory(tx.GetCategory(), common.TX_CATEGORY_WITHDRAWAL) {
+				withdrawals = append(withdrawals, tx)
+			}
+		case common.DEPOSIT_ORDER_TYPE:
+			if IsTransferCategory(tx.GetCategory(), common.TX_CATEGORY_DEPOSIT) {
+				deposits = append(deposits, tx)
+			}
+		}
+	}
+	sort.SliceStable(withdrawals, func(i, j int) bool {
+		return withdrawals[i].GetDate().Before(withdrawals[j].GetDate())
+	})
+	tolerance, _ := decimal.NewFromString(TRANSFER_AMOUNT_TOLERANCE)
+	matched := make(map[string]bool)
+	var transfers []common.Transfer
+	for _, withdrawal := range withdrawals {
+		currency := withdrawal.GetCurrencyPair().Base
+		quantity, _ := decimal.NewFromString(withdrawal.GetQuantity())
+		expected := quantity
+		if withdrawal.GetFeeCurrency() == "" || withdrawal.GetFeeCurrency() == currency {
+			fee, _ := decimal.NewFromString(withdrawal.GetFee())
+			expected = quantity.Sub(fee)
+		}
+		minimum := expected.Sub(expected.Mul(tolerance))
+		var match common.Transaction
+		var received decimal.Decimal
+		for _, deposit := range deposits {
+			if matched[deposit.GetId()] || deposit.GetCurrencyPair().Base != currency ||
+				deposit.GetNetwork() == withdrawal.GetNetwork() {
+				continue
+			}
+			elapsed := deposit.GetDate().Sub(withdrawal.GetDate())
+			if elapsed < 0 || elapsed > TRANSFER_MATCH_WINDOW {
+				continue
+			}
+			amount, _ := decimal.NewFromString(deposit.GetQuantity())
+			if amount.GreaterThan(quantity) || amount.LessThan(minimum) {
+				continue
+			}
+			if match == nil || deposit.GetDate().Before(match.GetDate()) {
+				match = deposit
+				received = amount
+			}
+		}
+		if match == nil {
+			continue
+		}
+		matched[match.GetId()] = true
+		transfers = append(transfers, &dto.TransferDTO{
+			Withdrawal: withdrawal,
+			Deposit:    match,
+			NetworkFee: quantity.Sub(received).String()})
+	}
+	return transfers
+}
+
+// IsTransferCategory returns true when a withdrawal or deposit has not been
+// categorized as anything other than a movement of funds.
+func IsTransferCategory(category, defaultCategory string) bool {
+	return category == "" || category == defaultCategory || category == common.TX_CATEGORY_TRANSFER
+}
+
 func (service *TransactionServiceImpl) GetHistory(order string) ([]common.Transaction, error) {
 	service.ctx.GetLogger().Debugf("[TransactionService.GetHistory] Retrieving transaction history for %s in %s order.",
 		service.ctx.GetUser().GetUsername(), order)
@@ -232,7 +364,15 @@ func (service *TransactionServiceImpl) GetImportedTransactions() []common.Transa
 	return txs
 }
 
+// ImportCSV imports an exchange's CSV export using the user's CSV template of
+// the same name, or else the exchange's own parser. Files with rows the
+// template cannot parse are rejected.
 func (service *TransactionServiceImpl) ImportCSV(file, exchangeName string) ([]common.Transaction, error) {
+	if service.csvTemplateService != nil {
+		if _, err := service.csvTemplateService.GetTemplate(exchangeName); err == nil {
+			return service.importTemplateCSV(file, exchangeName)
+		}
+	}
 	service.ctx.GetLogger().Debugf("[TransactionService.ImportCSV] Creating %s exchange service", exchangeName)
 	exchange, err := service.exchangeService.GetExchange(exchangeName)
 	if err != nil {
@@ -246,6 +386,9 @@ func (service *TransactionServiceImpl) ImportCSV(file, exchangeName string) ([]c
 		entity := service.mapper.MapTransactionDtoToEntity(dto)
 		service.dao.Create(entity)
 	}
+	if _, err := service.MatchTransfers(); err != nil {
+		service.ctx.GetLogger().Errorf("[TransactionService.ImportCSV] Error matching transfers: %s", err.Error())
+	}
 	return txDTOs, nil
 }
 
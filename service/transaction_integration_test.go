@@ -4,8 +4,11 @@ package service
 
 import (
 	"testing"
+	"time"
 
+	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
 	"github.com/jeremyhahn/tradebot/mapper"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,6 +46,86 @@ func TestTransactionService_ImportCSV(t *testing.T) {
 	CleanupIntegrationTest()
 }
 
+func TestTransactionService_MatchTransfers(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	transactionDAO := dao.NewTransactionDAO(ctx)
+	transactionMapper := mapper.NewTransactionMapper(ctx)
+	transactionService := NewTransactionService(ctx, transactionDAO, dao.NewTransactionRevisionDAO(ctx), transactionMapper,
+		nil, nil, nil, nil, nil)
+
+	withdrawn := time.Date(2018, 01, 01, 12, 0, 0, 0, time.UTC)
+	transactions := []common.Transaction{
+		&dto.TransactionDTO{
+			Id:           "withdrawal-1",
+			Date:         withdrawn,
+			MarketPair:   &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.WITHDRAWAL_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_WITHDRAWAL,
+			Network:      "gdax",
+			Quantity:     "1",
+			Fee:          "0.001",
+			FeeCurrency:  "BTC"},
+		&dto.TransactionDTO{
+			Id:           "deposit-late",
+			Date:         withdrawn.Add(TRANSFER_MATCH_WINDOW + time.Hour),
+			MarketPair:   &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_DEPOSIT,
+			Network:      "binance",
+			Quantity:     "0.999"},
+		&dto.TransactionDTO{
+			Id:           "deposit-1",
+			Date:         withdrawn.Add(time.Hour),
+			MarketPair:   &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_DEPOSIT,
+			Network:      "binance",
+			Quantity:     "0.999"},
+		&dto.TransactionDTO{
+			Id:           "deposit-income",
+			Date:         withdrawn.Add(time.Hour),
+			MarketPair:   &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "BTC", LocalCurrency: "USD"},
+			Type:         common.DEPOSIT_ORDER_TYPE,
+			Category:     common.TX_CATEGORY_INCOME,
+			Network:      "binance",
+			Quantity:     "0.999"}}
+	for _, tx := range transactions {
+		assert.Nil(t, transactionDAO.Create(transactionMapper.MapTransactionDtoToEntity(tx)))
+	}
+
+	transfers, err := transactionService.MatchTransfers()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transfers))
+	assert.Equal(t, "withdrawal-1", transfers[0].GetWithdrawal().GetId())
+	assert.Equal(t, "deposit-1", transfers[0].GetDeposit().GetId())
+	assert.Equal(t, "0.001", transfers[0].GetNetworkFee())
+
+	withdrawal, err := transactionDAO.Get("withdrawal-1")
+	assert.Nil(t, err)
+	assert.Equal(t, common.TX_CATEGORY_TRANSFER, withdrawal.GetCategory())
+	assert.Equal(t, "deposit-1", withdrawal.GetTransferId())
+
+	deposit, err := transactionDAO.Get("deposit-1")
+	assert.Nil(t, err)
+	assert.Equal(t, common.TX_CATEGORY_TRANSFER, deposit.GetCategory())
+	assert.Equal(t, "withdrawal-1", deposit.GetTransferId())
+
+	unmatched, err := transactionDAO.Get("deposit-late")
+	assert.Nil(t, err)
+	assert.Equal(t, common.TX_CATEGORY_DEPOSIT, unmatched.GetCategory())
+	assert.Equal(t, "", unmatched.GetTransferId())
+
+	transfers, err = transactionService.MatchTransfers()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(transfers))
+
+	CleanupIntegrationTest()
+}
+
 func createTransactionService() (dao.TransactionDAO, TransactionService) {
 	ctx := NewIntegrationTestContext()
 	pluginDAO := dao.NewPluginDAO(ctx)
@@ -59,6 +142,7 @@ func createTransactionService() (dao.TransactionDAO, TransactionService) {
 	fiatPriceService, _ := NewFiatPriceService(ctx, exchangeService)
 	walletService := NewWalletService(ctx, pluginService, fiatPriceService)
 	userService := NewUserService(ctx, userDAO, userMapper, userExchangeMapper, marketcapService, ethereumService, exchangeService, walletService)
-	return transactionDAO, NewTransactionService(ctx, transactionDAO, transactionMapper,
-		exchangeService, userService, ethereumService, fiatPriceService)
+	csvTemplateService := NewCSVTemplateService(ctx, dao.NewCSVTemplateDAO(ctx), mapper.NewCSVTemplateMapper(ctx))
+	return transactionDAO, NewTransactionService(ctx, transactionDAO, dao.NewTransactionRevisionDAO(ctx), transactionMapper,
+		exchangeService, userService, ethereumService, fiatPriceService, csvTemplateService)
 }
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/entity"
+	"github.com/shopspring/decimal"
+)
+
+const MANUAL_TRANSACTION_NETWORK = "manual"
+
+var transactionCategories = map[string]bool{
+	common.TX_CATEGORY_DEPOSIT:    true,
+	common.TX_CATEGORY_WITHDRAWAL: true,
+	common.TX_CATEGORY_TRADE:      true,
+	common.TX_CATEGORY_INCOME:     true,
+	common.TX_CATEGORY_GIFT:       true,
+	common.TX_CATEGORY_MINING:     true,
+	common.TX_CATEGORY_STAKING:    true,
+	common.TX_CATEGORY_SPEND:      true,
+	common.TX_CATEGORY_DONATION:   true,
+	common.TX_CATEGORY_LOST:       true,
+	common.TX_CATEGORY_TRANSFER:   true,
+	common.TX_CATEGORY_FORK:       true,
+	common.TX_CATEGORY_AIRDROP:    true}
+
+func (service *TransactionServiceImpl) GetTransaction(id string) (common.Transaction, error) {
+	if id == "" {
+		return nil, errors.New("Transaction id required")
+	}
+	entity, err := service.dao.Get(id)
+	if err != nil || entity.GetUserId() != service.ctx.GetUser().GetId() {
+		return nil, errors.New(fmt.Sprintf("Transaction not found: %s", id))
+	}
+	return service.mapper.MapTransactionEntityToDto(entity), nil
+}
+
+// CreateTransaction adds a transaction that did not come from an exchange or
+// wallet, such as an OTC trade or a cash purchase. Fiat values that are not
+// given are priced at the transaction date.
+func (service *TransactionServiceImpl) CreateTransaction(tx common.Transaction) (common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.CreateTransaction] %s", tx)
+	if err := validateTransaction(tx); err != nil {
+		return nil, err
+	}
+	localCurrency := service.ctx.GetUser().GetLocalCurrency()
+	input := &dto.TransactionDTO{
+		Date:     tx.GetDate(),
+		Type:     tx.GetType(),
+		Category: tx.GetCategory(),
+		CurrencyPair: &common.CurrencyPair{
+			Base:          strings.ToUpper(tx.GetCurrencyPair().Base),
+			Quote:         strings.ToUpper(tx.GetCurrencyPair().Quote),
+			LocalCurrency: localCurrency},
+		Quantity:          tx.GetQuantity(),
+		Price:             tx.GetPrice(),
+		Total:             tx.GetTotal(),
+		Fee:               tx.GetFee(),
+		FeeCurrency:       strings.ToUpper(tx.GetFeeCurrency()),
+		FiatTotal:         tx.GetFiatTotal(),
+		FiatTotalCurrency: localCurrency}
+	if input.Total == "" {
+		quantity, _ := decimal.NewFromString(input.Quantity)
+		price, _ := decimal.NewFromString(input.Price)
+		input.Total = quantity.Mul(price).String()
+	}
+	if input.FeeCurrency == "" {
+		input.FeeCurrency = input.CurrencyPair.Base
+		if input.Type == common.BUY_ORDER_TYPE || input.Type == common.SELL_ORDER_TYPE {
+			input.FeeCurrency = input.CurrencyPair.Quote
+		}
+	}
+	network := strings.TrimSpace(tx.GetNetwork())
+	if network == "" {
+		network = MANUAL_TRANSACTION_NETWORK
+	}
+	record := newUniversalCSVRecord(input)
+	record.Network = network
+	created, err := newUniversalCSVTransaction(record, localCurrency, service.getFiatPrice)
+	if err != nil {
+		return nil, err
+	}
+	created.Id = fmt.Sprintf("%s-%d", MANUAL_TRANSACTION_NETWORK, time.Now().UnixNano())
+	if tx.GetNetworkDisplayName() != "" {
+		created.NetworkDisplayName = tx.GetNetworkDisplayName()
+	}
+	if err := service.saveTransaction(common.TX_REVISION_CREATE, nil, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateTransaction replaces every field of an existing transaction other than
+// its id and deleted flag.
+func (service *TransactionServiceImpl) UpdateTransaction(tx common.Transaction) (common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.UpdateTransaction] %s", tx)
+	previous, err := service.GetTransaction(tx.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if previous.IsDeleted() {
+		return nil, errors.New(fmt.Sprintf("Deleted transaction %s must be restored before it can be edited", tx.GetId()))
+	}
+	if err := validateTransaction(tx); err != nil {
+		return nil, err
+	}
+	updated := cloneTransaction(tx)
+	updated.Deleted = false
+	if err := service.saveTransaction(common.TX_REVISION_UPDATE, previous, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// SplitTransaction replaces a transaction with one part for each quantity, for
+// example to separate the portion of a purchase made on behalf of someone else.
+// The quantities must add up to the original. Amounts are divided in proportion
+// to each part's quantity, with the last part taking any remainder so the parts
+// always add up to the original amounts.
+func (service *TransactionServiceImpl) SplitTransaction(id string, quantities []string) ([]common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.SplitTransaction] id: %s, quantities: %s", id, quantities)
+	tx, err := service.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if tx.IsDeleted() {
+		return nil, errors.New(fmt.Sprintf("Deleted transaction %s can not be split", id))
+	}
+	if tx.GetTransferId() != "" {
+		return nil, errors.New(fmt.Sprintf("Transaction %s is a matched transfer and can not be split", id))
+	}
+	if len(quantities) < 2 {
+		return nil, errors.New("At least two quantities are required to split a transaction")
+	}
+	quantity, _ := decimal.NewFromString(tx.GetQuantity())
+	parts := make([]decimal.Decimal, len(quantities))
+	sum := decimal.Zero
+	for i, q := range quantities {
+		part, err := decimal.NewFromString(strings.TrimSpace(q))
+		if err != nil || !part.IsPositive() {
+			return nil, errors.New(fmt.Sprintf("Invalid split quantity: %s", q))
+		}
+		parts[i] = part
+		sum = sum.Add(part)
+	}
+	if !sum.Equal(quantity) {
+		return nil, errors.New(fmt.Sprintf("Split quantities total %s, expected %s", sum, quantity))
+	}
+	var split []common.Transaction
+	allocated := make(map[string]decimal.Decimal)
+	for i, part := range parts {
+		splitTx := cloneTransaction(tx)
+		splitTx.Id = fmt.Sprintf("%s-split-%d", id, i+1)
+		splitTx.Quantity = part.String()
+		amounts := map[string]*string{
+			"fiat_quantity": &splitTx.FiatQuantity,
+			"fee":           &splitTx.Fee,
+			"fiat_fee":      &splitTx.FiatFee,
+			"total":         &splitTx.Total,
+			"fiat_total":    &splitTx.FiatTotal}
+		for name, amount := range amounts {
+			if *amount == "" {
+				continue
+			}
+			value, _ := decimal.NewFromString(*amount)
+			share := value.Mul(part).Div(quantity)
+			if i == len(parts)-1 {
+				share = value.Sub(allocated[name])
+			}
+			allocated[name] = allocated[name].Add(share)
+			*amount = share.String()
+		}
+		if err := service.saveTransaction(common.TX_REVISION_SPLIT, nil, splitTx); err != nil {
+			return nil, err
+		}
+		split = append(split, splitTx)
+	}
+	original := cloneTransaction(tx)
+	original.Deleted = true
+	if err := service.saveTransaction(common.TX_REVISION_SPLIT, tx, original); err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+// DeleteTransaction flags a transaction as deleted so it is left out of the
+// history and reports. A matched transfer is unlinked from its counterpart.
+func (service *TransactionServiceImpl) DeleteTransaction(id string) error {
+	service.ctx.GetLogger().Debugf("[TransactionService.DeleteTransaction] id: %s", id)
+	tx, err := service.GetTransaction(id)
+	if err != nil {
+		return err
+	}
+	if tx.IsDeleted() {
+		return errors.New(fmt.Sprintf("Transaction already deleted: %s", id))
+	}
+	if tx.GetTransferId() != "" {
+		if counterpart, err := service.GetTransaction(tx.GetTransferId()); err == nil {
+			unlinked := cloneTransaction(counterpart)
+			unlinked.TransferId = ""
+			if err := service.saveTransaction(common.TX_REVISION_UPDATE, counterpart, unlinked); err != nil {
+				return err
+			}
+		}
+	}
+	deleted := cloneTransaction(tx)
+	deleted.TransferId = ""
+	deleted.Deleted = true
+	return service.saveTransaction(common.TX_REVISION_DELETE, tx, deleted)
+}
+
+// RestoreTransaction undoes a delete. Restoring a transaction that was split
+// undoes the split: the original is restored and its parts deleted together so
+// the quantity is never counted twice.
+func (service *TransactionServiceImpl) RestoreTransaction(id string) (common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.RestoreTransaction] id: %s", id)
+	tx, err := service.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if !tx.IsDeleted() {
+		return nil, errors.New(fmt.Sprintf("Transaction is not deleted: %s", id))
+	}
+	revisions, err := service.revisionDAO.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 && revisions[len(revisions)-1].GetAction() == common.TX_REVISION_SPLIT {
+		return service.undoSplit(tx)
+	}
+	restored := cloneTransaction(tx)
+	restored.Deleted = false
+	if err := service.saveTransaction(common.TX_REVISION_RESTORE, tx, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// undoSplit restores a split transaction and deletes its parts in a single
+// database transaction. Parts that were since split themselves or matched to a
+// transfer have to be restored or deleted first.
+func (service *TransactionServiceImpl) undoSplit(tx common.Transaction) (common.Transaction, error) {
+	revisions, err := service.GetRevisions("")
+	if err != nil {
+		return nil, err
+	}
+	lastAction := make(map[string]string)
+	for _, revision := range revisions {
+		lastAction[revision.GetTransactionId()] = revision.GetAction()
+	}
+	restored := cloneTransaction(tx)
+	restored.Deleted = false
+	transactions := []entity.TransactionEntity{service.mapper.MapTransactionDtoToEntity(restored)}
+	changes := []entity.TransactionRevisionEntity{service.newRevision(common.TX_REVISION_RESTORE, tx, restored)}
+	prefix := fmt.Sprintf("%s-split-", tx.GetId())
+	for _, revision := range revisions {
+		if revision.GetAction() != common.TX_REVISION_SPLIT || revision.GetPrevious() != nil ||
+			!strings.HasPrefix(revision.GetTransactionId(), prefix) {
+			continue
+		}
+		part, err := service.GetTransaction(revision.GetTransactionId())
+		if err != nil {
+			return nil, err
+		}
+		if part.IsDeleted() {
+			if lastAction[part.GetId()] == common.TX_REVISION_SPLIT {
+				return nil, errors.New(fmt.Sprintf("Part %s of transaction %s was split, restore it first",
+					part.GetId(), tx.GetId()))
+			}
+			continue
+		}
+		if part.GetTransferId() != "" {
+			return nil, errors.New(fmt.Sprintf("Part %s of transaction %s is a matched transfer, delete it first",
+				part.GetId(), tx.GetId()))
+		}
+		deleted := cloneTransaction(part)
+		deleted.Deleted = true
+		transactions = append(transactions, service.mapper.MapTransactionDtoToEntity(deleted))
+		changes = append(changes, service.newRevision(common.TX_REVISION_DELETE, part, deleted))
+	}
+	if err := service.revisionDAO.Save(transactions, changes); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// GetRevisions returns the changes made to a transaction, oldest first, or the
+// changes made to every transaction when no id is given.
+func (service *TransactionServiceImpl) GetRevisions(transactionId string) ([]common.TransactionRevision, error) {
+	entities, err := service.revisionDAO.Find(transactionId)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]common.TransactionRevision, len(entities))
+	for i, entity := range entities {
+		revisions[i] = service.mapper.MapTransactionRevisionEntityToDto(&entity)
+	}
+	return revisions, nil
+}
+
+// GetHistoryAsOf returns the transaction history as it was right after the
+// given revision was made, so reports can be regenerated from an earlier edit
+// state. Transactions that were never edited are included as they are now.
+func (service *TransactionServiceImpl) GetHistoryAsOf(revision uint, order string) ([]common.Transaction, error) {
+	service.ctx.GetLogger().Debugf("[TransactionService.GetHistoryAsOf] Retrieving transaction history for %s as of revision %d in %s order.",
+		service.ctx.GetUser().GetUsername(), revision, order)
+	entities, err := service.dao.Find(order)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := service.GetRevisions("")
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]common.Transaction)
+	for _, r := range revisions {
+		id := r.GetTransactionId()
+		if r.GetId() <= revision {
+			states[id] = r.GetCurrent()
+		} else if _, revised := states[id]; !revised {
+			states[id] = r.GetPrevious()
+		}
+	}
+	var transactions []common.Transaction
+	for _, entity := range entities {
+		if _, revised := states[entity.GetId()]; !revised {
+			transactions = append(transactions, service.mapper.MapTransactionEntityToDto(&entity))
+		}
+	}
+	for _, tx := range states {
+		if tx != nil && !tx.IsDeleted() {
+			transactions = append(transactions, tx)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if order == "desc" {
+			a, b = b, a
+		}
+		if a.GetDate().Equal(b.GetDate()) {
+			return a.GetId() < b.GetId()
+		}
+		return a.GetDate().Before(b.GetDate())
+	})
+	return transactions, nil
+}
+
+// saveTransaction persists a change to a transaction and records it as a
+// revision. Previous is nil when the change creates the transaction.
+func (service *TransactionServiceImpl) saveTransaction(action string, previous, current common.Transaction) error {
+	entity := service.mapper.MapTransactionDtoToEntity(current)
+	if previous == nil {
+		if err := service.dao.Create(entity); err != nil {
+			return err
+		}
+	} else if err := service.dao.Save(entity); err != nil {
+		return err
+	}
+	return service.revisionDAO.Create(service.newRevision(action, previous, current))
+}
+
+func (service *TransactionServiceImpl) newRevision(action string, previous, current common.Transaction) entity.TransactionRevisionEntity {
+	return service.mapper.MapTransactionRevisionDtoToEntity(&dto.TransactionRevisionDTO{
+		TransactionId: current.GetId(),
+		Action:        action,
+		Date:          time.Now(),
+		Previous:      previous,
+		Current:       current})
+}
+
+// cloneTransaction returns a copy of the transaction that can be changed
+// without affecting the original.
+func cloneTransaction(tx common.Transaction) *dto.TransactionDTO {
+	clone := &dto.TransactionDTO{
+		Id:                     tx.GetId(),
+		Date:                   tx.GetDate(),
+		MarketPair:             copyCurrencyPair(tx.GetMarketPair()),
+		CurrencyPair:           copyCurrencyPair(tx.GetCurrencyPair()),
+		Type:                   tx.GetType(),
+		Category:               tx.GetCategory(),
+		Network:                tx.GetNetwork(),
+		NetworkDisplayName:     tx.GetNetworkDisplayName(),
+		Quantity:               tx.GetQuantity(),
+		QuantityCurrency:       tx.GetQuantityCurrency(),
+		FiatQuantity:           tx.GetFiatQuantity(),
+		FiatQuantityCurrency:   tx.GetFiatQuantityCurrency(),
+		Price:                  tx.GetPrice(),
+		PriceCurrency:          tx.GetPriceCurrency(),
+		FiatPrice:              tx.GetFiatPrice(),
+		FiatPriceCurrency:      tx.GetFiatPriceCurrency(),
+		QuoteFiatPrice:         tx.GetQuoteFiatPrice(),
+		QuoteFiatPriceCurrency: tx.GetQuoteFiatPriceCurrency(),
+		Fee:                    tx.GetFee(),
+		FeeCurrency:            tx.GetFeeCurrency(),
+		FiatFee:                tx.GetFiatFee(),
+		FiatFeeCurrency:        tx.GetFiatFeeCurrency(),
+		Total:                  tx.GetTotal(),
+		TotalCurrency:          tx.GetTotalCurrency(),
+		FiatTotal:              tx.GetFiatTotal(),
+		FiatTotalCurrency:      tx.GetFiatTotalCurrency(),
+		TransferId:             tx.GetTransferId(),
+		Deleted:                tx.IsDeleted()}
+	if clone.MarketPair == nil {
+		clone.MarketPair = copyCurrencyPair(clone.CurrencyPair)
+	}
+	return clone
+}
+
+func copyCurrencyPair(currencyPair *common.CurrencyPair) *common.CurrencyPair {
+	if currencyPair == nil {
+		return nil
+	}
+	pair := *currencyPair
+	return &pair
+}
+
+func validateTransaction(tx common.Transaction) error {
+	trade := false
+	switch tx.GetType() {
+	case common.BUY_ORDER_TYPE, common.SELL_ORDER_TYPE:
+		trade = true
+	case common.DEPOSIT_ORDER_TYPE, common.WITHDRAWAL_ORDER_TYPE:
+	default:
+		return errors.New(fmt.Sprintf("Invalid transaction type: %s", tx.GetType()))
+	}
+	if tx.GetDate().IsZero() {
+		return errors.New("Transaction date required")
+	}
+	currencyPair := tx.GetCurrencyPair()
+	if currencyPair == nil || currencyPair.Base == "" {
+		return errors.New("Transaction currency required")
+	}
+	if trade && currencyPair.Quote == "" {
+		return errors.New("Trades require a quote currency")
+	}
+	quantity, err := decimal.NewFromString(tx.GetQuantity())
+	if err != nil || !quantity.IsPositive() {
+		return errors.New(fmt.Sprintf("Invalid quantity: %s", tx.GetQuantity()))
+	}
+	amounts := []struct {
+		name  string
+		value string
+	}{
+		{"fiat quantity", tx.GetFiatQuantity()},
+		{"price", tx.GetPrice()},
+		{"fiat price", tx.GetFiatPrice()},
+		{"quote fiat price", tx.GetQuoteFiatPrice()},
+		{"fee", tx.GetFee()},
+		{"fiat fee", tx.GetFiatFee()},
+		{"total", tx.GetTotal()},
+		{"fiat total", tx.GetFiatTotal()}}
+	for _, amount := range amounts {
+		if amount.value == "" {
+			continue
+		}
+		value, err := decimal.NewFromString(amount.value)
+		if err != nil || value.IsNegative() {
+			return errors.New(fmt.Sprintf("Invalid %s: %s", amount.name, amount.value))
+		}
+	}
+	if trade {
+		price, _ := decimal.NewFromString(tx.GetPrice())
+		total, _ := decimal.NewFromString(tx.GetTotal())
+		if !price.IsPositive() && !total.IsPositive() {
+			return errors.New("Trades require a price or total")
+		}
+	}
+	if tx.GetCategory() != "" && !transactionCategories[tx.GetCategory()] {
+		return errors.New(fmt.Sprintf("Invalid transaction category: %s", tx.GetCategory()))
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// +build integration
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertDecimal(t *testing.T, expected, actual string) {
+	e, _ := decimal.NewFromString(expected)
+	a, err := decimal.NewFromString(actual)
+	assert.Nil(t, err)
+	assert.True(t, e.Equal(a), "expected %s, got %s", expected, actual)
+}
+
+func TestValidateTransaction(t *testing.T) {
+	tx := &dto.TransactionDTO{
+		Date:         time.Date(2018, 01, 01, 12, 0, 0, 0, time.UTC),
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD"},
+		Type:         common.BUY_ORDER_TYPE,
+		Quantity:     "2",
+		Price:        "1000"}
+	assert.Nil(t, validateTransaction(tx))
+
+	invalid := *tx
+	invalid.Type = "swap"
+	assert.Equal(t, "Invalid transaction type: swap", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.Date = time.Time{}
+	assert.Equal(t, "Transaction date required", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.CurrencyPair = &common.CurrencyPair{Base: "BTC"}
+	assert.Equal(t, "Trades require a quote currency", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.Quantity = "-1"
+	assert.Equal(t, "Invalid quantity: -1", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.Fee = "abc"
+	assert.Equal(t, "Invalid fee: abc", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.Price = ""
+	assert.Equal(t, "Trades require a price or total", validateTransaction(&invalid).Error())
+
+	invalid = *tx
+	invalid.Category = "swap"
+	assert.Equal(t, "Invalid transaction category: swap", validateTransaction(&invalid).Error())
+
+	deposit := *tx
+	deposit.Type = common.DEPOSIT_ORDER_TYPE
+	deposit.CurrencyPair = &common.CurrencyPair{Base: "BTC"}
+	deposit.Price = ""
+	deposit.Category = common.TX_CATEGORY_INCOME
+	assert.Nil(t, validateTransaction(&deposit))
+}
+
+func TestTransactionService_EditHistory(t *testing.T) {
+	ctx := NewIntegrationTestContext()
+	transactionDAO := dao.NewTransactionDAO(ctx)
+	transactionMapper := mapper.NewTransactionMapper(ctx)
+	transactionService := NewTransactionService(ctx, transactionDAO, dao.NewTransactionRevisionDAO(ctx), transactionMapper,
+		nil, nil, nil, nil, nil)
+
+	// Imported transactions without revisions are part of every revision's history
+	imported := &dto.TransactionDTO{
+		Id:           "gdax-1",
+		Date:         time.Date(2017, 06, 01, 12, 0, 0, 0, time.UTC),
+		MarketPair:   &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD", LocalCurrency: "USD"},
+		Type:         common.DEPOSIT_ORDER_TYPE,
+		Category:     common.TX_CATEGORY_DEPOSIT,
+		Network:      "gdax",
+		Quantity:     "1",
+		FiatTotal:    "2500.00"}
+	assert.Nil(t, transactionDAO.Create(transactionMapper.MapTransactionDtoToEntity(imported)))
+
+	created, err := transactionService.CreateTransaction(&dto.TransactionDTO{
+		Date:         time.Date(2018, 01, 01, 12, 0, 0, 0, time.UTC),
+		CurrencyPair: &common.CurrencyPair{Base: "btc", Quote: "usd"},
+		Type:         common.BUY_ORDER_TYPE,
+		Quantity:     "2",
+		Price:        "1000",
+		Fee:          "10"})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(created.GetId(), "manual-"))
+	assert.Equal(t, MANUAL_TRANSACTION_NETWORK, created.GetNetwork())
+	assert.Equal(t, common.TX_CATEGORY_TRADE, created.GetCategory())
+	assert.Equal(t, "BTC", created.GetCurrencyPair().Base)
+	assert.Equal(t, "USD", created.GetFeeCurrency())
+	assertDecimal(t, "2000", created.GetTotal())
+	assert.Equal(t, "2000.00", created.GetFiatTotal())
+	assert.Equal(t, "10.00", created.GetFiatFee())
+
+	_, err = transactionService.CreateTransaction(&dto.TransactionDTO{
+		Date:         time.Date(2018, 01, 01, 12, 0, 0, 0, time.UTC),
+		CurrencyPair: &common.CurrencyPair{Base: "BTC", Quote: "USD"},
+		Type:         common.BUY_ORDER_TYPE,
+		Quantity:     "0"})
+	assert.Equal(t, "Invalid quantity: 0", err.Error())
+
+	id := created.GetId()
+	edit := cloneTransaction(created)
+	edit.Fee = "12"
+	edit.FiatFee = "12.00"
+	updated, err := transactionService.UpdateTransaction(edit)
+	assert.Nil(t, err)
+	assert.Equal(t, "12", updated.GetFee())
+	persisted, err := transactionService.GetTransaction(id)
+	assert.Nil(t, err)
+	assert.Equal(t, "12.00", persisted.GetFiatFee())
+
+	split, err := transactionService.SplitTransaction(id, []string{"0.5", "1"})
+	assert.Equal(t, "Split quantities total 1.5, expected 2", err.Error())
+	split, err = transactionService.SplitTransaction(id, []string{"0.5", "1.5"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(split))
+	assert.Equal(t, id+"-split-1", split[0].GetId())
+	assert.Equal(t, "0.5", split[0].GetQuantity())
+	assertDecimal(t, "500", split[0].GetTotal())
+	assertDecimal(t, "3", split[0].GetFee())
+	assertDecimal(t, "1500", split[1].GetTotal())
+	assertDecimal(t, "9", split[1].GetFee())
+	assertDecimal(t, "1500", split[1].GetFiatTotal())
+
+	history, err := transactionService.GetHistory("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(history))
+
+	assert.Nil(t, transactionService.DeleteTransaction(split[1].GetId()))
+	assert.Equal(t, "Transaction already deleted: "+split[1].GetId(),
+		transactionService.DeleteTransaction(split[1].GetId()).Error())
+	history, err = transactionService.GetHistory("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history))
+
+	restored, err := transactionService.RestoreTransaction(split[1].GetId())
+	assert.Nil(t, err)
+	assert.False(t, restored.IsDeleted())
+
+	revisions, err := transactionService.GetRevisions(id)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, common.TX_REVISION_CREATE, revisions[0].GetAction())
+	assert.Nil(t, revisions[0].GetPrevious())
+	assert.Equal(t, common.TX_REVISION_UPDATE, revisions[1].GetAction())
+	assert.Equal(t, "10", revisions[1].GetPrevious().GetFee())
+	assert.Equal(t, "12", revisions[1].GetCurrent().GetFee())
+	assert.Equal(t, common.TX_REVISION_SPLIT, revisions[2].GetAction())
+	assert.True(t, revisions[2].GetCurrent().IsDeleted())
+
+	// Revisions: 1 create, 2 update, 3 and 4 split parts, 5 split original,
+	// 6 delete part 2 and 7 restore part 2
+	all, err := transactionService.GetRevisions("")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, len(all))
+
+	asOf, err := transactionService.GetHistoryAsOf(1, "asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(asOf))
+	assert.Equal(t, "gdax-1", asOf[0].GetId())
+	assert.Equal(t, "10", asOf[1].GetFee())
+
+	asOf, err = transactionService.GetHistoryAsOf(2, "asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(asOf))
+	assert.Equal(t, "12", asOf[1].GetFee())
+
+	asOf, err = transactionService.GetHistoryAsOf(5, "desc")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(asOf))
+	assert.Equal(t, id+"-split-2", asOf[0].GetId())
+	assert.Equal(t, "gdax-1", asOf[2].GetId())
+
+	asOf, err = transactionService.GetHistoryAsOf(6, "asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(asOf))
+	assert.Equal(t, id+"-split-1", asOf[1].GetId())
+
+	// Restoring the split original deletes its parts: 8 restore original,
+	// 9 delete part 1 and 10 delete part 2
+	restored, err = transactionService.RestoreTransaction(id)
+	assert.Nil(t, err)
+	assert.False(t, restored.IsDeleted())
+	assert.Equal(t, "2", restored.GetQuantity())
+	history, err = transactionService.GetHistory("asc")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, "gdax-1", history[0].GetId())
+	assert.Equal(t, id, history[1].GetId())
+	assert.Equal(t, "12", history[1].GetFee())
+
+	all, err = transactionService.GetRevisions("")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(all))
+	assert.Equal(t, common.TX_REVISION_RESTORE, all[7].GetAction())
+	assert.Equal(t, common.TX_REVISION_DELETE, all[8].GetAction())
+	assert.Equal(t, id+"-split-1", all[8].GetTransactionId())
+	assert.Equal(t, common.TX_REVISION_DELETE, all[9].GetAction())
+
+	_, err = transactionService.RestoreTransaction(id)
+	assert.Equal(t, "Transaction is not deleted: "+id, err.Error())
+
+	CleanupIntegrationTest()
+}
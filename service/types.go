@@ -6,6 +6,7 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
 	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dto"
 	"github.com/jeremyhahn/tradebot/entity"
 	"github.com/jeremyhahn/tradebot/mapper"
 	"github.com/shopspring/decimal"
@@ -109,19 +110,20 @@ type ChartService interface {
 
 type TradeService interface {
 	GetMapper() mapper.TradeMapper
-	Save(dto common.Trade)
+	Save(dto common.Trade) (common.Trade, error)
 	GetLastTrade(chart common.Chart) common.Trade
 	GetTradeHistory() []common.Transaction
 	GetTransactionMapper() mapper.TransactionMapper
 }
 
 type ProfitService interface {
-	Save(profit common.Profit)
+	Save(profit common.Profit) error
 	Find()
 }
 
 type ExchangeService interface {
 	CreateExchange(exchangeName string) (common.Exchange, error)
+	CreatePaperExchange(exchangeName string) (common.Exchange, error)
 	GetDisplayNames() ([]string, error)
 	GetExchanges() ([]common.Exchange, error)
 	GetExchange(name string) (common.Exchange, error)
@@ -135,8 +137,77 @@ type TransactionService interface {
 	GetDepositHistory() []common.Transaction
 	GetWithdrawalHistory() []common.Transaction
 	GetImportedTransactions() []common.Transaction
+	GetHistoryAsOf(revision uint, order string) ([]common.Transaction, error)
+	GetTransaction(id string) (common.Transaction, error)
+	CreateTransaction(tx common.Transaction) (common.Transaction, error)
+	UpdateTransaction(tx common.Transaction) (common.Transaction, error)
+	SplitTransaction(id string, quantities []string) ([]common.Transaction, error)
+	DeleteTransaction(id string) error
+	RestoreTransaction(id string) (common.Transaction, error)
+	GetRevisions(transactionId string) ([]common.TransactionRevision, error)
 	UpdateCategory(id, category string) error
 	ImportCSV(file, exchange string) ([]common.Transaction, error)
+	ImportUniversalCSV(file, format, network string) ([]common.Transaction, []CSVRowError, error)
+	ImportTemplateCSV(file, templateName string) ([]common.Transaction, []CSVRowError, error)
+	ExportUniversalCSV(filename, format string) error
 	Synchronize() ([]common.Transaction, error)
+	MatchTransfers() ([]common.Transfer, error)
 	//GetSourceTransaction(targetTx common.Transaction, transactions *[]common.Transaction) (common.Transaction, error)
 }
+
+type ForkEventService interface {
+	GetMapper() mapper.ForkEventMapper
+	GetForkEvents() ([]common.ForkEvent, error)
+	CreateForkEvent(event common.ForkEvent) ([]common.Transaction, error)
+	DeleteForkEvent(id uint) error
+}
+
+type PaperAccountService interface {
+	GetMapper() mapper.PaperAccountMapper
+	GetAccount(exchange string) (common.PaperAccount, error)
+	SaveAccount(account common.PaperAccount) (common.PaperAccount, error)
+	ResetAccount(exchange string) error
+	GetOrders(exchange string) ([]common.Order, error)
+	GetOrder(exchange, orderId string) (common.Order, error)
+	PlaceOrder(exchange string, order common.Order, marketPrice decimal.Decimal) (common.Order, error)
+	FillOrders(exchange string, currencyPair *common.CurrencyPair, marketPrice decimal.Decimal) ([]common.Order, error)
+	CancelOrder(exchange, orderId string) (common.Order, error)
+}
+
+type BacktestService interface {
+	GetChart(chartId uint) (common.Chart, error)
+	LoadCandlesticks(chart common.Chart, options *dto.BacktestOptionsDTO) ([]common.Candlestick, error)
+	Backtest(chartId uint, options *dto.BacktestOptionsDTO) (*dto.BacktestResultDTO, error)
+	Run(chart common.Chart, candles []common.Candlestick, options *dto.BacktestOptionsDTO) (*dto.BacktestResultDTO, error)
+}
+
+type OptimizerService interface {
+	Optimize(chartId uint, options *dto.OptimizationOptionsDTO) (*dto.OptimizationResultDTO, error)
+	SaveParameters(chartId uint, parameters *dto.OptimizationParameterSetDTO) (common.Chart, error)
+}
+
+type RiskService interface {
+	GetLimits() ([]common.RiskLimit, error)
+	SaveLimit(limit common.RiskLimit) (common.RiskLimit, error)
+	DeleteLimit(chartId uint) error
+	GetKillSwitch() (string, error)
+	SetKillSwitch(mode string) error
+	GetPosition(chart common.Chart) (decimal.Decimal, error)
+	GetRejections(limit int) ([]common.RiskRejection, error)
+	Evaluate(chart common.Chart, tradeType string, price, quantity decimal.Decimal, balances []common.Coin) (decimal.Decimal, error)
+}
+
+type CSVTemplateService interface {
+	GetMapper() mapper.CSVTemplateMapper
+	GetTemplates() ([]common.CSVTemplate, error)
+	GetTemplate(name string) (common.CSVTemplate, error)
+	SaveTemplate(template common.CSVTemplate) (common.CSVTemplate, error)
+	DeleteTemplate(name string) error
+}
+
+type LotSelectionService interface {
+	GetMapper() mapper.LotSelectionMapper
+	GetSelections() ([]common.LotSelection, error)
+	GetSelectionsFor(saleTransactionId string) ([]common.LotSelection, error)
+	SetSelections(saleTransactionId string, selections []common.LotSelection) error
+}
@@ -126,6 +126,11 @@ func CreateIntegrationTestContext(dotEnvDir, appRoot string) common.Context {
 		Filename: "binance.so",
 		Version:  "0.0.1a",
 		Type:     common.EXCHANGE_PLUGIN_TYPE})
+	pluginDAO.Create(&entity.Plugin{
+		Name:     "Paper",
+		Filename: "paper.so",
+		Version:  "0.0.1a",
+		Type:     common.EXCHANGE_PLUGIN_TYPE})
 
 	pluginDAO.Create(&entity.Plugin{
 		Name:     "BTC",
@@ -28,4 +28,5 @@ type Transaction struct {
 	TotalCurrency          string               `json:"total_currency"`
 	FiatTotal              string               `json:"fiat_total"`
 	FiatTotalCurrency      string               `json:"fiat_total_currency"`
+	TransferId             string               `json:"transfer_id"`
 }
@@ -58,12 +58,24 @@ export default class AuthService {
       })
     }
 
-    exportTransactions() {
-      return this.fetch(`${this.domain}/transactions/export`, {
-          method: 'GET'
-      }).then(res => {
-          return Promise.resolve(res)
+    exportTransactions(year, method) {
+      const params = new URLSearchParams({
+        year: year,
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone
+      })
+      if(method) {
+        params.append('method', method)
+      }
+      const headers = {}
+      if(this.loggedIn()) {
+          headers['Authorization'] = 'Bearer ' + this.getToken()
+      }
+      return fetch(`${this.domain}/transactions/export?${params.toString()}`, {
+          method: 'GET',
+          headers
       })
+      .then(this._checkStatus)
+      .then(response => response.text())
     }
 
     importOrders(formData) {
@@ -161,10 +161,13 @@ class Transactions extends React.Component {
         "income",
         "gift",
         "mining",
+        "staking",
         "spend",
         "donation",
         "lost",
-        "transfer"
+        "transfer",
+        "fork",
+        "airdrop"
       ],
       selectValues: {}
     }
@@ -222,12 +225,10 @@ class Transactions extends React.Component {
   }
 
   exportTransactions() {
-    this.Auth.exportTransactions()
-      .then(function (response) {
-        console.log(response);
-        if(response.success) {
-          downloader(response.payload, "8949.csv")
-        }
+    const year = new Date().getFullYear() - 1
+    this.Auth.exportTransactions(year)
+      .then(function (csv) {
+        downloader(csv, `8949-${year}.csv`)
       }.bind(this))
   }
 
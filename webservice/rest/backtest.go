@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type BacktestRestService interface {
+	Backtest(w http.ResponseWriter, r *http.Request)
+}
+
+type BacktestRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	BacktestRestService
+}
+
+func NewBacktestRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) BacktestRestService {
+	return &BacktestRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+// Backtest replays the chart's strategies over the date range posted as JSON
+// and returns the simulated trades, equity curve and summary stats.
+func (restService *BacktestRestServiceImpl) Backtest(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	chartId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid chart id"})
+		return
+	}
+	var options dto.BacktestOptionsDTO
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[BacktestRestService.Backtest] chart: %d, options: %+v", chartId, options)
+	result, err := restService.createBacktestService(ctx).Backtest(uint(chartId), &options)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: result})
+}
+
+func (restService *BacktestRestServiceImpl) createBacktestService(ctx common.Context) service.BacktestService {
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, dao.NewUserDAO(ctx), mapper.NewUserMapper(),
+		mapper.NewUserExchangeMapper(), pluginService)
+	return service.NewBacktestService(ctx, dao.NewChartDAO(ctx), dao.NewPriceHistoryDAO(ctx), exchangeService,
+		pluginService, mapper.NewChartMapper(ctx), mapper.NewPriceHistoryMapper())
+}
@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type CSVTemplateRestService interface {
+	GetTemplates(w http.ResponseWriter, r *http.Request)
+	GetTemplate(w http.ResponseWriter, r *http.Request)
+	SaveTemplate(w http.ResponseWriter, r *http.Request)
+	DeleteTemplate(w http.ResponseWriter, r *http.Request)
+}
+
+type CSVTemplateRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	CSVTemplateRestService
+}
+
+func NewCSVTemplateRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) CSVTemplateRestService {
+	return &CSVTemplateRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *CSVTemplateRestServiceImpl) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[CSVTemplateRestService.GetTemplates]")
+	templates, err := restService.createCSVTemplateService(ctx).GetTemplates()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: templates})
+}
+
+// GetTemplate returns a template, or with the download parameter, streams it as
+// a JSON file that can be shared with other users and saved as their own.
+func (restService *CSVTemplateRestServiceImpl) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	name := mux.Vars(r)["name"]
+	ctx.GetLogger().Debugf("[CSVTemplateRestService.GetTemplate] name: %s, download: %s", name, r.FormValue("download"))
+	template, err := restService.createCSVTemplateService(ctx).GetTemplate(name)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	if r.FormValue("download") == "" {
+		restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+			Success: true,
+			Payload: template})
+		return
+	}
+	shared := *template.(*dto.CSVTemplateDTO)
+	shared.Id, shared.UserId = 0, 0
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.json\"", name))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(&shared)
+}
+
+// SaveTemplate saves the template posted as JSON, replacing the user's template
+// of the same name. Shared templates are imported the same way.
+func (restService *CSVTemplateRestServiceImpl) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var template dto.CSVTemplateDTO
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[CSVTemplateRestService.SaveTemplate] template: %+v", template)
+	saved, err := restService.createCSVTemplateService(ctx).SaveTemplate(&template)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: saved})
+}
+
+func (restService *CSVTemplateRestServiceImpl) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	name := mux.Vars(r)["name"]
+	ctx.GetLogger().Debugf("[CSVTemplateRestService.DeleteTemplate] name: %s", name)
+	if err := restService.createCSVTemplateService(ctx).DeleteTemplate(name); err != nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: name})
+}
+
+func (restService *CSVTemplateRestServiceImpl) createCSVTemplateService(ctx common.Context) service.CSVTemplateService {
+	return service.NewCSVTemplateService(ctx, dao.NewCSVTemplateDAO(ctx), mapper.NewCSVTemplateMapper(ctx))
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type ForkEventRestService interface {
+	GetForkEvents(w http.ResponseWriter, r *http.Request)
+	CreateForkEvent(w http.ResponseWriter, r *http.Request)
+	DeleteForkEvent(w http.ResponseWriter, r *http.Request)
+}
+
+type ForkEventRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	ForkEventRestService
+}
+
+func NewForkEventRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) ForkEventRestService {
+	return &ForkEventRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *ForkEventRestServiceImpl) GetForkEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[ForkEventRestService.GetForkEvents]")
+	events, err := restService.createForkEventService(ctx).GetForkEvents()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: events})
+}
+
+func (restService *ForkEventRestServiceImpl) CreateForkEvent(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var event dto.ForkEventDTO
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[ForkEventRestService.CreateForkEvent] event: %+v", event)
+	deposits, err := restService.createForkEventService(ctx).CreateForkEvent(&event)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: deposits})
+}
+
+func (restService *ForkEventRestServiceImpl) DeleteForkEvent(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid fork event id"})
+		return
+	}
+	ctx.GetLogger().Debugf("[ForkEventRestService.DeleteForkEvent] id: %d", id)
+	if err := restService.createForkEventService(ctx).DeleteForkEvent(uint(id)); err != nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: id})
+}
+
+func (restService *ForkEventRestServiceImpl) createForkEventService(ctx common.Context) service.ForkEventService {
+	return service.NewForkEventService(ctx, dao.NewForkEventDAO(ctx), dao.NewTransactionDAO(ctx),
+		mapper.NewForkEventMapper(ctx), mapper.NewTransactionMapper(ctx))
+}
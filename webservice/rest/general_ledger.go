@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jeremyhahn/tradebot/accounting"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type GeneralLedgerRestService interface {
+	GetAccounts(w http.ResponseWriter, r *http.Request)
+	GetTrialBalance(w http.ResponseWriter, r *http.Request)
+	GetRegister(w http.ResponseWriter, r *http.Request)
+	GetBalanceSheet(w http.ResponseWriter, r *http.Request)
+}
+
+type GeneralLedgerRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	GeneralLedgerRestService
+}
+
+func NewGeneralLedgerRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) GeneralLedgerRestService {
+	return &GeneralLedgerRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *GeneralLedgerRestServiceImpl) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[GeneralLedgerRestService.GetAccounts] method: %s", r.FormValue("method"))
+	ledger, err := restService.createGeneralLedger(ctx, r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: ledger.GetAccounts()})
+}
+
+func (restService *GeneralLedgerRestServiceImpl) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[GeneralLedgerRestService.GetTrialBalance] date: %s, method: %s",
+		r.FormValue("date"), r.FormValue("method"))
+	date, err := parseLedgerDate(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ledger, err := restService.createGeneralLedger(ctx, r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: ledger.TrialBalance(date)})
+}
+
+func (restService *GeneralLedgerRestServiceImpl) GetRegister(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	account := r.FormValue("account")
+	ctx.GetLogger().Debugf("[GeneralLedgerRestService.GetRegister] account: %s, start: %s, end: %s, method: %s",
+		account, r.FormValue("start"), r.FormValue("end"), r.FormValue("method"))
+	if account == "" {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Account required"})
+		return
+	}
+	start, end := time.Time{}, time.Now()
+	if r.FormValue("start") != "" || r.FormValue("end") != "" {
+		location, err := parseLocation(r)
+		if err == nil {
+			start, end, err = parseDateRange(r, location)
+		}
+		if err != nil {
+			restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+	}
+	ledger, err := restService.createGeneralLedger(ctx, r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: ledger.Register(account, start, end)})
+}
+
+func (restService *GeneralLedgerRestServiceImpl) GetBalanceSheet(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[GeneralLedgerRestService.GetBalanceSheet] date: %s, method: %s",
+		r.FormValue("date"), r.FormValue("method"))
+	date, err := parseLedgerDate(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ledger, err := restService.createGeneralLedger(ctx, r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: ledger.BalanceSheet(date)})
+}
+
+// createGeneralLedger posts the user's full transaction history, relieving
+// disposals at the cost of the lots chosen by the requested method.
+func (restService *GeneralLedgerRestServiceImpl) createGeneralLedger(ctx common.Context, r *http.Request) (*accounting.GeneralLedger, error) {
+	txService, err := newTransactionService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		return nil, err
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		return nil, err
+	}
+	return accounting.NewGeneralLedger(ctx, report), nil
+}
+
+// parseLedgerDate returns the last instant of the requested date in the
+// requested time zone, or now when no date is given.
+func parseLedgerDate(r *http.Request) (time.Time, error) {
+	if r.FormValue("date") == "" {
+		return time.Now(), nil
+	}
+	location, err := parseLocation(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	date, err := time.ParseInLocation(EXPORT_DATE_FORMAT, r.FormValue("date"), location)
+	if err != nil {
+		return date, errors.New(fmt.Sprintf("Invalid date: %s", r.FormValue("date")))
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,331 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/accounting"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+	"github.com/shopspring/decimal"
+)
+
+type LotRestService interface {
+	GetOpenLots(w http.ResponseWriter, r *http.Request)
+	GetSelections(w http.ResponseWriter, r *http.Request)
+	SetSelections(w http.ResponseWriter, r *http.Request)
+	GetUnrealizedGains(w http.ResponseWriter, r *http.Request)
+	GetHarvestRecommendations(w http.ResponseWriter, r *http.Request)
+}
+
+type LotRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	LotRestService
+}
+
+func NewLotRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) LotRestService {
+	return &LotRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *LotRestServiceImpl) GetOpenLots(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	params := mux.Vars(r)
+	ctx.GetLogger().Debugf("[LotRestService.GetOpenLots] currency: %s", params["currency"])
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, accounting.COST_BASIS_SPECIFIC, r.FormValue("method"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report.Run(time.Time{}, time.Now())
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: report.GetOpenLots(params["currency"])})
+}
+
+func (restService *LotRestServiceImpl) GetSelections(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	params := mux.Vars(r)
+	ctx.GetLogger().Debugf("[LotRestService.GetSelections] sale: %s", params["id"])
+	selections, err := restService.createLotSelectionService(ctx).GetSelectionsFor(params["id"])
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: selections})
+}
+
+func (restService *LotRestServiceImpl) SetSelections(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	params := mux.Vars(r)
+	ctx.GetLogger().Debugf("[LotRestService.SetSelections] sale: %s", params["id"])
+	if params["id"] == "" {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Transaction id required"})
+		return
+	}
+	var selectionDTOs []dto.LotSelectionDTO
+	if err := json.NewDecoder(r.Body).Decode(&selectionDTOs); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	selections := make([]common.LotSelection, len(selectionDTOs))
+	for i := range selectionDTOs {
+		selections[i] = &selectionDTOs[i]
+	}
+	lotSelectionService := restService.createLotSelectionService(ctx)
+	if err := lotSelectionService.SetSelections(params["id"], selections); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	saved, err := lotSelectionService.GetSelectionsFor(params["id"])
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: saved})
+}
+
+func (restService *LotRestServiceImpl) GetUnrealizedGains(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[LotRestService.GetUnrealizedGains] method: %s", r.FormValue("method"))
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	now := time.Now()
+	if err := seedOpeningLots(ctx, report, now.Year(), now.Location()); err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report.Run(accounting.TaxYearBounds(now.Year(), now.Location()))
+	gains := accounting.NewUnrealizedGainsReport(ctx, service.NewMarketCapService(ctx)).
+		Run(report.GetOpenLotsByCurrency(), now)
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: gains})
+}
+
+func (restService *LotRestServiceImpl) GetHarvestRecommendations(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[LotRestService.GetHarvestRecommendations] method: %s, target: %s",
+		r.FormValue("method"), r.FormValue("target"))
+	target, err := parseDecimal(r.FormValue("target"), "0")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	shortTermRate, err := parseDecimal(r.FormValue("short_rate"), accounting.DEFAULT_SHORT_TERM_RATE)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	longTermRate, err := parseDecimal(r.FormValue("long_rate"), accounting.DEFAULT_LONG_TERM_RATE)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	now := time.Now()
+	ledger := accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+	if ledger.IsClosed(now.Year()) {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: fmt.Sprintf("Tax year %d is closed", now.Year())})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	realized, err := ledger.GetForm8949(now.Year(), report)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	unrealized := accounting.NewUnrealizedGainsReport(ctx, service.NewMarketCapService(ctx)).
+		Run(report.GetOpenLotsByCurrency(), now)
+	plan := accounting.NewTaxLossHarvester(ctx, shortTermRate, longTermRate).
+		Recommend(realized, unrealized, target)
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: plan})
+}
+
+func (restService *LotRestServiceImpl) createLotSelectionService(ctx common.Context) service.LotSelectionService {
+	return service.NewLotSelectionService(ctx, dao.NewLotSelectionDAO(ctx), dao.NewTransactionDAO(ctx),
+		mapper.NewLotSelectionMapper(ctx))
+}
+
+func (restService *LotRestServiceImpl) createTransactionService(ctx common.Context) (service.TransactionService, error) {
+	return newTransactionService(ctx)
+}
+
+// newCostBasisReport creates a report for the requested cost basis method. The
+// specific identification method loads the user's lot selections and uses
+// fallback for any sale without one. Income receipts are valued at their fair
+// market value on the day received and forked coins at the basis of the
+// user's fork events.
+func newCostBasisReport(ctx common.Context, transactions []common.Transaction, method, fallback string) (*accounting.Report, error) {
+	var report *accounting.Report
+	var err error
+	if method == accounting.COST_BASIS_SPECIFIC {
+		lotSelectionService := service.NewLotSelectionService(ctx, dao.NewLotSelectionDAO(ctx),
+			dao.NewTransactionDAO(ctx), mapper.NewLotSelectionMapper(ctx))
+		var selections []common.LotSelection
+		if selections, err = lotSelectionService.GetSelections(); err != nil {
+			return nil, err
+		}
+		report, err = accounting.NewSpecificIdentificationReport(ctx, transactions, selections, fallback)
+	} else {
+		report, err = accounting.NewReport(ctx, transactions, method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fiatPriceService, err := newFiatPriceService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.SetFiatPriceService(fiatPriceService)
+	forkEvents, err := service.NewForkEventService(ctx, dao.NewForkEventDAO(ctx), dao.NewTransactionDAO(ctx),
+		mapper.NewForkEventMapper(ctx), mapper.NewTransactionMapper(ctx)).GetForkEvents()
+	if err != nil {
+		return nil, err
+	}
+	report.SetForkEvents(forkEvents)
+	return report, nil
+}
+
+// seedOpeningLots starts the report from the lots carried forward by the
+// latest tax year closed before year, with tax years bounded in location.
+func seedOpeningLots(ctx common.Context, report *accounting.Report, year int, location *time.Location) error {
+	ledger := accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+	ledger.SetLocation(location)
+	openingLots, openingDate, err := ledger.GetOpeningLots(year)
+	if err != nil {
+		return err
+	}
+	if openingLots != nil {
+		report.SetOpeningLots(openingLots, openingDate)
+	}
+	return nil
+}
+
+// parseDecimal parses an optional decimal form value.
+func parseDecimal(value, defaultValue string) (decimal.Decimal, error) {
+	if value == "" {
+		value = defaultValue
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, errors.New(fmt.Sprintf("Invalid decimal value: %s", value))
+	}
+	return d, nil
+}
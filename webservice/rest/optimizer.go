@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type OptimizerRestService interface {
+	Optimize(w http.ResponseWriter, r *http.Request)
+	SaveParameters(w http.ResponseWriter, r *http.Request)
+}
+
+type OptimizerRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	OptimizerRestService
+}
+
+func NewOptimizerRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) OptimizerRestService {
+	return &OptimizerRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+// Optimize searches the parameter ranges posted as JSON for one of the chart's
+// strategies and returns the parameter sets ranked by out-of-sample score.
+func (restService *OptimizerRestServiceImpl) Optimize(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	chartId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid chart id"})
+		return
+	}
+	var options dto.OptimizationOptionsDTO
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[OptimizerRestService.Optimize] chart: %d, options: %+v", chartId, options)
+	result, err := restService.createOptimizerService(ctx).Optimize(uint(chartId), &options)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: result})
+}
+
+// SaveParameters applies a parameter set, typically one returned by Optimize,
+// to the chart's strategy and indicators.
+func (restService *OptimizerRestServiceImpl) SaveParameters(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	chartId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid chart id"})
+		return
+	}
+	var parameters dto.OptimizationParameterSetDTO
+	if err := json.NewDecoder(r.Body).Decode(&parameters); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[OptimizerRestService.SaveParameters] chart: %d, parameters: %+v", chartId, parameters)
+	chart, err := restService.createOptimizerService(ctx).SaveParameters(uint(chartId), &parameters)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: chart})
+}
+
+func (restService *OptimizerRestServiceImpl) createOptimizerService(ctx common.Context) service.OptimizerService {
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, dao.NewUserDAO(ctx), mapper.NewUserMapper(),
+		mapper.NewUserExchangeMapper(), pluginService)
+	chartMapper := mapper.NewChartMapper(ctx)
+	backtestService := service.NewBacktestService(ctx, dao.NewChartDAO(ctx), dao.NewPriceHistoryDAO(ctx), exchangeService,
+		pluginService, chartMapper, mapper.NewPriceHistoryMapper())
+	return service.NewOptimizerService(ctx, backtestService, pluginService, dao.NewChartIndicatorDAO(ctx),
+		dao.NewChartStrategyDAO(ctx), chartMapper)
+}
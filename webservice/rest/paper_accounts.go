@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type PaperAccountRestService interface {
+	GetAccount(w http.ResponseWriter, r *http.Request)
+	SaveAccount(w http.ResponseWriter, r *http.Request)
+	ResetAccount(w http.ResponseWriter, r *http.Request)
+	GetOrders(w http.ResponseWriter, r *http.Request)
+}
+
+type PaperAccountRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	PaperAccountRestService
+}
+
+func NewPaperAccountRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) PaperAccountRestService {
+	return &PaperAccountRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *PaperAccountRestServiceImpl) GetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	exchange := mux.Vars(r)["exchange"]
+	ctx.GetLogger().Debugf("[PaperAccountRestService.GetAccount] exchange: %s", exchange)
+	account, err := restService.createPaperAccountService(ctx).GetAccount(exchange)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: account})
+}
+
+// SaveAccount replaces the paper account's fee and slippage rates and balances
+// with the account posted as JSON.
+func (restService *PaperAccountRestServiceImpl) SaveAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var account dto.PaperAccountDTO
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	account.Exchange = mux.Vars(r)["exchange"]
+	ctx.GetLogger().Debugf("[PaperAccountRestService.SaveAccount] account: %+v", account)
+	saved, err := restService.createPaperAccountService(ctx).SaveAccount(&account)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: saved})
+}
+
+func (restService *PaperAccountRestServiceImpl) ResetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	exchange := mux.Vars(r)["exchange"]
+	ctx.GetLogger().Debugf("[PaperAccountRestService.ResetAccount] exchange: %s", exchange)
+	if err := restService.createPaperAccountService(ctx).ResetAccount(exchange); err != nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: exchange})
+}
+
+func (restService *PaperAccountRestServiceImpl) GetOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	exchange := mux.Vars(r)["exchange"]
+	ctx.GetLogger().Debugf("[PaperAccountRestService.GetOrders] exchange: %s", exchange)
+	orders, err := restService.createPaperAccountService(ctx).GetOrders(exchange)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: orders})
+}
+
+func (restService *PaperAccountRestServiceImpl) createPaperAccountService(ctx common.Context) service.PaperAccountService {
+	return service.NewPaperAccountService(ctx, dao.NewPaperAccountDAO(ctx), mapper.NewPaperAccountMapper(ctx))
+}
@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
+	"github.com/jeremyhahn/tradebot/mapper"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type RiskRestService interface {
+	GetLimits(w http.ResponseWriter, r *http.Request)
+	SaveLimit(w http.ResponseWriter, r *http.Request)
+	DeleteLimit(w http.ResponseWriter, r *http.Request)
+	GetKillSwitch(w http.ResponseWriter, r *http.Request)
+	SetKillSwitch(w http.ResponseWriter, r *http.Request)
+	GetRejections(w http.ResponseWriter, r *http.Request)
+}
+
+type RiskRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	RiskRestService
+}
+
+func NewRiskRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) RiskRestService {
+	return &RiskRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *RiskRestServiceImpl) GetLimits(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[RiskRestService.GetLimits]")
+	limits, err := restService.createRiskService(ctx).GetLimits()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: limits})
+}
+
+// SaveLimit creates or replaces the global risk limits (chart_id 0) or a
+// chart's risk limits with the limits posted as JSON.
+func (restService *RiskRestServiceImpl) SaveLimit(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var limit dto.RiskLimitDTO
+	if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[RiskRestService.SaveLimit] limit: %+v", limit)
+	saved, err := restService.createRiskService(ctx).SaveLimit(&limit)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: saved})
+}
+
+func (restService *RiskRestServiceImpl) DeleteLimit(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	chartId, err := strconv.ParseUint(mux.Vars(r)["chartId"], 10, 64)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid chart id"})
+		return
+	}
+	ctx.GetLogger().Debugf("[RiskRestService.DeleteLimit] chart: %d", chartId)
+	if err := restService.createRiskService(ctx).DeleteLimit(uint(chartId)); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: chartId})
+}
+
+func (restService *RiskRestServiceImpl) GetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[RiskRestService.GetKillSwitch]")
+	killSwitch, err := restService.createRiskService(ctx).GetKillSwitch()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: killSwitch})
+}
+
+// SetKillSwitch sets the kill switch to the kill_switch mode posted as JSON:
+// off, halt or flatten.
+func (restService *RiskRestServiceImpl) SetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var limit dto.RiskLimitDTO
+	if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[RiskRestService.SetKillSwitch] mode: %s", limit.GetKillSwitch())
+	if err := restService.createRiskService(ctx).SetKillSwitch(limit.GetKillSwitch()); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: limit.GetKillSwitch()})
+}
+
+func (restService *RiskRestServiceImpl) GetRejections(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[RiskRestService.GetRejections] limit: %s", r.FormValue("limit"))
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	rejections, err := restService.createRiskService(ctx).GetRejections(limit)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: rejections})
+}
+
+func (restService *RiskRestServiceImpl) createRiskService(ctx common.Context) service.RiskService {
+	return service.NewRiskService(ctx, dao.NewRiskDAO(ctx), dao.NewTradeDAO(ctx), dao.NewChartDAO(ctx),
+		mapper.NewRiskMapper(ctx))
+}
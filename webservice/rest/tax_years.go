@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/tradebot/accounting"
+	"github.com/jeremyhahn/tradebot/common"
+	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/service"
+)
+
+type TaxYearRestService interface {
+	GetTaxYears(w http.ResponseWriter, r *http.Request)
+	CloseYear(w http.ResponseWriter, r *http.Request)
+	ReopenYear(w http.ResponseWriter, r *http.Request)
+}
+
+type TaxYearRestServiceImpl struct {
+	middlewareService service.Middleware
+	jsonWriter        common.HttpWriter
+	TaxYearRestService
+}
+
+func NewTaxYearRestService(middlewareService service.Middleware, jsonWriter common.HttpWriter) TaxYearRestService {
+	return &TaxYearRestServiceImpl{
+		middlewareService: middlewareService,
+		jsonWriter:        jsonWriter}
+}
+
+func (restService *TaxYearRestServiceImpl) GetTaxYears(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TaxYearRestService.GetTaxYears]")
+	taxYears, err := restService.createTaxLotLedger(ctx).GetTaxYears()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: taxYears})
+}
+
+func (restService *TaxYearRestServiceImpl) CloseYear(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	params := mux.Vars(r)
+	ctx.GetLogger().Debugf("[TaxYearRestService.CloseYear] year: %s", params["year"])
+	year, err := strconv.Atoi(params["year"])
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid tax year"})
+		return
+	}
+	txService, err := newTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := txService.GetHistory("asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	form8949, err := restService.createTaxLotLedger(ctx).CloseYear(year, report)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: form8949})
+}
+
+func (restService *TaxYearRestServiceImpl) ReopenYear(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	params := mux.Vars(r)
+	ctx.GetLogger().Debugf("[TaxYearRestService.ReopenYear] year: %s", params["year"])
+	year, err := strconv.Atoi(params["year"])
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: "Invalid tax year"})
+		return
+	}
+	if err := restService.createTaxLotLedger(ctx).ReopenYear(year); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: year})
+}
+
+func (restService *TaxYearRestServiceImpl) createTaxLotLedger(ctx common.Context) accounting.TaxLotLedger {
+	return accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+}
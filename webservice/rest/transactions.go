@@ -1,22 +1,35 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/tradebot/accounting"
 	"github.com/jeremyhahn/tradebot/common"
 	"github.com/jeremyhahn/tradebot/dao"
+	"github.com/jeremyhahn/tradebot/dto"
 	"github.com/jeremyhahn/tradebot/mapper"
 	"github.com/jeremyhahn/tradebot/service"
 	"github.com/jeremyhahn/tradebot/viewmodel"
 )
 
+const (
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_JSON = "json"
+	EXPORT_FORMAT_TXF  = "txf"
+	EXPORT_FORMAT_PDF  = "pdf"
+	EXPORT_DATE_FORMAT = "2006-01-02"
+)
+
 type TransactionRestService interface {
 	GetHistory(w http.ResponseWriter, r *http.Request)
 	GetOrderHistory(w http.ResponseWriter, r *http.Request)
@@ -24,8 +37,22 @@ type TransactionRestService interface {
 	GetWithdrawalHistory(w http.ResponseWriter, r *http.Request)
 	GetImportedTransactions(w http.ResponseWriter, r *http.Request)
 	UpdateCategory(w http.ResponseWriter, r *http.Request)
+	UpdateTransaction(w http.ResponseWriter, r *http.Request)
+	CreateTransaction(w http.ResponseWriter, r *http.Request)
+	SplitTransaction(w http.ResponseWriter, r *http.Request)
+	DeleteTransaction(w http.ResponseWriter, r *http.Request)
+	RestoreTransaction(w http.ResponseWriter, r *http.Request)
+	GetRevisions(w http.ResponseWriter, r *http.Request)
 	Synchronize(w http.ResponseWriter, r *http.Request)
+	MatchTransfers(w http.ResponseWriter, r *http.Request)
 	Export(w http.ResponseWriter, r *http.Request)
+	ExportIncome(w http.ResponseWriter, r *http.Request)
+	ExportDisposals(w http.ResponseWriter, r *http.Request)
+	ExportCapitalGains(w http.ResponseWriter, r *http.Request)
+	ExportJournal(w http.ResponseWriter, r *http.Request)
+	ExportUniversalCSV(w http.ResponseWriter, r *http.Request)
+	Explain(w http.ResponseWriter, r *http.Request)
+	Reconcile(w http.ResponseWriter, r *http.Request)
 	Import(w http.ResponseWriter, r *http.Request)
 }
 
@@ -67,6 +94,33 @@ func (restService *TransactionRestServiceImpl) Synchronize(w http.ResponseWriter
 		Payload: restService.formatTransactions(ctx, txs)})
 }
 
+func (restService *TransactionRestServiceImpl) MatchTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.MatchTransfers]")
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transfers, err := txService.MatchTransfers()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: transfers})
+}
+
 func (restService *TransactionRestServiceImpl) GetHistory(w http.ResponseWriter, r *http.Request) {
 	ctx, err := restService.middlewareService.CreateContext(w, r)
 	if err != nil {
@@ -81,7 +135,7 @@ func (restService *TransactionRestServiceImpl) GetHistory(w http.ResponseWriter,
 			Payload: err.Error()})
 		return
 	}
-	txs, err := txService.GetHistory("desc")
+	txs, err := getHistory(txService, r, "desc")
 	if err != nil {
 		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
 			Success: false,
@@ -218,6 +272,214 @@ func (restService *TransactionRestServiceImpl) UpdateCategory(w http.ResponseWri
 		Payload: true})
 }
 
+// UpdateTransaction applies the fields posted as JSON to the transaction, so
+// only the fields being edited need to be sent. Form posts update the category.
+func (restService *TransactionRestServiceImpl) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		restService.UpdateCategory(w, r)
+		return
+	}
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	ctx.GetLogger().Debugf("[TransactionRestService.UpdateTransaction] id: %s", id)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	current, err := txService.GetTransaction(id)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	tx := *current.(*dto.TransactionDTO)
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	tx.Id = id
+	updated, err := txService.UpdateTransaction(&tx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: updated})
+}
+
+func (restService *TransactionRestServiceImpl) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	var tx dto.TransactionDTO
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[TransactionRestService.CreateTransaction] transaction: %+v", tx)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	created, err := txService.CreateTransaction(&tx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: created})
+}
+
+// SplitTransaction splits the transaction into one part for each of the
+// quantities posted as JSON, e.g. {"quantities": ["0.4", "0.6"]}.
+func (restService *TransactionRestServiceImpl) SplitTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	var split struct {
+		Quantities []string `json:"quantities"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&split); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ctx.GetLogger().Debugf("[TransactionRestService.SplitTransaction] id: %s, quantities: %s", id, split.Quantities)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := txService.SplitTransaction(id, split.Quantities)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: transactions})
+}
+
+func (restService *TransactionRestServiceImpl) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	ctx.GetLogger().Debugf("[TransactionRestService.DeleteTransaction] id: %s", id)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	if err := txService.DeleteTransaction(id); err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: id})
+}
+
+func (restService *TransactionRestServiceImpl) RestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	ctx.GetLogger().Debugf("[TransactionRestService.RestoreTransaction] id: %s", id)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restored, err := txService.RestoreTransaction(id)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: restored})
+}
+
+// GetRevisions returns the changes made to the transaction, or to every
+// transaction when the route has no id. Reports accept the id of a revision
+// to be regenerated as of that revision.
+func (restService *TransactionRestServiceImpl) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	ctx.GetLogger().Debugf("[TransactionRestService.GetRevisions] id: %s", id)
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	revisions, err := txService.GetRevisions(id)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: revisions})
+}
+
 func (restService *TransactionRestServiceImpl) Import(w http.ResponseWriter, r *http.Request) {
 
 	ctx, err := restService.middlewareService.CreateContext(w, r)
@@ -256,6 +518,15 @@ func (restService *TransactionRestServiceImpl) Import(w http.ResponseWriter, r *
 		return
 	}
 
+	if format := r.FormValue("format"); format != "" {
+		restService.importUniversalCSV(w, ctx, orderService, filename, format, r.FormValue("exchange"))
+		return
+	}
+	if template := r.FormValue("template"); template != "" {
+		restService.importTemplateCSV(w, ctx, orderService, filename, template)
+		return
+	}
+
 	records, err := orderService.ImportCSV(filename, r.FormValue("exchange"))
 	if err != nil {
 		ctx.GetLogger().Errorf("[TransactionRestServiceImpl.Import] %s", err.Error())
@@ -275,49 +546,666 @@ func (restService *TransactionRestServiceImpl) Import(w http.ResponseWriter, r *
 		Payload: response})
 }
 
-func (restService *TransactionRestServiceImpl) Export(w http.ResponseWriter, r *http.Request) {
+// TransactionImport is the result of a universal or template CSV import: the
+// transactions imported and the rows that were skipped.
+type TransactionImport struct {
+	Transactions []viewmodel.Transaction `json:"transactions"`
+	Errors       []service.CSVRowError   `json:"errors"`
+}
+
+// importUniversalCSV imports a Koinly or CoinTracking CSV file. A file with an
+// invalid header is rejected while invalid rows are reported alongside the
+// transactions imported from the rest of the file.
+func (restService *TransactionRestServiceImpl) importUniversalCSV(w http.ResponseWriter, ctx common.Context,
+	txService service.TransactionService, filename, format, network string) {
+	ctx.GetLogger().Debugf("[TransactionRestService.importUniversalCSV] format: %s, network: %s", format, network)
+	records, rowErrors, err := txService.ImportUniversalCSV(filename, format, network)
+	restService.writeImport(w, ctx, txService, records, rowErrors, err)
+}
+
+// importTemplateCSV imports a CSV file using one of the user's CSV templates,
+// reporting invalid rows alongside the transactions imported from the rest of
+// the file.
+func (restService *TransactionRestServiceImpl) importTemplateCSV(w http.ResponseWriter, ctx common.Context,
+	txService service.TransactionService, filename, template string) {
+	ctx.GetLogger().Debugf("[TransactionRestService.importTemplateCSV] template: %s", template)
+	records, rowErrors, err := txService.ImportTemplateCSV(filename, template)
+	restService.writeImport(w, ctx, txService, records, rowErrors, err)
+}
+
+// writeImport writes the transactions imported from a CSV file along with the
+// rows that were skipped.
+func (restService *TransactionRestServiceImpl) writeImport(w http.ResponseWriter, ctx common.Context,
+	txService service.TransactionService, records []common.Transaction, rowErrors []service.CSVRowError, err error) {
+	if err != nil {
+		ctx.GetLogger().Errorf("[TransactionRestService.writeImport] %s", err.Error())
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false, Payload: err.Error()})
+		return
+	}
+	response := TransactionImport{
+		Transactions: []viewmodel.Transaction{},
+		Errors:       rowErrors}
+	for _, record := range records {
+		response.Transactions = append(response.Transactions,
+			txService.GetMapper().MapTransactionDtoToViewModel(record))
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: len(rowErrors) == 0,
+		Payload: response})
+}
+
+// ExportUniversalCSV streams the transaction history as a Koinly or
+// CoinTracking CSV file.
+func (restService *TransactionRestServiceImpl) ExportUniversalCSV(w http.ResponseWriter, r *http.Request) {
 	ctx, err := restService.middlewareService.CreateContext(w, r)
 	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
 		return
 	}
 	defer ctx.Close()
-	ctx.GetLogger().Debugf("[TransactionHistoryRestService.Export]")
-	service, err := restService.createTransactionService(ctx)
+	ctx.GetLogger().Debugf("[TransactionRestService.ExportUniversalCSV] format: %s", r.FormValue("format"))
+	format := r.FormValue("format")
+	if format != service.CSV_FORMAT_KOINLY && format != service.CSV_FORMAT_COINTRACKING {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: fmt.Sprintf("Unsupported CSV format: %s", format)})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
 	if err != nil {
 		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
 			Success: false,
 			Payload: err.Error()})
 		return
 	}
-	transactions, err := service.GetHistory("asc")
+	filename := fmt.Sprintf("%s-%s.csv", ctx.GetUser().GetUsername(), format)
+	restService.streamFile(w, r, filename, "text/csv", func(filename string) error {
+		return txService.ExportUniversalCSV(filename, format)
+	})
+}
+
+// Export returns Form 8949 for a tax year, or for the range between the start
+// and end dates inclusive. Years and dates are bounded in the requested time
+// zone, defaulting to the server's. The form is streamed as a CSV, TXF or PDF
+// attachment, the PDF ending with a Schedule D summary, unless the json format
+// is requested. The reported_on_1099b and basis_reported flags check boxes A/B
+// or D/E instead of C and F for sales a broker reported on Form 1099-B.
+func (restService *TransactionRestServiceImpl) Export(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.Export] year: %s, start: %s, end: %s, timezone: %s, method: %s, format: %s, reported_on_1099b: %s, basis_reported: %s",
+		r.FormValue("year"), r.FormValue("start"), r.FormValue("end"), r.FormValue("timezone"),
+		r.FormValue("method"), r.FormValue("format"), r.FormValue("reported_on_1099b"), r.FormValue("basis_reported"))
+	format := r.FormValue("format")
+	if format == "" {
+		format = EXPORT_FORMAT_CSV
+	}
+	if format != EXPORT_FORMAT_CSV && format != EXPORT_FORMAT_JSON &&
+		format != EXPORT_FORMAT_TXF && format != EXPORT_FORMAT_PDF {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: fmt.Sprintf("Unsupported export format: %s", format)})
+		return
+	}
+	reportedOn1099B, err := parseFlag(r, "reported_on_1099b")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	basisReported, err := parseFlag(r, "basis_reported")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	location, err := parseLocation(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
 	if err != nil {
 		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
 			Success: false,
 			Payload: err.Error()})
 		return
 	}
-	fifo := accounting.NewFifoReport(ctx, transactions)
-	location := time.Now().Location()
-	start := time.Date(2017, 01, 01, 0, 0, 0, 0, location)
-	end := time.Date(2017, 12, 31, 0, 0, 0, 0, location)
-	form8948 := fifo.Run(start, end)
-
-	filename := fmt.Sprintf("/tmp/%s-%s", ctx.GetUser().GetUsername(), "-8949.csv")
-	form8948.WriteCSV(filename)
-
-	csvBytes, err := ioutil.ReadFile(filename)
+	transactions, err := getHistory(txService, r, "asc")
 	if err != nil {
 		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
 			Success: false,
 			Payload: err.Error()})
 		return
 	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	ledger := accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+	ledger.SetLocation(location)
 
-	restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
-		Success: true,
-		Payload: string(csvBytes)})
+	var form8949 *accounting.Form8949
+	var period string
+	if r.FormValue("start") != "" || r.FormValue("end") != "" {
+		start, end, err := parseDateRange(r, location)
+		if err != nil {
+			restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+		openingLots, openingDate, err := ledger.GetOpeningLots(start.Year())
+		if err != nil {
+			restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+		if openingLots != nil {
+			report.SetOpeningLots(openingLots, openingDate)
+		}
+		form8949 = report.Run(start, end)
+		period = fmt.Sprintf("%s-%s", start.Format(EXPORT_DATE_FORMAT), end.Format(EXPORT_DATE_FORMAT))
+	} else {
+		year, err := parseTaxYear(r)
+		if err != nil {
+			restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+		if form8949, err = ledger.GetForm8949(year, report); err != nil {
+			restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+		period = strconv.Itoa(year)
+	}
+	form8949.ReportedOn1099B = reportedOn1099B
+	form8949.BasisReported = basisReported
 
-	os.Remove(filename)
+	filename := fmt.Sprintf("%s-%s-%s-8949.%s", ctx.GetUser().GetUsername(), period, report.GetMethod(), format)
+	switch format {
+	case EXPORT_FORMAT_JSON:
+		restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+			Success: true,
+			Payload: form8949})
+	case EXPORT_FORMAT_TXF:
+		restService.streamFile(w, r, filename, "text/plain", form8949.WriteTXF)
+	case EXPORT_FORMAT_PDF:
+		restService.streamFile(w, r, filename, "application/pdf", form8949.WritePDF)
+	default:
+		restService.streamFile(w, r, filename, "text/csv", form8949.WriteCSV)
+	}
+}
+
+// ExportIncome returns the income received during a tax year, bounded in the
+// requested time zone, as JSON or streamed as a CSV attachment.
+func (restService *TransactionRestServiceImpl) ExportIncome(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.ExportIncome] year: %s, timezone: %s, format: %s",
+		r.FormValue("year"), r.FormValue("timezone"), r.FormValue("format"))
+	year, err := parseTaxYear(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	location, err := parseLocation(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	fiatPriceService, err := newFiatPriceService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	start, end := accounting.TaxYearBounds(year, location)
+	statement := accounting.NewIncomeReport(ctx, transactions, fiatPriceService).Run(start, end)
+
+	if r.FormValue("format") != "csv" {
+		restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+			Success: true,
+			Payload: statement})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%d-income.csv", ctx.GetUser().GetUsername(), year)
+	restService.streamFile(w, r, filename, "text/csv", statement.WriteCSV)
+}
+
+// ExportDisposals returns the charitable contributions and casualty losses of
+// a tax year, bounded in the requested time zone, as JSON or streamed as a CSV
+// attachment.
+func (restService *TransactionRestServiceImpl) ExportDisposals(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.ExportDisposals] year: %s, timezone: %s, format: %s",
+		r.FormValue("year"), r.FormValue("timezone"), r.FormValue("format"))
+	year, err := parseTaxYear(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	location, err := parseLocation(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	if err := seedOpeningLots(ctx, report, year, location); err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report.Run(accounting.TaxYearBounds(year, location))
+	schedule := report.GetDisposalSchedule()
+
+	if r.FormValue("format") != "csv" {
+		restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+			Success: true,
+			Payload: schedule})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%d-disposals.csv", ctx.GetUser().GetUsername(), year)
+	restService.streamFile(w, r, filename, "text/csv", schedule.WriteCSV)
+}
+
+// ExportCapitalGains returns the capital gains report of the requested
+// jurisdiction: Form 8949 (us), the SA108 summary with Section 104 pools (uk)
+// or Schedule 3 at adjusted cost base (ca). The tax year is bounded in the
+// requested time zone and the report streamed as a CSV attachment unless JSON
+// is requested.
+func (restService *TransactionRestServiceImpl) ExportCapitalGains(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.ExportCapitalGains] jurisdiction: %s, year: %s, timezone: %s, format: %s",
+		r.FormValue("jurisdiction"), r.FormValue("year"), r.FormValue("timezone"), r.FormValue("format"))
+	jurisdiction, err := accounting.NewJurisdiction(r.FormValue("jurisdiction"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	year, err := parseTaxYear(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	location, err := parseLocation(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	var statement accounting.TaxStatement
+	if jurisdiction.GetCode() == accounting.JURISDICTION_US {
+		ledger := accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+		ledger.SetLocation(location)
+		if statement, err = ledger.GetForm8949(year, report); err != nil {
+			restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+				Success: false,
+				Payload: err.Error()})
+			return
+		}
+	} else {
+		start, end := jurisdiction.GetTaxYearBounds(year, location)
+		statement = jurisdiction.Calculate(report, start, end)
+	}
+
+	if r.FormValue("format") != "csv" {
+		restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+			Success: true,
+			Payload: statement})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%d-%s-capitalgains.csv", ctx.GetUser().GetUsername(), year, jurisdiction.GetCode())
+	restService.streamFile(w, r, filename, "text/csv", statement.WriteCSV)
+}
+
+// ExportJournal streams the transaction history as a beancount or ledger-cli
+// journal.
+func (restService *TransactionRestServiceImpl) ExportJournal(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.ExportJournal] format: %s", r.FormValue("format"))
+	format := r.FormValue("format")
+	if format == "" {
+		format = accounting.JOURNAL_FORMAT_BEANCOUNT
+	}
+	if format != accounting.JOURNAL_FORMAT_BEANCOUNT && format != accounting.JOURNAL_FORMAT_LEDGER {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: fmt.Sprintf("Unsupported journal format: %s", format)})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	journal := accounting.NewJournal(ctx, transactions)
+	filename := fmt.Sprintf("%s.%s", ctx.GetUser().GetUsername(), format)
+	restService.streamFile(w, r, filename, "text/plain", func(filename string) error {
+		return journal.Write(filename, format)
+	})
+}
+
+// Explain returns the Form 8949 lines reported for a sale along with the lots
+// they consumed and the arithmetic used to arrive at each figure. Closed years
+// are explained from the filed form using the method they were closed with.
+// The sale is placed in the tax year of the requested time zone.
+func (restService *TransactionRestServiceImpl) Explain(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	id := mux.Vars(r)["id"]
+	ctx.GetLogger().Debugf("[TransactionRestService.Explain] id: %s, method: %s, timezone: %s",
+		id, r.FormValue("method"), r.FormValue("timezone"))
+	location, err := parseLocation(r)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	var sale common.Transaction
+	for _, tx := range transactions {
+		if tx.GetId() == id {
+			sale = tx
+			break
+		}
+	}
+	if sale == nil {
+		restService.jsonWriter.Write(w, http.StatusNotFound, common.JsonResponse{
+			Success: false,
+			Payload: fmt.Sprintf("Transaction not found: %s", id)})
+		return
+	}
+	report, err := newCostBasisReport(ctx, transactions, r.FormValue("method"), r.FormValue("fallback"))
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusBadRequest, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	year := sale.GetDate().In(location).Year()
+	ledger := accounting.NewTaxLotLedger(ctx, dao.NewTaxYearDAO(ctx))
+	ledger.SetLocation(location)
+	form8949, err := ledger.GetForm8949(year, report)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	method := report.GetMethod()
+	taxYears, err := ledger.GetTaxYears()
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	for _, taxYear := range taxYears {
+		if taxYear.Year == year {
+			method = taxYear.Method
+		}
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: accounting.ExplainLineItems(form8949, id, method)})
+}
+
+func (restService *TransactionRestServiceImpl) Reconcile(w http.ResponseWriter, r *http.Request) {
+	ctx, err := restService.middlewareService.CreateContext(w, r)
+	if err != nil {
+		RestError(w, r, err, restService.jsonWriter)
+		return
+	}
+	defer ctx.Close()
+	ctx.GetLogger().Debugf("[TransactionRestService.Reconcile]")
+	txService, err := restService.createTransactionService(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	transactions, err := getHistory(txService, r, "asc")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	exchanges, wallets, err := newReconciliationSources(ctx)
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	restService.jsonWriter.Write(w, http.StatusOK, common.JsonResponse{
+		Success: true,
+		Payload: accounting.NewReconciliationReport(ctx, transactions).Run(exchanges, wallets)})
+}
+
+// streamFile writes a report to a temporary file and responds with it as an
+// attachment named filename.
+func (restService *TransactionRestServiceImpl) streamFile(w http.ResponseWriter, r *http.Request,
+	filename, contentType string, write func(filename string) error) {
+
+	tmpfile, err := ioutil.TempFile("", "tradebot-export-")
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+	if err := write(tmpfile.Name()); err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	file, err := os.Open(tmpfile.Name())
+	if err != nil {
+		restService.jsonWriter.Write(w, http.StatusInternalServerError, common.JsonResponse{
+			Success: false,
+			Payload: err.Error()})
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	http.ServeContent(w, r, filename, time.Now(), file)
+}
+
+// parseTaxYear returns the year form value, defaulting to the current year.
+func parseTaxYear(r *http.Request) (int, error) {
+	if r.FormValue("year") == "" {
+		return time.Now().Year(), nil
+	}
+	year, err := strconv.Atoi(r.FormValue("year"))
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid tax year: %s", r.FormValue("year")))
+	}
+	return year, nil
+}
+
+// parseFlag returns the boolean form value of name, false when it is not given.
+func parseFlag(r *http.Request, name string) (bool, error) {
+	if r.FormValue(name) == "" {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(r.FormValue(name))
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("Invalid %s: %s", name, r.FormValue(name)))
+	}
+	return flag, nil
+}
+
+// parseLocation returns the time zone named by the timezone form value,
+// defaulting to the server's local time zone.
+func parseLocation(r *http.Request) (*time.Location, error) {
+	if r.FormValue("timezone") == "" {
+		return time.Now().Location(), nil
+	}
+	location, err := time.LoadLocation(r.FormValue("timezone"))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid time zone: %s", r.FormValue("timezone")))
+	}
+	return location, nil
+}
+
+// parseDateRange returns the first instant of the start date and the last
+// instant of the end date in location.
+func parseDateRange(r *http.Request, location *time.Location) (time.Time, time.Time, error) {
+	var start, end time.Time
+	start, err := time.ParseInLocation(EXPORT_DATE_FORMAT, r.FormValue("start"), location)
+	if err != nil {
+		return start, end, errors.New(fmt.Sprintf("Invalid start date: %s", r.FormValue("start")))
+	}
+	end, err = time.ParseInLocation(EXPORT_DATE_FORMAT, r.FormValue("end"), location)
+	if err != nil {
+		return start, end, errors.New(fmt.Sprintf("Invalid end date: %s", r.FormValue("end")))
+	}
+	if end.Before(start) {
+		return start, end, errors.New(fmt.Sprintf("End date %s is before start date %s", r.FormValue("end"), r.FormValue("start")))
+	}
+	return start, end.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
 }
 
 func (restService *TransactionRestServiceImpl) formatTransactions(ctx common.Context, txs []common.Transaction) []viewmodel.Transaction {
@@ -330,6 +1218,23 @@ func (restService *TransactionRestServiceImpl) formatTransactions(ctx common.Con
 }
 
 func (restService *TransactionRestServiceImpl) createTransactionService(ctx common.Context) (service.TransactionService, error) {
+	return newTransactionService(ctx)
+}
+
+// getHistory returns the transaction history, or with the revision parameter,
+// the history as it was right after that revision was made.
+func getHistory(txService service.TransactionService, r *http.Request, order string) ([]common.Transaction, error) {
+	if r.FormValue("revision") == "" {
+		return txService.GetHistory(order)
+	}
+	revision, err := strconv.ParseUint(r.FormValue("revision"), 10, 64)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid revision: %s", r.FormValue("revision")))
+	}
+	return txService.GetHistoryAsOf(uint(revision), order)
+}
+
+func newTransactionService(ctx common.Context) (service.TransactionService, error) {
 	pluginDAO := dao.NewPluginDAO(ctx)
 	userDAO := dao.NewUserDAO(ctx)
 	transactionDAO := dao.NewTransactionDAO(ctx)
@@ -350,5 +1255,44 @@ func (restService *TransactionRestServiceImpl) createTransactionService(ctx comm
 	}
 	walletService := service.NewWalletService(ctx, pluginService, fiatPriceService)
 	userService := service.NewUserService(ctx, userDAO, userMapper, userExchangeMapper, marketcapService, ethereumService, exchangeService, walletService)
-	return service.NewTransactionService(ctx, transactionDAO, transactionMapper, exchangeService, userService, ethereumService, fiatPriceService), nil
+	csvTemplateService := service.NewCSVTemplateService(ctx, dao.NewCSVTemplateDAO(ctx), mapper.NewCSVTemplateMapper(ctx))
+	return service.NewTransactionService(ctx, transactionDAO, dao.NewTransactionRevisionDAO(ctx), transactionMapper,
+		exchangeService, userService, ethereumService, fiatPriceService, csvTemplateService), nil
+}
+
+func newFiatPriceService(ctx common.Context) (common.FiatPriceService, error) {
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, dao.NewUserDAO(ctx), mapper.NewUserMapper(),
+		mapper.NewUserExchangeMapper(), pluginService)
+	return service.NewFiatPriceService(ctx, exchangeService)
+}
+
+// newReconciliationSources returns the user's exchanges and wallets, which
+// report the live balances the transaction history is reconciled against.
+func newReconciliationSources(ctx common.Context) ([]common.Exchange, []common.Wallet, error) {
+	userDAO := dao.NewUserDAO(ctx)
+	userMapper := mapper.NewUserMapper()
+	userExchangeMapper := mapper.NewUserExchangeMapper()
+	marketcapService := service.NewMarketCapService(ctx)
+	pluginService := service.NewPluginService(ctx, dao.NewPluginDAO(ctx), mapper.NewPluginMapper())
+	exchangeService := service.NewExchangeService(ctx, userDAO, userMapper, userExchangeMapper, pluginService)
+	fiatPriceService, err := service.NewFiatPriceService(ctx, exchangeService)
+	if err != nil {
+		return nil, nil, err
+	}
+	ethereumService, err := service.NewEthereumService(ctx, userDAO, userMapper, marketcapService, exchangeService)
+	if err != nil {
+		return nil, nil, err
+	}
+	walletService := service.NewWalletService(ctx, pluginService, fiatPriceService)
+	userService := service.NewUserService(ctx, userDAO, userMapper, userExchangeMapper, marketcapService, ethereumService, exchangeService, walletService)
+	exchanges, err := exchangeService.GetExchanges()
+	if err != nil {
+		return nil, nil, err
+	}
+	wallets, err := userService.GetWalletPlugins()
+	if err != nil {
+		return nil, nil, err
+	}
+	return exchanges, wallets, nil
 }
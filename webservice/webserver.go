@@ -51,10 +51,23 @@ func (ws *WebServer) Start() {
 	exchangeRestService := rest.NewExchangeRestService(ws.jsonWebTokenService, jsonWriter)
 	userRestService := rest.NewUserRestService(ws.jsonWebTokenService, jsonWriter)
 	transactionRestService := rest.NewTransactionRestService(ws.jsonWebTokenService, jsonWriter)
+	lotRestService := rest.NewLotRestService(ws.jsonWebTokenService, jsonWriter)
+	taxYearRestService := rest.NewTaxYearRestService(ws.jsonWebTokenService, jsonWriter)
+	generalLedgerRestService := rest.NewGeneralLedgerRestService(ws.jsonWebTokenService, jsonWriter)
+	forkEventRestService := rest.NewForkEventRestService(ws.jsonWebTokenService, jsonWriter)
+	csvTemplateRestService := rest.NewCSVTemplateRestService(ws.jsonWebTokenService, jsonWriter)
+	paperAccountRestService := rest.NewPaperAccountRestService(ws.jsonWebTokenService, jsonWriter)
+	backtestRestService := rest.NewBacktestRestService(ws.jsonWebTokenService, jsonWriter)
+	optimizerRestService := rest.NewOptimizerRestService(ws.jsonWebTokenService, jsonWriter)
+	riskRestService := rest.NewRiskRestService(ws.jsonWebTokenService, jsonWriter)
 	router.Handle("/api/v1/transactions", negroni.New(
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
 		negroni.Wrap(http.HandlerFunc(transactionRestService.GetHistory)),
-	))
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.CreateTransaction)),
+	)).Methods("POST")
 	router.Handle("/api/v1/transactions/orderhistory", negroni.New(
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
 		negroni.Wrap(http.HandlerFunc(transactionRestService.GetOrderHistory)),
@@ -79,14 +92,194 @@ func (ws *WebServer) Start() {
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
 		negroni.Wrap(http.HandlerFunc(transactionRestService.Export)),
 	))
+	router.Handle("/api/v1/transactions/income", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.ExportIncome)),
+	))
+	router.Handle("/api/v1/transactions/disposals", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.ExportDisposals)),
+	))
+	router.Handle("/api/v1/transactions/capitalgains", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.ExportCapitalGains)),
+	))
+	router.Handle("/api/v1/transactions/journal", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.ExportJournal)),
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions/csv", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.ExportUniversalCSV)),
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions/transfers/match", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.MatchTransfers)),
+	)).Methods("POST")
+	router.Handle("/api/v1/transactions/reconcile", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.Reconcile)),
+	)).Methods("GET")
 	router.Handle("/api/v1/transactions/sync", negroni.New(
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
 		negroni.Wrap(http.HandlerFunc(transactionRestService.Synchronize)),
 	))
+	router.Handle("/api/v1/transactions/revisions", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.GetRevisions)),
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions/{id}/explain", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.Explain)),
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions/{id}/revisions", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.GetRevisions)),
+	)).Methods("GET")
+	router.Handle("/api/v1/transactions/{id}/split", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.SplitTransaction)),
+	)).Methods("POST")
+	router.Handle("/api/v1/transactions/{id}/restore", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.RestoreTransaction)),
+	)).Methods("POST")
+	router.Handle("/api/v1/transactions/{id}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.UpdateTransaction)),
+	)).Methods("PUT")
 	router.Handle("/api/v1/transactions/{id}", negroni.New(
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
-		negroni.Wrap(http.HandlerFunc(transactionRestService.UpdateCategory)),
+		negroni.Wrap(http.HandlerFunc(transactionRestService.DeleteTransaction)),
+	)).Methods("DELETE")
+	router.Handle("/api/v1/lots/selections/{id}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(lotRestService.GetSelections)),
+	)).Methods("GET")
+	router.Handle("/api/v1/lots/selections/{id}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(lotRestService.SetSelections)),
 	)).Methods("PUT")
+	router.Handle("/api/v1/lots/unrealized", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(lotRestService.GetUnrealizedGains)),
+	))
+	router.Handle("/api/v1/lots/harvest", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(lotRestService.GetHarvestRecommendations)),
+	))
+	router.Handle("/api/v1/lots/{currency}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(lotRestService.GetOpenLots)),
+	))
+	router.Handle("/api/v1/taxyears", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(taxYearRestService.GetTaxYears)),
+	))
+	router.Handle("/api/v1/taxyears/{year}/close", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(taxYearRestService.CloseYear)),
+	)).Methods("POST")
+	router.Handle("/api/v1/taxyears/{year}/reopen", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(taxYearRestService.ReopenYear)),
+	)).Methods("POST")
+	router.Handle("/api/v1/forks", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(forkEventRestService.GetForkEvents)),
+	)).Methods("GET")
+	router.Handle("/api/v1/forks", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(forkEventRestService.CreateForkEvent)),
+	)).Methods("POST")
+	router.Handle("/api/v1/forks/{id}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(forkEventRestService.DeleteForkEvent)),
+	)).Methods("DELETE")
+	router.Handle("/api/v1/csvtemplates", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(csvTemplateRestService.GetTemplates)),
+	)).Methods("GET")
+	router.Handle("/api/v1/csvtemplates", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(csvTemplateRestService.SaveTemplate)),
+	)).Methods("POST")
+	router.Handle("/api/v1/csvtemplates/{name}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(csvTemplateRestService.GetTemplate)),
+	)).Methods("GET")
+	router.Handle("/api/v1/csvtemplates/{name}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(csvTemplateRestService.DeleteTemplate)),
+	)).Methods("DELETE")
+	router.Handle("/api/v1/paper/{exchange}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(paperAccountRestService.GetAccount)),
+	)).Methods("GET")
+	router.Handle("/api/v1/paper/{exchange}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(paperAccountRestService.SaveAccount)),
+	)).Methods("PUT")
+	router.Handle("/api/v1/paper/{exchange}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(paperAccountRestService.ResetAccount)),
+	)).Methods("DELETE")
+	router.Handle("/api/v1/paper/{exchange}/orders", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(paperAccountRestService.GetOrders)),
+	)).Methods("GET")
+	router.Handle("/api/v1/charts/{id}/backtest", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(backtestRestService.Backtest)),
+	)).Methods("POST")
+	router.Handle("/api/v1/charts/{id}/optimize", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(optimizerRestService.Optimize)),
+	)).Methods("POST")
+	router.Handle("/api/v1/charts/{id}/parameters", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(optimizerRestService.SaveParameters)),
+	)).Methods("PUT")
+	router.Handle("/api/v1/risk/limits", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.GetLimits)),
+	)).Methods("GET")
+	router.Handle("/api/v1/risk/limits", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.SaveLimit)),
+	)).Methods("PUT")
+	router.Handle("/api/v1/risk/limits/{chartId}", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.DeleteLimit)),
+	)).Methods("DELETE")
+	router.Handle("/api/v1/risk/killswitch", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.GetKillSwitch)),
+	)).Methods("GET")
+	router.Handle("/api/v1/risk/killswitch", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.SetKillSwitch)),
+	)).Methods("PUT")
+	router.Handle("/api/v1/risk/rejections", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(riskRestService.GetRejections)),
+	)).Methods("GET")
+	router.Handle("/api/v1/ledger/accounts", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(generalLedgerRestService.GetAccounts)),
+	))
+	router.Handle("/api/v1/ledger/trialbalance", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(generalLedgerRestService.GetTrialBalance)),
+	))
+	router.Handle("/api/v1/ledger/register", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(generalLedgerRestService.GetRegister)),
+	))
+	router.Handle("/api/v1/ledger/balancesheet", negroni.New(
+		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
+		negroni.Wrap(http.HandlerFunc(generalLedgerRestService.GetBalanceSheet)),
+	))
 	router.Handle("/api/v1/exchanges/names", negroni.New(
 		negroni.HandlerFunc(ws.jsonWebTokenService.Validate),
 		negroni.Wrap(http.HandlerFunc(exchangeRestService.GetDisplayNames)),